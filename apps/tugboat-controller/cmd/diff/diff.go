@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/object88/tugboat/apps/tugboat-controller/pkg/cliflags"
+	"github.com/object88/tugboat/apps/tugboat-controller/pkg/releasediff"
+	internalcliflags "github.com/object88/tugboat/internal/cmd/cliflags"
+	"github.com/object88/tugboat/internal/cmd/common"
+	"github.com/object88/tugboat/pkg/helm"
+	k8scliflags "github.com/object88/tugboat/pkg/k8s/cliflags"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	fromKey string = "from"
+	toKey          = "to"
+)
+
+type command struct {
+	cobra.Command
+	*common.CommonArgs
+
+	from      int
+	to        int
+	namespace string
+	output    internalcliflags.Output
+	differ    *releasediff.Differ
+
+	flagMgr    *cliflags.FlagManager
+	k8sFlagMgr *k8scliflags.FlagManager
+}
+
+// CreateCommand returns the `diff` Command
+func CreateCommand(ca *common.CommonArgs) *cobra.Command {
+	var c *command
+	c = &command{
+		Command: cobra.Command{
+			Use:   "diff RELEASE",
+			Short: "diff reports the changes to a release's manifest and values between two revisions",
+			Args:  cobra.ExactArgs(1),
+			PreRunE: func(cmd *cobra.Command, args []string) error {
+				return c.preexecute(cmd, args)
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.execute(cmd, args)
+			},
+		},
+		CommonArgs: ca,
+		flagMgr:    cliflags.New(),
+		k8sFlagMgr: k8scliflags.New(),
+	}
+
+	flags := c.Flags()
+
+	flags.IntVar(&c.from, fromKey, 0, "revision to diff from; defaults to the revision before --to")
+	flags.IntVar(&c.to, toKey, 0, "revision to diff to; defaults to the latest revision")
+	c.FlagMgr.ConfigureOutputFlag(flags)
+	c.flagMgr.ConfigureDiffFlags(flags)
+	c.k8sFlagMgr.ConfigureKubernetesConfig(flags)
+
+	return common.TraverseRunHooks(&c.Command)
+}
+
+func (c *command) preexecute(cmd *cobra.Command, args []string) error {
+	c.output = c.FlagMgr.Output()
+
+	redactor, err := c.flagMgr.DiffRedactor()
+	if err != nil {
+		return err
+	}
+
+	getter := c.k8sFlagMgr.KubernetesConfig()
+	if c.namespace, _, err = getter.ToRawKubeConfigLoader().Namespace(); err != nil {
+		return err
+	}
+	cfg, err := getter.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	c.differ = releasediff.New(releasediff.FromClientset(cmd.Context(), clientset), redactor)
+	return nil
+}
+
+func (c *command) execute(cmd *cobra.Command, args []string) error {
+	d, err := c.differ.Diff(c.namespace, args[0], c.from, c.to)
+	if err != nil {
+		return err
+	}
+	return write(d, c.output)
+}
+
+func write(d *helm.ReleaseDiff, output internalcliflags.Output) error {
+	switch output {
+	case internalcliflags.Text:
+		os.Stdout.WriteString(d.String())
+	case internalcliflags.JSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d); err != nil {
+			return fmt.Errorf("internal error: failed to encode diff: %w", err)
+		}
+	case internalcliflags.JSONCompact:
+		if err := json.NewEncoder(os.Stdout).Encode(d); err != nil {
+			return fmt.Errorf("internal error: failed to encode diff: %w", err)
+		}
+	case internalcliflags.Yaml:
+		b, err := yaml.Marshal(d)
+		if err != nil {
+			return fmt.Errorf("internal error: failed to encode diff: %w", err)
+		}
+		os.Stdout.Write(b)
+	}
+	return nil
+}
@@ -6,17 +6,22 @@ import (
 	"strings"
 	"time"
 
+	"github.com/object88/tugboat/apps/tugboat-controller/pkg/cliflags"
 	"github.com/object88/tugboat/apps/tugboat-controller/pkg/controller/releasehistory"
 	"github.com/object88/tugboat/apps/tugboat-controller/pkg/controller/secret"
+	"github.com/object88/tugboat/apps/tugboat-controller/pkg/conversion"
 	v1 "github.com/object88/tugboat/apps/tugboat-controller/pkg/http/router/v1"
+	"github.com/object88/tugboat/apps/tugboat-controller/pkg/migration"
+	"github.com/object88/tugboat/apps/tugboat-controller/pkg/releasediff"
 	"github.com/object88/tugboat/apps/tugboat-controller/pkg/validator"
 	"github.com/object88/tugboat/internal/cmd/common"
+	"github.com/object88/tugboat/pkg/helm"
 	"github.com/object88/tugboat/pkg/http"
 	httpcliflags "github.com/object88/tugboat/pkg/http/cliflags"
 	"github.com/object88/tugboat/pkg/http/probes"
 	"github.com/object88/tugboat/pkg/http/router"
 	"github.com/object88/tugboat/pkg/k8s/apis"
-	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	tugboat "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat"
 	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned"
 	"github.com/object88/tugboat/pkg/k8s/client/informers/externalversions"
 	listerv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/listers/engineering.tugboat/v1alpha1"
@@ -25,8 +30,10 @@ import (
 	"github.com/spf13/cobra"
 	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apiextclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apimachinerymetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
@@ -35,6 +42,7 @@ import (
 	listercorev1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
@@ -43,14 +51,22 @@ type command struct {
 	cobra.Command
 	*common.CommonArgs
 
+	access                 *v1.SecretAccess
+	apiextclientset        apiextclientset.Interface
 	dyn                    dynamic.Interface
 	mapper                 *restmapper.DeferredDiscoveryRESTMapper
 	mgr                    manager.Manager
+	recorder               record.EventRecorder
 	scheme                 *runtime.Scheme
 	versionedclientset     *versioned.Clientset
+	freezepolicyinformer   cache.SharedIndexInformer
 	releasehistoryinformer cache.SharedIndexInformer
 	secretinformer         cache.SharedIndexInformer
+	informers              *informermanager.Manager
+	redactor               *helm.KeyRedactor
+	source                 helm.SourceOptions
 
+	flagMgr     *cliflags.FlagManager
 	httpFlagMgr *httpcliflags.FlagManager
 	k8sFlagMgr  *k8scliflags.FlagManager
 
@@ -73,6 +89,7 @@ func CreateCommand(ca *common.CommonArgs) *cobra.Command {
 			},
 		},
 		CommonArgs:  ca,
+		flagMgr:     cliflags.New(),
 		httpFlagMgr: httpcliflags.New(),
 		k8sFlagMgr:  k8scliflags.New(),
 	}
@@ -82,6 +99,11 @@ func CreateCommand(ca *common.CommonArgs) *cobra.Command {
 	c.httpFlagMgr.ConfigureHttpFlag(flags)
 	c.httpFlagMgr.ConfigureHttpsFlags(flags)
 	c.k8sFlagMgr.ConfigureKubernetesConfig(flags)
+	c.flagMgr.ConfigureDeployerFlags(flags)
+	c.flagMgr.ConfigureDiffFlags(flags)
+	c.flagMgr.ConfigureFreezeFlags(flags)
+	c.flagMgr.ConfigureSourceFlags(flags)
+	c.flagMgr.ConfigureStatusWriterFlags(flags)
 
 	return common.TraverseRunHooks(&c.Command)
 }
@@ -120,6 +142,13 @@ func (c *command) preexecute(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if c.redactor, err = c.flagMgr.DiffRedactor(); err != nil {
+		return err
+	}
+	if c.source, err = c.flagMgr.SourceOptions(); err != nil {
+		return err
+	}
+
 	getter := c.k8sFlagMgr.KubernetesConfig()
 
 	cfg, err := getter.ToRESTConfig()
@@ -136,22 +165,45 @@ func (c *command) preexecute(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	c.recorder = c.mgr.GetEventRecorderFor("tugboat-controller")
 
 	c.versionedclientset, err = versioned.NewForConfig(cfg)
 	if err != nil {
 		return err
 	}
+	c.apiextclientset, err = apiextclientset.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
 	externalversionsfactory := externalversions.NewSharedInformerFactory(c.versionedclientset, 10*time.Second)
 	c.releasehistoryinformer = externalversionsfactory.Tugboat().V1alpha1().ReleaseHistories().Informer()
+	if c.flagMgr.EnforceFreezes() {
+		c.freezepolicyinformer = externalversionsfactory.Tugboat().V1alpha1().FreezePolicies().Informer()
+	}
 
 	clientset, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		return err
 	}
 
+	c.access = v1.NewSecretAccess(clientset)
+
 	factory := informers.NewSharedInformerFactory(clientset, time.Second*10)
 	c.secretinformer = factory.Core().V1().Secrets().Informer()
 
+	c.informers = informermanager.New(c.Log)
+	if err := c.informers.Add("releasehistories", c.releasehistoryinformer); err != nil {
+		return err
+	}
+	if err := c.informers.Add("secrets", c.secretinformer); err != nil {
+		return err
+	}
+	if c.freezepolicyinformer != nil {
+		if err := c.informers.Add("freezepolicies", c.freezepolicyinformer); err != nil {
+			return err
+		}
+	}
+
 	dc, err := getter.ToDiscoveryClient()
 	if err != nil {
 		return err
@@ -170,8 +222,8 @@ func (c *command) preexecute(cmd *cobra.Command, args []string) error {
 	}
 
 	isTugboatGroupVersion := func(apiResourceList *apimachinerymetav1.APIResourceList) bool {
-		gv := apiResourceList.GroupVersion
-		return gv == v1alpha1.SchemeGroupVersion.String()
+		gv, err := schema.ParseGroupVersion(apiResourceList.GroupVersion)
+		return err == nil && gv.Group == tugboat.GroupName
 	}
 
 	count := 0
@@ -213,7 +265,7 @@ func (c *command) preexecute(cmd *cobra.Command, args []string) error {
 }
 
 func (c *command) execute(cmd *cobra.Command, args []string) error {
-	return common.Multiblock(c.Log, c.probe, c.startHTTPServer, c.startControllerManager, c.startInformerManager)
+	return common.Multiblock(c.Log, c.probe, c.startHTTPServer, c.startControllerManager, c.startInformerManager, c.startMigrator)
 }
 
 func (c *command) startHTTPServer(ctx context.Context, r probes.Reporter) error {
@@ -221,9 +273,19 @@ func (c *command) startHTTPServer(ctx context.Context, r probes.Reporter) error
 	secretlister := listercorev1.NewSecretLister(c.secretinformer.GetIndexer())
 
 	m := validator.NewMutator(c.Log, c.versionedclientset, lister, secretlister, c.dyn, c.mapper)
+	m.Recorder = c.recorder
 	v := validator.New(c.Log, c.scheme)
-	v2 := validator.NewV2(c.Log, c.scheme, c.versionedclientset, lister)
-	rts, err := router.New(c.Log).Route(router.LoggingDefaultRoute, router.Defaults(c.probe, v1.Defaults(c.Log, m, v, v2)))
+	v.StatusWriters = c.flagMgr.StatusWriters()
+	v2 := validator.NewV2(c.Log, c.scheme, c.versionedclientset, lister, secretlister)
+	v2.CIKeys = c.flagMgr.DeployerCIKeys()
+	v2.Recorder = c.recorder
+	v2.Source = c.source
+	if c.freezepolicyinformer != nil {
+		v2.Freezes = listerv1alpha1.NewFreezePolicyLister(c.freezepolicyinformer.GetIndexer())
+	}
+	cv := conversion.New(c.Log)
+	d := releasediff.New(releasediff.FromLister(secretlister), c.redactor)
+	rts, err := router.New(c.Log).Route(router.LoggingDefaultRoute, router.Defaults(c.probe, v1.Defaults(c.Log, m, v, v2, cv, d, c.access, c.informers)))
 	if err != nil {
 		return err
 	}
@@ -268,6 +330,7 @@ func (c *command) startControllerManager(ctx context.Context, r probes.Reporter)
 		Client:          c.mgr.GetClient(),
 		VersionedClient: c.versionedclientset,
 		Log:             c.Log,
+		Recorder:        c.recorder,
 	}).SetupWithManager(c.mgr); err != nil {
 		return err
 	}
@@ -281,6 +344,12 @@ func (c *command) startInformerManager(ctx context.Context, r probes.Reporter) e
 	c.Log.Info("starting watcher")
 	defer c.Log.Info("watcher complete")
 
-	mgr := informermanager.New(c.Log)
-	return mgr.Run(ctx, r, c.releasehistoryinformer, c.secretinformer)
+	return c.informers.Run(ctx, r)
+}
+
+func (c *command) startMigrator(ctx context.Context, r probes.Reporter) error {
+	c.Log.Info("starting storage migrator")
+	defer c.Log.Info("storage migrator complete")
+
+	return migration.New(c.Log, c.versionedclientset, c.apiextclientset.ApiextensionsV1()).Run(ctx, r)
 }
@@ -0,0 +1,99 @@
+package cliflags
+
+import (
+	"strings"
+
+	"github.com/object88/tugboat/pkg/helm"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	deployerCIKeyKey  string = "deployer-ci-key"
+	diffRedactKeyKey         = "diff-redact-key"
+	enforceFreezesKey        = "enforce-freezes"
+	sourceKeyKey             = "source-key"
+	sourcePatternKey         = "source-description-pattern"
+	statusWritersKey         = "status-writers"
+)
+
+type FlagManager struct {
+	deployerCIKeys []string
+	diffRedactKeys []string
+	enforceFreezes bool
+	sourceKeys     []string
+	sourcePatterns []string
+	statusWriters  []string
+}
+
+func New() *FlagManager {
+	return &FlagManager{}
+}
+
+func (fm *FlagManager) ConfigureDeployerFlags(flags *pflag.FlagSet) {
+	// Keys are not bound to viper, which would split them on commas and
+	// whitespace.
+	flags.StringArrayVar(&fm.deployerCIKeys, deployerCIKeyKey, nil, "user extra field or helm release secret annotation identifying the CI job which deployed a revision.  May be repeated")
+}
+
+func (fm *FlagManager) DeployerCIKeys() []string {
+	return fm.deployerCIKeys
+}
+
+func (fm *FlagManager) ConfigureDiffFlags(flags *pflag.FlagSet) {
+	// Patterns are not bound to viper, which would split them on commas and
+	// whitespace.
+	flags.StringArrayVar(&fm.diffRedactKeys, diffRedactKeyKey, helm.DefaultRedactKeyPatterns, "regular expression matching keys whose values are redacted from release diffs; Secret data is always redacted.  May be repeated; replaces the defaults")
+}
+
+func (fm *FlagManager) DiffRedactor() (*helm.KeyRedactor, error) {
+	return helm.NewKeyRedactor(fm.diffRedactKeys)
+}
+
+func (fm *FlagManager) ConfigureFreezeFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&fm.enforceFreezes, enforceFreezesKey, false, "deny new helm release revisions while a FreezePolicy window is open")
+	viper.BindEnv(enforceFreezesKey)
+	viper.BindPFlag(enforceFreezesKey, flags.Lookup(enforceFreezesKey))
+}
+
+func (fm *FlagManager) EnforceFreezes() bool {
+	return viper.GetBool(enforceFreezesKey)
+}
+
+func (fm *FlagManager) ConfigureStatusWriterFlags(flags *pflag.FlagSet) {
+	flags.StringSliceVar(&fm.statusWriters, statusWritersKey, nil, "Kubernetes users allowed to change the status of a ReleaseHistory, e.g. 'system:serviceaccount:tugboat:tugboat-watcher'; empty allows any user")
+	viper.BindEnv(statusWritersKey)
+	viper.BindPFlag(statusWritersKey, flags.Lookup(statusWritersKey))
+}
+
+func (fm *FlagManager) StatusWriters() []string {
+	return viper.GetStringSlice(statusWritersKey)
+}
+
+func (fm *FlagManager) ConfigureSourceFlags(flags *pflag.FlagSet) {
+	// Keys and patterns are not bound to viper, which would split them on
+	// commas and whitespace.
+	flags.StringArrayVar(&fm.sourceKeys, sourceKeyKey, nil, "annotation or label key holding a revision's source-control metadata, as 'field=key', where field is one of "+strings.Join(helm.SourceFields, ", ")+".  May be repeated; replaces the tugboat.engineering/ defaults")
+	flags.StringArrayVar(&fm.sourcePatterns, sourcePatternKey, nil, "regular expression matched against the helm release description, whose named groups set source-control metadata fields.  May be repeated; replaces the defaults")
+}
+
+func (fm *FlagManager) SourceOptions() (helm.SourceOptions, error) {
+	opts := helm.DefaultSourceOptions()
+
+	if len(fm.sourceKeys) != 0 {
+		keys, err := helm.ParseSourceKeys(fm.sourceKeys)
+		if err != nil {
+			return helm.SourceOptions{}, err
+		}
+		opts.Keys = keys
+	}
+	if len(fm.sourcePatterns) != 0 {
+		patterns, err := helm.ParseDescriptionPatterns(fm.sourcePatterns)
+		if err != nil {
+			return helm.SourceOptions{}, err
+		}
+		opts.DescriptionPatterns = patterns
+	}
+
+	return opts, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -25,6 +26,10 @@ type ReconcileSecret struct {
 	Client          client.Client
 	VersionedClient versioned.Interface
 	Log             logr.Logger
+
+	// Recorder records Events on the ReleaseHistories of the helm secrets.  If
+	// nil, no Events are recorded.
+	Recorder record.EventRecorder
 }
 
 func (r *ReconcileSecret) SetupWithManager(mgr ctrl.Manager) error {
@@ -138,13 +143,18 @@ func (r *ReconcileSecret) markReleaseHistoryUninstalled(ctx context.Context, s *
 	}
 
 	newrh := rh.DeepCopy()
-	if _, ok := newrh.Labels[constants.LabelState]; ok {
+	if state, ok := newrh.Labels[constants.LabelState]; ok {
 		newrh.Labels[constants.LabelState] = constants.LabelStateUninstalled
 		_, err = r.VersionedClient.TugboatV1alpha1().ReleaseHistories(s.Namespace).Update(ctx, newrh, metav1.UpdateOptions{})
 		if err != nil {
 			r.Log.Info("failed to update; retrying", "name", chartname, "namespace", s.Namespace, "err", err.Error())
 			return true
 		}
+		if state != constants.LabelStateUninstalled && r.Recorder != nil {
+			// Helm deletes the secret of every revision when uninstalling; only
+			// record the first.
+			r.Recorder.Eventf(rh, v1.EventTypeNormal, constants.EventReasonUninstalled, "Helm release secret %s was deleted; marked the release uninstalled", s.Name)
+		}
 	}
 
 	return false
@@ -3,6 +3,7 @@ package secret
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -111,10 +113,12 @@ func Test_Reconcile_SecretWithFinalizer(t *testing.T) {
 
 func Test_Reconcile_DeletedSecretWithFinalizer(t *testing.T) {
 	req, s, rel, _, _ := createSecretAndReleaseHistory("test", "testns", true, true)
+	recorder := record.NewFakeRecorder(10)
 	rs := &ReconcileSecret{
 		Client:          fakeclient.NewFakeClient(s),
 		Log:             testlogger.TestLogger{T: t},
 		VersionedClient: fake.NewSimpleClientset(rel),
+		Recorder:        recorder,
 	}
 
 	result, err := rs.Reconcile(context.TODO(), req)
@@ -134,6 +138,35 @@ func Test_Reconcile_DeletedSecretWithFinalizer(t *testing.T) {
 	if !hasState(getReleaseHistoryFromFakeClient(t, rs.VersionedClient, rel), constants.LabelStateUninstalled) {
 		t.Error("Deleting secret does not have uninstalled state")
 	}
+
+	// Ensure that the uninstall has been recorded.
+	if len(recorder.Events) != 1 {
+		t.Fatalf("Incorrect number of events: %d", len(recorder.Events))
+	}
+	if e := <-recorder.Events; !strings.HasPrefix(e, "Normal Uninstalled ") {
+		t.Errorf("Incorrect event '%s'", e)
+	}
+}
+
+func Test_Reconcile_DeletedSecretOfUninstalledRelease(t *testing.T) {
+	req, s, rel, _, _ := createSecretAndReleaseHistory("test", "testns", true, true)
+	rel.Labels[constants.LabelState] = constants.LabelStateUninstalled
+	recorder := record.NewFakeRecorder(10)
+	rs := &ReconcileSecret{
+		Client:          fakeclient.NewFakeClient(s),
+		Log:             testlogger.TestLogger{T: t},
+		VersionedClient: fake.NewSimpleClientset(rel),
+		Recorder:        recorder,
+	}
+
+	if _, err := rs.Reconcile(context.TODO(), req); err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+
+	// The uninstall was recorded with the secret of another revision.
+	if len(recorder.Events) != 0 {
+		t.Errorf("Unexpected event '%s'", <-recorder.Events)
+	}
 }
 
 func createSecretAndReleaseHistory(name string, namespace string, withfinalizer bool, deleted bool) (reconcile.Request, *v1.Secret, *v1alpha1.ReleaseHistory, metav1.Time, *metav1.Time) {
@@ -0,0 +1,126 @@
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1beta1"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Converter is the conversion webhook of the ReleaseHistory CRD.  It
+// converts ReleaseHistories between v1alpha1 and v1beta1, by way of v1beta1.
+type Converter struct {
+	Log logr.Logger
+}
+
+// New returns a new Converter
+func New(log logr.Logger) *Converter {
+	return &Converter{
+		Log: log,
+	}
+}
+
+// ProcessConversion decodes a ConversionReview from the request, converts its
+// objects, and writes the ConversionReview response
+func (c *Converter) ProcessConversion(w http.ResponseWriter, r *http.Request) {
+	var body []byte
+	if r.Body != nil {
+		if data, err := ioutil.ReadAll(r.Body); err == nil {
+			body = data
+		}
+	}
+
+	cr := apiextv1.ConversionReview{}
+	if err := json.Unmarshal(body, &cr); err != nil || cr.Request == nil {
+		if err == nil {
+			err = fmt.Errorf("ConversionReview has no request")
+		}
+		c.Log.Error(err, "could not decode ConversionReview")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := apiextv1.ConversionReview{
+		TypeMeta: cr.TypeMeta,
+		Response: c.Convert(cr.Request),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		c.Log.Error(err, "failed to write response")
+	}
+}
+
+// Convert converts each of the objects of the request to its desired API
+// version.  If any cannot be converted, none are.
+func (c *Converter) Convert(req *apiextv1.ConversionRequest) *apiextv1.ConversionResponse {
+	converted := make([]runtime.RawExtension, len(req.Objects))
+	for k, obj := range req.Objects {
+		raw, err := convert(obj.Raw, req.DesiredAPIVersion)
+		if err != nil {
+			c.Log.Error(err, "failed to convert object", "desiredAPIVersion", req.DesiredAPIVersion)
+			return &apiextv1.ConversionResponse{
+				UID: req.UID,
+				Result: metav1.Status{
+					Status:  metav1.StatusFailure,
+					Message: err.Error(),
+				},
+			}
+		}
+		converted[k] = runtime.RawExtension{Raw: raw}
+	}
+
+	return &apiextv1.ConversionResponse{
+		UID:              req.UID,
+		ConvertedObjects: converted,
+		Result: metav1.Status{
+			Status: metav1.StatusSuccess,
+		},
+	}
+}
+
+func convert(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	tm := metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, &tm); err != nil {
+		return nil, err
+	}
+	if tm.Kind != "ReleaseHistory" {
+		return nil, fmt.Errorf("cannot convert kind '%s'", tm.Kind)
+	}
+	if tm.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	hub := v1beta1.ReleaseHistory{}
+	switch tm.APIVersion {
+	case v1alpha1.SchemeGroupVersion.String():
+		rh := v1alpha1.ReleaseHistory{}
+		if err := json.Unmarshal(raw, &rh); err != nil {
+			return nil, err
+		}
+		hub.ConvertFrom(&rh)
+	case v1beta1.SchemeGroupVersion.String():
+		if err := json.Unmarshal(raw, &hub); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("cannot convert from API version '%s'", tm.APIVersion)
+	}
+
+	switch desiredAPIVersion {
+	case v1alpha1.SchemeGroupVersion.String():
+		rh := v1alpha1.ReleaseHistory{}
+		hub.ConvertTo(&rh)
+		return json.Marshal(rh)
+	case v1beta1.SchemeGroupVersion.String():
+		return json.Marshal(hub)
+	default:
+		return nil, fmt.Errorf("cannot convert to API version '%s'", desiredAPIVersion)
+	}
+}
@@ -0,0 +1,139 @@
+package conversion
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1beta1"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func Test_Converter_ProcessConversion(t *testing.T) {
+	now := metav1.NewTime(time.Date(2021, time.January, 8, 12, 0, 0, 0, time.UTC))
+	alpha := v1alpha1.ReleaseHistory{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "tugboat.engineering/v1alpha1", Kind: "ReleaseHistory"},
+		ObjectMeta: metav1.ObjectMeta{Name: "checkout-api", Namespace: "payments"},
+		Spec:       v1alpha1.ReleaseHistorySpec{ReleaseName: "checkout-api"},
+		Status: v1alpha1.ReleaseHistoryStatus{
+			DeployedAt: now,
+			Revisions: []v1alpha1.ReleaseHistoryRevision{
+				{Revision: 3, DeployedAt: now, GVKs: map[string]string{"apps/v1, Kind=Deployment": "true"}},
+			},
+			Rollbacks: []v1alpha1.ReleaseHistoryRollback{{Time: now, From: 3, To: 2, Outcome: v1alpha1.RollbackSucceeded}},
+		},
+	}
+	alpharaw, _ := json.Marshal(alpha)
+
+	tcs := []struct {
+		name    string
+		objects [][]byte
+		desired string
+		failed  bool
+	}{
+		{
+			name:    "to-v1beta1",
+			objects: [][]byte{alpharaw},
+			desired: "tugboat.engineering/v1beta1",
+		},
+		{
+			name:    "to-same-version",
+			objects: [][]byte{alpharaw},
+			desired: "tugboat.engineering/v1alpha1",
+		},
+		{
+			name:    "to-unknown-version",
+			objects: [][]byte{alpharaw},
+			desired: "tugboat.engineering/v2",
+			failed:  true,
+		},
+		{
+			name:    "wrong-kind",
+			objects: [][]byte{[]byte(`{"apiVersion":"tugboat.engineering/v1alpha1","kind":"FreezePolicy"}`)},
+			desired: "tugboat.engineering/v1beta1",
+			failed:  true,
+		},
+		{
+			name:    "malformed",
+			objects: [][]byte{alpharaw, []byte(`{"apiVersion":"tugboat.engineering/v1alpha1","kind":"ReleaseHistory","status":"garbage"}`)},
+			desired: "tugboat.engineering/v1beta1",
+			failed:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := New(testlogger.TestLogger{T: t})
+
+			cr := apiextv1.ConversionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+				Request:  &apiextv1.ConversionRequest{UID: "123", DesiredAPIVersion: tc.desired},
+			}
+			for _, o := range tc.objects {
+				cr.Request.Objects = append(cr.Request.Objects, runtime.RawExtension{Raw: o})
+			}
+			body, _ := json.Marshal(cr)
+			w := httptest.NewRecorder()
+			c.ProcessConversion(w, httptest.NewRequest(http.MethodPost, "/v1/api/convert", bytes.NewReader(body)))
+
+			var actual apiextv1.ConversionReview
+			if err := json.NewDecoder(w.Body).Decode(&actual); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual.Kind != "ConversionReview" || actual.Response == nil || actual.Response.UID != "123" {
+				t.Fatalf("incorrect review: %#v", actual)
+			}
+			if tc.failed {
+				if actual.Response.Result.Status != metav1.StatusFailure || len(actual.Response.ConvertedObjects) != 0 {
+					t.Errorf("conversion did not fail: %#v", actual.Response)
+				}
+				return
+			}
+			if actual.Response.Result.Status != metav1.StatusSuccess || len(actual.Response.ConvertedObjects) != 1 {
+				t.Fatalf("conversion failed: %#v", actual.Response)
+			}
+
+			converted := actual.Response.ConvertedObjects[0].Raw
+			switch tc.desired {
+			case "tugboat.engineering/v1alpha1":
+				var rh v1alpha1.ReleaseHistory
+				if err := json.Unmarshal(converted, &rh); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !equality.Semantic.DeepEqual(rh, alpha) {
+					t.Errorf("incorrect conversion: %#v", rh)
+				}
+			case "tugboat.engineering/v1beta1":
+				var rh v1beta1.ReleaseHistory
+				if err := json.Unmarshal(converted, &rh); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				expected := []metav1.GroupVersionKind{{Group: "apps", Version: "v1", Kind: "Deployment"}}
+				if rh.APIVersion != tc.desired || rh.Status.Revisions[0].Revision != 3 || !reflect.DeepEqual(rh.Status.Revisions[0].Kinds, expected) || rh.Status.Rollbacks[0].To != 2 {
+					t.Errorf("incorrect conversion: %#v", rh)
+				}
+			}
+		})
+	}
+}
+
+func Test_Converter_BadRequest(t *testing.T) {
+	c := New(testlogger.TestLogger{T: t})
+
+	for _, body := range []string{"", "garbage", `{"apiVersion":"apiextensions.k8s.io/v1","kind":"ConversionReview"}`} {
+		w := httptest.NewRecorder()
+		c.ProcessConversion(w, httptest.NewRequest(http.MethodPost, "/v1/api/convert", bytes.NewReader([]byte(body))))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body '%s' returned status %d", body, w.Code)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package freeze
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"github.com/robfig/cron/v3"
+)
+
+// Freeze is a freeze window which is open
+type Freeze struct {
+	// Policy is the FreezePolicy, as "namespace/name"
+	Policy string
+	Window string
+
+	// Until is when the window closes
+	Until   time.Time
+	Message string
+
+	// Override is the policy's override for the release, if the freeze is
+	// overridden
+	Override *v1alpha1.FreezeOverride
+}
+
+// String describes the freeze for whoever attempted to deploy
+func (f *Freeze) String() string {
+	s := fmt.Sprintf("deployments are frozen by window '%s' of FreezePolicy '%s' until %s", f.Window, f.Policy, f.Until.UTC().Format(time.RFC3339))
+	if f.Message != "" {
+		s += ": " + f.Message
+	}
+	return s
+}
+
+// Active returns the open freeze window which closes last among the policies
+// which apply to the release, or nil if there is none.  The windows of a
+// policy with an unexpired override for the release are only returned if no
+// other window is open, with the override set.  Windows and overrides which
+// cannot be parsed are skipped and reported in the error, so that one
+// malformed policy does not disable the others.
+func Active(policies []*v1alpha1.FreezePolicy, release string, now time.Time) (*Freeze, error) {
+	var active, overridden *Freeze
+	var result *multierror.Error
+
+	for _, p := range policies {
+		applies, err := p.AppliesTo(release)
+		if err != nil {
+			result = multierror.Append(result, err)
+			continue
+		} else if !applies {
+			continue
+		}
+
+		override, err := overrideFor(p, release, now)
+		if err != nil {
+			result = multierror.Append(result, err)
+		}
+
+		for _, w := range p.Spec.Windows {
+			until, err := openUntil(w, now)
+			if err != nil {
+				result = multierror.Append(result, fmt.Errorf("FreezePolicy '%s/%s' window '%s': %w", p.Namespace, p.Name, w.Name, err))
+				continue
+			}
+			if until.IsZero() {
+				continue
+			}
+			f := &Freeze{
+				Policy:   p.Namespace + "/" + p.Name,
+				Window:   w.Name,
+				Until:    until,
+				Message:  w.Message,
+				Override: override,
+			}
+			if override == nil {
+				active = later(active, f)
+			} else {
+				overridden = later(overridden, f)
+			}
+		}
+	}
+
+	if active == nil {
+		active = overridden
+	}
+	return active, result.ErrorOrNil()
+}
+
+// later returns whichever freeze closes last, preferring a if they close
+// together
+func later(a *Freeze, b *Freeze) *Freeze {
+	if a != nil && !b.Until.After(a.Until) {
+		return a
+	}
+	return b
+}
+
+// overrideFor returns the policy's unexpired override for the release, or nil
+// if there is none.  An override without a reason does not apply.
+func overrideFor(p *v1alpha1.FreezePolicy, release string, now time.Time) (*v1alpha1.FreezeOverride, error) {
+	for i, o := range p.Spec.Overrides {
+		if o.Release != release || !now.Before(o.Expires.Time) {
+			continue
+		}
+		if o.Reason == "" {
+			return nil, fmt.Errorf("FreezePolicy '%s/%s' override for release '%s' must give a reason", p.Namespace, p.Name, release)
+		}
+		return &p.Spec.Overrides[i], nil
+	}
+	return nil, nil
+}
+
+// openUntil returns when the window closes, if it is open now.  The window is
+// open if it opened within its duration before now; if it opened more than
+// once, e.g. hourly for two hours, it closes after the last opening.
+func openUntil(w v1alpha1.FreezeWindow, now time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(w.Schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule '%s': %w", w.Schedule, err)
+	}
+	if w.Duration.Duration <= 0 {
+		return time.Time{}, fmt.Errorf("duration must be positive")
+	}
+	loc := time.UTC
+	if w.TimeZone != "" {
+		if loc, err = time.LoadLocation(w.TimeZone); err != nil {
+			return time.Time{}, fmt.Errorf("invalid time zone '%s': %w", w.TimeZone, err)
+		}
+	}
+
+	var until time.Time
+	for opened := schedule.Next(now.In(loc).Add(-w.Duration.Duration)); !opened.IsZero() && !opened.After(now); opened = schedule.Next(opened) {
+		until = opened.Add(w.Duration.Duration)
+	}
+	return until, nil
+}
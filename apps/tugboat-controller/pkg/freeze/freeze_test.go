@@ -0,0 +1,174 @@
+package freeze
+
+import (
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_Active(t *testing.T) {
+	weekend := v1alpha1.FreezeWindow{Name: "weekend", Schedule: "0 17 * * FRI", Duration: metav1.Duration{Duration: 63 * time.Hour}, Message: "no weekend deploys"}
+	holidays := v1alpha1.FreezeWindow{Name: "holidays", Schedule: "0 0 20 12 *", Duration: metav1.Duration{Duration: 17 * 24 * time.Hour}}
+
+	// Friday, January 8th 2021
+	friday := time.Date(2021, time.January, 8, 0, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		name             string
+		releases         []string
+		windows          []v1alpha1.FreezeWindow
+		overrides        []v1alpha1.FreezeOverride
+		now              time.Time
+		expected         string
+		expectedUntil    time.Time
+		expectedOverride bool
+		expectedErr      bool
+	}{
+		{
+			name:    "before",
+			windows: []v1alpha1.FreezeWindow{weekend},
+			now:     friday.Add(16 * time.Hour),
+		},
+		{
+			name:          "opened",
+			windows:       []v1alpha1.FreezeWindow{weekend},
+			now:           friday.Add(17 * time.Hour),
+			expected:      "weekend",
+			expectedUntil: friday.Add(80 * time.Hour),
+		},
+		{
+			name:          "during",
+			windows:       []v1alpha1.FreezeWindow{weekend},
+			now:           friday.Add(60 * time.Hour),
+			expected:      "weekend",
+			expectedUntil: friday.Add(80 * time.Hour),
+		},
+		{
+			name:    "closed",
+			windows: []v1alpha1.FreezeWindow{weekend},
+			now:     friday.Add(80 * time.Hour),
+		},
+		{
+			name:          "time-zone",
+			windows:       []v1alpha1.FreezeWindow{{Name: "weekend", Schedule: "0 17 * * FRI", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "America/New_York"}},
+			now:           friday.Add(22*time.Hour + 30*time.Minute),
+			expected:      "weekend",
+			expectedUntil: friday.Add(23 * time.Hour),
+		},
+		{
+			name:          "latest-closing",
+			windows:       []v1alpha1.FreezeWindow{weekend, holidays},
+			now:           time.Date(2021, time.January, 1, 20, 0, 0, 0, time.UTC),
+			expected:      "holidays",
+			expectedUntil: time.Date(2021, time.January, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "other-release",
+			releases: []string{"checkout*"},
+			windows:  []v1alpha1.FreezeWindow{weekend},
+			now:      friday.Add(60 * time.Hour),
+		},
+		{
+			name:          "invalid-window",
+			windows:       []v1alpha1.FreezeWindow{{Name: "broken", Schedule: "every friday"}, weekend},
+			now:           friday.Add(60 * time.Hour),
+			expected:      "weekend",
+			expectedUntil: friday.Add(80 * time.Hour),
+			expectedErr:   true,
+		},
+		{
+			name:             "overridden",
+			windows:          []v1alpha1.FreezeWindow{weekend},
+			overrides:        []v1alpha1.FreezeOverride{{Release: "web", Reason: "hotfix", Expires: metav1.NewTime(friday.Add(61 * time.Hour))}},
+			now:              friday.Add(60 * time.Hour),
+			expected:         "weekend",
+			expectedUntil:    friday.Add(80 * time.Hour),
+			expectedOverride: true,
+		},
+		{
+			name:          "override-expired",
+			windows:       []v1alpha1.FreezeWindow{weekend},
+			overrides:     []v1alpha1.FreezeOverride{{Release: "web", Reason: "hotfix", Expires: metav1.NewTime(friday.Add(60 * time.Hour))}},
+			now:           friday.Add(60 * time.Hour),
+			expected:      "weekend",
+			expectedUntil: friday.Add(80 * time.Hour),
+		},
+		{
+			name:          "override-other-release",
+			windows:       []v1alpha1.FreezeWindow{weekend},
+			overrides:     []v1alpha1.FreezeOverride{{Release: "api", Reason: "hotfix", Expires: metav1.NewTime(friday.Add(61 * time.Hour))}},
+			now:           friday.Add(60 * time.Hour),
+			expected:      "weekend",
+			expectedUntil: friday.Add(80 * time.Hour),
+		},
+		{
+			name:          "override-without-reason",
+			windows:       []v1alpha1.FreezeWindow{weekend},
+			overrides:     []v1alpha1.FreezeOverride{{Release: "web", Expires: metav1.NewTime(friday.Add(61 * time.Hour))}},
+			now:           friday.Add(60 * time.Hour),
+			expected:      "weekend",
+			expectedUntil: friday.Add(80 * time.Hour),
+			expectedErr:   true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &v1alpha1.FreezePolicy{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "freezes"},
+				Spec:       v1alpha1.FreezePolicySpec{Releases: tc.releases, Windows: tc.windows, Overrides: tc.overrides},
+			}
+
+			f, err := Active([]*v1alpha1.FreezePolicy{p}, "web", tc.now)
+			if tc.expectedErr != (err != nil) {
+				t.Errorf("unexpected error result: %v", err)
+			}
+			if tc.expected == "" {
+				if f != nil {
+					t.Errorf("unexpected freeze: %#v", f)
+				}
+				return
+			}
+			if f == nil {
+				t.Fatalf("expected freeze")
+			}
+			if f.Window != tc.expected || !f.Until.Equal(tc.expectedUntil) || f.Policy != "shop/freezes" {
+				t.Errorf("incorrect freeze: %#v", f)
+			}
+			if tc.expectedOverride != (f.Override != nil) {
+				t.Errorf("incorrect override: %#v", f.Override)
+			}
+		})
+	}
+}
+
+func Test_Active_OverriddenPolicy(t *testing.T) {
+	// Friday, January 8th 2021
+	friday := time.Date(2021, time.January, 8, 0, 0, 0, 0, time.UTC)
+	now := friday.Add(60 * time.Hour)
+
+	overridden := &v1alpha1.FreezePolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "weekends"},
+		Spec: v1alpha1.FreezePolicySpec{
+			Windows:   []v1alpha1.FreezeWindow{{Name: "weekend", Schedule: "0 17 * * FRI", Duration: metav1.Duration{Duration: 63 * time.Hour}}},
+			Overrides: []v1alpha1.FreezeOverride{{Release: "web", Reason: "hotfix", Expires: metav1.NewTime(now.Add(time.Hour))}},
+		},
+	}
+	other := &v1alpha1.FreezePolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "maintenance"},
+		Spec: v1alpha1.FreezePolicySpec{
+			Windows: []v1alpha1.FreezeWindow{{Name: "maintenance", Schedule: "0 0 * * SUN", Duration: metav1.Duration{Duration: 14 * time.Hour}}},
+		},
+	}
+
+	// An override only lifts the windows of its own policy.
+	f, err := Active([]*v1alpha1.FreezePolicy{overridden, other}, "web", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if f == nil || f.Policy != "shop/maintenance" || f.Override != nil {
+		t.Errorf("incorrect freeze: %#v", f)
+	}
+}
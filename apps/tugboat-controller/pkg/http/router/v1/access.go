@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/pkg/grpc/auth"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SecretAccess decides whether a caller may read the Helm release secrets of
+// a namespace.  The caller's bearer token is authenticated with the
+// TokenReview API, and the caller must be allowed to get secrets in the
+// namespace, as checked with a SubjectAccessReview.
+type SecretAccess struct {
+	clientset     kubernetes.Interface
+	authenticator auth.Authenticator
+}
+
+// NewSecretAccess returns a new SecretAccess
+func NewSecretAccess(clientset kubernetes.Interface) *SecretAccess {
+	return &SecretAccess{
+		clientset:     clientset,
+		authenticator: auth.NewTokenReview(clientset),
+	}
+}
+
+// Authorize returns auth.ErrUnauthenticated if the token is not valid, and
+// auth.ErrPermissionDenied if its user may not get secrets in the namespace
+func (a *SecretAccess) Authorize(ctx context.Context, token string, namespace string) error {
+	id, err := a.authenticator.Authenticate(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	result, err := a.clientset.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   id.Username,
+			Groups: id.Groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "get",
+				Resource:  "secrets",
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to review access: %w", err)
+	}
+	if !result.Status.Allowed {
+		return fmt.Errorf("user '%s' may not get secrets in namespace '%s': %w", id.Username, namespace, auth.ErrPermissionDenied)
+	}
+	return nil
+}
+
+// requireSecretAccess rejects requests whose bearer token may not read the
+// secrets of the namespace in the path
+func requireSecretAccess(logger logr.Logger, a *SecretAccess, namespace func(r *http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const prefix = "bearer "
+		header := r.Header.Get("Authorization")
+		if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		err := a.Authorize(r.Context(), strings.TrimSpace(header[len(prefix):]), namespace(r))
+		switch {
+		case err == nil:
+			next(w, r)
+		case errors.Is(err, auth.ErrUnauthenticated):
+			logger.Info("rejected request", "path", r.URL.Path, "reason", err.Error())
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case errors.Is(err, auth.ErrPermissionDenied):
+			logger.Info("rejected request", "path", r.URL.Path, "reason", err.Error())
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			logger.Error(err, "failed to authorize request", "path", r.URL.Path)
+			http.Error(w, "failed to authorize request", http.StatusServiceUnavailable)
+		}
+	}
+}
@@ -1,17 +1,23 @@
 package v1
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/go-logr/logr"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/object88/tugboat/apps/tugboat-controller/pkg/conversion"
+	"github.com/object88/tugboat/apps/tugboat-controller/pkg/releasediff"
 	"github.com/object88/tugboat/apps/tugboat-controller/pkg/validator"
 	"github.com/object88/tugboat/pkg/http/router/route"
+	"github.com/object88/tugboat/pkg/k8s/informermanager"
 	"github.com/object88/tugboat/pkg/logging"
 )
 
-func Defaults(logger logr.Logger, m *validator.M, v *validator.V, v2 *validator.V2) []*route.Route {
+func Defaults(logger logr.Logger, m *validator.M, v *validator.V, v2 *validator.V2, c *conversion.Converter, d *releasediff.Differ, a *SecretAccess, im *informermanager.Manager) []*route.Route {
 	return []*route.Route{
 		{
 			Path:       "/v1/api",
@@ -32,6 +38,21 @@ func Defaults(logger logr.Logger, m *validator.M, v *validator.V, v2 *validator.
 					Handler: configureValidatingHelmSecretAdmission(v2),
 					Methods: []string{http.MethodPost},
 				},
+				{
+					Path:    "/convert",
+					Handler: configureConversion(c),
+					Methods: []string{http.MethodPost},
+				},
+				{
+					Path:    "/releases/{namespace}/{name}/diff",
+					Handler: requireSecretAccess(logger, a, namespaceVar, configureReleaseDiff(logger, d)),
+					Methods: []string{http.MethodGet},
+				},
+				{
+					Path:    "/informers",
+					Handler: configureInformers(logger, im),
+					Methods: []string{http.MethodGet},
+				},
 			},
 		},
 	}
@@ -65,6 +86,74 @@ func configureValidatingHelmSecretAdmission(v *validator.V2) http.HandlerFunc {
 	}
 }
 
+func configureConversion(c *conversion.Converter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c.ProcessConversion(w, r)
+	}
+}
+
+// configureReleaseDiff reports the difference between two revisions of a
+// release.  The optional `from` and `to` query parameters select the
+// revisions; by default, the latest revision is compared to the one before it.
+// The diff is read from the release secrets, so the caller must be allowed
+// to get secrets in the release's namespace.
+func configureReleaseDiff(logger logr.Logger, d *releasediff.Differ) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		revisions := [2]int{}
+		for k, param := range []string{"from", "to"} {
+			raw := r.URL.Query().Get(param)
+			if raw == "" {
+				continue
+			}
+			rev, err := strconv.Atoi(raw)
+			if err != nil || rev < 1 {
+				http.Error(w, "query param '"+param+"' must be a revision number", http.StatusBadRequest)
+				return
+			}
+			revisions[k] = rev
+		}
+		if revisions[0] != 0 && revisions[0] >= revisions[1] && revisions[1] != 0 {
+			http.Error(w, "query param 'from' must be before 'to'", http.StatusBadRequest)
+			return
+		}
+
+		diff, err := d.Diff(vars["namespace"], vars["name"], revisions[0], revisions[1])
+		if errors.Is(err, releasediff.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if errors.Is(err, releasediff.ErrInvalidRange) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			logger.Error(err, "failed to diff release", "namespace", vars["namespace"], "name", vars["name"])
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(diff); err != nil {
+			logger.Error(err, "failed to write release diff")
+		}
+	}
+}
+
+// configureInformers reports whether each informer has synced, and its last
+// watch error
+func configureInformers(logger logr.Logger, im *informermanager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(im.Status()); err != nil {
+			logger.Error(err, "failed to write informer status")
+		}
+	}
+}
+
+func namespaceVar(r *http.Request) string {
+	return mux.Vars(r)["namespace"]
+}
+
 type LogContextHandler struct {
 	logger logr.Logger
 	next   http.Handler
@@ -0,0 +1,153 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/go-multierror"
+	"github.com/object88/tugboat/pkg/http/probes"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1beta1"
+	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned"
+	apiextclientv1 "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// DefaultInterval is how long to wait before retrying a migration which
+	// did not complete
+	DefaultInterval = time.Minute
+
+	// CRDName is the name of the ReleaseHistory CustomResourceDefinition
+	CRDName = "releasehistories.tugboat.engineering"
+
+	// listLimit is the number of ReleaseHistories read at once
+	listLimit = 100
+)
+
+// Migrator moves ReleaseHistories stored as an older API version, e.g.
+// v1alpha1, to the storage version, v1beta1.  Each ReleaseHistory is
+// rewritten unchanged, which the API server stores as v1beta1, and then the
+// older versions are removed from the stored versions of the CRD, so that
+// they can eventually stop being served.
+type Migrator struct {
+	log       logr.Logger
+	clientset versioned.Interface
+	crds      apiextclientv1.CustomResourceDefinitionsGetter
+
+	// Interval is how long to wait before retrying a migration which did not
+	// complete
+	Interval time.Duration
+}
+
+// New returns a new Migrator
+func New(log logr.Logger, clientset versioned.Interface, crds apiextclientv1.CustomResourceDefinitionsGetter) *Migrator {
+	return &Migrator{
+		log:       log,
+		clientset: clientset,
+		crds:      crds,
+		Interval:  DefaultInterval,
+	}
+}
+
+// Run migrates the ReleaseHistories, retrying every interval until the
+// migration completes, and then waits for the context to be done.  It is
+// ready immediately: the API server converts stored ReleaseHistories with
+// the conversion webhook, which must be served for the migration to
+// complete.
+func (m *Migrator) Run(ctx context.Context, r probes.Reporter) error {
+	r.Ready()
+	for {
+		err := m.Migrate(ctx)
+		if err == nil {
+			break
+		}
+		m.log.Error(err, "failed to migrate release histories; will retry", "interval", m.Interval)
+
+		select {
+		case <-ctx.Done():
+			r.NotReady()
+			return ctx.Err()
+		case <-time.After(m.Interval):
+		}
+	}
+
+	<-ctx.Done()
+	r.NotReady()
+	return ctx.Err()
+}
+
+// Migrate rewrites every ReleaseHistory, unless only the storage version is
+// stored, and then records that only the storage version is stored
+func (m *Migrator) Migrate(ctx context.Context) error {
+	crd, err := m.crds.CustomResourceDefinitions().Get(ctx, CRDName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get CRD %s: %w", CRDName, err)
+	}
+	if migrated(crd.Status.StoredVersions) {
+		m.log.V(1).Info("release histories are stored as the storage version", "version", v1beta1.SchemeGroupVersion.Version)
+		return nil
+	}
+
+	m.log.Info("migrating release histories", "from", crd.Status.StoredVersions, "to", v1beta1.SchemeGroupVersion.Version)
+	count, err := m.rewrite(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := m.crds.CustomResourceDefinitions().Get(ctx, CRDName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		copycrd := current.DeepCopy()
+		copycrd.Status.StoredVersions = []string{v1beta1.SchemeGroupVersion.Version}
+		_, err = m.crds.CustomResourceDefinitions().UpdateStatus(ctx, copycrd, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update stored versions of CRD %s: %w", CRDName, err)
+	}
+
+	m.log.Info("migrated release histories", "count", count)
+	return nil
+}
+
+// rewrite updates each ReleaseHistory without changing it.  A
+// ReleaseHistory which has been deleted or changed since it was listed has
+// already been rewritten.
+func (m *Migrator) rewrite(ctx context.Context) (int, error) {
+	rhs := m.clientset.TugboatV1beta1().ReleaseHistories(metav1.NamespaceAll)
+
+	var errs *multierror.Error
+	count := 0
+	opts := metav1.ListOptions{Limit: listLimit}
+	for {
+		list, err := rhs.List(ctx, opts)
+		if err != nil {
+			return count, fmt.Errorf("failed to list release histories: %w", err)
+		}
+		for k := range list.Items {
+			rh := &list.Items[k]
+			_, err := m.clientset.TugboatV1beta1().ReleaseHistories(rh.Namespace).Update(ctx, rh, metav1.UpdateOptions{})
+			if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+				errs = multierror.Append(errs, fmt.Errorf("failed to rewrite release history %s/%s: %w", rh.Namespace, rh.Name, err))
+				continue
+			}
+			count++
+		}
+		if list.Continue == "" {
+			break
+		}
+		opts.Continue = list.Continue
+	}
+
+	return count, errs.ErrorOrNil()
+}
+
+func migrated(storedVersions []string) bool {
+	return len(storedVersions) == 1 && storedVersions[0] == v1beta1.SchemeGroupVersion.Version
+}
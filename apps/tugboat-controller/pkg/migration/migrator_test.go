@@ -0,0 +1,89 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1beta1"
+	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/fake"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_Migrator_Migrate(t *testing.T) {
+	tcs := []struct {
+		name            string
+		storedVersions  []string
+		updateErr       error
+		expectedUpdates int
+		expectedStored  []string
+		expectedErr     bool
+	}{
+		{
+			name:            "migrates",
+			storedVersions:  []string{"v1alpha1", "v1beta1"},
+			expectedUpdates: 2,
+			expectedStored:  []string{"v1beta1"},
+		},
+		{
+			name:           "already-migrated",
+			storedVersions: []string{"v1beta1"},
+			expectedStored: []string{"v1beta1"},
+		},
+		{
+			name:            "rewrite-fails",
+			storedVersions:  []string{"v1alpha1", "v1beta1"},
+			updateErr:       fmt.Errorf("admission webhook denied the request"),
+			expectedUpdates: 2,
+			expectedStored:  []string{"v1alpha1", "v1beta1"},
+			expectedErr:     true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(
+				&v1beta1.ReleaseHistory{ObjectMeta: metav1.ObjectMeta{Name: "checkout-api", Namespace: "payments"}},
+				&v1beta1.ReleaseHistory{ObjectMeta: metav1.ObjectMeta{Name: "search", Namespace: "default"}},
+			)
+			updates := 0
+			clientset.PrependReactor("update", "releasehistories", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				updates++
+				if tc.updateErr != nil {
+					return true, nil, tc.updateErr
+				}
+				return false, nil, nil
+			})
+			crds := apiextfake.NewSimpleClientset(&apiextv1.CustomResourceDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: CRDName},
+				Status:     apiextv1.CustomResourceDefinitionStatus{StoredVersions: tc.storedVersions},
+			})
+
+			m := New(testlogger.TestLogger{T: t}, clientset, crds.ApiextensionsV1())
+			err := m.Migrate(context.Background())
+			if tc.expectedErr && err == nil {
+				t.Errorf("did not get expected error")
+			} else if !tc.expectedErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if updates != tc.expectedUpdates {
+				t.Errorf("incorrect updates: expected %d, got %d", tc.expectedUpdates, updates)
+			}
+
+			crd, err := crds.ApiextensionsV1().CustomResourceDefinitions().Get(context.Background(), CRDName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(crd.Status.StoredVersions, tc.expectedStored) {
+				t.Errorf("incorrect stored versions: %v", crd.Status.StoredVersions)
+			}
+		})
+	}
+}
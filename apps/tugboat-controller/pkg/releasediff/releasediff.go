@@ -0,0 +1,132 @@
+package releasediff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/helm"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/kubernetes"
+	listercorev1 "k8s.io/client-go/listers/core/v1"
+)
+
+// ErrNotFound is returned when a release or one of its revisions does not
+// exist
+var ErrNotFound = errors.New("not found")
+
+// ErrInvalidRange is returned when the from revision is not before the to
+// revision
+var ErrInvalidRange = errors.New("invalid revision range")
+
+// SecretLister lists the secrets in a namespace which match a selector
+type SecretLister func(namespace string, selector labels.Selector) ([]*v1.Secret, error)
+
+// FromLister lists secrets from an informer's cache
+func FromLister(l listercorev1.SecretLister) SecretLister {
+	return func(namespace string, selector labels.Selector) ([]*v1.Secret, error) {
+		return l.Secrets(namespace).List(selector)
+	}
+}
+
+// FromClientset lists secrets from the API server
+func FromClientset(ctx context.Context, clientset kubernetes.Interface) SecretLister {
+	return func(namespace string, selector labels.Selector) ([]*v1.Secret, error) {
+		list, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, err
+		}
+		secrets := make([]*v1.Secret, len(list.Items))
+		for k := range list.Items {
+			secrets[k] = &list.Items[k]
+		}
+		return secrets, nil
+	}
+}
+
+// Differ diffs revisions of the releases stored in helm release secrets
+type Differ struct {
+	secrets  SecretLister
+	redactor *helm.KeyRedactor
+}
+
+// New returns a Differ
+func New(secrets SecretLister, redactor *helm.KeyRedactor) *Differ {
+	return &Differ{
+		secrets:  secrets,
+		redactor: redactor,
+	}
+}
+
+// Diff returns the difference between two revisions of a release.  If to is
+// 0, the latest revision is used; if from is 0, the revision before to is
+// used.
+func (d *Differ) Diff(namespace string, name string, from int, to int) (*helm.ReleaseDiff, error) {
+	r0, err0 := labels.NewRequirement(constants.HelmSecretLabelOwner, selection.Equals, []string{constants.HelmSecretLabelOwnerHelm})
+	r1, err1 := labels.NewRequirement(constants.HelmSecretLabelName, selection.Equals, []string{name})
+	if err0 != nil || err1 != nil {
+		return nil, fmt.Errorf("failed to create requirement: %v, %v", err0, err1)
+	}
+
+	secrets, err := d.secrets(namespace, labels.NewSelector().Add(*r0, *r1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list release secrets: %w", err)
+	}
+
+	revisions := map[int]*v1.Secret{}
+	latest := 0
+	for _, s := range secrets {
+		rev, err := strconv.Atoi(s.Labels[constants.HelmSecretLabelRevision])
+		if err != nil {
+			continue
+		}
+		revisions[rev] = s
+		if rev > latest {
+			latest = rev
+		}
+	}
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("release '%s/%s': %w", namespace, name, ErrNotFound)
+	}
+
+	if to == 0 {
+		to = latest
+	}
+	if from == 0 {
+		for rev := range revisions {
+			if rev < to && rev > from {
+				from = rev
+			}
+		}
+		if from == 0 {
+			return nil, fmt.Errorf("revision before %d of release '%s/%s': %w", to, namespace, name, ErrNotFound)
+		}
+	}
+	if from >= to {
+		return nil, fmt.Errorf("revision %d is not before revision %d: %w", from, to, ErrInvalidRange)
+	}
+
+	prev, ok := revisions[from]
+	if !ok {
+		return nil, fmt.Errorf("revision %d of release '%s/%s': %w", from, namespace, name, ErrNotFound)
+	}
+	curr, ok := revisions[to]
+	if !ok {
+		return nil, fmt.Errorf("revision %d of release '%s/%s': %w", to, namespace, name, ErrNotFound)
+	}
+
+	prevRelease, err := helm.DecodeRelease(prev)
+	if err != nil {
+		return nil, err
+	}
+	currRelease, err := helm.DecodeRelease(curr)
+	if err != nil {
+		return nil, err
+	}
+	return helm.Diff(prevRelease, currRelease, d.redactor)
+}
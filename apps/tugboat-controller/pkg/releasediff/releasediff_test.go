@@ -0,0 +1,101 @@
+package releasediff
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/object88/tugboat/internal/constants"
+	"helm.sh/helm/v3/pkg/release"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_Differ_Diff(t *testing.T) {
+	tcs := []struct {
+		name         string
+		from         int
+		to           int
+		expectedFrom int
+		expectedTo   int
+		expectedErr  error
+	}{
+		{name: "latest", expectedFrom: 2, expectedTo: 3},
+		{name: "to", to: 2, expectedFrom: 1, expectedTo: 2},
+		{name: "from", from: 1, expectedFrom: 1, expectedTo: 3},
+		{name: "from-and-to", from: 1, to: 3, expectedFrom: 1, expectedTo: 3},
+		{name: "missing", from: 1, to: 4, expectedErr: ErrNotFound},
+		{name: "first", to: 1, expectedErr: ErrNotFound},
+		{name: "same", from: 2, to: 2, expectedErr: ErrInvalidRange},
+		{name: "reversed", from: 3, to: 2, expectedErr: ErrInvalidRange},
+		{name: "from-latest", from: 3, expectedErr: ErrInvalidRange},
+		{name: "from-after-latest", from: 5, expectedErr: ErrInvalidRange},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			objs := []runtime.Object{}
+			for rev := 1; rev <= 3; rev++ {
+				objs = append(objs, releaseSecret(t, rev))
+			}
+			d := New(FromClientset(context.Background(), fake.NewSimpleClientset(objs...)), nil)
+
+			actual, err := d.Diff("store", "shop", tc.from, tc.to)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("expected error '%v'; got '%v'", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual.From != tc.expectedFrom || actual.To != tc.expectedTo {
+				t.Errorf("incorrect revisions: expected %d to %d, actual %d to %d", tc.expectedFrom, tc.expectedTo, actual.From, actual.To)
+			}
+			if len(actual.Values) != 1 || actual.Values[0].Path != "replicas" {
+				t.Errorf("incorrect values: %#v", actual.Values)
+			}
+		})
+	}
+}
+
+func Test_Differ_Diff_NotFound(t *testing.T) {
+	d := New(FromClientset(context.Background(), fake.NewSimpleClientset()), nil)
+	if _, err := d.Diff("store", "shop", 0, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound; got %v", err)
+	}
+}
+
+// releaseSecret stores an uncompressed release the way that helm does
+func releaseSecret(t *testing.T, rev int) *v1.Secret {
+	rls := &release.Release{
+		Name:      "shop",
+		Namespace: "store",
+		Version:   rev,
+		Config:    map[string]interface{}{"replicas": float64(rev)},
+	}
+	b, err := json.Marshal(rls)
+	if err != nil {
+		t.Fatalf("failed to marshal release: %v", err)
+	}
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "store",
+			Name:      fmt.Sprintf("sh.helm.release.v1.shop.v%d", rev),
+			Labels: map[string]string{
+				constants.HelmSecretLabelOwner:    constants.HelmSecretLabelOwnerHelm,
+				constants.HelmSecretLabelName:     "shop",
+				constants.HelmSecretLabelRevision: strconv.Itoa(rev),
+			},
+		},
+		Type: constants.HelmSecretType,
+		Data: map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(b))},
+	}
+}
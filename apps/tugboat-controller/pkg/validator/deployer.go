@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+const serviceAccountUsernamePrefix = "system:serviceaccount:"
+
+// deployer identifies who created a helm release secret.  CI identity is read
+// from each of the ciKeys, first from the user's extra fields, which an
+// authenticating proxy or impersonation may set, and then from the secret's
+// annotations.
+func deployer(user authenticationv1.UserInfo, annotations map[string]string, ciKeys []string) *v1alpha1.ReleaseHistoryDeployer {
+	if user.Username == "" {
+		return nil
+	}
+
+	d := &v1alpha1.ReleaseHistoryDeployer{
+		Username: user.Username,
+		Groups:   user.Groups,
+	}
+
+	// Service account usernames are "system:serviceaccount:namespace:name".
+	if strings.HasPrefix(user.Username, serviceAccountUsernamePrefix) {
+		parts := strings.Split(strings.TrimPrefix(user.Username, serviceAccountUsernamePrefix), ":")
+		if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+			d.ServiceAccount = parts[0] + "/" + parts[1]
+		}
+	}
+
+	for _, key := range ciKeys {
+		value := ""
+		if extra, ok := user.Extra[key]; ok && len(extra) != 0 {
+			value = strings.Join(extra, ",")
+		} else if a, ok := annotations[key]; ok {
+			value = a
+		}
+		if value == "" {
+			continue
+		}
+		if d.CI == nil {
+			d.CI = map[string]string{}
+		}
+		d.CI[key] = value
+	}
+
+	return d
+}
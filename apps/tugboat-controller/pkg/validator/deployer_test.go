@@ -0,0 +1,69 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+func Test_Deployer(t *testing.T) {
+	ciKeys := []string{"ci.example.com/job", "ci.example.com/pipeline"}
+
+	tcs := []struct {
+		name        string
+		user        authenticationv1.UserInfo
+		annotations map[string]string
+		expected    *v1alpha1.ReleaseHistoryDeployer
+	}{
+		{
+			name: "anonymous",
+		},
+		{
+			name: "user",
+			user: authenticationv1.UserInfo{Username: "alice@example.com", Groups: []string{"developers", "system:authenticated"}},
+			expected: &v1alpha1.ReleaseHistoryDeployer{
+				Username: "alice@example.com",
+				Groups:   []string{"developers", "system:authenticated"},
+			},
+		},
+		{
+			name: "service-account",
+			user: authenticationv1.UserInfo{Username: "system:serviceaccount:ci:deployer"},
+			expected: &v1alpha1.ReleaseHistoryDeployer{
+				Username:       "system:serviceaccount:ci:deployer",
+				ServiceAccount: "ci/deployer",
+			},
+		},
+		{
+			name: "ci-from-extra-and-annotations",
+			user: authenticationv1.UserInfo{
+				Username: "system:serviceaccount:ci:deployer",
+				Extra:    map[string]authenticationv1.ExtraValue{"ci.example.com/job": {"1234"}},
+			},
+			annotations: map[string]string{
+				"ci.example.com/job":      "ignored",
+				"ci.example.com/pipeline": "https://ci.example.com/shop/42",
+				"unrelated":               "value",
+			},
+			expected: &v1alpha1.ReleaseHistoryDeployer{
+				Username:       "system:serviceaccount:ci:deployer",
+				ServiceAccount: "ci/deployer",
+				CI: map[string]string{
+					"ci.example.com/job":      "1234",
+					"ci.example.com/pipeline": "https://ci.example.com/shop/42",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := deployer(tc.user, tc.annotations, ciKeys)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("incorrect deployer:\nexpected: %#v\nactual: %#v", tc.expected, actual)
+			}
+		})
+	}
+}
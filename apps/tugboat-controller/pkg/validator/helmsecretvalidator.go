@@ -4,13 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/apps/tugboat-controller/pkg/freeze"
 	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/helm"
 	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
 	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned"
+	typedv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/typed/engineering.tugboat/v1alpha1"
 	listerv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/listers/engineering.tugboat/v1alpha1"
+	"helm.sh/helm/v3/pkg/release"
 	v1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -18,21 +25,41 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
+	listercorev1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/retry"
 )
 
 type V2 struct {
 	Webhook
+
+	// CIKeys are the user extra fields and helm release secret annotations
+	// which identify the CI job deploying a revision
+	CIKeys []string
+
+	// Source describes where the source-control metadata of a revision is
+	// found
+	Source helm.SourceOptions
+
+	// Freezes lists the FreezePolicies.  If set, new revisions are denied
+	// while a freeze window is open.
+	Freezes listerv1alpha1.FreezePolicyLister
+
 	scheme             *runtime.Scheme
-	versionedclientset *versioned.Clientset
+	versionedclientset versioned.Interface
 	lister             listerv1alpha1.ReleaseHistoryLister
+	secretlister       listercorev1.SecretLister
+	now                func() time.Time
 }
 
-func NewV2(log logr.Logger, scheme *runtime.Scheme, clientset *versioned.Clientset, lister listerv1alpha1.ReleaseHistoryLister) *V2 {
+func NewV2(log logr.Logger, scheme *runtime.Scheme, clientset versioned.Interface, lister listerv1alpha1.ReleaseHistoryLister, secretlister listercorev1.SecretLister) *V2 {
 	v := V2{
 		Webhook:            NewWebhook(log),
 		scheme:             scheme,
 		versionedclientset: clientset,
 		lister:             lister,
+		secretlister:       secretlister,
+		Source:             helm.DefaultSourceOptions(),
+		now:                time.Now,
 	}
 	v.WebhookProcessor = &v
 	return &v
@@ -87,6 +114,11 @@ func (v *V2) Process(ctx context.Context, req *v1.AdmissionRequest) *v1.Admissio
 		}
 	}
 
+	denied, warnings := v.checkFreezes(req, obj, chartnamespace, chartname)
+	if denied != nil {
+		return denied
+	}
+
 	// Check to see if there is a release history.  If there isn't one, then we
 	// want to create one and wait for it to be available.
 	annotations := obj.Annotations
@@ -129,30 +161,48 @@ func (v *V2) Process(ctx context.Context, req *v1.AdmissionRequest) *v1.Admissio
 		// There was no error, indicating that the history does already exist.
 		v.Log.Info("already have release history; ignoring", "name", chartname, "namespace", obj.Namespace)
 
-		found := false
-		for _, rev := range rh.Status.Revisions {
-			if int(rev.Revision) == chartrevision {
-				found = true
-				break
-			}
-		}
+		i := indexOfRevision(rh.Status.Revisions, v1alpha1.Revision(chartrevision))
 		// TODO: consider checking the "status" of the secret so that we don't
 		// create revision records for past objects.
-		// if !found && obj.Labels["status"] == "deploying" {
-		if !found {
+		// if i == -1 && obj.Labels["status"] == "deploying" {
+		if i == -1 {
 			// This is a novel revision; add it to the pile
 			rev := v1alpha1.ReleaseHistoryRevision{
 				DeployedAt: obj.CreationTimestamp,
 				GVKs:       map[string]string{},
 				Revision:   v1alpha1.Revision(chartrevision),
+				Status:     obj.Labels[constants.HelmSecretLabelStatus],
+				DeployedBy: deployer(req.UserInfo, obj.Annotations, v.CIKeys),
+				Source:     v.harvestSource(log, obj),
+				Changes:    v.summarizeChanges(log, obj, chartname, chartrevision),
 			}
 
 			newrh := rh.DeepCopy()
 			newrh.Status.Revisions = append(newrh.Status.Revisions, rev)
+			if over := len(newrh.Status.Revisions) - v1alpha1.MaxRevisions; over > 0 {
+				newrh.Status.Revisions = newrh.Status.Revisions[over:]
+			}
 
 			_, err = namespacedHistories.UpdateStatus(ctx, newrh, metav1.UpdateOptions{})
 			if err != nil {
 				v.Log.Info("failed to upate existing release history status with new revision", "err", err.Error())
+			} else {
+				v.eventf(rh, corev1.EventTypeNormal, constants.EventReasonRevisionAdded, "Added revision %d", chartrevision)
+			}
+		} else {
+			if v.failed(req, obj) {
+				v.eventf(rh, corev1.EventTypeWarning, constants.EventReasonRolloutFailed, "Revision %d failed: %s", chartrevision, failureDescription(obj))
+			}
+			if status := obj.Labels[constants.HelmSecretLabelStatus]; status != "" && status != rh.Status.Revisions[i].Status {
+				var source *v1alpha1.ReleaseHistorySource
+				if status == string(release.StatusDeployed) || status == string(release.StatusFailed) {
+					// Helm only records the release's description, e.g. from
+					// `--description`, once it has finished deploying it.
+					source = v.harvestSource(log, obj)
+				}
+				if err = v.updateRevisionStatus(ctx, namespacedHistories, chartname, chartrevision, status, source); err != nil {
+					log.Info("failed to update status of revision", "revision", chartrevision, "status", status, "err", err.Error())
+				}
 			}
 		}
 
@@ -184,12 +234,17 @@ func (v *V2) Process(ctx context.Context, req *v1.AdmissionRequest) *v1.Admissio
 					DeployedAt: obj.CreationTimestamp,
 					GVKs:       map[string]string{},
 					Revision:   v1alpha1.Revision(uint(chartrevision)),
+					Status:     obj.Labels[constants.HelmSecretLabelStatus],
+					DeployedBy: deployer(req.UserInfo, obj.Annotations, v.CIKeys),
+					Source:     v.harvestSource(log, obj),
 				},
 			},
 		}
 		_, err = namespacedHistories.UpdateStatus(context.Background(), newrh, metav1.UpdateOptions{})
 		if err != nil {
 			v.Log.Error(err, "failed to update v1alpha1.ReleaseHistory with status after create")
+		} else {
+			v.eventf(newrh, corev1.EventTypeNormal, constants.EventReasonRevisionAdded, "Created release history with revision %d", chartrevision)
 		}
 
 		v.Log.Info("added", "name", chartname, "namespace", chartnamespace, "uid", obj.UID)
@@ -197,7 +252,220 @@ func (v *V2) Process(ctx context.Context, req *v1.AdmissionRequest) *v1.Admissio
 
 	// Regardless, we want this to succeed.
 	return &v1.AdmissionResponse{
-		Allowed: true,
+		Allowed:  true,
+		UID:      req.UID,
+		Warnings: warnings,
+	}
+}
+
+// updateRevisionStatus records the status of the helm release of a revision,
+// e.g. when helm marks it deployed, or superseded by the next revision.  The
+// source fields which the revision lacks are set from source, if any.
+func (v *V2) updateRevisionStatus(ctx context.Context, histories typedv1alpha1.ReleaseHistoryInterface, name string, revision int, status string, source *v1alpha1.ReleaseHistorySource) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		rh, err := histories.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		i := indexOfRevision(rh.Status.Revisions, v1alpha1.Revision(revision))
+		if i == -1 {
+			return nil
+		}
+		merged, changed := mergeSource(rh.Status.Revisions[i].Source, source)
+		if rh.Status.Revisions[i].Status == status && !changed {
+			return nil
+		}
+		newrh := rh.DeepCopy()
+		newrh.Status.Revisions[i].Status = status
+		newrh.Status.Revisions[i].Source = merged
+		_, err = histories.UpdateStatus(ctx, newrh, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// mergeSource returns the source with any empty fields set from the other
+// source, and whether any were set
+func mergeSource(source *v1alpha1.ReleaseHistorySource, other *v1alpha1.ReleaseHistorySource) (*v1alpha1.ReleaseHistorySource, bool) {
+	if other == nil {
+		return source, false
+	}
+	merged := &v1alpha1.ReleaseHistorySource{}
+	if source != nil {
+		*merged = *source
+	}
+	changed := false
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&merged.Commit, other.Commit},
+		{&merged.Branch, other.Branch},
+		{&merged.PullRequest, other.PullRequest},
+		{&merged.Pipeline, other.Pipeline},
+	} {
+		if *f.dst == "" && f.src != "" {
+			*f.dst = f.src
+			changed = true
+		}
+	}
+	if !changed {
+		return source, false
+	}
+	return merged, true
+}
+
+// failed reports whether an update marks the helm release secret of a
+// revision as failed, i.e. helm has given up on deploying it
+func (v *V2) failed(req *v1.AdmissionRequest, obj *corev1.Secret) bool {
+	if req.Operation != v1.Update || obj.Labels[constants.HelmSecretLabelStatus] != string(release.StatusFailed) {
+		return false
+	}
+
+	var old *corev1.Secret
+	if err := json.Unmarshal(req.OldObject.Raw, &old); err != nil || old == nil {
+		// Without the old secret, assume that the status changed.
+		return true
+	}
+	return old.Labels[constants.HelmSecretLabelStatus] != string(release.StatusFailed)
+}
+
+// failureDescription returns helm's description of a failed revision, which
+// includes the error that it failed with
+func failureDescription(s *corev1.Secret) string {
+	rls, err := helm.DecodeRelease(s)
+	if err != nil || rls.Info == nil || rls.Info.Description == "" {
+		return "no description"
+	}
+	return rls.Info.Description
+}
+
+// checkFreezes denies the creation of a new revision while a freeze window
+// applies to its release, unless the FreezePolicy has an unexpired override
+// for the release, or the revision is a rollback.  An overridden freeze is
+// reported to the deployer as a warning.  Freezes are not enforced if the
+// policies cannot be read.
+func (v *V2) checkFreezes(req *v1.AdmissionRequest, obj *corev1.Secret, namespace string, release string) (*v1.AdmissionResponse, []string) {
+	if v.Freezes == nil || req.Operation != v1.Create {
+		return nil, nil
+	}
+
+	policies, err := v.Freezes.FreezePolicies(namespace).List(labels.Everything())
+	if err != nil {
+		v.Log.Error(err, "failed to list freeze policies; not enforcing freezes", "namespace", namespace)
+		return nil, nil
+	}
+	f, err := freeze.Active(policies, release, v.now())
+	if err != nil {
+		v.Log.Error(err, "skipped invalid freeze windows", "namespace", namespace)
+	}
+	if f == nil {
+		return nil, nil
+	}
+
+	if f.Override != nil {
+		v.Log.Info("allowed new revision during freeze by override", "namespace", namespace, "release", release, "policy", f.Policy, "window", f.Window, "reason", f.Override.Reason)
+		return nil, []string{fmt.Sprintf("%s; overridden until %s: %s", f, f.Override.Expires.UTC().Format(time.RFC3339), f.Override.Reason)}
+	}
+
+	// Rolling back restores a revision which was already deployed, and is how
+	// the watcher remediates a failed one.
+	if rls, err := helm.DecodeRelease(obj); err == nil && rls.Info != nil && strings.HasPrefix(rls.Info.Description, constants.HelmRollbackDescription) {
+		v.Log.Info("allowed rollback during freeze", "namespace", namespace, "release", release, "policy", f.Policy, "window", f.Window)
+		return nil, []string{fmt.Sprintf("%s; allowed rollback", f)}
+	}
+
+	v.Log.Info("denied new revision during freeze", "namespace", namespace, "release", release, "policy", f.Policy, "window", f.Window)
+	return &v1.AdmissionResponse{
+		Allowed: false,
 		UID:     req.UID,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusForbidden,
+			Reason:  metav1.StatusReasonForbidden,
+			Message: fmt.Sprintf("%s.  To override, add an override for release '%s' with a reason and expiry to FreezePolicy '%s'", f, release, f.Policy),
+		},
+	}, nil
+}
+
+// harvestSource reads the source-control metadata of a revision.  A failure is
+// logged, and leaves the revision without it.
+func (v *V2) harvestSource(log logr.Logger, s *corev1.Secret) *v1alpha1.ReleaseHistorySource {
+	rls, err := helm.DecodeRelease(s)
+	if err != nil {
+		log.Info("failed to decode revision; not harvesting source", "err", err.Error())
+		return nil
+	}
+	source, err := v.Source.Harvest(s, rls)
+	if err != nil {
+		log.Info("failed to read revision; not harvesting source", "err", err.Error())
+		return nil
+	}
+	return source
+}
+
+// summarizeChanges compares the workloads of a revision with those of the
+// previous revision of the release.  A failure is logged, and leaves the
+// revision without a summary.
+func (v *V2) summarizeChanges(log logr.Logger, s *corev1.Secret, name string, revision int) []v1alpha1.ReleaseHistoryChange {
+	prev, err := v.previousReleaseSecret(s.Namespace, name, revision)
+	if err != nil {
+		log.Info("failed to find previous revision; not summarizing changes", "err", err.Error())
+		return nil
+	} else if prev == nil {
+		return nil
+	}
+
+	current, err := releaseWorkloads(s)
+	if err != nil {
+		log.Info("failed to read workloads of revision; not summarizing changes", "revision", revision, "err", err.Error())
+		return nil
+	}
+	previous, err := releaseWorkloads(prev)
+	if err != nil {
+		log.Info("failed to read workloads of previous revision; not summarizing changes", "revision", prev.Labels[constants.HelmSecretLabelRevision], "err", err.Error())
+		return nil
+	}
+
+	return helm.Summarize(previous, current)
+}
+
+// previousReleaseSecret returns the helm release secret of the latest
+// revision before the given one, if there is one
+func (v *V2) previousReleaseSecret(namespace string, name string, revision int) (*corev1.Secret, error) {
+	r0, err0 := labels.NewRequirement(constants.HelmSecretLabelOwner, selection.Equals, []string{constants.HelmSecretLabelOwnerHelm})
+	r1, err1 := labels.NewRequirement(constants.HelmSecretLabelName, selection.Equals, []string{name})
+	if err0 != nil || err1 != nil {
+		return nil, fmt.Errorf("failed to create requirement: %v, %v", err0, err1)
+	}
+
+	secrets, err := v.secretlister.Secrets(namespace).List(labels.NewSelector().Add(*r0, *r1))
+	if err != nil {
+		return nil, err
+	}
+
+	var prev *corev1.Secret
+	prevRevision := 0
+	for _, x := range secrets {
+		rev, err := strconv.Atoi(x.Labels[constants.HelmSecretLabelRevision])
+		if err != nil || rev >= revision {
+			continue
+		}
+		if rev > prevRevision {
+			prev = x
+			prevRevision = rev
+		}
+	}
+	return prev, nil
+}
+
+func releaseWorkloads(s *corev1.Secret) ([]helm.Workload, error) {
+	rls, err := helm.DecodeRelease(s)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := helm.Objects(rls.Manifest)
+	if err != nil {
+		return nil, err
 	}
+	return helm.Workloads(objs)
 }
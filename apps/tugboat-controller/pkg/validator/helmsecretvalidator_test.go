@@ -0,0 +1,463 @@
+package validator
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/fake"
+	listerv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/listers/engineering.tugboat/v1alpha1"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	"helm.sh/helm/v3/pkg/release"
+	v1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	listercorev1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func Test_V2_Freezes(t *testing.T) {
+	weekend := v1alpha1.FreezeWindow{Name: "weekend", Schedule: "0 17 * * FRI", Duration: metav1.Duration{Duration: 63 * time.Hour}, Message: "no weekend deploys"}
+
+	// Saturday, January 9th 2021
+	saturday := time.Date(2021, time.January, 9, 12, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		name             string
+		enforce          bool
+		operation        v1.Operation
+		release          string
+		history          bool
+		description      string
+		overrides        []v1alpha1.FreezeOverride
+		now              time.Time
+		expectedAllowed  bool
+		expectedWarnings bool
+	}{
+		{
+			name:            "not-enforced",
+			operation:       v1.Create,
+			release:         "web",
+			history:         true,
+			now:             saturday,
+			expectedAllowed: true,
+		},
+		{
+			name:            "frozen",
+			enforce:         true,
+			operation:       v1.Create,
+			release:         "web",
+			history:         true,
+			now:             saturday,
+			expectedAllowed: false,
+		},
+		{
+			name:            "thawed",
+			enforce:         true,
+			operation:       v1.Create,
+			release:         "web",
+			history:         true,
+			now:             saturday.Add(48 * time.Hour),
+			expectedAllowed: true,
+		},
+		{
+			name:            "other-release",
+			enforce:         true,
+			operation:       v1.Create,
+			release:         "api",
+			history:         true,
+			now:             saturday,
+			expectedAllowed: true,
+		},
+		{
+			name:            "update",
+			enforce:         true,
+			operation:       v1.Update,
+			release:         "web",
+			history:         true,
+			now:             saturday,
+			expectedAllowed: true,
+		},
+		{
+			name:             "overridden",
+			enforce:          true,
+			operation:        v1.Create,
+			release:          "web",
+			history:          true,
+			overrides:        []v1alpha1.FreezeOverride{{Release: "web", Reason: "INC-1234 hotfix", Expires: metav1.NewTime(saturday.Add(time.Hour))}},
+			now:              saturday,
+			expectedAllowed:  true,
+			expectedWarnings: true,
+		},
+		{
+			name:             "overridden-first-install",
+			enforce:          true,
+			operation:        v1.Create,
+			release:          "web",
+			overrides:        []v1alpha1.FreezeOverride{{Release: "web", Reason: "INC-1234 hotfix", Expires: metav1.NewTime(saturday.Add(time.Hour))}},
+			now:              saturday,
+			expectedAllowed:  true,
+			expectedWarnings: true,
+		},
+		{
+			name:            "overridden-expired",
+			enforce:         true,
+			operation:       v1.Create,
+			release:         "web",
+			history:         true,
+			overrides:       []v1alpha1.FreezeOverride{{Release: "web", Reason: "INC-1234 hotfix", Expires: metav1.NewTime(saturday.Add(-time.Hour))}},
+			now:             saturday,
+			expectedAllowed: false,
+		},
+		{
+			name:            "overridden-other-release",
+			enforce:         true,
+			operation:       v1.Create,
+			release:         "web",
+			history:         true,
+			overrides:       []v1alpha1.FreezeOverride{{Release: "api", Reason: "INC-1234 hotfix", Expires: metav1.NewTime(saturday.Add(time.Hour))}},
+			now:             saturday,
+			expectedAllowed: false,
+		},
+		{
+			name:            "overridden-without-reason",
+			enforce:         true,
+			operation:       v1.Create,
+			release:         "web",
+			history:         true,
+			overrides:       []v1alpha1.FreezeOverride{{Release: "web", Expires: metav1.NewTime(saturday.Add(time.Hour))}},
+			now:             saturday,
+			expectedAllowed: false,
+		},
+		{
+			name:             "rollback",
+			enforce:          true,
+			operation:        v1.Create,
+			release:          "web",
+			history:          true,
+			description:      "Rollback to 1",
+			now:              saturday,
+			expectedAllowed:  true,
+			expectedWarnings: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			l := testlogger.TestLogger{T: t}
+
+			policy := &v1alpha1.FreezePolicy{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "freezes"},
+				Spec: v1alpha1.FreezePolicySpec{
+					Releases:  []string{"web"},
+					Windows:   []v1alpha1.FreezeWindow{weekend},
+					Overrides: tc.overrides,
+				},
+			}
+
+			var objs []runtime.Object
+			histories := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tc.history {
+				rh := &v1alpha1.ReleaseHistory{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: tc.release},
+					Spec:       v1alpha1.ReleaseHistorySpec{ReleaseName: tc.release},
+				}
+				histories.Add(rh)
+				objs = append(objs, rh)
+			}
+			policies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			policies.Add(policy)
+			secrets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+
+			v := NewV2(l, runtime.NewScheme(), fake.NewSimpleClientset(objs...), listerv1alpha1.NewReleaseHistoryLister(histories), listercorev1.NewSecretLister(secrets))
+			v.now = func() time.Time { return tc.now }
+			if tc.enforce {
+				v.Freezes = listerv1alpha1.NewFreezePolicyLister(policies)
+			}
+
+			obj := helmSecret(t, tc.release, 2)
+			if tc.description != "" {
+				obj = helmSecretWithDescription(t, tc.release, 2, "pending-rollback", tc.description)
+			}
+			ar := v1.AdmissionReview{
+				TypeMeta: metav1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+				Request: &v1.AdmissionRequest{
+					UID:       "123",
+					Operation: tc.operation,
+					Object:    runtime.RawExtension{Raw: obj},
+				},
+			}
+			w, req := makeAdmissionRequest(t, &ar)
+			v.ProcessAdmission(w, &req)
+
+			resp := fromResponseWriter(t, w).Response
+			if resp.Allowed != tc.expectedAllowed {
+				t.Fatalf("incorrect allowed: expected %t, got %t", tc.expectedAllowed, resp.Allowed)
+			}
+			if tc.expectedWarnings != (len(resp.Warnings) != 0) {
+				t.Errorf("unexpected warnings: %v", resp.Warnings)
+			}
+			if !resp.Allowed {
+				if resp.Result == nil || resp.Result.Code != 403 || !strings.Contains(resp.Result.Message, "no weekend deploys") || !strings.Contains(resp.Result.Message, "FreezePolicy 'shop/freezes'") {
+					t.Errorf("incorrect denial: %#v", resp.Result)
+				}
+			}
+		})
+	}
+}
+
+func Test_V2_Events(t *testing.T) {
+	tcs := []struct {
+		name      string
+		operation v1.Operation
+		existing  []v1alpha1.Revision
+		status    string
+		oldStatus string
+		expected  []string
+	}{
+		{
+			name:      "new-release",
+			operation: v1.Create,
+			status:    "pending-install",
+			expected:  []string{"Normal RevisionAdded Created release history with revision 2"},
+		},
+		{
+			name:      "new-revision",
+			operation: v1.Create,
+			existing:  []v1alpha1.Revision{1},
+			status:    "pending-upgrade",
+			expected:  []string{"Normal RevisionAdded Added revision 2"},
+		},
+		{
+			name:      "deployed",
+			operation: v1.Update,
+			existing:  []v1alpha1.Revision{1, 2},
+			status:    "deployed",
+			oldStatus: "pending-upgrade",
+		},
+		{
+			name:      "failed",
+			operation: v1.Update,
+			existing:  []v1alpha1.Revision{1, 2},
+			status:    "failed",
+			oldStatus: "pending-upgrade",
+			expected:  []string{"Warning RolloutFailed Revision 2 failed: no description"},
+		},
+		{
+			name:      "still-failed",
+			operation: v1.Update,
+			existing:  []v1alpha1.Revision{1, 2},
+			status:    "failed",
+			oldStatus: "failed",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var objs []runtime.Object
+			if tc.existing != nil {
+				rh := &v1alpha1.ReleaseHistory{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web"},
+					Spec:       v1alpha1.ReleaseHistorySpec{ReleaseName: "web"},
+				}
+				for _, rev := range tc.existing {
+					rh.Status.Revisions = append(rh.Status.Revisions, v1alpha1.ReleaseHistoryRevision{Revision: rev, GVKs: map[string]string{}})
+				}
+				objs = append(objs, rh)
+			}
+			histories := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			secrets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+
+			recorder := record.NewFakeRecorder(10)
+			clientset := fake.NewSimpleClientset(objs...)
+			v := NewV2(testlogger.TestLogger{T: t}, runtime.NewScheme(), clientset, listerv1alpha1.NewReleaseHistoryLister(histories), listercorev1.NewSecretLister(secrets))
+			v.Recorder = recorder
+
+			ar := v1.AdmissionReview{
+				TypeMeta: metav1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+				Request: &v1.AdmissionRequest{
+					UID:       "123",
+					Operation: tc.operation,
+					Object:    runtime.RawExtension{Raw: helmSecretWithStatus(t, "web", 2, tc.status)},
+				},
+			}
+			if tc.operation == v1.Update {
+				ar.Request.OldObject = runtime.RawExtension{Raw: helmSecretWithStatus(t, "web", 2, tc.oldStatus)}
+			}
+			w, req := makeAdmissionRequest(t, &ar)
+			v.ProcessAdmission(w, &req)
+
+			if resp := fromResponseWriter(t, w).Response; !resp.Allowed {
+				t.Fatalf("unexpectedly denied: %#v", resp.Result)
+			}
+
+			rh, err := clientset.TugboatV1alpha1().ReleaseHistories("shop").Get(context.Background(), "web", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if i := indexOfRevision(rh.Status.Revisions, 2); i == -1 || rh.Status.Revisions[i].Status != tc.status {
+				t.Errorf("revision 2 does not have status '%s': %#v", tc.status, rh.Status.Revisions)
+			}
+
+			close(recorder.Events)
+			actual := []string{}
+			for e := range recorder.Events {
+				actual = append(actual, e)
+			}
+			if len(actual) != len(tc.expected) {
+				t.Fatalf("incorrect events: expected %v, got %v", tc.expected, actual)
+			}
+			for k := range actual {
+				if actual[k] != tc.expected[k] {
+					t.Errorf("incorrect event %d: expected '%s', got '%s'", k, tc.expected[k], actual[k])
+				}
+			}
+		})
+	}
+}
+
+func Test_V2_MaxRevisions(t *testing.T) {
+	rh := &v1alpha1.ReleaseHistory{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web"},
+		Spec:       v1alpha1.ReleaseHistorySpec{ReleaseName: "web"},
+	}
+	for k := 1; k <= v1alpha1.MaxRevisions; k++ {
+		rh.Status.Revisions = append(rh.Status.Revisions, v1alpha1.ReleaseHistoryRevision{Revision: v1alpha1.Revision(k), GVKs: map[string]string{}})
+	}
+	histories := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	secrets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+
+	clientset := fake.NewSimpleClientset(rh)
+	v := NewV2(testlogger.TestLogger{T: t}, runtime.NewScheme(), clientset, listerv1alpha1.NewReleaseHistoryLister(histories), listercorev1.NewSecretLister(secrets))
+	v.Recorder = record.NewFakeRecorder(10)
+
+	ar := v1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+		Request: &v1.AdmissionRequest{
+			UID:       "123",
+			Operation: v1.Create,
+			Object:    runtime.RawExtension{Raw: helmSecretWithStatus(t, "web", v1alpha1.MaxRevisions+1, "pending-upgrade")},
+		},
+	}
+	w, req := makeAdmissionRequest(t, &ar)
+	v.ProcessAdmission(w, &req)
+
+	actual, err := clientset.TugboatV1alpha1().ReleaseHistories("shop").Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	revs := actual.Status.Revisions
+	if len(revs) != v1alpha1.MaxRevisions {
+		t.Fatalf("incorrect number of revisions: expected %d, got %d", v1alpha1.MaxRevisions, len(revs))
+	}
+	if revs[0].Revision != 2 || revs[len(revs)-1].Revision != v1alpha1.MaxRevisions+1 {
+		t.Errorf("incorrect revisions retained: %d to %d", revs[0].Revision, revs[len(revs)-1].Revision)
+	}
+}
+
+func Test_V2_Source(t *testing.T) {
+	rh := &v1alpha1.ReleaseHistory{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web"},
+		Spec:       v1alpha1.ReleaseHistorySpec{ReleaseName: "web"},
+		Status: v1alpha1.ReleaseHistoryStatus{
+			Revisions: []v1alpha1.ReleaseHistoryRevision{
+				{Revision: 2, Status: "pending-upgrade", GVKs: map[string]string{}, Source: &v1alpha1.ReleaseHistorySource{Commit: "fedcba9", Branch: "main"}},
+			},
+		},
+	}
+	histories := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	secrets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+
+	clientset := fake.NewSimpleClientset(rh)
+	v := NewV2(testlogger.TestLogger{T: t}, runtime.NewScheme(), clientset, listerv1alpha1.NewReleaseHistoryLister(histories), listercorev1.NewSecretLister(secrets))
+	v.Recorder = record.NewFakeRecorder(10)
+
+	// Helm only sets the description given with `--description` once the
+	// revision is deployed
+	ar := v1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+		Request: &v1.AdmissionRequest{
+			UID:       "123",
+			Operation: v1.Update,
+			Object:    runtime.RawExtension{Raw: helmSecretWithDescription(t, "web", 2, "deployed", "commit: 0123abc from https://github.com/example/web/pull/7")},
+			OldObject: runtime.RawExtension{Raw: helmSecretWithDescription(t, "web", 2, "pending-upgrade", "Preparing upgrade")},
+		},
+	}
+	w, req := makeAdmissionRequest(t, &ar)
+	v.ProcessAdmission(w, &req)
+
+	actual, err := clientset.TugboatV1alpha1().ReleaseHistories("shop").Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rev := actual.Status.Revisions[0]
+	if rev.Status != "deployed" {
+		t.Errorf("incorrect status '%s'", rev.Status)
+	}
+	expected := v1alpha1.ReleaseHistorySource{Commit: "fedcba9", Branch: "main", PullRequest: "https://github.com/example/web/pull/7"}
+	if rev.Source == nil || *rev.Source != expected {
+		t.Errorf("incorrect source: expected %#v, got %#v", expected, rev.Source)
+	}
+}
+
+func helmSecret(t *testing.T, release string, revision int) []byte {
+	return helmSecretWithStatus(t, release, revision, "")
+}
+
+func helmSecretWithStatus(t *testing.T, release string, revision int, status string) []byte {
+	s := corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "shop",
+			Name:      fmt.Sprintf("sh.helm.release.v1.%s.v%d", release, revision),
+			Labels: map[string]string{
+				constants.HelmSecretLabelName:     release,
+				constants.HelmSecretLabelRevision: strconv.Itoa(revision),
+			},
+		},
+		Type: constants.HelmSecretType,
+	}
+	if status != "" {
+		s.Labels[constants.HelmSecretLabelStatus] = status
+	}
+	buf, err := json.Marshal(&s)
+	if err != nil {
+		t.Fatalf("failed to marshal secret: %s", err.Error())
+	}
+	return buf
+}
+
+func helmSecretWithDescription(t *testing.T, name string, revision int, status string, description string) []byte {
+	rls, err := json.Marshal(&release.Release{
+		Name:    name,
+		Version: revision,
+		Info:    &release.Info{Status: release.Status(status), Description: description},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal release: %s", err.Error())
+	}
+
+	var s corev1.Secret
+	if err := json.Unmarshal(helmSecretWithStatus(t, name, revision, status), &s); err != nil {
+		t.Fatalf("failed to unmarshal secret: %s", err.Error())
+	}
+	s.Data = map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(rls))}
+	buf, err := json.Marshal(&s)
+	if err != nil {
+		t.Fatalf("failed to marshal secret: %s", err.Error())
+	}
+	return buf
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
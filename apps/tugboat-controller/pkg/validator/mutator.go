@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"io"
 	"strconv"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/object88/tugboat/internal/constants"
@@ -14,6 +15,7 @@ import (
 	listerv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/listers/engineering.tugboat/v1alpha1"
 	"helm.sh/helm/v3/pkg/release"
 	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
@@ -88,8 +90,7 @@ func (m *M) Process(ctx context.Context, req *admissionv1.AdmissionRequest) *adm
 	}
 
 	// This one is interesting.
-	annotations := ownerunstruct.GetAnnotations()
-	helmReleaseName := annotations[constants.HelmLabelReleaseName]
+	helmReleaseName, _ := releaseOf(ownerunstruct)
 
 	rel, err := m.versionedclientset.TugboatV1alpha1().ReleaseHistories(ownerunstruct.GetNamespace()).Get(ctx, helmReleaseName, metav1.GetOptions{})
 	if err != nil {
@@ -98,11 +99,21 @@ func (m *M) Process(ctx context.Context, req *admissionv1.AdmissionRequest) *adm
 	}
 
 	deployingRevision := m.findDeployingRevision(ownerunstruct.GetNamespace(), helmReleaseName, rel.Status.Revisions)
-	if deployingRevision == v1alpha1.Revision(0) {
-		log.Info("failed to find an actively deploying revision")
+	if deployingRevision == v1alpha1.Revision(0) && isTestHook(ownerunstruct) {
+		// `helm test` runs against the deployed release, so nothing is deploying.
+		latest, _ := rel.LatestRevision()
+		deployingRevision = latest.Revision
 	}
 
-	if i := indexOfRevision(rel.Status.Revisions, deployingRevision); i != -1 {
+	switch i := indexOfRevision(rel.Status.Revisions, deployingRevision); {
+	case deployingRevision == v1alpha1.Revision(0):
+		// Pods are created outside of a deploy all the time, e.g. when a
+		// workload scales or a pod is evicted, so this is not worth an event.
+		log.V(1).Info("no revision is deploying; object is not attributed to a revision")
+	case i == -1:
+		log.Info("deploying revision is not in the release history", "revision", deployingRevision)
+		m.eventf(rel, corev1.EventTypeWarning, constants.EventReasonOwnerNotResolved, "Revision %d is deploying %s %s/%s, but is not recorded; it is not attributed to a revision", deployingRevision, unstruct.GetKind(), unstruct.GetNamespace(), unstruct.GetName())
+	default:
 		copyrel := rel.DeepCopy()
 		// "GROUP/VERSION, Kind=KIND"
 		// ex: "/v1, Kind=Pod", "apps/v1, Kind=StatefulSet"
@@ -211,7 +222,6 @@ func (m *M) findOwner(ctx context.Context, log logr.Logger, unstruct *unstructur
 }
 
 func (m *M) checkUnstruct(log logr.Logger, unstruct *unstructured.Unstructured) bool {
-	annotations := unstruct.GetAnnotations()
 	lbls := unstruct.GetLabels()
 
 	if managedBy, ok := lbls["app.kubernetes.io/managed-by"]; !ok {
@@ -222,8 +232,10 @@ func (m *M) checkUnstruct(log logr.Logger, unstruct *unstructured.Unstructured)
 		return false
 	}
 
-	helmReleaseName := annotations[constants.HelmLabelReleaseName]
-	helmReleaseNamespace := annotations[constants.HelmLabelReleaseNamespace]
+	helmReleaseName, helmReleaseNamespace := releaseOf(unstruct)
+	if helmReleaseName == "" {
+		return false
+	}
 
 	r0, err0 := labels.NewRequirement(constants.LabelReleaseName, selection.Equals, []string{helmReleaseName})
 	r1, err1 := labels.NewRequirement(constants.LabelReleaseNamespace, selection.Equals, []string{helmReleaseNamespace})
@@ -241,3 +253,28 @@ func (m *M) checkUnstruct(log logr.Logger, unstruct *unstructured.Unstructured)
 
 	return len(rhs) != 0
 }
+
+// releaseOf returns the name and namespace of the helm release which
+// created an object.  Helm annotates the resources of a release, but not its
+// hooks; a hook is attributed to the release named by its
+// app.kubernetes.io/instance label, in the hook's own namespace.
+func releaseOf(unstruct *unstructured.Unstructured) (string, string) {
+	annotations := unstruct.GetAnnotations()
+	if name := annotations[constants.HelmLabelReleaseName]; name != "" {
+		return name, annotations[constants.HelmLabelReleaseNamespace]
+	}
+	if _, ok := annotations[constants.HelmAnnotationHook]; ok {
+		return unstruct.GetLabels()[constants.KubernetesLabelInstance], unstruct.GetNamespace()
+	}
+	return "", ""
+}
+
+// isTestHook reports whether an object is run by `helm test`
+func isTestHook(unstruct *unstructured.Unstructured) bool {
+	for _, e := range strings.Split(unstruct.GetAnnotations()[constants.HelmAnnotationHook], ",") {
+		if e = strings.TrimSpace(e); e == constants.HelmHookTest || e == constants.HelmHookTestSuccess {
+			return true
+		}
+	}
+	return false
+}
@@ -5,11 +5,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/object88/tugboat/internal/constants"
 	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
 	"github.com/object88/tugboat/pkg/logging/testlogger"
 	"helm.sh/helm/v3/pkg/release"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
 	listercorev1 "k8s.io/client-go/listers/core/v1"
@@ -58,6 +60,60 @@ func Test_Mutator_FindDeployingRevision(t *testing.T) {
 	}
 }
 
+func Test_ReleaseOf(t *testing.T) {
+	tcs := []struct {
+		name        string
+		annotations map[string]string
+		labels      map[string]string
+		release     string
+		namespace   string
+		test        bool
+	}{
+		{
+			name:        "resource",
+			annotations: map[string]string{constants.HelmLabelReleaseName: "test", constants.HelmLabelReleaseNamespace: "testns"},
+			release:     "test",
+			namespace:   "testns",
+		},
+		{
+			name:        "hook",
+			annotations: map[string]string{constants.HelmAnnotationHook: "pre-upgrade,pre-install"},
+			labels:      map[string]string{constants.KubernetesLabelInstance: "test"},
+			release:     "test",
+			namespace:   "hookns",
+		},
+		{
+			name:        "test-hook",
+			annotations: map[string]string{constants.HelmAnnotationHook: "test"},
+			labels:      map[string]string{constants.KubernetesLabelInstance: "test"},
+			release:     "test",
+			namespace:   "hookns",
+			test:        true,
+		},
+		{
+			name:   "untracked",
+			labels: map[string]string{constants.KubernetesLabelInstance: "test"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			u.SetNamespace("hookns")
+			u.SetAnnotations(tc.annotations)
+			u.SetLabels(tc.labels)
+
+			release, namespace := releaseOf(u)
+			if release != tc.release || namespace != tc.namespace {
+				t.Errorf("incorrect release: expected %s/%s, actual %s/%s", tc.namespace, tc.release, namespace, release)
+			}
+			if isTestHook(u) != tc.test {
+				t.Errorf("incorrect test hook")
+			}
+		})
+	}
+}
+
 func createSecret(name string, namespace string, revision int, status release.Status) *v1.Secret {
 	now := time.Now()
 	createdtime := metav1.Time{Time: now.Add(-60 * time.Minute)}
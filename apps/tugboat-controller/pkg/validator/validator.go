@@ -4,24 +4,41 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/internal/constants"
 	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
 	v1 "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
+// maxClockSkew is how far in the future a time may be, to allow for the
+// clocks of the API server and its clients to differ
+const maxClockSkew = time.Minute
+
 // V ensures that an incoming ReleaseHistory is properly shaped
 type V struct {
 	Webhook
+
+	// StatusWriters are the users, e.g.
+	// "system:serviceaccount:tugboat:tugboat-watcher", which may change the
+	// status of a ReleaseHistory.  If empty, anyone may.
+	StatusWriters []string
+
 	scheme *runtime.Scheme
+	now    func() time.Time
 }
 
 func New(log logr.Logger, scheme *runtime.Scheme) *V {
 	v := V{
 		Webhook: NewWebhook(log),
 		scheme:  scheme,
+		now:     time.Now,
 	}
 	v.WebhookProcessor = &v
 	return &v
@@ -58,9 +75,135 @@ func (v *V) Process(ctx context.Context, req *v1.AdmissionRequest) *v1.Admission
 		}
 	}
 
+	if req.SubResource == "status" && !v.mayWriteStatus(req.UserInfo.Username) {
+		v.Log.Info("Denied change to releasehistory status", "name", req.Name, "namespace", req.Namespace, "user", req.UserInfo.Username)
+		return &v1.AdmissionResponse{
+			Allowed: false,
+			UID:     req.UID,
+			Result: &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Code:    http.StatusForbidden,
+				Reason:  metav1.StatusReasonForbidden,
+				Message: fmt.Sprintf("the status of ReleaseHistory %s/%s is maintained by tugboat, and may only be changed by %s", req.Namespace, req.Name, strings.Join(v.StatusWriters, ", ")),
+			},
+		}
+	}
+
+	var old *v1alpha1.ReleaseHistory
+	if req.Operation == v1.Update && len(req.OldObject.Raw) != 0 {
+		if err := json.Unmarshal(req.OldObject.Raw, &old); err != nil {
+			v.Log.Error(err, "Could not unmarshal raw old object", "name", req.Name, "namespace", req.Namespace)
+			return &v1.AdmissionResponse{
+				Allowed: false,
+				UID:     req.UID,
+				Result: &metav1.Status{
+					Status:  metav1.StatusFailure,
+					Message: err.Error(),
+				},
+			}
+		}
+	}
+
+	if errs := v.validate(obj, old, req.SubResource == "status"); len(errs) != 0 {
+		v.Log.Info("Rejected invalid releasehistory", "name", req.Name, "namespace", req.Namespace, "errors", errs.ToAggregate().Error())
+		return &v1.AdmissionResponse{
+			Allowed: false,
+			UID:     req.UID,
+			Result: &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Code:    http.StatusUnprocessableEntity,
+				Reason:  metav1.StatusReasonInvalid,
+				Message: fmt.Sprintf("ReleaseHistory %s/%s is invalid: %s", req.Namespace, req.Name, errs.ToAggregate().Error()),
+			},
+		}
+	}
+
 	v.Log.Info("Validated incoming releasehistory", "name", req.Name, "namespace", req.Namespace)
 	return &v1.AdmissionResponse{
 		Allowed: true,
 		UID:     req.UID,
 	}
 }
+
+// validate checks the labels and spec of a ReleaseHistory, and, if it is
+// being created or its status is being changed, its status.  The old object
+// is nil unless the ReleaseHistory is being updated.  The status of an
+// existing ReleaseHistory is not checked when its metadata or spec change,
+// so that a ReleaseHistory whose status predates validation can still be,
+// e.g., annotated.
+func (v *V) validate(rh *v1alpha1.ReleaseHistory, old *v1alpha1.ReleaseHistory, status bool) field.ErrorList {
+	errs := field.ErrorList{}
+
+	labels := field.NewPath("metadata", "labels")
+	releasename := field.NewPath("spec", "releasename")
+	if rh.Spec.ReleaseName == "" {
+		errs = append(errs, field.Required(releasename, ""))
+	}
+	for k, expected := range map[string]string{
+		constants.LabelReleaseName:      rh.Spec.ReleaseName,
+		constants.LabelReleaseNamespace: rh.Namespace,
+		constants.LabelState:            "",
+	} {
+		actual, ok := rh.Labels[k]
+		switch {
+		case !ok:
+			errs = append(errs, field.Required(labels.Key(k), ""))
+		case expected != "" && actual != expected:
+			errs = append(errs, field.Invalid(labels.Key(k), actual, fmt.Sprintf("must be '%s'", expected)))
+		}
+	}
+	if state, ok := rh.Labels[constants.LabelState]; ok && state != constants.LabelStateActive && state != constants.LabelStateUninstalled {
+		errs = append(errs, field.NotSupported(labels.Key(constants.LabelState), state, []string{constants.LabelStateActive, constants.LabelStateUninstalled}))
+	}
+
+	if old != nil && old.Spec.ReleaseName != rh.Spec.ReleaseName {
+		errs = append(errs, field.Invalid(releasename, rh.Spec.ReleaseName, "field is immutable"))
+	}
+
+	if old == nil || status {
+		errs = append(errs, v.validateStatus(rh.Status, field.NewPath("status"))...)
+	}
+
+	return errs
+}
+
+// validateStatus checks that the revisions are unique and in increasing
+// order, and that nothing was deployed in the future
+func (v *V) validateStatus(s v1alpha1.ReleaseHistoryStatus, p *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	latest := v.now().Add(maxClockSkew)
+
+	if s.DeployedAt.Time.After(latest) {
+		errs = append(errs, field.Invalid(p.Child("deployedat"), s.DeployedAt.Time.UTC().Format(time.RFC3339), "must not be in the future"))
+	}
+
+	seen := map[v1alpha1.Revision]bool{}
+	for k, rev := range s.Revisions {
+		rp := p.Child("revisions").Index(k)
+		switch {
+		case seen[rev.Revision]:
+			errs = append(errs, field.Duplicate(rp.Child("revision"), rev.Revision))
+		case k != 0 && rev.Revision < s.Revisions[k-1].Revision:
+			errs = append(errs, field.Invalid(rp.Child("revision"), rev.Revision, fmt.Sprintf("must be greater than the previous revision, %d", s.Revisions[k-1].Revision)))
+		}
+		seen[rev.Revision] = true
+
+		if rev.DeployedAt.Time.After(latest) {
+			errs = append(errs, field.Invalid(rp.Child("deployedat"), rev.DeployedAt.Time.UTC().Format(time.RFC3339), "must not be in the future"))
+		}
+	}
+
+	return errs
+}
+
+func (v *V) mayWriteStatus(username string) bool {
+	if len(v.StatusWriters) == 0 {
+		return true
+	}
+	for _, w := range v.StatusWriters {
+		if w == username {
+			return true
+		}
+	}
+	return false
+}
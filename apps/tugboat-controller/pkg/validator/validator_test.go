@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"net/http"
 	"testing"
+	"time"
 
+	"github.com/object88/tugboat/internal/constants"
 	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
 	"github.com/object88/tugboat/pkg/logging/testlogger"
 	v1 "k8s.io/api/admission/v1"
@@ -122,3 +125,216 @@ func Test_Validator_IncorrectType(t *testing.T) {
 		})
 	}
 }
+
+func Test_Validator_ReleaseHistory(t *testing.T) {
+	now := time.Date(2021, time.January, 8, 12, 0, 0, 0, time.UTC)
+	watcher := "system:serviceaccount:tugboat:tugboat-watcher"
+
+	valid := func() *v1alpha1.ReleaseHistory {
+		return &v1alpha1.ReleaseHistory{
+			TypeMeta: metav1.TypeMeta{APIVersion: "tugboat.engineering/v1alpha1", Kind: "ReleaseHistory"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "checkout-api",
+				Namespace: "payments",
+				Labels: map[string]string{
+					constants.LabelReleaseName:      "checkout-api",
+					constants.LabelReleaseNamespace: "payments",
+					constants.LabelState:            constants.LabelStateActive,
+				},
+			},
+			Spec: v1alpha1.ReleaseHistorySpec{ReleaseName: "checkout-api"},
+			Status: v1alpha1.ReleaseHistoryStatus{
+				DeployedAt: metav1.NewTime(now.Add(-time.Hour)),
+				Revisions: []v1alpha1.ReleaseHistoryRevision{
+					{Revision: 1, DeployedAt: metav1.NewTime(now.Add(-2 * time.Hour))},
+					{Revision: 2, DeployedAt: metav1.NewTime(now.Add(-time.Hour))},
+				},
+			},
+		}
+	}
+
+	tcs := []struct {
+		name        string
+		operation   v1.Operation
+		subresource string
+		user        string
+		old         func(rh *v1alpha1.ReleaseHistory)
+		change      func(rh *v1alpha1.ReleaseHistory)
+		code        int32
+	}{
+		{
+			name:      "create",
+			operation: v1.Create,
+		},
+		{
+			name:      "create-missing-label",
+			operation: v1.Create,
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				delete(rh.Labels, constants.LabelState)
+			},
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "create-unknown-state",
+			operation: v1.Create,
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Labels[constants.LabelState] = "deleted"
+			},
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "create-mismatched-name",
+			operation: v1.Create,
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Labels[constants.LabelReleaseName] = "checkout-web"
+			},
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "create-mismatched-namespace",
+			operation: v1.Create,
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Labels[constants.LabelReleaseNamespace] = "default"
+			},
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "create-duplicate-revision",
+			operation: v1.Create,
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Status.Revisions[1].Revision = 1
+			},
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "create-decreasing-revision",
+			operation: v1.Create,
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Status.Revisions[0].Revision = 3
+			},
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "create-deployed-in-future",
+			operation: v1.Create,
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Status.Revisions[1].DeployedAt = metav1.NewTime(now.Add(time.Hour))
+			},
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "create-within-clock-skew",
+			operation: v1.Create,
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Status.DeployedAt = metav1.NewTime(now.Add(30 * time.Second))
+			},
+		},
+		{
+			name:      "update-uninstalled",
+			operation: v1.Update,
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Labels[constants.LabelState] = constants.LabelStateUninstalled
+			},
+		},
+		{
+			name:      "update-releasename",
+			operation: v1.Update,
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Spec.ReleaseName = "checkout-web"
+				rh.Labels[constants.LabelReleaseName] = "checkout-web"
+			},
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "update-ignores-existing-status",
+			operation: v1.Update,
+			old: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Status.Revisions[1].Revision = 1
+			},
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Status.Revisions[1].Revision = 1
+				rh.Annotations = map[string]string{constants.AnnotationAutoRollback: "true"}
+			},
+		},
+		{
+			name:        "update-status",
+			operation:   v1.Update,
+			subresource: "status",
+			user:        watcher,
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Status.Revisions = append(rh.Status.Revisions, v1alpha1.ReleaseHistoryRevision{Revision: 3, DeployedAt: metav1.NewTime(now)})
+			},
+		},
+		{
+			name:        "update-status-decreasing-revision",
+			operation:   v1.Update,
+			subresource: "status",
+			user:        watcher,
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Status.Revisions = append(rh.Status.Revisions, v1alpha1.ReleaseHistoryRevision{Revision: 1, DeployedAt: metav1.NewTime(now)})
+			},
+			code: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "update-status-by-person",
+			operation:   v1.Update,
+			subresource: "status",
+			user:        "jane@example.com",
+			change: func(rh *v1alpha1.ReleaseHistory) {
+				rh.Status.Revisions = rh.Status.Revisions[:1]
+			},
+			code: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			v := New(testlogger.TestLogger{T: t}, runtime.NewScheme())
+			v.StatusWriters = []string{"system:serviceaccount:tugboat:tugboat-controller", watcher}
+			v.now = func() time.Time { return now }
+
+			rh := valid()
+			if tc.change != nil {
+				tc.change(rh)
+			}
+			raw, err := json.Marshal(rh)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			req := v1.AdmissionRequest{
+				Name:        rh.Name,
+				Namespace:   rh.Namespace,
+				Operation:   tc.operation,
+				SubResource: tc.subresource,
+				Object:      runtime.RawExtension{Raw: raw},
+			}
+			req.UserInfo.Username = tc.user
+			if tc.operation == v1.Update {
+				old := valid()
+				if tc.old != nil {
+					tc.old(old)
+				}
+				if req.OldObject.Raw, err = json.Marshal(old); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			resp := v.Process(context.TODO(), &req)
+			if resp == nil {
+				t.Fatalf("Did not get expected response")
+			}
+			if tc.code == 0 {
+				if !resp.Allowed {
+					t.Errorf("Valid request was denied: %v", resp.Result)
+				}
+				return
+			}
+			if resp.Allowed {
+				t.Fatalf("Invalid request was allowed")
+			}
+			if resp.Result == nil || resp.Result.Code != tc.code {
+				t.Errorf("Incorrect result: %v", resp.Result)
+			}
+		})
+	}
+}
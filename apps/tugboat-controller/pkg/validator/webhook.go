@@ -12,6 +12,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/tools/record"
 )
 
 // WebhookProcessor is the interface that the HTTP web hooks call into via the
@@ -25,7 +26,12 @@ type WebhookProcessor interface {
 // a WebbookProcessor for handling
 type Webhook struct {
 	WebhookProcessor
-	Log              logr.Logger
+	Log logr.Logger
+
+	// Recorder records the decisions of the WebhookProcessor as Events on the
+	// ReleaseHistories they concern.  If nil, no Events are recorded.
+	Recorder record.EventRecorder
+
 	admissionDecoder runtime.Decoder
 }
 
@@ -88,3 +94,11 @@ func (wh *Webhook) ProcessAdmission(w http.ResponseWriter, r *http.Request) {
 		wh.Log.Error(err, "failed to write response")
 	}
 }
+
+// eventf records an Event on a ReleaseHistory, if there is a Recorder
+func (wh *Webhook) eventf(obj runtime.Object, eventtype string, reason string, messageFmt string, args ...interface{}) {
+	if wh.Recorder == nil {
+		return
+	}
+	wh.Recorder.Eventf(obj, eventtype, reason, messageFmt, args...)
+}
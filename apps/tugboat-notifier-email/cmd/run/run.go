@@ -0,0 +1,110 @@
+package run
+
+import (
+	"context"
+
+	"github.com/object88/tugboat/apps/tugboat-notifier-email/pkg/digest"
+	"github.com/object88/tugboat/apps/tugboat-notifier-email/pkg/mail"
+	"github.com/object88/tugboat/apps/tugboat-notifier-email/pkg/notification"
+	"github.com/object88/tugboat/internal/cmd/common"
+	emailcliflags "github.com/object88/tugboat/internal/email/cliflags"
+	grpccliflags "github.com/object88/tugboat/pkg/grpc/cliflags"
+	"github.com/object88/tugboat/pkg/grpc/server"
+	"github.com/object88/tugboat/pkg/http"
+	httpcliflags "github.com/object88/tugboat/pkg/http/cliflags"
+	"github.com/object88/tugboat/pkg/http/probes"
+	"github.com/object88/tugboat/pkg/http/router"
+	k8scliflags "github.com/object88/tugboat/pkg/k8s/cliflags"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+type command struct {
+	cobra.Command
+	*common.CommonArgs
+
+	emailFlagMgr *emailcliflags.FlagManager
+	grpcFlagMgr  *grpccliflags.FlagManager
+	httpFlagMgr  *httpcliflags.FlagManager
+	k8sFlagMgr   *k8scliflags.FlagManager
+
+	aggregator *digest.Aggregator
+	grpcOpts   []grpc.ServerOption
+	probe      *probes.Probe
+}
+
+// CreateCommand returns the `run` Command
+func CreateCommand(ca *common.CommonArgs) *cobra.Command {
+	var c command
+	c = command{
+		Command: cobra.Command{
+			Use:   "run",
+			Short: "run emails a digest of each deployment",
+			Args:  cobra.NoArgs,
+			PreRunE: func(cmd *cobra.Command, args []string) error {
+				return c.preexecute(cmd, args)
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.execute(cmd, args)
+			},
+		},
+		CommonArgs:   ca,
+		emailFlagMgr: emailcliflags.New(),
+		grpcFlagMgr:  grpccliflags.New(),
+		httpFlagMgr:  httpcliflags.New(),
+		k8sFlagMgr:   k8scliflags.New(),
+	}
+
+	flags := c.Flags()
+
+	c.emailFlagMgr.ConfigureFlags(flags)
+	c.grpcFlagMgr.ConfigureGrpcPortFlag(flags)
+	c.grpcFlagMgr.ConfigureServerAuthFlags(flags)
+	c.grpcFlagMgr.ConfigureTLSFlags(flags)
+	c.httpFlagMgr.ConfigureHttpFlag(flags)
+	c.k8sFlagMgr.ConfigureKubernetesConfig(flags)
+
+	return common.TraverseRunHooks(&c.Command)
+}
+
+func (c *command) preexecute(cmd *cobra.Command, args []string) error {
+	cfg, err := c.emailFlagMgr.Config()
+	if err != nil {
+		return err
+	}
+
+	c.aggregator = digest.New(c.Log, cfg, mail.NewSMTPSender(cfg.SMTP))
+
+	c.grpcOpts, err = c.grpcFlagMgr.ServerOptions(c.Log, c.k8sFlagMgr.KubernetesConfig())
+	if err != nil {
+		return err
+	}
+
+	c.probe = probes.New()
+
+	return nil
+}
+
+func (c *command) execute(cmd *cobra.Command, args []string) error {
+	return common.Multiblock(c.Log, c.probe, c.aggregator.Run, c.startGRPCServer, c.startHTTPServer)
+}
+
+func (c *command) startGRPCServer(ctx context.Context, r probes.Reporter) error {
+	g, err := server.NewWithOptions(c.Log, c.grpcFlagMgr.GRPCPort(), c.grpcOpts, notification.New(c.Log, c.aggregator))
+	if err != nil {
+		return err
+	}
+	g.Probe = c.probe
+
+	return g.Serve(ctx, r)
+}
+
+func (c *command) startHTTPServer(ctx context.Context, r probes.Reporter) error {
+	m, err := router.New(c.Log).Route(router.LoggingDefaultRoute, router.Defaults(c.probe))
+	if err != nil {
+		return err
+	}
+
+	http.New(c.Log, m, c.httpFlagMgr.HttpPort()).Serve(ctx, r)
+	return nil
+}
@@ -0,0 +1,253 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/apps/tugboat-notifier-email/pkg/mail"
+	"github.com/object88/tugboat/internal/email/config"
+	"github.com/object88/tugboat/pkg/http/probes"
+)
+
+// Outcome is the final state of a deployment
+type Outcome string
+
+const (
+	OutcomeFailed    Outcome = "failed"
+	OutcomeSucceeded Outcome = "succeeded"
+	OutcomeTimedOut  Outcome = "timed out"
+	OutcomeUnknown   Outcome = "finished"
+)
+
+// Key identifies a single revision of a release
+type Key struct {
+	Namespace string
+	Release   string
+	Revision  int32
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s/%s#%d", k.Namespace, k.Release, k.Revision)
+}
+
+// Entry is a single event reported during a deployment
+type Entry struct {
+	Time    time.Time
+	Reason  string
+	Message string
+}
+
+// Digest is the summary of a deployment
+type Digest struct {
+	Key
+
+	ID      string
+	Opened  time.Time
+	Closed  time.Time
+	Outcome Outcome
+	Message string
+	Entries []Entry
+
+	// DeployedBy describes who deployed the revision, if known
+	DeployedBy string
+
+	// Source links the revision to the change which caused it, if known
+	Source string
+
+	// Changes summarize how the revision's workloads differ from the previous
+	// revision's
+	Changes []string
+
+	deadline time.Time
+}
+
+// Duration returns how long the deployment took
+func (d *Digest) Duration() time.Duration {
+	return d.Closed.Sub(d.Opened).Round(time.Second)
+}
+
+// Aggregator collects the events for each release revision, and sends a
+// single digest email when the deployment closes or times out
+type Aggregator struct {
+	logger logr.Logger
+	cfg    *config.Config
+	sender mail.Sender
+
+	mu      sync.Mutex
+	digests map[Key]*Digest
+	ready   chan *Digest
+
+	interval time.Duration
+	now      func() time.Time
+}
+
+// New returns a new Aggregator
+func New(logger logr.Logger, cfg *config.Config, sender mail.Sender) *Aggregator {
+	interval := time.Second
+	if cfg.Timeout > 0 && cfg.Timeout/10 < interval {
+		interval = cfg.Timeout / 10
+	}
+
+	return &Aggregator{
+		logger:   logger,
+		cfg:      cfg,
+		sender:   sender,
+		digests:  map[Key]*Digest{},
+		ready:    make(chan *Digest, 100),
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Opening describes a revision which started deploying
+type Opening struct {
+	ID         string
+	DeployedBy string
+	Source     string
+	Changes    []string
+}
+
+// Open starts a digest for the revision.  If the revision already has a
+// digest, the existing one is kept, and only the details it lacks are set.
+func (a *Aggregator) Open(key Key, o Opening) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	d := a.get(key)
+	if o.ID != "" {
+		d.ID = o.ID
+	}
+	if o.DeployedBy != "" {
+		d.DeployedBy = o.DeployedBy
+	}
+	if o.Source != "" {
+		d.Source = o.Source
+	}
+	if len(o.Changes) != 0 {
+		d.Changes = o.Changes
+	}
+}
+
+// Update adds an entry to the revision's digest.  A revision without an open
+// digest, e.g. because its digest was already sent, is ignored, so that late
+// updates do not open a digest which would only time out.
+func (a *Aggregator) Update(key Key, e Entry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	d, ok := a.digests[key]
+	if !ok {
+		a.logger.V(1).Info("no open digest; ignoring update", "release", key.String(), "reason", e.Reason)
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = a.now()
+	}
+	d.Entries = append(d.Entries, e)
+}
+
+// Close completes the revision's digest and schedules it to be sent.  A
+// revision without an open digest, e.g. because its digest was already sent,
+// is ignored.
+func (a *Aggregator) Close(key Key, outcome Outcome, message string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	d, ok := a.digests[key]
+	if !ok {
+		a.logger.V(1).Info("no open digest; ignoring completion", "release", key.String(), "outcome", outcome)
+		return
+	}
+	delete(a.digests, key)
+
+	d.Closed = a.now()
+	d.Outcome = outcome
+	d.Message = message
+	a.schedule(d)
+}
+
+// Run sends digests as they close or expire, until the context is canceled
+func (a *Aggregator) Run(ctx context.Context, r probes.Reporter) error {
+	t := time.NewTicker(a.interval)
+	defer t.Stop()
+
+	r.Ready()
+	defer r.NotReady()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			a.expire()
+		case d := <-a.ready:
+			if err := a.send(d); err != nil {
+				a.logger.Error(err, "failed to send digest", "release", d.Key.String())
+			}
+		}
+	}
+}
+
+// get returns the digest for the key, creating it if necessary.  The caller
+// must hold the lock.
+func (a *Aggregator) get(key Key) *Digest {
+	d, ok := a.digests[key]
+	if !ok {
+		now := a.now()
+		d = &Digest{
+			Key:      key,
+			Opened:   now,
+			deadline: now.Add(a.cfg.Timeout),
+		}
+		a.digests[key] = d
+	}
+	return d
+}
+
+// schedule queues the digest for sending.  The caller must hold the lock.
+func (a *Aggregator) schedule(d *Digest) {
+	select {
+	case a.ready <- d:
+	default:
+		a.logger.Info("digest queue is full; dropping digest", "release", d.Key.String())
+	}
+}
+
+func (a *Aggregator) expire() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	for k, d := range a.digests {
+		if a.cfg.Timeout <= 0 || now.Before(d.deadline) {
+			continue
+		}
+		delete(a.digests, k)
+
+		d.Closed = now
+		d.Outcome = OutcomeTimedOut
+		d.Message = fmt.Sprintf("No completion was reported within %s.", a.cfg.Timeout)
+		a.schedule(d)
+	}
+}
+
+func (a *Aggregator) send(d *Digest) error {
+	to := a.cfg.Routes.Recipients(d.Namespace, d.Release)
+	if len(to) == 0 {
+		a.logger.Info("no recipients for digest; skipping", "release", d.Key.String())
+		return nil
+	}
+
+	m, err := render(d)
+	if err != nil {
+		return err
+	}
+	m.From = a.cfg.From
+	m.To = to
+	m.Date = d.Closed
+
+	return a.sender.Send(m)
+}
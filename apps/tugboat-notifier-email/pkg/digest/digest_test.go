@@ -0,0 +1,153 @@
+package digest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/apps/tugboat-notifier-email/pkg/mail"
+	"github.com/object88/tugboat/internal/email/config"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+)
+
+type fakeSender struct {
+	messages []*mail.Message
+}
+
+func (s *fakeSender) Send(m *mail.Message) error {
+	s.messages = append(s.messages, m)
+	return nil
+}
+
+func Test_Aggregator_Close(t *testing.T) {
+	a, sender, clock := createAggregator(t)
+
+	key := Key{Namespace: "payments", Release: "checkout", Revision: 4}
+	a.Open(key, Opening{
+		ID:         "abc-123",
+		DeployedBy: "ci/deployer",
+		Source:     "commit 0123abc on main",
+		Changes:    []string{"Deployment/checkout (app): tag 1.2.0 → 1.3.0"},
+	})
+	*clock = clock.Add(time.Minute)
+	a.Update(key, Entry{Reason: "Pulled", Message: "Successfully pulled image"})
+	a.Update(key, Entry{Reason: "Started", Message: "Started container <checkout>"})
+	*clock = clock.Add(time.Minute)
+	a.Close(key, OutcomeSucceeded, "")
+
+	other := Key{Namespace: "web", Release: "frontend", Revision: 1}
+	a.Open(other, Opening{})
+
+	flush(t, a)
+
+	if len(sender.messages) != 1 {
+		t.Fatalf("incorrect number of messages: expected 1, actual %d", len(sender.messages))
+	}
+	m := sender.messages[0]
+	if m.Subject != "[tugboat] payments/checkout revision 4 succeeded" {
+		t.Errorf("incorrect subject '%s'", m.Subject)
+	}
+	if strings.Join(m.To, ",") != "payments@example.com" {
+		t.Errorf("incorrect recipients %v", m.To)
+	}
+	if m.From != "tugboat@example.com" {
+		t.Errorf("incorrect sender '%s'", m.From)
+	}
+	for _, s := range []string{"Successfully pulled image", "Started container <checkout>", "(2m0s)", "Deployed by: ci/deployer", "Source: commit 0123abc on main", "tag 1.2.0 → 1.3.0"} {
+		if !strings.Contains(m.Text, s) {
+			t.Errorf("text does not contain '%s':\n%s", s, m.Text)
+		}
+	}
+	if !strings.Contains(m.HTML, "Started container &lt;checkout&gt;") {
+		t.Errorf("HTML is not escaped:\n%s", m.HTML)
+	}
+
+	if _, ok := a.digests[other]; !ok {
+		t.Errorf("open digest for other release was removed")
+	}
+}
+
+func Test_Aggregator_CloseUnknown(t *testing.T) {
+	a, sender, _ := createAggregator(t)
+
+	key := Key{Namespace: "payments", Release: "checkout", Revision: 4}
+	a.Open(key, Opening{ID: "abc-123"})
+	a.Close(key, OutcomeSucceeded, "")
+	a.Close(key, OutcomeFailed, "verification failed")
+	a.Close(Key{Namespace: "web", Release: "frontend", Revision: 1}, OutcomeSucceeded, "")
+	a.Update(key, Entry{Reason: "CheckPassed", Message: "PodsReady passed"})
+	a.Update(Key{Namespace: "web", Release: "frontend", Revision: 1}, Entry{Reason: "TestSucceeded"})
+	flush(t, a)
+
+	if len(sender.messages) != 1 {
+		t.Fatalf("incorrect number of messages: expected 1, actual %d", len(sender.messages))
+	}
+	if len(a.digests) != 0 {
+		t.Errorf("closing or updating an unknown digest opened one")
+	}
+}
+
+func Test_Aggregator_Timeout(t *testing.T) {
+	a, sender, clock := createAggregator(t)
+
+	key := Key{Namespace: "web", Release: "frontend", Revision: 2}
+	a.Open(key, Opening{})
+	a.Update(key, Entry{Reason: "BackOff", Message: "Back-off restarting failed container"})
+
+	*clock = clock.Add(5 * time.Minute)
+	a.expire()
+	flush(t, a)
+	if len(sender.messages) != 0 {
+		t.Fatalf("digest sent before timeout")
+	}
+
+	*clock = clock.Add(30 * time.Minute)
+	a.expire()
+	flush(t, a)
+	if len(sender.messages) != 1 {
+		t.Fatalf("incorrect number of messages: expected 1, actual %d", len(sender.messages))
+	}
+	m := sender.messages[0]
+	if m.Subject != "[tugboat] web/frontend revision 2 timed out" {
+		t.Errorf("incorrect subject '%s'", m.Subject)
+	}
+	if strings.Join(m.To, ",") != "oncall@example.com" {
+		t.Errorf("incorrect recipients %v", m.To)
+	}
+	if len(a.digests) != 0 {
+		t.Errorf("expired digest was not removed")
+	}
+}
+
+func createAggregator(t *testing.T) (*Aggregator, *fakeSender, *time.Time) {
+	sender := &fakeSender{}
+	cfg := &config.Config{
+		From:    "tugboat@example.com",
+		Timeout: 30 * time.Minute,
+		Routes: config.Routes{
+			Default: []string{"oncall@example.com"},
+			Routes: []config.Route{
+				{Namespace: "payments", To: []string{"payments@example.com"}},
+			},
+		},
+	}
+
+	clock := time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC)
+	a := New(testlogger.TestLogger{T: t}, cfg, sender)
+	a.now = func() time.Time { return clock }
+	return a, sender, &clock
+}
+
+// flush sends every digest that is ready
+func flush(t *testing.T, a *Aggregator) {
+	for {
+		select {
+		case d := <-a.ready:
+			if err := a.send(d); err != nil {
+				t.Fatalf("failed to send digest: %s", err.Error())
+			}
+		default:
+			return
+		}
+	}
+}
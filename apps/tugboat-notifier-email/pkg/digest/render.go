@@ -0,0 +1,82 @@
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/object88/tugboat/apps/tugboat-notifier-email/pkg/mail"
+)
+
+const textSource = `Release {{ .Release }} in namespace {{ .Namespace }}, revision {{ .Revision }}: {{ .Outcome }}
+{{ with .Message }}
+{{ . }}
+{{ end }}
+Started:  {{ ts .Opened }}
+Finished: {{ ts .Closed }} ({{ .Duration }})
+{{ with .DeployedBy }}Deployed by: {{ . }}
+{{ end }}{{ with .Source }}Source: {{ . }}
+{{ end }}{{ if .Changes }}
+Changes:
+{{ range .Changes }}  {{ . }}
+{{ end }}{{ end }}{{ if .Entries }}
+Events:
+{{ range .Entries }}  {{ ts .Time }}  {{ .Reason }}  {{ .Message }}
+{{ end }}{{ else }}
+No events were reported.
+{{ end }}`
+
+const htmlSource = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+<h2>{{ .Release }} revision {{ .Revision }}: {{ .Outcome }}</h2>
+<p>Namespace <code>{{ .Namespace }}</code></p>
+{{ with .Message }}<p>{{ . }}</p>{{ end }}
+<table>
+<tr><th align="left">Started</th><td>{{ ts .Opened }}</td></tr>
+<tr><th align="left">Finished</th><td>{{ ts .Closed }} ({{ .Duration }})</td></tr>
+{{ with .DeployedBy }}<tr><th align="left">Deployed by</th><td>{{ . }}</td></tr>
+{{ end }}{{ with .Source }}<tr><th align="left">Source</th><td>{{ . }}</td></tr>
+{{ end }}</table>
+{{ if .Changes }}<h3>Changes</h3>
+<ul>
+{{ range .Changes }}<li>{{ . }}</li>
+{{ end }}</ul>
+{{ end }}{{ if .Entries }}<h3>Events</h3>
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>Time</th><th>Reason</th><th>Message</th></tr>
+{{ range .Entries }}<tr><td>{{ ts .Time }}</td><td>{{ .Reason }}</td><td>{{ .Message }}</td></tr>
+{{ end }}</table>
+{{ else }}<p>No events were reported.</p>
+{{ end }}</body>
+</html>
+`
+
+func ts(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+var (
+	textTemplate = texttemplate.Must(texttemplate.New("text").Funcs(texttemplate.FuncMap{"ts": ts}).Parse(textSource))
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(htmltemplate.FuncMap{"ts": ts}).Parse(htmlSource))
+)
+
+func render(d *Digest) (*mail.Message, error) {
+	var text bytes.Buffer
+	if err := textTemplate.Execute(&text, d); err != nil {
+		return nil, fmt.Errorf("failed to render text digest: %w", err)
+	}
+
+	var html bytes.Buffer
+	if err := htmlTemplate.Execute(&html, d); err != nil {
+		return nil, fmt.Errorf("failed to render HTML digest: %w", err)
+	}
+
+	return &mail.Message{
+		Subject: fmt.Sprintf("[tugboat] %s/%s revision %d %s", d.Namespace, d.Release, d.Revision, d.Outcome),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
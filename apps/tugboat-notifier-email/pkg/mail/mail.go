@@ -0,0 +1,128 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/object88/tugboat/internal/email/config"
+)
+
+// Message is a multipart email with plain text and HTML bodies
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Date    time.Time
+	Text    string
+	HTML    string
+}
+
+// Sender delivers a Message
+type Sender interface {
+	Send(m *Message) error
+}
+
+// SMTPSender delivers messages to an SMTP server
+type SMTPSender struct {
+	cfg config.SMTP
+}
+
+var _ Sender = &SMTPSender{}
+
+// NewSMTPSender returns a new SMTPSender.  If a username is configured, the
+// sender authenticates with PLAIN auth, which net/smtp only permits over TLS
+// or to localhost.
+func NewSMTPSender(cfg config.SMTP) *SMTPSender {
+	return &SMTPSender{
+		cfg: cfg,
+	}
+}
+
+// Send satisfies the Sender interface
+func (s *SMTPSender) Send(m *Message) error {
+	if len(m.To) == 0 {
+		return fmt.Errorf("message '%s' has no recipients", m.Subject)
+	}
+
+	buf, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	if err := smtp.SendMail(s.cfg.Address(), auth, m.From, m.To, buf); err != nil {
+		return fmt.Errorf("failed to send message '%s' via '%s': %w", m.Subject, s.cfg.Address(), err)
+	}
+	return nil
+}
+
+// Bytes renders the message as a multipart/alternative MIME document
+func (m *Message) Bytes() ([]byte, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{contentType: "text/plain; charset=utf-8", content: m.Text},
+		{contentType: "text/html; charset=utf-8", content: m.HTML},
+	}
+	for _, p := range parts {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	var buf bytes.Buffer
+	to := make([]string, len(m.To))
+	for k, addr := range m.To {
+		to[k] = headerValue(addr)
+	}
+	fmt.Fprintf(&buf, "From: %s\r\n", headerValue(m.From))
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n", w.Boundary())
+	fmt.Fprintf(&buf, "\r\n")
+	buf.Write(body.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+// headerValue strips the line breaks from a header value, so that it cannot
+// end the header and inject others
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
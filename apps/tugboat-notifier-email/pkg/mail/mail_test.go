@@ -0,0 +1,189 @@
+package mail
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/object88/tugboat/internal/email/config"
+)
+
+// smtpServer is a minimal SMTP stand-in that accepts a single message per
+// connection and records it
+type smtpServer struct {
+	l        net.Listener
+	messages chan received
+}
+
+type received struct {
+	from string
+	to   []string
+	data string
+}
+
+func startSMTPServer(t *testing.T) *smtpServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err.Error())
+	}
+	s := &smtpServer{l: l, messages: make(chan received, 10)}
+	go s.serve()
+	return s
+}
+
+func (s *smtpServer) config() config.SMTP {
+	host, port, _ := net.SplitHostPort(s.l.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return config.SMTP{Host: host, Port: p}
+}
+
+func (s *smtpServer) serve() {
+	for {
+		conn, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		fmt.Fprintf(conn, "%s\r\n", line)
+	}
+
+	reply("220 localhost ESMTP test")
+	msg := received{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			msg.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.data = data.String()
+			s.messages <- msg
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func Test_SMTPSender_Send(t *testing.T) {
+	s := startSMTPServer(t)
+	defer s.l.Close()
+
+	sender := NewSMTPSender(s.config())
+	m := &Message{
+		From:    "tugboat@example.com",
+		To:      []string{"a@example.com", "b@example.com"},
+		Subject: "[tugboat] payments/checkout revision 4 succeeded",
+		Text:    "checkout is deployed",
+		HTML:    "<p>checkout is <b>deployed</b></p>",
+	}
+	if err := sender.Send(m); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	rcv := <-s.messages
+	if rcv.from != "tugboat@example.com" {
+		t.Errorf("incorrect sender '%s'", rcv.from)
+	}
+	if strings.Join(rcv.to, ",") != "a@example.com,b@example.com" {
+		t.Errorf("incorrect recipients %v", rcv.to)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(rcv.data))
+	if err != nil {
+		t.Fatalf("failed to parse message: %s", err.Error())
+	}
+	if parsed.Header.Get("Subject") != m.Subject {
+		t.Errorf("incorrect subject '%s'", parsed.Header.Get("Subject"))
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("incorrect content type '%s'", parsed.Header.Get("Content-Type"))
+	}
+
+	parts := map[string]string{}
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		buf, _ := ioutil.ReadAll(p)
+		parts[p.Header.Get("Content-Type")] = string(buf)
+	}
+	if parts["text/plain; charset=utf-8"] != m.Text {
+		t.Errorf("incorrect text part '%s'", parts["text/plain; charset=utf-8"])
+	}
+	if parts["text/html; charset=utf-8"] != m.HTML {
+		t.Errorf("incorrect HTML part '%s'", parts["text/html; charset=utf-8"])
+	}
+}
+
+func Test_SMTPSender_NoRecipients(t *testing.T) {
+	sender := NewSMTPSender(config.SMTP{Host: "127.0.0.1", Port: 1})
+	if err := sender.Send(&Message{Subject: "nobody"}); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func Test_Message_Bytes_HeaderInjection(t *testing.T) {
+	m := &Message{
+		From:    "tugboat@example.com\r\nBcc: evil@example.com",
+		To:      []string{"a@example.com\nCc: evil@example.com"},
+		Subject: "injected",
+		Text:    "body",
+	}
+	buf, err := m.Bytes()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(buf)))
+	if err != nil {
+		t.Fatalf("failed to parse message: %s", err.Error())
+	}
+	for _, h := range []string{"Bcc", "Cc"} {
+		if v := parsed.Header.Get(h); v != "" {
+			t.Errorf("injected header %s: '%s'", h, v)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/apps/tugboat-notifier-email/pkg/digest"
+	"github.com/object88/tugboat/internal/generated/notifier"
+	"github.com/object88/tugboat/internal/notifications/changes"
+	"github.com/object88/tugboat/internal/notifications/deployer"
+	"github.com/object88/tugboat/internal/notifications/source"
+	"google.golang.org/grpc"
+)
+
+type Listener struct {
+	notifier.UnimplementedListenerServer
+	logger logr.Logger
+
+	aggregator *digest.Aggregator
+}
+
+func New(logger logr.Logger, aggregator *digest.Aggregator) *Listener {
+	return &Listener{
+		aggregator: aggregator,
+		logger:     logger,
+	}
+}
+
+func (l *Listener) Register(s *grpc.Server, logger logr.Logger) error {
+	notifier.RegisterListenerServer(s, l)
+	return nil
+}
+
+func (l *Listener) OpenDeployment(ctx context.Context, req *notifier.StartDeploymentRequest) (*notifier.StartDeploymentResponse, error) {
+	key := digest.Key{Namespace: req.GetNamespace(), Release: req.GetReleaseName(), Revision: req.GetRevision()}
+	l.logger.Info("Got OpenDeployment rpc", "release", key.String())
+
+	d := req.GetDeployedBy()
+	l.aggregator.Open(key, digest.Opening{
+		ID:         req.GetId().GetValue(),
+		DeployedBy: deployer.Format(d, deployer.Name(d)),
+		Source:     source.Format(req.GetSource()),
+		Changes:    changes.FormatAll(req.GetChanges()),
+	})
+	return &notifier.StartDeploymentResponse{Id: req.GetId()}, nil
+}
+
+func (l *Listener) UpdateDeployment(ctx context.Context, req *notifier.UpdateDeploymentRequest) (*notifier.UpdateDeploymentResponse, error) {
+	key := digest.Key{Namespace: req.GetNamespace(), Release: req.GetReleaseName(), Revision: req.GetRevision()}
+	l.logger.V(1).Info("Got UpdateDeployment rpc", "release", key.String(), "reason", req.GetReason())
+
+	e := digest.Entry{
+		Reason:  req.GetReason(),
+		Message: req.GetMessage(),
+	}
+	if req.GetTime() != nil {
+		e.Time = req.GetTime().AsTime()
+	}
+	l.aggregator.Update(key, e)
+	return &notifier.UpdateDeploymentResponse{Id: req.GetId()}, nil
+}
+
+func (l *Listener) CloseDeployment(ctx context.Context, req *notifier.CloseDeploymentRequest) (*notifier.CloseDeploymentResponse, error) {
+	key := digest.Key{Namespace: req.GetNamespace(), Release: req.GetReleaseName(), Revision: req.GetRevision()}
+	l.logger.Info("Got CloseDeployment rpc", "release", key.String(), "outcome", req.GetOutcome().String())
+
+	var outcome digest.Outcome
+	switch req.GetOutcome() {
+	case notifier.Outcome_OUTCOME_SUCCEEDED:
+		outcome = digest.OutcomeSucceeded
+	case notifier.Outcome_OUTCOME_FAILED:
+		outcome = digest.OutcomeFailed
+	default:
+		outcome = digest.OutcomeUnknown
+	}
+	l.aggregator.Close(key, outcome, req.GetMessage())
+	return &notifier.CloseDeploymentResponse{Id: req.GetId()}, nil
+}
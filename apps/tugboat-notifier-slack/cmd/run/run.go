@@ -8,13 +8,16 @@ import (
 	"github.com/object88/tugboat/internal/cmd/common"
 	"github.com/object88/tugboat/internal/slack"
 	slackcliflags "github.com/object88/tugboat/internal/slack/cliflags"
+	"github.com/object88/tugboat/internal/slack/config"
 	grpccliflags "github.com/object88/tugboat/pkg/grpc/cliflags"
 	"github.com/object88/tugboat/pkg/grpc/server"
 	"github.com/object88/tugboat/pkg/http"
 	httpcliflags "github.com/object88/tugboat/pkg/http/cliflags"
 	"github.com/object88/tugboat/pkg/http/probes"
 	"github.com/object88/tugboat/pkg/http/router"
+	k8scliflags "github.com/object88/tugboat/pkg/k8s/cliflags"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
 )
 
 type command struct {
@@ -23,10 +26,13 @@ type command struct {
 
 	grpcFlagMgr  *grpccliflags.FlagManager
 	httpFlagMgr  *httpcliflags.FlagManager
+	k8sFlagMgr   *k8scliflags.FlagManager
 	slackFlagMgr *slackcliflags.FlagManager
 
-	bot   *slack.Bot
-	probe *probes.Probe
+	bot      *slack.Bot
+	users    config.Users
+	grpcOpts []grpc.ServerOption
+	probe    *probes.Probe
 }
 
 // CreateCommand returns the `run` Command
@@ -47,14 +53,19 @@ func CreateCommand(ca *common.CommonArgs) *cobra.Command {
 		CommonArgs:   ca,
 		grpcFlagMgr:  grpccliflags.New(),
 		httpFlagMgr:  httpcliflags.New(),
+		k8sFlagMgr:   k8scliflags.New(),
 		slackFlagMgr: slackcliflags.New(),
 	}
 
 	flags := c.Flags()
 
 	c.grpcFlagMgr.ConfigureGrpcPortFlag(flags)
+	c.grpcFlagMgr.ConfigureServerAuthFlags(flags)
+	c.grpcFlagMgr.ConfigureTLSFlags(flags)
 	c.httpFlagMgr.ConfigureHttpFlag(flags)
+	c.k8sFlagMgr.ConfigureKubernetesConfig(flags)
 	c.slackFlagMgr.ConfigureFlags(flags)
+	c.slackFlagMgr.ConfigureChannelFlag(flags)
 
 	return common.TraverseRunHooks(&c.Command)
 }
@@ -65,6 +76,16 @@ func (c *command) preexecute(cmd *cobra.Command, args []string) error {
 	c.bot = slack.New(&cfg)
 	c.bot.Logger = c.Log
 
+	var err error
+	if c.users, err = c.slackFlagMgr.Users(); err != nil {
+		return err
+	}
+
+	c.grpcOpts, err = c.grpcFlagMgr.ServerOptions(c.Log, c.k8sFlagMgr.KubernetesConfig())
+	if err != nil {
+		return err
+	}
+
 	c.probe = probes.New()
 
 	return nil
@@ -75,10 +96,15 @@ func (c *command) execute(cmd *cobra.Command, args []string) error {
 }
 
 func (c *command) startGRPCServer(ctx context.Context, r probes.Reporter) error {
-	g, err := server.New(c.Log, c.grpcFlagMgr.GRPCPort(), notification.New(c.Log, c.bot))
+	l := notification.New(c.Log, c.bot)
+	l.Channel = c.slackFlagMgr.Channel()
+	l.Users = c.users
+
+	g, err := server.NewWithOptions(c.Log, c.grpcFlagMgr.GRPCPort(), c.grpcOpts, l)
 	if err != nil {
 		return err
 	}
+	g.Probe = c.probe
 
 	return g.Serve(ctx, r)
 }
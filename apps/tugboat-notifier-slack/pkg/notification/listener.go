@@ -2,24 +2,58 @@ package notification
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	"github.com/go-logr/logr"
 	"github.com/object88/tugboat/internal/generated/notifier"
-	"github.com/object88/tugboat/internal/slack"
+	"github.com/object88/tugboat/internal/notifications/changes"
+	"github.com/object88/tugboat/internal/notifications/deployer"
+	"github.com/object88/tugboat/internal/notifications/source"
+	"github.com/object88/tugboat/internal/slack/config"
 	"google.golang.org/grpc"
 )
 
+// DefaultChannel is the channel that notifications are sent to if none is
+// configured
+const DefaultChannel = "general"
+
+// Sender sends messages to Slack, e.g. a slack.Bot
+type Sender interface {
+	StartThread(channel string, msg string) (string, error)
+	SendThreadedMessage(channel string, ts string, msg string) error
+	BroadcastThreadedMessage(channel string, ts string, msg string) error
+}
+
+// Listener posts a message to the channel when a deployment starts.  Its
+// updates are replied in the message's thread, and its outcome is replied
+// and also sent to the channel.
 type Listener struct {
 	notifier.UnimplementedListenerServer
 	logger logr.Logger
 
-	bot *slack.Bot
+	sender Sender
+
+	// Channel is the channel that notifications are sent to
+	Channel string
+
+	// Users maps deployers' Kubernetes usernames to Slack user IDs, so that
+	// they are @-mentioned
+	Users config.Users
+
+	mu sync.Mutex
+
+	// threads maps the IDs of open deployments to the timestamps of their
+	// messages
+	threads map[string]string
 }
 
-func New(logger logr.Logger, bot *slack.Bot) *Listener {
+func New(logger logr.Logger, sender Sender) *Listener {
 	return &Listener{
-		bot:    bot,
-		logger: logger,
+		sender:  sender,
+		logger:  logger,
+		Channel: DefaultChannel,
+		threads: map[string]string{},
 	}
 }
 
@@ -30,8 +64,83 @@ func (l *Listener) Register(s *grpc.Server, logger logr.Logger) error {
 
 func (l *Listener) OpenDeployment(ctx context.Context, req *notifier.StartDeploymentRequest) (*notifier.StartDeploymentResponse, error) {
 	l.logger.Info("Got OpenDeployment rpc")
-	if err := l.bot.SendMessage("general", "deployment started"); err != nil {
+	msg := fmt.Sprintf("Revision %d of `%s` in `%s` started deploying", req.GetRevision(), req.GetReleaseName(), req.GetNamespace())
+	if d := req.GetDeployedBy(); d != nil {
+		name, ok := l.Users.Mention(d.GetUsername())
+		if !ok {
+			name = "`" + deployer.Name(d) + "`"
+		}
+		if by := deployer.Format(d, name); by != "" {
+			msg += " by " + by
+		}
+	}
+	if s := source.Format(req.GetSource()); s != "" {
+		msg += "\n" + s
+	}
+	for _, c := range changes.FormatAll(req.GetChanges()) {
+		msg += "\n• " + c
+	}
+	ts, err := l.sender.StartThread(l.Channel, msg)
+	if err != nil {
+		l.logger.Error(err, "failed to send message to Slack", "error", err)
+	} else if id := req.GetId().GetValue(); id != "" {
+		l.mu.Lock()
+		l.threads[id] = ts
+		l.mu.Unlock()
+	}
+	return &notifier.StartDeploymentResponse{Id: req.GetId()}, nil
+}
+
+func (l *Listener) UpdateDeployment(ctx context.Context, req *notifier.UpdateDeploymentRequest) (*notifier.UpdateDeploymentResponse, error) {
+	l.logger.V(1).Info("Got UpdateDeployment rpc", "id", req.GetId().GetValue(), "release", req.GetReleaseName(), "namespace", req.GetNamespace(), "reason", req.GetReason())
+	msg := fmt.Sprintf("`%s`: %s", req.GetReason(), req.GetMessage())
+
+	l.mu.Lock()
+	ts, ok := l.threads[req.GetId().GetValue()]
+	l.mu.Unlock()
+
+	var err error
+	if ok {
+		err = l.sender.SendThreadedMessage(l.Channel, ts, msg)
+	} else {
+		_, err = l.sender.StartThread(l.Channel, fmt.Sprintf("Revision %d of `%s` in `%s`: %s", req.GetRevision(), req.GetReleaseName(), req.GetNamespace(), msg))
+	}
+	if err != nil {
+		l.logger.Error(err, "failed to send message to Slack", "error", err)
+	}
+	return &notifier.UpdateDeploymentResponse{Id: req.GetId()}, nil
+}
+
+func (l *Listener) CloseDeployment(ctx context.Context, req *notifier.CloseDeploymentRequest) (*notifier.CloseDeploymentResponse, error) {
+	l.logger.Info("Got CloseDeployment rpc", "id", req.GetId().GetValue(), "release", req.GetReleaseName(), "namespace", req.GetNamespace(), "outcome", req.GetOutcome().String())
+	var outcome string
+	switch req.GetOutcome() {
+	case notifier.Outcome_OUTCOME_SUCCEEDED:
+		outcome = "deployed"
+	case notifier.Outcome_OUTCOME_FAILED:
+		outcome = "failed to deploy"
+	default:
+		outcome = "finished deploying"
+	}
+	msg := fmt.Sprintf("Revision %d of `%s` in `%s` %s", req.GetRevision(), req.GetReleaseName(), req.GetNamespace(), outcome)
+	if m := req.GetMessage(); m != "" {
+		msg += "\n" + m
+	}
+
+	id := req.GetId().GetValue()
+	l.mu.Lock()
+	ts, ok := l.threads[id]
+	delete(l.threads, id)
+	l.mu.Unlock()
+
+	var err error
+	if ok {
+		err = l.sender.BroadcastThreadedMessage(l.Channel, ts, msg)
+	} else {
+		_, err = l.sender.StartThread(l.Channel, msg)
+	}
+	if err != nil {
 		l.logger.Error(err, "failed to send message to Slack", "error", err)
 	}
-	return &notifier.StartDeploymentResponse{}, nil
+	return &notifier.CloseDeploymentResponse{Id: req.GetId()}, nil
 }
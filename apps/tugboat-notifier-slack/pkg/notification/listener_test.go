@@ -0,0 +1,68 @@
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/object88/tugboat/internal/generated/notifier"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+)
+
+// message is a message sent to Slack.  A reply has the timestamp of the
+// message that it replies to.
+type message struct {
+	channel   string
+	ts        string
+	text      string
+	broadcast bool
+}
+
+type fakeSender struct {
+	messages []message
+}
+
+func (f *fakeSender) StartThread(channel string, msg string) (string, error) {
+	f.messages = append(f.messages, message{channel: channel, text: msg})
+	return "1610000000.000100", nil
+}
+
+func (f *fakeSender) SendThreadedMessage(channel string, ts string, msg string) error {
+	f.messages = append(f.messages, message{channel: channel, ts: ts, text: msg})
+	return nil
+}
+
+func (f *fakeSender) BroadcastThreadedMessage(channel string, ts string, msg string) error {
+	f.messages = append(f.messages, message{channel: channel, ts: ts, text: msg, broadcast: true})
+	return nil
+}
+
+func Test_Listener(t *testing.T) {
+	sender := &fakeSender{}
+	l := New(testlogger.TestLogger{T: t}, sender)
+	l.Channel = "deploys"
+
+	id := &notifier.UUID{Value: "abc-123"}
+	ctx := context.Background()
+	l.OpenDeployment(ctx, &notifier.StartDeploymentRequest{Id: id, ReleaseName: "checkout", Namespace: "payments", Revision: 4})
+	l.UpdateDeployment(ctx, &notifier.UpdateDeploymentRequest{Id: id, ReleaseName: "checkout", Namespace: "payments", Revision: 4, Reason: "Stalled", Message: "Deployment/checkout has not rolled out"})
+	l.CloseDeployment(ctx, &notifier.CloseDeploymentRequest{Id: id, ReleaseName: "checkout", Namespace: "payments", Revision: 4, Outcome: notifier.Outcome_OUTCOME_FAILED})
+	l.UpdateDeployment(ctx, &notifier.UpdateDeploymentRequest{Id: id, ReleaseName: "checkout", Namespace: "payments", Revision: 4, Reason: "BackOff", Message: "late"})
+
+	expected := []message{
+		{channel: "deploys", text: "Revision 4 of `checkout` in `payments` started deploying"},
+		{channel: "deploys", ts: "1610000000.000100", text: "`Stalled`: Deployment/checkout has not rolled out"},
+		{channel: "deploys", ts: "1610000000.000100", text: "Revision 4 of `checkout` in `payments` failed to deploy", broadcast: true},
+		{channel: "deploys", text: "Revision 4 of `checkout` in `payments`: `BackOff`: late"},
+	}
+	if len(sender.messages) != len(expected) {
+		t.Fatalf("incorrect number of messages: expected %d, actual %d", len(expected), len(sender.messages))
+	}
+	for k, m := range sender.messages {
+		if m != expected[k] {
+			t.Errorf("incorrect message %d:\nexpected %#v\nactual   %#v", k, expected[k], m)
+		}
+	}
+	if len(l.threads) != 0 {
+		t.Errorf("thread of closed deployment is kept: %v", l.threads)
+	}
+}
@@ -0,0 +1,112 @@
+package run
+
+import (
+	"context"
+
+	"github.com/object88/tugboat/apps/tugboat-notifier-webhook/pkg/notification"
+	"github.com/object88/tugboat/apps/tugboat-notifier-webhook/pkg/webhook"
+	"github.com/object88/tugboat/internal/cmd/common"
+	webhookcliflags "github.com/object88/tugboat/internal/webhook/cliflags"
+	grpccliflags "github.com/object88/tugboat/pkg/grpc/cliflags"
+	"github.com/object88/tugboat/pkg/grpc/server"
+	"github.com/object88/tugboat/pkg/http"
+	httpcliflags "github.com/object88/tugboat/pkg/http/cliflags"
+	"github.com/object88/tugboat/pkg/http/probes"
+	"github.com/object88/tugboat/pkg/http/router"
+	k8scliflags "github.com/object88/tugboat/pkg/k8s/cliflags"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+type command struct {
+	cobra.Command
+	*common.CommonArgs
+
+	grpcFlagMgr    *grpccliflags.FlagManager
+	httpFlagMgr    *httpcliflags.FlagManager
+	k8sFlagMgr     *k8scliflags.FlagManager
+	webhookFlagMgr *webhookcliflags.FlagManager
+
+	dispatcher *webhook.Dispatcher
+	grpcOpts   []grpc.ServerOption
+	probe      *probes.Probe
+}
+
+// CreateCommand returns the `run` Command
+func CreateCommand(ca *common.CommonArgs) *cobra.Command {
+	var c command
+	c = command{
+		Command: cobra.Command{
+			Use:   "run",
+			Short: "run posts deployment events to the configured webhooks",
+			Args:  cobra.NoArgs,
+			PreRunE: func(cmd *cobra.Command, args []string) error {
+				return c.preexecute(cmd, args)
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.execute(cmd, args)
+			},
+		},
+		CommonArgs:     ca,
+		grpcFlagMgr:    grpccliflags.New(),
+		httpFlagMgr:    httpcliflags.New(),
+		k8sFlagMgr:     k8scliflags.New(),
+		webhookFlagMgr: webhookcliflags.New(),
+	}
+
+	flags := c.Flags()
+
+	c.grpcFlagMgr.ConfigureGrpcPortFlag(flags)
+	c.grpcFlagMgr.ConfigureServerAuthFlags(flags)
+	c.grpcFlagMgr.ConfigureTLSFlags(flags)
+	c.httpFlagMgr.ConfigureHttpFlag(flags)
+	c.k8sFlagMgr.ConfigureKubernetesConfig(flags)
+	c.webhookFlagMgr.ConfigureFlags(flags)
+
+	return common.TraverseRunHooks(&c.Command)
+}
+
+func (c *command) preexecute(cmd *cobra.Command, args []string) error {
+	cfg, err := c.webhookFlagMgr.Config()
+	if err != nil {
+		return err
+	}
+
+	c.dispatcher, err = webhook.New(c.Log, cfg)
+	if err != nil {
+		return err
+	}
+
+	c.grpcOpts, err = c.grpcFlagMgr.ServerOptions(c.Log, c.k8sFlagMgr.KubernetesConfig())
+	if err != nil {
+		return err
+	}
+
+	c.probe = probes.New()
+
+	return nil
+}
+
+func (c *command) execute(cmd *cobra.Command, args []string) error {
+	return common.Multiblock(c.Log, c.probe, c.dispatcher.Run, c.startGRPCServer, c.startHTTPServer)
+}
+
+func (c *command) startGRPCServer(ctx context.Context, r probes.Reporter) error {
+	g, err := server.NewWithOptions(c.Log, c.grpcFlagMgr.GRPCPort(), c.grpcOpts, notification.New(c.Log, c.dispatcher))
+	if err != nil {
+		return err
+	}
+	g.Probe = c.probe
+
+	return g.Serve(ctx, r)
+}
+
+func (c *command) startHTTPServer(ctx context.Context, r probes.Reporter) error {
+	m, err := router.New(c.Log).Route(router.LoggingDefaultRoute, router.Defaults(c.probe))
+	if err != nil {
+		return err
+	}
+
+	http.New(c.Log, m, c.httpFlagMgr.HttpPort()).Serve(ctx, r)
+	return nil
+}
@@ -0,0 +1,138 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/object88/tugboat/apps/tugboat-notifier-webhook/pkg/webhook"
+	"github.com/object88/tugboat/internal/generated/notifier"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Listener struct {
+	notifier.UnimplementedListenerServer
+	logger logr.Logger
+
+	dispatcher *webhook.Dispatcher
+}
+
+func New(logger logr.Logger, dispatcher *webhook.Dispatcher) *Listener {
+	return &Listener{
+		dispatcher: dispatcher,
+		logger:     logger,
+	}
+}
+
+func (l *Listener) Register(s *grpc.Server, logger logr.Logger) error {
+	notifier.RegisterListenerServer(s, l)
+	return nil
+}
+
+func (l *Listener) OpenDeployment(ctx context.Context, req *notifier.StartDeploymentRequest) (*notifier.StartDeploymentResponse, error) {
+	id := req.GetId().GetValue()
+	if id == "" {
+		id = uuid.New().String()
+	}
+	l.logger.Info("Got OpenDeployment rpc", "id", id, "release", req.GetReleaseName(), "namespace", req.GetNamespace())
+
+	e := webhook.Event{
+		ID:        id,
+		Type:      webhook.EventDeploymentStarted,
+		Timestamp: time.Now().UTC(),
+		Release: webhook.Release{
+			Name:      req.GetReleaseName(),
+			Namespace: req.GetNamespace(),
+			Revision:  req.GetRevision(),
+		},
+	}
+	if d := req.GetDeployedBy(); d.GetUsername() != "" {
+		e.Deployer = &webhook.Deployer{
+			Username:       d.GetUsername(),
+			Groups:         d.GetGroups(),
+			ServiceAccount: d.GetServiceAccount(),
+			CI:             d.GetCi(),
+		}
+	}
+	if s := req.GetSource(); s != nil {
+		e.Source = &webhook.Source{
+			Commit:      s.GetCommit(),
+			Branch:      s.GetBranch(),
+			PullRequest: s.GetPullRequest(),
+			Pipeline:    s.GetPipeline(),
+		}
+	}
+	for _, c := range req.GetChanges() {
+		e.Changes = append(e.Changes, webhook.Change{
+			Object:    c.GetObject(),
+			Container: c.GetContainer(),
+			Field:     c.GetField(),
+			From:      c.GetFrom(),
+			To:        c.GetTo(),
+		})
+	}
+	if err := l.dispatcher.Enqueue(e); err != nil {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	return &notifier.StartDeploymentResponse{Id: &notifier.UUID{Value: id}}, nil
+}
+
+func (l *Listener) UpdateDeployment(ctx context.Context, req *notifier.UpdateDeploymentRequest) (*notifier.UpdateDeploymentResponse, error) {
+	l.logger.V(1).Info("Got UpdateDeployment rpc", "id", req.GetId().GetValue(), "release", req.GetReleaseName(), "namespace", req.GetNamespace(), "reason", req.GetReason())
+
+	e := webhook.Event{
+		ID:        uuid.New().String(),
+		Type:      webhook.EventDeploymentUpdated,
+		Timestamp: time.Now().UTC(),
+		Release: webhook.Release{
+			Name:      req.GetReleaseName(),
+			Namespace: req.GetNamespace(),
+			Revision:  req.GetRevision(),
+		},
+		DeploymentID: req.GetId().GetValue(),
+		Reason:       req.GetReason(),
+		Message:      req.GetMessage(),
+	}
+	if req.GetTime() != nil {
+		e.Timestamp = req.GetTime().AsTime().UTC()
+	}
+	if err := l.dispatcher.Enqueue(e); err != nil {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	return &notifier.UpdateDeploymentResponse{Id: req.GetId()}, nil
+}
+
+func (l *Listener) CloseDeployment(ctx context.Context, req *notifier.CloseDeploymentRequest) (*notifier.CloseDeploymentResponse, error) {
+	l.logger.Info("Got CloseDeployment rpc", "id", req.GetId().GetValue(), "release", req.GetReleaseName(), "namespace", req.GetNamespace(), "outcome", req.GetOutcome().String())
+
+	e := webhook.Event{
+		ID:        uuid.New().String(),
+		Type:      webhook.EventDeploymentCompleted,
+		Timestamp: time.Now().UTC(),
+		Release: webhook.Release{
+			Name:      req.GetReleaseName(),
+			Namespace: req.GetNamespace(),
+			Revision:  req.GetRevision(),
+		},
+		DeploymentID: req.GetId().GetValue(),
+		Message:      req.GetMessage(),
+	}
+	switch req.GetOutcome() {
+	case notifier.Outcome_OUTCOME_SUCCEEDED:
+		e.Outcome = webhook.OutcomeSucceeded
+	case notifier.Outcome_OUTCOME_FAILED:
+		e.Outcome = webhook.OutcomeFailed
+	default:
+		e.Outcome = webhook.OutcomeUnknown
+	}
+	if err := l.dispatcher.Enqueue(e); err != nil {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	return &notifier.CloseDeploymentResponse{Id: req.GetId()}, nil
+}
@@ -0,0 +1,96 @@
+package webhook
+
+import "time"
+
+const (
+	// EventDeploymentStarted is sent when a new revision of a release begins
+	// deploying
+	EventDeploymentStarted = "deployment.started"
+
+	// EventDeploymentUpdated is sent when something happens while a revision
+	// deploys, e.g. it stalls, a container fails, a hook runs, or the release
+	// is rolled back
+	EventDeploymentUpdated = "deployment.updated"
+
+	// EventDeploymentCompleted is sent when a revision has finished deploying
+	EventDeploymentCompleted = "deployment.completed"
+)
+
+// Outcomes of a completed deployment
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeUnknown   = "unknown"
+)
+
+// Event is the payload delivered to each endpoint, and the data available to
+// payload templates
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Release   Release   `json:"release"`
+
+	// DeploymentID identifies the deployment that a deployment.updated or
+	// deployment.completed event concerns; it is the ID of its
+	// deployment.started event
+	DeploymentID string `json:"deploymentId,omitempty"`
+
+	// Reason is a short, machine-readable cause of a deployment.updated
+	// event, e.g. "Stalled" or "CrashLoopBackOff"
+	Reason string `json:"reason,omitempty"`
+
+	// Message describes a deployment.updated or deployment.completed event
+	Message string `json:"message,omitempty"`
+
+	// Outcome is "succeeded", "failed" or "unknown", for a
+	// deployment.completed event
+	Outcome string `json:"outcome,omitempty"`
+
+	// Deployer identifies who deployed the revision, if known
+	Deployer *Deployer `json:"deployer,omitempty"`
+
+	// Source links the revision to the change which caused it, if known
+	Source *Source `json:"source,omitempty"`
+
+	// Changes summarize how the revision's workloads differ from the previous
+	// revision's
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// Release identifies the helm release that the event concerns
+type Release struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Revision  int32  `json:"revision"`
+}
+
+// Deployer identifies who deployed the revision
+type Deployer struct {
+	Username string   `json:"username"`
+	Groups   []string `json:"groups,omitempty"`
+
+	// ServiceAccount is "namespace/name", if the deployer is a service account
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+
+	// CI identifies the CI job which deployed the revision
+	CI map[string]string `json:"ci,omitempty"`
+}
+
+// Source is the source-control metadata of the revision
+type Source struct {
+	Commit      string `json:"commit,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	PullRequest string `json:"pullRequest,omitempty"`
+	Pipeline    string `json:"pipeline,omitempty"`
+}
+
+// Change is a difference in a workload between the revision and the
+// previous revision
+type Change struct {
+	Object    string `json:"object"`
+	Container string `json:"container,omitempty"`
+	Field     string `json:"field"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+}
@@ -0,0 +1,241 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/internal/webhook/config"
+	"github.com/object88/tugboat/pkg/http/probes"
+)
+
+const (
+	// HeaderDelivery carries the unique ID of the event
+	HeaderDelivery = "X-Tugboat-Delivery"
+
+	// HeaderEvent carries the type of the event
+	HeaderEvent = "X-Tugboat-Event"
+
+	// HeaderSignature carries the hex-encoded HMAC-SHA256 of the request body,
+	// prefixed with `sha256=`
+	HeaderSignature = "X-Tugboat-Signature"
+
+	queueSize = 100
+)
+
+// ErrQueueFull is returned by Enqueue when no endpoint can accept more
+// events
+var ErrQueueFull = errors.New("webhook event queue is full")
+
+// Dispatcher delivers events to the configured HTTP endpoints.  Each endpoint
+// has its own queue, so that an endpoint which is slow or down does not delay
+// delivery to the others.
+type Dispatcher struct {
+	Client *http.Client
+
+	logger    logr.Logger
+	endpoints []*endpoint
+}
+
+type endpoint struct {
+	config.Endpoint
+	tmpl   *template.Template
+	events chan Event
+}
+
+// New returns a new Dispatcher for the provided configuration
+func New(logger logr.Logger, cfg *config.Config) (*Dispatcher, error) {
+	cfg.Default()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	eps := make([]*endpoint, len(cfg.Endpoints))
+	for k, v := range cfg.Endpoints {
+		eps[k] = &endpoint{Endpoint: v, events: make(chan Event, queueSize)}
+		if v.Template == "" {
+			continue
+		}
+		tmpl, err := template.New(v.Name).Funcs(config.TemplateFuncs()).Option("missingkey=error").Parse(v.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template for endpoint '%s': %w", v.Name, err)
+		}
+		eps[k].tmpl = tmpl
+	}
+
+	return &Dispatcher{
+		Client:    &http.Client{},
+		logger:    logger,
+		endpoints: eps,
+	}, nil
+}
+
+// Enqueue schedules the event for delivery to each endpoint.  It does not
+// block; an endpoint whose queue is full misses the event, which is logged.
+// If every endpoint's queue is full, ErrQueueFull is returned, and the event
+// may be enqueued again.
+func (d *Dispatcher) Enqueue(e Event) error {
+	full := 0
+	for _, ep := range d.endpoints {
+		select {
+		case ep.events <- e:
+		default:
+			d.logger.Info("endpoint queue is full; dropping event", "endpoint", ep.Name, "id", e.ID, "type", e.Type)
+			full++
+		}
+	}
+	if full != 0 && full == len(d.endpoints) {
+		return ErrQueueFull
+	}
+	return nil
+}
+
+// Run delivers enqueued events, with a worker for each endpoint, until the
+// context is canceled
+func (d *Dispatcher) Run(ctx context.Context, r probes.Reporter) error {
+	var wg sync.WaitGroup
+	wg.Add(len(d.endpoints))
+	for _, ep := range d.endpoints {
+		go func(ep *endpoint) {
+			defer wg.Done()
+			d.work(ctx, ep)
+		}(ep)
+	}
+
+	r.Ready()
+	defer r.NotReady()
+
+	wg.Wait()
+	<-ctx.Done()
+	return nil
+}
+
+// work delivers the events queued for an endpoint, in order, until the
+// context is canceled
+func (d *Dispatcher) work(ctx context.Context, ep *endpoint) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-ep.events:
+			if err := d.deliver(ctx, ep, e); err != nil {
+				d.logger.Error(err, "failed to deliver event", "endpoint", ep.Name, "id", e.ID, "type", e.Type)
+			}
+		}
+	}
+}
+
+// deliver sends the event to the endpoint, retrying failures which may
+// succeed on retry with exponential backoff
+func (d *Dispatcher) deliver(ctx context.Context, ep *endpoint, e Event) error {
+	body, err := ep.render(e)
+	if err != nil {
+		return fmt.Errorf("failed to render payload for endpoint '%s': %w", ep.Name, err)
+	}
+
+	backoff := ep.InitialBackoff.Duration
+	attempts := *ep.MaxRetries + 1
+	for attempt := 1; ; attempt++ {
+		retry, err := d.post(ctx, ep, e, body)
+		if err == nil {
+			d.logger.V(1).Info("delivered event", "endpoint", ep.Name, "id", e.ID, "attempt", attempt)
+			return nil
+		}
+		if !retry || attempt >= attempts {
+			return fmt.Errorf("failed to deliver event '%s' to endpoint '%s' after %d attempt(s): %w", e.ID, ep.Name, attempt, err)
+		}
+
+		d.logger.Info("delivery failed; retrying", "endpoint", ep.Name, "id", e.ID, "attempt", attempt, "backoff", backoff, "error", err.Error())
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("gave up delivering event '%s' to endpoint '%s': %w", e.ID, ep.Name, ctx.Err())
+		case <-t.C:
+		}
+
+		backoff *= 2
+		if backoff > ep.MaxBackoff.Duration {
+			backoff = ep.MaxBackoff.Duration
+		}
+	}
+}
+
+// post makes a single delivery attempt.  The returned bool reports whether a
+// failure may succeed on retry.
+func (d *Dispatcher) post(ctx context.Context, ep *endpoint, e Event, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, ep.Timeout.Duration)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for k, v := range ep.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, e.ID)
+	req.Header.Set(HeaderEvent, e.Type)
+	if ep.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign([]byte(ep.Secret), body))
+	}
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode >= 500:
+		return true, fmt.Errorf("received status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("received status %d", resp.StatusCode)
+	}
+}
+
+func (ep *endpoint) render(e Event) ([]byte, error) {
+	if ep.tmpl == nil {
+		return json.Marshal(e)
+	}
+
+	var buf bytes.Buffer
+	if err := ep.tmpl.Execute(&buf, e); err != nil {
+		return nil, err
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("template did not produce valid JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+// Sign returns the value of the signature header for the body: the
+// hex-encoded HMAC-SHA256, prefixed with `sha256=`
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature header value matches the body.
+// Receivers may use this to authenticate requests.
+func Verify(secret []byte, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
@@ -0,0 +1,316 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/internal/webhook/config"
+	"github.com/object88/tugboat/pkg/http/probes"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rcv *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	status := http.StatusOK
+	if n := len(rcv.requests); n < len(rcv.statuses) {
+		status = rcv.statuses[n]
+	}
+	rcv.requests = append(rcv.requests, r)
+	rcv.bodies = append(rcv.bodies, body)
+
+	w.WriteHeader(status)
+}
+
+func Test_Dispatcher_Run(t *testing.T) {
+	tcs := []struct {
+		name          string
+		statuses      []int
+		maxRetries    int
+		expectedCalls int
+	}{
+		{
+			name:          "success",
+			statuses:      []int{http.StatusOK},
+			maxRetries:    3,
+			expectedCalls: 1,
+		},
+		{
+			name:          "retry-then-success",
+			statuses:      []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusAccepted},
+			maxRetries:    3,
+			expectedCalls: 3,
+		},
+		{
+			name:          "retries-exhausted",
+			statuses:      []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			maxRetries:    2,
+			expectedCalls: 3,
+		},
+		{
+			name:          "client-error-not-retried",
+			statuses:      []int{http.StatusBadRequest},
+			maxRetries:    3,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			rcv := &receiver{statuses: tc.statuses}
+			srv := httptest.NewServer(rcv)
+			defer srv.Close()
+
+			d := createDispatcher(t, config.Endpoint{
+				Name:       "test",
+				URL:        srv.URL,
+				Secret:     "s3cr3t",
+				MaxRetries: &tc.maxRetries,
+			})
+
+			stop := run(d)
+			defer stop()
+
+			if err := d.Enqueue(createEvent()); err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			waitForRequests(t, rcv, tc.expectedCalls)
+
+			// Give any unexpected retry the time to arrive.
+			time.Sleep(50 * time.Millisecond)
+			rcv.mu.Lock()
+			defer rcv.mu.Unlock()
+			if len(rcv.requests) != tc.expectedCalls {
+				t.Fatalf("incorrect number of requests: expected %d, actual %d", tc.expectedCalls, len(rcv.requests))
+			}
+			for k, r := range rcv.requests {
+				if !Verify([]byte("s3cr3t"), rcv.bodies[k], r.Header.Get(HeaderSignature)) {
+					t.Errorf("request %d has invalid signature '%s'", k, r.Header.Get(HeaderSignature))
+				}
+				if r.Header.Get(HeaderDelivery) != "abc-123" {
+					t.Errorf("request %d has incorrect delivery header '%s'", k, r.Header.Get(HeaderDelivery))
+				}
+			}
+		})
+	}
+}
+
+func Test_Dispatcher_Payload(t *testing.T) {
+	rcv := &receiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	d := createDispatcher(t,
+		config.Endpoint{
+			Name: "default",
+			URL:  srv.URL,
+		},
+		config.Endpoint{
+			Name:     "templated",
+			URL:      srv.URL + "/templated",
+			Headers:  map[string]string{"Authorization": "Token abc"},
+			Template: `{"summary": {{ printf "%s/%s deploying revision %d" .Release.Namespace .Release.Name .Release.Revision | json }}, "dedup_key": {{ json .ID }}}`,
+		})
+
+	stop := run(d)
+	defer stop()
+
+	if err := d.Enqueue(createEvent()); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	waitForRequests(t, rcv, 2)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	for k, r := range rcv.requests {
+		if r.Header.Get(HeaderSignature) != "" {
+			t.Errorf("unsigned endpoint sent signature header")
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("incorrect content type '%s'", r.Header.Get("Content-Type"))
+		}
+
+		switch r.URL.Path {
+		case "/templated":
+			if r.Header.Get("Authorization") != "Token abc" {
+				t.Errorf("missing custom header")
+			}
+			var actual map[string]string
+			if err := json.Unmarshal(rcv.bodies[k], &actual); err != nil {
+				t.Fatalf("failed to decode templated body '%s': %s", rcv.bodies[k], err.Error())
+			}
+			if actual["summary"] != "payments/checkout deploying revision 4" || actual["dedup_key"] != "abc-123" {
+				t.Errorf("incorrect templated body: %#v", actual)
+			}
+		default:
+			var actual Event
+			if err := json.Unmarshal(rcv.bodies[k], &actual); err != nil {
+				t.Fatalf("failed to decode body '%s': %s", rcv.bodies[k], err.Error())
+			}
+			if actual.Type != EventDeploymentStarted || actual.Release.Name != "checkout" || actual.Release.Revision != 4 {
+				t.Errorf("incorrect body: %#v", actual)
+			}
+		}
+	}
+}
+
+func Test_Dispatcher_Canceled(t *testing.T) {
+	rcv := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	retries := 5
+	d := createDispatcher(t, config.Endpoint{
+		Name:           "slow",
+		URL:            srv.URL,
+		MaxRetries:     &retries,
+		InitialBackoff: &metav1.Duration{Duration: time.Hour},
+		MaxBackoff:     &metav1.Duration{Duration: time.Hour},
+	})
+
+	p := probes.New()
+	p.SetCapacity(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx, p.Reporter(0))
+		close(done)
+	}()
+
+	if err := d.Enqueue(createEvent()); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	waitForRequests(t, rcv, 1)
+
+	// The endpoint's worker is waiting an hour to retry; canceling stops it.
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("run did not stop while retrying")
+	}
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	if len(rcv.requests) != 1 {
+		t.Errorf("incorrect number of requests: expected 1, actual %d", len(rcv.requests))
+	}
+}
+
+func Test_Dispatcher_Run_SlowEndpoint(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	rcv := &receiver{}
+	fast := httptest.NewServer(rcv)
+	defer fast.Close()
+
+	d := createDispatcher(t,
+		config.Endpoint{Name: "slow", URL: slow.URL, Timeout: &metav1.Duration{Duration: time.Hour}},
+		config.Endpoint{Name: "fast", URL: fast.URL},
+	)
+
+	stop := run(d)
+	defer stop()
+
+	for k := 0; k < 3; k++ {
+		if err := d.Enqueue(createEvent()); err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+	}
+
+	// The fast endpoint receives every event while the slow endpoint is
+	// blocked.
+	waitForRequests(t, rcv, 3)
+}
+
+func Test_New_InvalidTemplate(t *testing.T) {
+	_, err := New(testlogger.TestLogger{T: t}, &config.Config{
+		Endpoints: []config.Endpoint{
+			{Name: "bad", URL: "http://example.com", Template: "{{ .Release.Name "},
+		},
+	})
+	if err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+// run runs the dispatcher until the returned func is called
+func run(d *Dispatcher) func() {
+	p := probes.New()
+	p.SetCapacity(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx, p.Reporter(0))
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func waitForRequests(t *testing.T, rcv *receiver, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rcv.mu.Lock()
+		actual := len(rcv.requests)
+		rcv.mu.Unlock()
+		if actual >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("received %d of %d requests", actual, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func createDispatcher(t *testing.T, endpoints ...config.Endpoint) *Dispatcher {
+	for k := range endpoints {
+		if endpoints[k].InitialBackoff == nil {
+			endpoints[k].InitialBackoff = &metav1.Duration{Duration: time.Millisecond}
+		}
+	}
+	d, err := New(testlogger.TestLogger{T: t}, &config.Config{Endpoints: endpoints})
+	if err != nil {
+		t.Fatalf("failed to create dispatcher: %s", err.Error())
+	}
+	return d
+}
+
+func createEvent() Event {
+	return Event{
+		ID:        "abc-123",
+		Type:      EventDeploymentStarted,
+		Timestamp: time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC),
+		Release: Release{
+			Name:      "checkout",
+			Namespace: "payments",
+			Revision:  4,
+		},
+	}
+}
@@ -2,16 +2,24 @@ package serve
 
 import (
 	"context"
+	"time"
 
 	v1 "github.com/object88/tugboat/apps/tugboat-slack/pkg/http/router/v1"
 	"github.com/object88/tugboat/internal/cmd/common"
 	"github.com/object88/tugboat/internal/slack"
 	slackcliflags "github.com/object88/tugboat/internal/slack/cliflags"
+	"github.com/object88/tugboat/internal/slack/query"
 	"github.com/object88/tugboat/pkg/http"
 	httpcliflags "github.com/object88/tugboat/pkg/http/cliflags"
 	"github.com/object88/tugboat/pkg/http/probes"
 	"github.com/object88/tugboat/pkg/http/router"
+	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned"
+	"github.com/object88/tugboat/pkg/k8s/client/informers/externalversions"
+	listerv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/listers/engineering.tugboat/v1alpha1"
+	k8scliflags "github.com/object88/tugboat/pkg/k8s/cliflags"
+	"github.com/object88/tugboat/pkg/k8s/informermanager"
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/cache"
 )
 
 type command struct {
@@ -19,9 +27,12 @@ type command struct {
 	*common.CommonArgs
 
 	httpFlagMgr  *httpcliflags.FlagManager
+	k8sFlagMgr   *k8scliflags.FlagManager
 	slackFlagMgr *slackcliflags.FlagManager
 
 	bot *slack.Bot
+
+	releasehistoryinformer cache.SharedIndexInformer
 }
 
 func CreateCommand(cmmn *common.CommonArgs) *cobra.Command {
@@ -41,12 +52,14 @@ func CreateCommand(cmmn *common.CommonArgs) *cobra.Command {
 		},
 		CommonArgs:   cmmn,
 		httpFlagMgr:  httpcliflags.New(),
+		k8sFlagMgr:   k8scliflags.New(),
 		slackFlagMgr: slackcliflags.New(),
 	}
 
 	flags := c.Flags()
 
 	c.httpFlagMgr.ConfigureHttpFlag(flags)
+	c.k8sFlagMgr.ConfigureKubernetesConfig(flags)
 	c.slackFlagMgr.ConfigureFlags(flags)
 
 	return common.TraverseRunHooks(&c.Command)
@@ -58,6 +71,21 @@ func (c *command) preexecute(cmd *cobra.Command, args []string) error {
 	c.bot = slack.New(&cfg)
 	c.bot.Logger = c.Log
 
+	restcfg, err := c.k8sFlagMgr.KubernetesConfig().ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	versionedclientset, err := versioned.NewForConfig(restcfg)
+	if err != nil {
+		return err
+	}
+	externalversionsfactory := externalversions.NewSharedInformerFactory(versionedclientset, 10*time.Second)
+	c.releasehistoryinformer = externalversionsfactory.Tugboat().V1alpha1().ReleaseHistories().Informer()
+
+	lister := listerv1alpha1.NewReleaseHistoryLister(c.releasehistoryinformer.GetIndexer())
+	c.bot.Answerer = query.NewAnswerer(lister)
+
 	return nil
 }
 
@@ -65,7 +93,7 @@ func (c *command) execute(cmd *cobra.Command, args []string) error {
 	p := probes.New()
 	rtr := router.New(c.Log)
 
-	return common.Block(c.Log, p, func(ctx context.Context, r probes.Reporter) error {
+	f0 := func(ctx context.Context, r probes.Reporter) error {
 		m, err := rtr.Route(router.LoggingDefaultRoute, router.Defaults(p, v1.Defaults(c.Log, c.bot)))
 		if err != nil {
 			return err
@@ -74,5 +102,15 @@ func (c *command) execute(cmd *cobra.Command, args []string) error {
 		s := http.New(c.Log, m, c.httpFlagMgr.HttpPort())
 		s.Serve(ctx, r)
 		return nil
-	})
+	}
+
+	f1 := func(ctx context.Context, r probes.Reporter) error {
+		mgr := informermanager.New(c.Log)
+		if err := mgr.Add("releasehistories", c.releasehistoryinformer); err != nil {
+			return err
+		}
+		return mgr.Run(ctx, r)
+	}
+
+	return common.Multiblock(c.Log, p, f0, f1)
 }
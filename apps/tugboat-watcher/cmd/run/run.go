@@ -5,10 +5,27 @@ import (
 	"fmt"
 	"time"
 
+	watchercliflags "github.com/object88/tugboat/apps/tugboat-watcher/pkg/cliflags"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/diagnostics"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/feed"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/history"
+	v1 "github.com/object88/tugboat/apps/tugboat-watcher/pkg/http/router/v1"
 	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/informerhandlers"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/notify"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/remediation"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/stall"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/verification"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/watcher"
 	"github.com/object88/tugboat/internal/cmd/common"
+	"github.com/object88/tugboat/internal/constants"
 	notificationsclient "github.com/object88/tugboat/internal/notifications/client"
 	notificationscliflags "github.com/object88/tugboat/internal/notifications/cliflags"
+	"github.com/object88/tugboat/internal/notifications/discovery"
+	"github.com/object88/tugboat/internal/notifications/outbox"
+	grpccliflags "github.com/object88/tugboat/pkg/grpc/cliflags"
+	"github.com/object88/tugboat/pkg/grpc/server"
+	"github.com/object88/tugboat/pkg/helm"
 	"github.com/object88/tugboat/pkg/http"
 	httpcliflags "github.com/object88/tugboat/pkg/http/cliflags"
 	"github.com/object88/tugboat/pkg/http/probes"
@@ -19,11 +36,17 @@ import (
 	k8scliflags "github.com/object88/tugboat/pkg/k8s/cliflags"
 	"github.com/object88/tugboat/pkg/k8s/informermanager"
 	"github.com/spf13/cobra"
-	v1 "k8s.io/api/core/v1"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -31,15 +54,26 @@ type command struct {
 	cobra.Command
 	*common.CommonArgs
 
+	grpcFlagMgr          *grpccliflags.FlagManager
 	httpFlagMgr          *httpcliflags.FlagManager
 	k8sFlagMgr           *k8scliflags.FlagManager
 	notificationsFlagMgr *notificationscliflags.FlagManager
+	watcherFlagMgr       *watchercliflags.FlagManager
 
 	versionedclientset *versioned.Clientset
 
-	// w                      cache.SharedIndexInformer
-	eventinformer          cache.SharedIndexInformer
-	releasehistoryinformer cache.SharedIndexInformer
+	collector  *diagnostics.Collector
+	feed       *feed.Feed
+	grpcOpts   []grpc.ServerOption
+	notifier   *notificationsclient.Client
+	outbox     *outbox.Outbox
+	recorder   *history.Recorder
+	remediator *remediation.Remediator
+	stalls     *stall.Detector
+	verifier   *verification.Verifier
+
+	// informers runs the informers, in the order that they are added
+	informers *informermanager.Manager
 }
 
 // CreateCommand returns the `run` Command
@@ -58,98 +92,212 @@ func CreateCommand(ca *common.CommonArgs) *cobra.Command {
 			},
 		},
 		CommonArgs:           ca,
+		grpcFlagMgr:          grpccliflags.New(),
 		httpFlagMgr:          httpcliflags.New(),
 		k8sFlagMgr:           cliflags.New(),
 		notificationsFlagMgr: notificationscliflags.New(),
+		watcherFlagMgr:       watchercliflags.New(),
 	}
 
 	flags := c.Flags()
 
+	c.grpcFlagMgr.ConfigureBackoffFlags(flags)
+	c.grpcFlagMgr.ConfigureClientAuthFlags(flags)
+	c.grpcFlagMgr.ConfigureGrpcPortFlag(flags)
+	c.grpcFlagMgr.ConfigureServerAuthFlags(flags)
+	c.grpcFlagMgr.ConfigureTLSFlags(flags)
 	c.httpFlagMgr.ConfigureHttpFlag(flags)
 	c.k8sFlagMgr.ConfigureKubernetesConfig(flags)
+	c.notificationsFlagMgr.ConfigureListenerSelectorFlag(flags)
 	c.notificationsFlagMgr.ConfigureListenersFlag(flags)
+	c.notificationsFlagMgr.ConfigureOutboxFlags(flags)
+	c.watcherFlagMgr.ConfigureAutoRollbackFlags(flags)
+	c.watcherFlagMgr.ConfigureDiagnosticsFlags(flags)
+	c.watcherFlagMgr.ConfigureStallFlags(flags)
 
 	return common.TraverseRunHooks(&c.Command)
 }
 
 func (c *command) preexecute(cmd *cobra.Command, args []string) error {
+	getter := c.k8sFlagMgr.KubernetesConfig()
+
+	cfg, err := getter.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	journal, err := c.notificationsFlagMgr.OutboxJournal(clientset)
+	if err != nil {
+		return err
+	}
+	c.outbox = outbox.New(c.Log, journal, c.notificationsFlagMgr.OutboxOptions())
+	if err := c.outbox.Load(cmd.Context()); err != nil {
+		return fmt.Errorf("failed to load notification outbox: %w", err)
+	}
+
+	c.grpcOpts, err = c.grpcFlagMgr.ServerOptions(c.Log, getter)
+	if err != nil {
+		return err
+	}
+
+	dialOpts, err := c.grpcFlagMgr.DialOptions(c.Log)
+	if err != nil {
+		return err
+	}
+
 	targets, err := c.notificationsFlagMgr.Listeners()
 	if err != nil {
 		return fmt.Errorf("failed to get notification listeners: %w", err)
 	}
 	c.Log.Info("Listeners", "listeners", targets)
-	notifier := notificationsclient.New(c.Log)
-	if err := notifier.Connect(targets); err != nil {
+	c.notifier = notificationsclient.New(c.Log, c.outbox, dialOpts...)
+	if err := c.notifier.Connect(targets); err != nil {
 		return fmt.Errorf("failed to establish clients for notification listeners: %w", err)
 	}
 
-	getter := c.k8sFlagMgr.KubernetesConfig()
-
-	cfg, err := getter.ToRESTConfig()
+	selector, discover, err := c.notificationsFlagMgr.ListenerSelector()
 	if err != nil {
 		return err
 	}
+	var serviceinformer cache.SharedIndexInformer
+	if discover {
+		c.Log.Info("Discovering listeners", "selector", selector.String())
+		servicefactory := informers.NewSharedInformerFactoryWithOptions(clientset, 10*time.Second, informers.WithTweakListOptions(func(lo *metav1.ListOptions) {
+			lo.LabelSelector = selector.String()
+		}))
+		serviceinformer = servicefactory.Core().V1().Services().Informer()
+		discoverer := discovery.New(c.Log, c.outbox, dialOpts...)
+		discoverer.Static = targets
+		serviceinformer.AddEventHandler(discoverer)
+	}
 
-	clientset, err := kubernetes.NewForConfig(cfg)
+	c.versionedclientset, err = versioned.NewForConfig(cfg)
 	if err != nil {
 		return err
 	}
 
-	c.versionedclientset, err = versioned.NewForConfig(cfg)
+	factory := externalversions.NewSharedInformerFactory(c.versionedclientset, 10*time.Second)
+	releasehistoryinformer := factory.Tugboat().V1alpha1().ReleaseHistories().Informer()
+
+	c.feed = feed.New(feed.DefaultCapacity)
+	c.recorder = history.New(c.Log, c.versionedclientset)
+	sink := events.Sinks{c.feed, c.recorder, notify.New(c.Log, c.notifier)}
+	if c.watcherFlagMgr.AutoRollback() {
+		// The remediator publishes its rollbacks to the other sinks.
+		c.remediator = remediation.New(c.Log, c.versionedclientset, helm.NewActions(c.Log, getter), sink, c.watcherFlagMgr.AutoRollbackOptions())
+		sink = append(sink, c.remediator)
+	}
+
+	handler, err := informerhandlers.NewReleaseHistory(c.Log, sink)
 	if err != nil {
 		return err
 	}
+	releasehistoryinformer.AddEventHandler(handler)
 
-	// r, err := labels.NewRequirement("tugboat.engineering/releasehistory", selection.Exists, nil)
-	// if err != nil {
-	//  // log.Info("failed to create requirement", "err0", err0.Error(), "err1", err1.Error())
-	//  return err
-	// }
-
-	fact := informers.NewSharedInformerFactoryWithOptions(clientset, 1*time.Second, informers.WithTweakListOptions(func(lo *metav1.ListOptions) {
-		// lo.LabelSelector = labels.NewSelector().Add(*r).String()
-		lo.FieldSelector = field.NewPath("involvedObject.namespace=default").String()
+	// Only pods and workloads which the mutating webhook has labelled as
+	// belonging to a release are watched.
+	r, err := labels.NewRequirement(constants.LabelReleaseHistory, selection.Exists, nil)
+	if err != nil {
+		return err
+	}
+	releasefactory := informers.NewSharedInformerFactoryWithOptions(clientset, 10*time.Second, informers.WithTweakListOptions(func(lo *metav1.ListOptions) {
+		lo.LabelSelector = labels.NewSelector().Add(*r).String()
 	}))
+	podinformer := releasefactory.Core().V1().Pods().Informer()
+	diagnosticsOpts, err := c.watcherFlagMgr.DiagnosticsOptions()
+	if err != nil {
+		return err
+	}
+	c.collector = diagnostics.New(c.Log, clientset, sink, diagnosticsOpts)
+	podwatcher := watcher.NewPodWatcher(c.Log, sink)
+	podwatcher.Diagnoser = c.collector
+	podinformer.AddEventHandler(podwatcher)
 
-	c.eventinformer = fact.Core().V1().Events().Informer()
-
-	c.eventinformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: func(obj interface{}) {
-			evt, ok := obj.(*v1.Event)
-			if !ok {
-				return
-			}
-			fmt.Printf("thing added: %s \n", evt.InvolvedObject.Name)
-		},
-		DeleteFunc: func(obj interface{}) {
-			// fmt.Printf("service deleted: %s \n", obj)
-		},
-		UpdateFunc: func(oldObj, newObj interface{}) {
-			// fmt.Printf("service changed \n")
-		},
+	apps := releasefactory.Apps().V1()
+	batch := releasefactory.Batch().V1()
+	// Helm hooks are Jobs or pods.
+	hookwatcher := watcher.NewHookWatcher(c.Log, sink)
+	podinformer.AddEventHandler(hookwatcher)
+	batch.Jobs().Informer().AddEventHandler(hookwatcher)
+	c.stalls = stall.New(c.Log, sink, c.watcherFlagMgr.StallDeadlines(), stall.Listers{
+		Deployments:      apps.Deployments().Lister(),
+		StatefulSets:     apps.StatefulSets().Lister(),
+		DaemonSets:       apps.DaemonSets().Lister(),
+		Jobs:             batch.Jobs().Lister(),
+		Pods:             releasefactory.Core().V1().Pods().Lister(),
+		ReleaseHistories: factory.Tugboat().V1alpha1().ReleaseHistories().Lister(),
 	})
+	verificationinformer := factory.Tugboat().V1alpha1().DeploymentVerifications().Informer()
+	c.verifier = verification.New(c.Log, c.versionedclientset, sink, verification.Listers{
+		Pods:                    releasefactory.Core().V1().Pods().Lister(),
+		Jobs:                    batch.Jobs().Lister(),
+		ReleaseHistories:        factory.Tugboat().V1alpha1().ReleaseHistories().Lister(),
+		DeploymentVerifications: factory.Tugboat().V1alpha1().DeploymentVerifications().Lister(),
+	})
+	handler.Verifier = c.verifier
 
-	// v1.Event.
-
-	// c.w = watcher.New(c.Log, clientset)
-
-	factory := externalversions.NewSharedInformerFactory(c.versionedclientset, 10*time.Second)
-	c.releasehistoryinformer = factory.Tugboat().V1alpha1().ReleaseHistories().Informer()
-
-	handler, err := informerhandlers.NewReleaseHistory(c.Log)
+	dc, err := getter.ToDiscoveryClient()
 	if err != nil {
 		return err
 	}
-	c.releasehistoryinformer.AddEventHandler(handler)
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc))
+	resolver := watcher.NewObjectResolver(releasefactory.Core().V1().Pods().Lister(), dyn, mapper)
+
+	// Only warnings are correlated with releases.
+	eventfactory := informers.NewSharedInformerFactoryWithOptions(clientset, 10*time.Second, informers.WithTweakListOptions(func(lo *metav1.ListOptions) {
+		lo.FieldSelector = fields.OneTermEqualSelector("type", corev1.EventTypeWarning).String()
+	}))
+	eventinformer := eventfactory.Core().V1().Events().Informer()
+	eventinformer.AddEventHandler(watcher.NewEventWatcher(c.Log, sink, resolver))
+
+	// The informers start in order.  The service informer syncs first, so
+	// that the listeners are discovered before any notification is sent, and
+	// then the pod informer, so that events can be correlated with the pods
+	// in its cache.
+	c.informers = informermanager.New(c.Log)
+	c.informers.Ordered = true
+	if serviceinformer != nil {
+		if err := c.informers.Add("services", serviceinformer); err != nil {
+			return err
+		}
+	}
+	for _, i := range []struct {
+		name     string
+		informer cache.SharedIndexInformer
+	}{
+		{"pods", podinformer},
+		{"releasehistories", releasehistoryinformer},
+		{"events", eventinformer},
+		{"deploymentverifications", verificationinformer},
+		{"deployments", apps.Deployments().Informer()},
+		{"statefulsets", apps.StatefulSets().Informer()},
+		{"daemonsets", apps.DaemonSets().Informer()},
+		{"jobs", batch.Jobs().Informer()},
+	} {
+		if err := c.informers.Add(i.name, i.informer); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
 func (c *command) execute(cmd *cobra.Command, args []string) error {
+	defer c.notifier.Close()
+
 	p := probes.New()
 
 	f0 := func(ctx context.Context, r probes.Reporter) error {
-		m, err := router.New(c.Log).Route(router.LoggingDefaultRoute, router.Defaults(p))
+		m, err := router.New(c.Log).Route(router.LoggingDefaultRoute, router.Defaults(p, v1.Defaults(c.Log, c.outbox, c.informers)))
 		if err != nil {
 			return err
 		}
@@ -159,9 +307,18 @@ func (c *command) execute(cmd *cobra.Command, args []string) error {
 	}
 
 	f1 := func(ctx context.Context, r probes.Reporter) error {
-		mgr := informermanager.New(c.Log)
-		return mgr.Run(ctx, r, c.eventinformer, c.releasehistoryinformer)
+		g, err := server.NewWithOptions(c.Log, c.grpcFlagMgr.GRPCPort(), c.grpcOpts, feed.NewHandler(c.Log, c.feed))
+		if err != nil {
+			return err
+		}
+		g.Probe = p
+
+		return g.Serve(ctx, r)
 	}
 
-	return common.Multiblock(c.Log, p, f0, f1)
+	blocks := []common.Blocker{f0, f1, c.informers.Run, c.outbox.Run, c.recorder.Run, c.collector.Run, c.stalls.Run, c.verifier.Run}
+	if c.remediator != nil {
+		blocks = append(blocks, c.remediator.Run)
+	}
+	return common.Multiblock(c.Log, p, blocks...)
 }
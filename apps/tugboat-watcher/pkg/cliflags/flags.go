@@ -0,0 +1,119 @@
+package cliflags
+
+import (
+	"time"
+
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/diagnostics"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/remediation"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/stall"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	autoRollbackKey             string = "auto-rollback"
+	autoRollbackLimitKey        string = "auto-rollback-limit"
+	autoRollbackWindowKey       string = "auto-rollback-window"
+	diagnosticsIntervalKey      string = "diagnostics-interval"
+	diagnosticsLogLinesKey      string = "diagnostics-log-lines"
+	diagnosticsRedactPatternKey string = "diagnostics-redact-pattern"
+	stallDaemonSetKey           string = "stall-deadline-daemonset"
+	stallJobKey                 string = "stall-deadline-job"
+	stallStatefulSetKey         string = "stall-deadline-statefulset"
+)
+
+type FlagManager struct {
+	autoRollback              bool
+	autoRollbackLimit         int
+	autoRollbackWindow        time.Duration
+	diagnosticsInterval       time.Duration
+	diagnosticsLogLines       int64
+	diagnosticsRedactPatterns []string
+	stallDaemonSet            time.Duration
+	stallJob                  time.Duration
+	stallStatefulSet          time.Duration
+}
+
+func New() *FlagManager {
+	return &FlagManager{}
+}
+
+func (fm *FlagManager) ConfigureAutoRollbackFlags(flags *pflag.FlagSet) {
+	defaults := remediation.DefaultOptions()
+
+	flags.BoolVar(&fm.autoRollback, autoRollbackKey, false, "roll back releases whose chart or ReleaseHistory is annotated with tugboat.engineering/auto-rollback=true when their latest revision fails or stalls")
+	viper.BindEnv(autoRollbackKey)
+	viper.BindPFlag(autoRollbackKey, flags.Lookup(autoRollbackKey))
+
+	flags.IntVar(&fm.autoRollbackLimit, autoRollbackLimitKey, defaults.Limit, "most automatic rollbacks of a release within the auto-rollback window")
+	viper.BindEnv(autoRollbackLimitKey)
+	viper.BindPFlag(autoRollbackLimitKey, flags.Lookup(autoRollbackLimitKey))
+
+	flags.DurationVar(&fm.autoRollbackWindow, autoRollbackWindowKey, defaults.Window, "period over which automatic rollbacks of a release are limited")
+	viper.BindEnv(autoRollbackWindowKey)
+	viper.BindPFlag(autoRollbackWindowKey, flags.Lookup(autoRollbackWindowKey))
+}
+
+func (fm *FlagManager) AutoRollback() bool {
+	return viper.GetBool(autoRollbackKey)
+}
+
+func (fm *FlagManager) AutoRollbackOptions() remediation.Options {
+	return remediation.Options{
+		Limit:  viper.GetInt(autoRollbackLimitKey),
+		Window: viper.GetDuration(autoRollbackWindowKey),
+	}
+}
+
+func (fm *FlagManager) ConfigureDiagnosticsFlags(flags *pflag.FlagSet) {
+	defaults := diagnostics.DefaultOptions()
+
+	flags.Int64Var(&fm.diagnosticsLogLines, diagnosticsLogLinesKey, defaults.LogLines, "number of lines of a failed container's current and previous logs to capture")
+	viper.BindEnv(diagnosticsLogLinesKey)
+	viper.BindPFlag(diagnosticsLogLinesKey, flags.Lookup(diagnosticsLogLinesKey))
+
+	flags.DurationVar(&fm.diagnosticsInterval, diagnosticsIntervalKey, defaults.Interval, "least time between capturing diagnostics for the same container")
+	viper.BindEnv(diagnosticsIntervalKey)
+	viper.BindPFlag(diagnosticsIntervalKey, flags.Lookup(diagnosticsIntervalKey))
+
+	// Patterns are not bound to viper, which would split them on commas and
+	// whitespace.
+	flags.StringArrayVar(&fm.diagnosticsRedactPatterns, diagnosticsRedactPatternKey, diagnostics.DefaultRedactPatterns, "regular expression matching secrets to redact from captured logs; if it has a capture group, only the group is redacted.  May be repeated; replaces the defaults")
+}
+
+func (fm *FlagManager) DiagnosticsOptions() (diagnostics.Options, error) {
+	r, err := diagnostics.NewRedactor(fm.diagnosticsRedactPatterns)
+	if err != nil {
+		return diagnostics.Options{}, err
+	}
+
+	return diagnostics.Options{
+		LogLines: viper.GetInt64(diagnosticsLogLinesKey),
+		Interval: viper.GetDuration(diagnosticsIntervalKey),
+		Redactor: r,
+	}, nil
+}
+
+func (fm *FlagManager) ConfigureStallFlags(flags *pflag.FlagSet) {
+	defaults := stall.DefaultDeadlines()
+
+	flags.DurationVar(&fm.stallStatefulSet, stallStatefulSetKey, defaults.StatefulSet, "how long a StatefulSet rollout may take before it is stalled; 0 disables")
+	viper.BindEnv(stallStatefulSetKey)
+	viper.BindPFlag(stallStatefulSetKey, flags.Lookup(stallStatefulSetKey))
+
+	flags.DurationVar(&fm.stallDaemonSet, stallDaemonSetKey, defaults.DaemonSet, "how long a DaemonSet rollout may take before it is stalled; 0 disables")
+	viper.BindEnv(stallDaemonSetKey)
+	viper.BindPFlag(stallDaemonSetKey, flags.Lookup(stallDaemonSetKey))
+
+	flags.DurationVar(&fm.stallJob, stallJobKey, defaults.Job, "how long a Job may take to complete before it is stalled; 0 disables")
+	viper.BindEnv(stallJobKey)
+	viper.BindPFlag(stallJobKey, flags.Lookup(stallJobKey))
+}
+
+func (fm *FlagManager) StallDeadlines() stall.Deadlines {
+	return stall.Deadlines{
+		StatefulSet: viper.GetDuration(stallStatefulSetKey),
+		DaemonSet:   viper.GetDuration(stallDaemonSetKey),
+		Job:         viper.GetDuration(stallJobKey),
+	}
+}
@@ -0,0 +1,203 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/pkg/http/probes"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// maxLineLength bounds each captured log line
+	maxLineLength = 512
+
+	// queueLength bounds the failures waiting to be diagnosed; failures
+	// beyond it are dropped
+	queueLength = 100
+)
+
+// Options control what is collected
+type Options struct {
+	// LogLines is the number of lines of each log to capture
+	LogLines int64
+
+	// Interval is the least time between diagnosing the same container
+	Interval time.Duration
+
+	Redactor Redactor
+}
+
+// DefaultOptions returns the default Options
+func DefaultOptions() Options {
+	r, _ := NewRedactor(DefaultRedactPatterns)
+	return Options{
+		LogLines: 20,
+		Interval: 10 * time.Minute,
+		Redactor: r,
+	}
+}
+
+type request struct {
+	pod       *v1.Pod
+	container string
+	release   string
+	revision  int
+}
+
+// Collector captures the exit code, termination message and log excerpts of
+// failed containers, and publishes them.  Logs are fetched in the
+// background, so that diagnosing does not delay the informer.
+type Collector struct {
+	log       logr.Logger
+	clientset kubernetes.Interface
+	sink      events.Sink
+	opts      Options
+
+	queue chan request
+	now   func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// New returns a new Collector
+func New(log logr.Logger, clientset kubernetes.Interface, sink events.Sink, opts Options) *Collector {
+	return &Collector{
+		log:       log,
+		clientset: clientset,
+		sink:      sink,
+		opts:      opts,
+		queue:     make(chan request, queueLength),
+		now:       time.Now,
+		seen:      map[string]time.Time{},
+	}
+}
+
+// Diagnose queues a failed container to be diagnosed, unless it was
+// diagnosed within the interval.  It satisfies the watcher.Diagnoser
+// interface.
+func (c *Collector) Diagnose(p *v1.Pod, container string, release string, revision int) {
+	if !c.admit(p.Namespace + "/" + p.Name + "/" + container) {
+		return
+	}
+
+	select {
+	case c.queue <- request{pod: p, container: container, release: release, revision: revision}:
+	default:
+		c.log.Info("dropping diagnostics; queue is full", "name", p.Name, "namespace", p.Namespace, "container", container)
+	}
+}
+
+// Run diagnoses queued failures until the context is done
+func (c *Collector) Run(ctx context.Context, r probes.Reporter) error {
+	r.Ready()
+	for {
+		select {
+		case <-ctx.Done():
+			r.NotReady()
+			return ctx.Err()
+		case req := <-c.queue:
+			c.sink.Publish(c.collect(ctx, req))
+		}
+	}
+}
+
+func (c *Collector) admit(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for k, t := range c.seen {
+		if now.Sub(t) >= c.opts.Interval {
+			delete(c.seen, k)
+		}
+	}
+	if _, ok := c.seen[key]; ok {
+		return false
+	}
+	c.seen[key] = now
+	return true
+}
+
+func (c *Collector) collect(ctx context.Context, req request) events.Event {
+	p := req.pod
+	d := &events.Diagnostics{Container: req.container}
+
+	var restarted bool
+	for _, cs := range append(append([]v1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...) {
+		if cs.Name != req.container {
+			continue
+		}
+		restarted = cs.RestartCount > 0
+
+		t := cs.State.Terminated
+		if t == nil {
+			t = cs.LastTerminationState.Terminated
+		}
+		if t != nil {
+			d.Reason = t.Reason
+			d.ExitCode = t.ExitCode
+			d.TerminationMessage = c.opts.Redactor.Redact(strings.TrimSpace(t.Message))
+		}
+		if w := cs.State.Waiting; w != nil && d.Reason == "" {
+			d.Reason = w.Reason
+		}
+	}
+
+	d.Logs = c.logs(ctx, p, req.container, false)
+	if restarted {
+		d.PreviousLogs = c.logs(ctx, p, req.container, true)
+	}
+
+	return events.Event{
+		Time:        c.now(),
+		Namespace:   p.Namespace,
+		Release:     req.release,
+		Revision:    req.revision,
+		Type:        events.TypeProgressing,
+		Severity:    events.SeverityError,
+		Reason:      "ContainerDiagnostics",
+		Message:     fmt.Sprintf("Collected diagnostics for container %s", req.container),
+		Object:      "Pod/" + p.Name,
+		Diagnostics: d,
+	}
+}
+
+// logs returns the last lines of a container's log, redacted.  A log which
+// cannot be read, e.g. because the container never started, is omitted.
+func (c *Collector) logs(ctx context.Context, p *v1.Pod, container string, previous bool) []string {
+	lines := c.opts.LogLines
+	buf, err := c.clientset.CoreV1().Pods(p.Namespace).GetLogs(p.Name, &v1.PodLogOptions{
+		Container: container,
+		Previous:  previous,
+		TailLines: &lines,
+	}).DoRaw(ctx)
+	if err != nil {
+		c.log.V(1).Info("failed to get logs", "name", p.Name, "namespace", p.Namespace, "container", container, "previous", previous, "err", err.Error())
+		return nil
+	}
+
+	raw := strings.Split(strings.TrimRight(string(buf), "\n"), "\n")
+	if len(raw) == 1 && raw[0] == "" {
+		return nil
+	}
+	if over := len(raw) - int(lines); over > 0 {
+		raw = raw[over:]
+	}
+	result := make([]string, len(raw))
+	for k, l := range raw {
+		l = c.opts.Redactor.Redact(l)
+		if len(l) > maxLineLength {
+			l = l[:maxLineLength] + "..."
+		}
+		result[k] = l
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package diagnostics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_Collector_Collect(t *testing.T) {
+	p := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "checkout-api-abc12", Namespace: "payments"},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:         "app",
+					RestartCount: 2,
+					State:        v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+						Reason:   "Error",
+						ExitCode: 2,
+						Message:  "failed to connect: password=hunter2\n",
+					}},
+				},
+			},
+		},
+	}
+
+	c := New(testlogger.TestLogger{T: t}, fake.NewSimpleClientset(p), events.SinkFunc(func(events.Event) {}), DefaultOptions())
+	e := c.collect(context.Background(), request{pod: p, container: "app", release: "checkout-api", revision: 3})
+
+	if e.Release != "checkout-api" || e.Revision != 3 || e.Object != "Pod/checkout-api-abc12" || e.Severity != events.SeverityError {
+		t.Errorf("incorrect event: %#v", e)
+	}
+	d := e.Diagnostics
+	if d == nil {
+		t.Fatalf("missing diagnostics")
+	}
+	if d.Reason != "Error" || d.ExitCode != 2 {
+		t.Errorf("incorrect termination: %s, %d", d.Reason, d.ExitCode)
+	}
+	if d.TerminationMessage != "failed to connect: password=[REDACTED]" {
+		t.Errorf("incorrect termination message '%s'", d.TerminationMessage)
+	}
+	// The fake clientset returns "fake logs" for every log request.
+	if len(d.Logs) != 1 || len(d.PreviousLogs) != 1 {
+		t.Errorf("incorrect logs: %v, %v", d.Logs, d.PreviousLogs)
+	}
+}
+
+func Test_Collector_Diagnose(t *testing.T) {
+	p := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "checkout-api-abc12", Namespace: "payments"}}
+
+	c := New(testlogger.TestLogger{T: t}, fake.NewSimpleClientset(), events.SinkFunc(func(events.Event) {}), DefaultOptions())
+	now := time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	c.Diagnose(p, "app", "checkout-api", 3)
+	c.Diagnose(p, "app", "checkout-api", 3)
+	c.Diagnose(p, "sidecar", "checkout-api", 3)
+	now = now.Add(DefaultOptions().Interval)
+	c.Diagnose(p, "app", "checkout-api", 3)
+
+	if len(c.queue) != 3 {
+		t.Errorf("incorrect number of queued diagnoses: %d", len(c.queue))
+	}
+}
@@ -0,0 +1,58 @@
+package diagnostics
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Redacted replaces redacted text
+const Redacted = "[REDACTED]"
+
+// DefaultRedactPatterns match common forms of credentials in logs
+var DefaultRedactPatterns = []string{
+	`(?i)(?:password|passwd|secret|token|api[_-]?key)["']?\s*[:=]\s*["']?([^\s"',]+)`,
+	`(?i)bearer\s+([A-Za-z0-9\-._~+/]+=*)`,
+	`AKIA[0-9A-Z]{16}`,
+}
+
+// Redactor removes secrets from text.  Where a pattern has a capture group,
+// only the text matched by the first group is redacted, so that context such
+// as "password=" is preserved; otherwise, the whole match is redacted.
+type Redactor []*regexp.Regexp
+
+// NewRedactor compiles the patterns
+func NewRedactor(patterns []string) (Redactor, error) {
+	r := make(Redactor, len(patterns))
+	for k, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile redact pattern '%s': %w", p, err)
+		}
+		r[k] = re
+	}
+	return r, nil
+}
+
+// Redact returns the text with secrets replaced
+func (r Redactor) Redact(s string) string {
+	for _, re := range r {
+		matches := re.FindAllStringSubmatchIndex(s, -1)
+		if matches == nil {
+			continue
+		}
+
+		out := make([]byte, 0, len(s))
+		last := 0
+		for _, m := range matches {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] != -1 {
+				start, end = m[2], m[3]
+			}
+			out = append(out, s[last:start]...)
+			out = append(out, Redacted...)
+			last = end
+		}
+		s = string(append(out, s[last:]...))
+	}
+	return s
+}
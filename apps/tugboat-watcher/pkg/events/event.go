@@ -0,0 +1,226 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/object88/tugboat/internal/constants"
+)
+
+// Type is the stage of a deployment's lifecycle that an event reports
+type Type string
+
+const (
+	// TypeStarted reports that a new revision of a release began deploying
+	TypeStarted Type = "Started"
+
+	// TypeProgressing reports something that happened while deploying
+	TypeProgressing Type = "Progressing"
+
+	// TypeSucceeded reports that a revision finished deploying
+	TypeSucceeded Type = "Succeeded"
+
+	// TypeFailed reports that a revision failed to deploy
+	TypeFailed Type = "Failed"
+
+	// TypeStalled reports that a revision's rollout stopped making progress
+	TypeStalled Type = "Stalled"
+)
+
+// Severity ranks events so that consumers may ignore the mundane
+type Severity int
+
+const (
+	SeverityInfo Severity = iota + 1
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "Info"
+	case SeverityWarning:
+		return "Warning"
+	case SeverityError:
+		return "Error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Event is something that happened to a revision of a release, normalized
+// from whichever Kubernetes resource reported it
+type Event struct {
+	Time      time.Time
+	Namespace string
+	Release   string
+	Revision  int
+	Type      Type
+	Severity  Severity
+
+	// Reason is a short, machine-readable cause, e.g. "CrashLoopBackOff"
+	Reason  string
+	Message string
+
+	// Object is the resource the event is about, as "Kind/name", if any
+	Object string
+
+	// Count is the number of times the event has occurred, if it recurs
+	Count int
+
+	// Diagnostics describe why a container failed, if the event reports a
+	// failure
+	Diagnostics *Diagnostics
+
+	// Hook describes the Helm hook that the event reports on, if any
+	Hook *Hook
+
+	// Changes summarize how a revision's workloads differ from the previous
+	// revision's, if the event reports that the revision started
+	Changes []Change
+
+	// DeployedBy identifies who deployed the revision, if the event reports
+	// that the revision started
+	DeployedBy *Deployer
+
+	// Source links the revision to the change which caused it, if the event
+	// reports that the revision started
+	Source *Source
+}
+
+// Source is the source-control metadata of a revision
+type Source struct {
+	Commit      string
+	Branch      string
+	PullRequest string
+	Pipeline    string
+}
+
+// Deployer identifies who deployed a revision
+type Deployer struct {
+	Username string
+	Groups   []string
+
+	// ServiceAccount is "namespace/name", if the deployer is a service account
+	ServiceAccount string
+
+	// CI identifies the CI job which deployed the revision
+	CI map[string]string
+}
+
+// Change is a difference in a workload between a revision and the previous
+// revision, e.g. a new image tag
+type Change struct {
+	Object    string
+	Container string
+	Field     string
+	From      string
+	To        string
+}
+
+// HookPhase is how far a Helm hook has run
+type HookPhase string
+
+const (
+	HookRunning   HookPhase = "Running"
+	HookSucceeded HookPhase = "Succeeded"
+	HookFailed    HookPhase = "Failed"
+)
+
+// Hook describes a run of a Helm hook, e.g. a pre-upgrade Job or a
+// `helm test` pod
+type Hook struct {
+	// Events are the hook events which run the hook, e.g. "pre-upgrade"
+	Events []string
+	Weight int
+	Phase  HookPhase
+
+	StartedAt time.Time
+
+	// CompletedAt is zero while the hook is running
+	CompletedAt time.Time
+}
+
+// IsTest reports whether the hook is run by `helm test`
+func (h *Hook) IsTest() bool {
+	for _, e := range h.Events {
+		if e == constants.HelmHookTest || e == constants.HelmHookTestSuccess {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration is how long the hook ran, or zero if it is still running
+func (h *Hook) Duration() time.Duration {
+	if h.CompletedAt.IsZero() {
+		return 0
+	}
+	return h.CompletedAt.Sub(h.StartedAt)
+}
+
+// Diagnostics describe why a container failed, with excerpts of its logs
+type Diagnostics struct {
+	Container          string
+	Reason             string
+	ExitCode           int32
+	TerminationMessage string
+
+	// Logs are the last lines of the container's log, and PreviousLogs the
+	// last lines of the log of the container before it last restarted
+	Logs         []string
+	PreviousLogs []string
+}
+
+// String renders the diagnostics for people to read
+func (d *Diagnostics) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Container %s", d.Container)
+	if d.Reason != "" {
+		fmt.Fprintf(&sb, ": %s", d.Reason)
+	}
+	fmt.Fprintf(&sb, " (exit code %d)\n", d.ExitCode)
+	if d.TerminationMessage != "" {
+		fmt.Fprintf(&sb, "Termination message: %s\n", d.TerminationMessage)
+	}
+	for _, l := range []struct {
+		title string
+		lines []string
+	}{{"Previous logs", d.PreviousLogs}, {"Logs", d.Logs}} {
+		if len(l.lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s:\n", l.title)
+		for _, line := range l.lines {
+			fmt.Fprintf(&sb, "  %s\n", line)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// Sink receives events
+type Sink interface {
+	Publish(e Event)
+}
+
+// Sinks publishes each event to every sink
+type Sinks []Sink
+
+var _ Sink = Sinks{}
+
+// Publish satisfies the Sink interface
+func (ss Sinks) Publish(e Event) {
+	for _, s := range ss {
+		s.Publish(e)
+	}
+}
+
+// SinkFunc adapts a function to the Sink interface
+type SinkFunc func(e Event)
+
+// Publish satisfies the Sink interface
+func (f SinkFunc) Publish(e Event) {
+	f(e)
+}
@@ -0,0 +1,201 @@
+package feed
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+)
+
+// DefaultCapacity is the number of events retained for resuming subscribers
+const DefaultCapacity = 1000
+
+// ErrResumeExpired is returned when a resume token refers to an event which
+// is no longer retained, or which was published by a previous watcher
+var ErrResumeExpired = errors.New("resume token has expired")
+
+// Filter selects events.  Empty fields match every event.
+type Filter struct {
+	Namespaces []string
+
+	// Releases are glob patterns, as matched by path.Match
+	Releases []string
+
+	MinSeverity events.Severity
+}
+
+// Matches reports whether the event passes the filter
+func (f Filter) Matches(e events.Event) bool {
+	if e.Severity < f.MinSeverity {
+		return false
+	}
+	if len(f.Namespaces) != 0 && !contains(f.Namespaces, e.Namespace) {
+		return false
+	}
+	if len(f.Releases) != 0 {
+		matched := false
+		for _, r := range f.Releases {
+			if ok, _ := path.Match(r, e.Release); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Entry is a published event and the token to resume after it
+type Entry struct {
+	events.Event
+	ResumeToken string
+}
+
+// Feed retains the most recent events and streams them to subscribers.
+// Subscribers read from the retained events at their own pace, so a slow
+// subscriber does not delay publishing; one that falls so far behind that
+// its next event is discarded fails with ErrResumeExpired.
+type Feed struct {
+	epoch    string
+	capacity int
+
+	mu     sync.Mutex
+	ring   []events.Event
+	next   uint64
+	notify chan struct{}
+}
+
+var _ events.Sink = &Feed{}
+
+// New returns a new Feed which retains capacity events
+func New(capacity int) *Feed {
+	if capacity < 1 {
+		capacity = DefaultCapacity
+	}
+	return &Feed{
+		epoch:    uuid.New().String(),
+		capacity: capacity,
+		ring:     make([]events.Event, capacity),
+		next:     1,
+		notify:   make(chan struct{}),
+	}
+}
+
+// Publish satisfies the events.Sink interface
+func (f *Feed) Publish(e events.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.ring[f.next%uint64(f.capacity)] = e
+	f.next++
+
+	close(f.notify)
+	f.notify = make(chan struct{})
+}
+
+// Subscribe calls send with each event matching the filter, until the
+// context is done or send returns an error.  If resumeToken is empty, only
+// events published after the call are sent; otherwise, events published
+// after the one which carried the token are sent first.
+func (f *Feed) Subscribe(ctx context.Context, filter Filter, resumeToken string, send func(Entry) error) error {
+	seq, err := f.start(resumeToken)
+	if err != nil {
+		return err
+	}
+
+	for {
+		batch, next, wait, err := f.since(seq)
+		if err != nil {
+			return err
+		}
+		for k, e := range batch {
+			if !filter.Matches(e) {
+				continue
+			}
+			if err := send(Entry{Event: e, ResumeToken: f.token(seq + uint64(k))}); err != nil {
+				return err
+			}
+		}
+		seq = next
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wait:
+		}
+	}
+}
+
+// start returns the sequence of the first event to send
+func (f *Feed) start(resumeToken string) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if resumeToken == "" {
+		return f.next, nil
+	}
+
+	epoch, seq, err := parseToken(resumeToken)
+	if err != nil {
+		return 0, err
+	}
+	if epoch != f.epoch || seq >= f.next {
+		return 0, ErrResumeExpired
+	}
+	return seq + 1, nil
+}
+
+// since returns the retained events from seq onwards, the sequence after
+// them, and a channel which is closed when another event is published
+func (f *Feed) since(seq uint64) ([]events.Event, uint64, <-chan struct{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.next-seq > uint64(f.capacity) {
+		return nil, 0, nil, ErrResumeExpired
+	}
+
+	batch := make([]events.Event, 0, f.next-seq)
+	for s := seq; s < f.next; s++ {
+		batch = append(batch, f.ring[s%uint64(f.capacity)])
+	}
+	return batch, f.next, f.notify, nil
+}
+
+func (f *Feed) token(seq uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(f.epoch + "/" + strconv.FormatUint(seq, 10)))
+}
+
+func parseToken(token string) (string, uint64, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed resume token: %w", err)
+	}
+	parts := strings.Split(string(buf), "/")
+	if len(parts) != 2 {
+		return "", 0, errors.New("malformed resume token")
+	}
+	seq, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed resume token: %w", err)
+	}
+	return parts[0], seq, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+)
+
+func Test_Filter_Matches(t *testing.T) {
+	e := events.Event{Namespace: "payments", Release: "checkout-api", Severity: events.SeverityWarning}
+
+	tcs := []struct {
+		name     string
+		filter   Filter
+		expected bool
+	}{
+		{
+			name:     "empty",
+			expected: true,
+		},
+		{
+			name:     "namespace",
+			filter:   Filter{Namespaces: []string{"search", "payments"}},
+			expected: true,
+		},
+		{
+			name:   "other-namespace",
+			filter: Filter{Namespaces: []string{"search"}},
+		},
+		{
+			name:     "release-glob",
+			filter:   Filter{Releases: []string{"checkout*"}},
+			expected: true,
+		},
+		{
+			name:   "other-release",
+			filter: Filter{Releases: []string{"cart"}},
+		},
+		{
+			name:     "severity",
+			filter:   Filter{MinSeverity: events.SeverityWarning},
+			expected: true,
+		},
+		{
+			name:   "too-mild",
+			filter: Filter{MinSeverity: events.SeverityError},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := tc.filter.Matches(e); actual != tc.expected {
+				t.Errorf("incorrect match: expected %t, actual %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_Feed_Subscribe(t *testing.T) {
+	f := New(10)
+	f.Publish(event("before"))
+
+	received := make(chan Entry, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := subscribe(ctx, f, Filter{Namespaces: []string{"payments"}}, "", received)
+
+	// Wait for the subscriber to start, so that it does not see "before".
+	time.Sleep(10 * time.Millisecond)
+	f.Publish(event("a"))
+	f.Publish(events.Event{Namespace: "search", Release: "filtered"})
+	f.Publish(event("b"))
+
+	first := next(t, received)
+	second := next(t, received)
+	if first.Release != "a" || second.Release != "b" {
+		t.Errorf("incorrect events: '%s', '%s'", first.Release, second.Release)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Resuming after "a" replays "b" and continues.
+	received = make(chan Entry, 10)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	subscribe(ctx, f, Filter{}, first.ResumeToken, received)
+	filtered := next(t, received)
+	replayed := next(t, received)
+	if filtered.Release != "filtered" || replayed.Release != "b" {
+		t.Errorf("incorrect resumed events: '%s', '%s'", filtered.Release, replayed.Release)
+	}
+	f.Publish(event("c"))
+	if e := next(t, received); e.Release != "c" {
+		t.Errorf("incorrect event after resume: '%s'", e.Release)
+	}
+}
+
+func Test_Feed_ResumeExpired(t *testing.T) {
+	f := New(2)
+	f.Publish(event("a"))
+	token := f.token(1)
+	f.Publish(event("b"))
+	f.Publish(event("c"))
+	f.Publish(event("d"))
+
+	tcs := []struct {
+		name  string
+		feed  *Feed
+		token string
+	}{
+		{
+			name:  "discarded",
+			feed:  f,
+			token: token,
+		},
+		{
+			name:  "previous-watcher",
+			feed:  New(2),
+			token: token,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.feed.Subscribe(context.Background(), Filter{}, tc.token, func(Entry) error { return nil })
+			if !errors.Is(err, ErrResumeExpired) {
+				t.Errorf("expected ErrResumeExpired; got %v", err)
+			}
+		})
+	}
+
+	if err := f.Subscribe(context.Background(), Filter{}, "not a token", func(Entry) error { return nil }); err == nil || errors.Is(err, ErrResumeExpired) {
+		t.Errorf("expected malformed token error; got %v", err)
+	}
+}
+
+func event(release string) events.Event {
+	return events.Event{
+		Namespace: "payments",
+		Release:   release,
+		Type:      events.TypeProgressing,
+		Severity:  events.SeverityInfo,
+	}
+}
+
+func subscribe(ctx context.Context, f *Feed, filter Filter, token string, received chan<- Entry) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- f.Subscribe(ctx, filter, token, func(e Entry) error {
+			received <- e
+			return nil
+		})
+	}()
+	return done
+}
+
+func next(t *testing.T, received <-chan Entry) Entry {
+	select {
+	case e := <-received:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for event")
+		return Entry{}
+	}
+}
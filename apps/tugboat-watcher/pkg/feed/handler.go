@@ -0,0 +1,118 @@
+package feed
+
+import (
+	"errors"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/internal/generated/deployments"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Handler serves the Deployments gRPC service from a Feed
+type Handler struct {
+	deployments.UnimplementedDeploymentsServer
+	logger logr.Logger
+
+	feed *Feed
+}
+
+func NewHandler(logger logr.Logger, feed *Feed) *Handler {
+	return &Handler{
+		logger: logger,
+		feed:   feed,
+	}
+}
+
+func (h *Handler) Register(s *grpc.Server, logger logr.Logger) error {
+	deployments.RegisterDeploymentsServer(s, h)
+	return nil
+}
+
+// Watch satisfies the deployments.DeploymentsServer interface
+func (h *Handler) Watch(req *deployments.WatchRequest, stream deployments.Deployments_WatchServer) error {
+	filter := Filter{
+		Namespaces:  req.GetNamespaces(),
+		Releases:    req.GetReleases(),
+		MinSeverity: events.Severity(req.GetMinSeverity()),
+	}
+
+	h.logger.Info("Subscriber connected", "namespaces", filter.Namespaces, "releases", filter.Releases, "minseverity", filter.MinSeverity.String(), "resuming", req.GetResumeToken() != "")
+	err := h.feed.Subscribe(stream.Context(), filter, req.GetResumeToken(), func(e Entry) error {
+		return stream.Send(ToProto(e))
+	})
+	h.logger.Info("Subscriber disconnected", "err", err)
+
+	switch {
+	case errors.Is(err, ErrResumeExpired):
+		return status.Error(codes.OutOfRange, err.Error())
+	case stream.Context().Err() != nil:
+		return status.FromContextError(stream.Context().Err()).Err()
+	case err != nil:
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
+
+// ToProto converts a feed entry to its gRPC representation
+func ToProto(e Entry) *deployments.DeploymentEvent {
+	var d *deployments.Diagnostics
+	if e.Diagnostics != nil {
+		d = &deployments.Diagnostics{
+			Container:          e.Diagnostics.Container,
+			Reason:             e.Diagnostics.Reason,
+			ExitCode:           e.Diagnostics.ExitCode,
+			TerminationMessage: e.Diagnostics.TerminationMessage,
+			Logs:               e.Diagnostics.Logs,
+			PreviousLogs:       e.Diagnostics.PreviousLogs,
+		}
+	}
+	var h *deployments.Hook
+	if e.Hook != nil {
+		h = &deployments.Hook{
+			Events:    e.Hook.Events,
+			Weight:    int32(e.Hook.Weight),
+			Phase:     hookPhases[e.Hook.Phase],
+			StartedAt: timestamppb.New(e.Hook.StartedAt),
+			Test:      e.Hook.IsTest(),
+		}
+		if !e.Hook.CompletedAt.IsZero() {
+			h.CompletedAt = timestamppb.New(e.Hook.CompletedAt)
+		}
+	}
+	return &deployments.DeploymentEvent{
+		ResumeToken: e.ResumeToken,
+		Time:        timestamppb.New(e.Time),
+		Namespace:   e.Namespace,
+		ReleaseName: e.Release,
+		Revision:    int32(e.Revision),
+		Type:        eventTypes[e.Type],
+		Severity:    deployments.Severity(e.Severity),
+		Reason:      e.Reason,
+		Message:     e.Message,
+		Object:      e.Object,
+		Count:       int32(e.Count),
+		Diagnostics: d,
+		Hook:        h,
+	}
+}
+
+var eventTypes = map[events.Type]deployments.EventType{
+	events.TypeStarted:     deployments.EventType_EVENT_TYPE_STARTED,
+	events.TypeProgressing: deployments.EventType_EVENT_TYPE_PROGRESSING,
+	events.TypeSucceeded:   deployments.EventType_EVENT_TYPE_SUCCEEDED,
+	events.TypeFailed:      deployments.EventType_EVENT_TYPE_FAILED,
+	events.TypeStalled:     deployments.EventType_EVENT_TYPE_STALLED,
+}
+
+var hookPhases = map[events.HookPhase]deployments.HookPhase{
+	events.HookRunning:   deployments.HookPhase_HOOK_PHASE_RUNNING,
+	events.HookSucceeded: deployments.HookPhase_HOOK_PHASE_SUCCEEDED,
+	events.HookFailed:    deployments.HookPhase_HOOK_PHASE_FAILED,
+}
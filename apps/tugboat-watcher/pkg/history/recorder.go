@@ -0,0 +1,258 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/pkg/http/probes"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultInterval is how often pending events are written
+const DefaultInterval = 5 * time.Second
+
+type key struct {
+	namespace string
+	release   string
+}
+
+// Recorder appends events to the event log of the revision that they
+// describe, in the status of the release's ReleaseHistory.  Events are
+// batched, so that a burst of events causes a single update.
+type Recorder struct {
+	log       logr.Logger
+	clientset versioned.Interface
+	interval  time.Duration
+
+	mu      sync.Mutex
+	pending map[key][]events.Event
+}
+
+var _ events.Sink = &Recorder{}
+
+// New returns a new Recorder
+func New(log logr.Logger, clientset versioned.Interface) *Recorder {
+	return &Recorder{
+		log:       log,
+		clientset: clientset,
+		interval:  DefaultInterval,
+		pending:   map[key][]events.Event{},
+	}
+}
+
+// Publish satisfies the events.Sink interface.  Events which do not
+// describe a revision are ignored.
+func (r *Recorder) Publish(e events.Event) {
+	if e.Revision <= 0 || e.Release == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := key{namespace: e.Namespace, release: e.Release}
+	r.pending[k] = bound(append(r.pending[k], e))
+}
+
+// Run writes pending events every interval until the context is done
+func (r *Recorder) Run(ctx context.Context, rep probes.Reporter) error {
+	t := time.NewTicker(r.interval)
+	defer t.Stop()
+
+	rep.Ready()
+	for {
+		select {
+		case <-ctx.Done():
+			rep.NotReady()
+			return ctx.Err()
+		case <-t.C:
+			r.Flush(ctx)
+		}
+	}
+}
+
+// Flush writes the pending events.  Events for a ReleaseHistory which could
+// not be updated are retried by the next flush.
+func (r *Recorder) Flush(ctx context.Context) {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = map[key][]events.Event{}
+	r.mu.Unlock()
+
+	for k, es := range pending {
+		if err := r.write(ctx, k, es); err != nil {
+			r.log.Error(err, "failed to record events", "name", k.release, "namespace", k.namespace)
+			r.requeue(k, es)
+		}
+	}
+}
+
+func (r *Recorder) write(ctx context.Context, k key, es []events.Event) error {
+	rhs := r.clientset.TugboatV1alpha1().ReleaseHistories(k.namespace)
+	rh, err := rhs.Get(ctx, k.release, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		r.log.V(1).Info("dropping events for unknown release history", "name", k.release, "namespace", k.namespace, "count", len(es))
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get release history: %w", err)
+	}
+
+	copyrh := rh.DeepCopy()
+	changed := false
+	for _, e := range es {
+		i := indexOfRevision(copyrh.Status.Revisions, v1alpha1.Revision(e.Revision))
+		if i == -1 {
+			r.log.V(1).Info("dropping event for unknown revision", "name", k.release, "namespace", k.namespace, "revision", e.Revision, "reason", e.Reason)
+			continue
+		}
+
+		rev := &copyrh.Status.Revisions[i]
+		rev.Events = appendEvent(rev.Events, ToReleaseHistoryEvent(e))
+		if over := len(rev.Events) - v1alpha1.MaxRevisionEvents; over > 0 {
+			rev.Events = rev.Events[over:]
+		}
+		if e.Type == events.TypeStalled && rev.StalledAt == nil {
+			t := metav1.NewTime(e.Time)
+			rev.StalledAt = &t
+		}
+		if e.Diagnostics != nil {
+			rev.Diagnostics = append(rev.Diagnostics, ToReleaseHistoryDiagnostic(e))
+			if over := len(rev.Diagnostics) - v1alpha1.MaxRevisionDiagnostics; over > 0 {
+				rev.Diagnostics = rev.Diagnostics[over:]
+			}
+		}
+		if e.Hook != nil {
+			rev.Hooks = appendHook(rev.Hooks, ToReleaseHistoryHook(e))
+			if over := len(rev.Hooks) - v1alpha1.MaxRevisionHooks; over > 0 {
+				rev.Hooks = rev.Hooks[over:]
+			}
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	if _, err := rhs.UpdateStatus(ctx, copyrh, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update release history status: %w", err)
+	}
+	return nil
+}
+
+// requeue returns events to the front of the pending events, ahead of any
+// published since the flush began
+func (r *Recorder) requeue(k key, es []events.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pending[k] = bound(append(es, r.pending[k]...))
+}
+
+// ToReleaseHistoryEvent converts an event to its ReleaseHistory
+// representation
+func ToReleaseHistoryEvent(e events.Event) v1alpha1.ReleaseHistoryEvent {
+	return v1alpha1.ReleaseHistoryEvent{
+		Time:     metav1.NewTime(e.Time),
+		Type:     string(e.Type),
+		Severity: e.Severity.String(),
+		Reason:   e.Reason,
+		Message:  e.Message,
+		Object:   e.Object,
+		Count:    int32(e.Count),
+	}
+}
+
+// ToReleaseHistoryDiagnostic converts an event's diagnostics to their
+// ReleaseHistory representation
+func ToReleaseHistoryDiagnostic(e events.Event) v1alpha1.ReleaseHistoryDiagnostic {
+	d := e.Diagnostics
+	return v1alpha1.ReleaseHistoryDiagnostic{
+		Time:               metav1.NewTime(e.Time),
+		Object:             e.Object,
+		Container:          d.Container,
+		Reason:             d.Reason,
+		ExitCode:           d.ExitCode,
+		TerminationMessage: d.TerminationMessage,
+		Logs:               d.Logs,
+		PreviousLogs:       d.PreviousLogs,
+	}
+}
+
+// ToReleaseHistoryHook converts an event's hook to its ReleaseHistory
+// representation.  The order is assigned when the hook is added to a
+// revision.
+func ToReleaseHistoryHook(e events.Event) v1alpha1.ReleaseHistoryHook {
+	h := e.Hook
+	rh := v1alpha1.ReleaseHistoryHook{
+		Object:    e.Object,
+		Events:    h.Events,
+		Weight:    int32(h.Weight),
+		Phase:     string(h.Phase),
+		StartedAt: metav1.NewTime(h.StartedAt),
+	}
+	if h.Phase == events.HookFailed {
+		rh.Message = e.Message
+	}
+	if !h.CompletedAt.IsZero() {
+		completedAt := metav1.NewTime(h.CompletedAt)
+		rh.CompletedAt = &completedAt
+		rh.Duration = &metav1.Duration{Duration: h.Duration()}
+	}
+	return rh
+}
+
+// appendHook adds a hook run to a revision's hooks, or updates it if the run
+// has already been added.  A hook which is run again, e.g. by a second
+// `helm test`, is a new run.
+func appendHook(hooks []v1alpha1.ReleaseHistoryHook, h v1alpha1.ReleaseHistoryHook) []v1alpha1.ReleaseHistoryHook {
+	for k := len(hooks) - 1; k >= 0; k-- {
+		if hooks[k].Object == h.Object && hooks[k].StartedAt.Equal(&h.StartedAt) {
+			h.Order = hooks[k].Order
+			hooks[k] = h
+			return hooks
+		}
+	}
+	h.Order = 1
+	if n := len(hooks); n != 0 {
+		h.Order = hooks[n-1].Order + 1
+	}
+	return append(hooks, h)
+}
+
+// appendEvent adds an event to a revision's event log.  A recurrence of the
+// most recent event replaces it, rather than being added again.
+func appendEvent(log []v1alpha1.ReleaseHistoryEvent, e v1alpha1.ReleaseHistoryEvent) []v1alpha1.ReleaseHistoryEvent {
+	if n := len(log); n != 0 && e.Count > 1 {
+		last := log[n-1]
+		if last.Reason == e.Reason && last.Object == e.Object && last.Message == e.Message {
+			log[n-1] = e
+			return log
+		}
+	}
+	return append(log, e)
+}
+
+// bound discards the oldest events which could not be retained by a
+// revision anyway
+func bound(es []events.Event) []events.Event {
+	if over := len(es) - v1alpha1.MaxRevisionEvents; over > 0 {
+		return es[over:]
+	}
+	return es
+}
+
+func indexOfRevision(revs []v1alpha1.ReleaseHistoryRevision, rev v1alpha1.Revision) int {
+	for k, v := range revs {
+		if v.Revision == rev {
+			return k
+		}
+	}
+	return -1
+}
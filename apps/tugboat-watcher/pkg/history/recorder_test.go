@@ -0,0 +1,199 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/fake"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_Recorder_Flush(t *testing.T) {
+	rh := &v1alpha1.ReleaseHistory{
+		ObjectMeta: metav1.ObjectMeta{Name: "checkout-api", Namespace: "payments"},
+		Status: v1alpha1.ReleaseHistoryStatus{
+			Revisions: []v1alpha1.ReleaseHistoryRevision{{Revision: 1}, {Revision: 2}},
+		},
+	}
+	clientset := fake.NewSimpleClientset(rh)
+	r := New(testlogger.TestLogger{T: t}, clientset)
+
+	for k := 0; k < v1alpha1.MaxRevisionEvents+5; k++ {
+		r.Publish(event(2, fmt.Sprintf("Reason%d", k)))
+	}
+	r.Publish(event(1, "PodDeleted"))
+	r.Publish(event(7, "Unknown"))
+	r.Publish(events.Event{Namespace: "payments", Release: "other", Revision: 1})
+	r.Flush(context.Background())
+
+	actual, err := clientset.TugboatV1alpha1().ReleaseHistories("payments").Get(context.Background(), "checkout-api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The pending events are bounded across revisions, so the oldest events
+	// for revision 2 make room for those of revisions 1 and 7.
+	rev2 := actual.Status.Revisions[1].Events
+	if len(rev2) != v1alpha1.MaxRevisionEvents-2 {
+		t.Fatalf("incorrect number of events for revision 2: %d", len(rev2))
+	}
+	if rev2[len(rev2)-1].Reason != fmt.Sprintf("Reason%d", v1alpha1.MaxRevisionEvents+4) || rev2[0].Severity != "Warning" {
+		t.Errorf("incorrect events for revision 2: %v", rev2)
+	}
+	rev1 := actual.Status.Revisions[0].Events
+	if len(rev1) != 1 || rev1[0].Reason != "PodDeleted" {
+		t.Errorf("incorrect events for revision 1: %v", rev1)
+	}
+	if len(r.pending) != 0 {
+		t.Errorf("events should not remain pending: %v", r.pending)
+	}
+}
+
+func Test_Recorder_Retry(t *testing.T) {
+	rh := &v1alpha1.ReleaseHistory{
+		ObjectMeta: metav1.ObjectMeta{Name: "checkout-api", Namespace: "payments"},
+		Status: v1alpha1.ReleaseHistoryStatus{
+			Revisions: []v1alpha1.ReleaseHistoryRevision{{Revision: 1}},
+		},
+	}
+	clientset := fake.NewSimpleClientset(rh)
+	failures := 1
+	clientset.PrependReactor("update", "releasehistories", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failures == 0 {
+			return false, nil, nil
+		}
+		failures--
+		return true, nil, fmt.Errorf("conflict")
+	})
+	r := New(testlogger.TestLogger{T: t}, clientset)
+
+	r.Publish(event(1, "PodReady"))
+	r.Flush(context.Background())
+	if len(r.pending) != 1 {
+		t.Fatalf("failed events should remain pending")
+	}
+
+	r.Flush(context.Background())
+	actual, _ := clientset.TugboatV1alpha1().ReleaseHistories("payments").Get(context.Background(), "checkout-api", metav1.GetOptions{})
+	if events := actual.Status.Revisions[0].Events; len(events) != 1 || events[0].Reason != "PodReady" {
+		t.Errorf("incorrect events after retry: %v", events)
+	}
+}
+
+func event(revision int, reason string) events.Event {
+	return events.Event{
+		Time:      time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+		Namespace: "payments",
+		Release:   "checkout-api",
+		Revision:  revision,
+		Type:      events.TypeProgressing,
+		Severity:  events.SeverityWarning,
+		Reason:    reason,
+	}
+}
+
+func Test_AppendEvent(t *testing.T) {
+	backoff := v1alpha1.ReleaseHistoryEvent{Reason: "BackOff", Object: "Pod/a", Message: "Back-off", Count: 1}
+	recurrence := backoff
+	recurrence.Count = 4
+
+	tcs := []struct {
+		name     string
+		log      []v1alpha1.ReleaseHistoryEvent
+		event    v1alpha1.ReleaseHistoryEvent
+		expected int
+	}{
+		{
+			name:     "empty",
+			event:    backoff,
+			expected: 1,
+		},
+		{
+			name:     "recurrence",
+			log:      []v1alpha1.ReleaseHistoryEvent{backoff},
+			event:    recurrence,
+			expected: 1,
+		},
+		{
+			name:     "repeat-without-count",
+			log:      []v1alpha1.ReleaseHistoryEvent{backoff},
+			event:    backoff,
+			expected: 2,
+		},
+		{
+			name:     "other-object",
+			log:      []v1alpha1.ReleaseHistoryEvent{{Reason: "BackOff", Object: "Pod/b", Message: "Back-off"}},
+			event:    recurrence,
+			expected: 2,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := appendEvent(tc.log, tc.event)
+			if len(actual) != tc.expected {
+				t.Fatalf("incorrect length: expected %d, actual %d", tc.expected, len(actual))
+			}
+			if actual[len(actual)-1].Count != tc.event.Count {
+				t.Errorf("latest event was not recorded: %v", actual)
+			}
+		})
+	}
+}
+
+func Test_AppendHook(t *testing.T) {
+	started := metav1.NewTime(time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC))
+	rerun := metav1.NewTime(started.Add(time.Hour))
+	migrate := v1alpha1.ReleaseHistoryHook{Object: "Job/migrate", Phase: "Running", StartedAt: started, Order: 1}
+
+	tcs := []struct {
+		name     string
+		hooks    []v1alpha1.ReleaseHistoryHook
+		hook     v1alpha1.ReleaseHistoryHook
+		expected int32
+	}{
+		{
+			name:     "empty",
+			hook:     migrate,
+			expected: 1,
+		},
+		{
+			name:     "completed",
+			hooks:    []v1alpha1.ReleaseHistoryHook{migrate},
+			hook:     v1alpha1.ReleaseHistoryHook{Object: "Job/migrate", Phase: "Succeeded", StartedAt: started},
+			expected: 1,
+		},
+		{
+			name:     "other-hook",
+			hooks:    []v1alpha1.ReleaseHistoryHook{migrate},
+			hook:     v1alpha1.ReleaseHistoryHook{Object: "Pod/smoke-test", Phase: "Running", StartedAt: started},
+			expected: 2,
+		},
+		{
+			name:     "rerun",
+			hooks:    []v1alpha1.ReleaseHistoryHook{migrate},
+			hook:     v1alpha1.ReleaseHistoryHook{Object: "Job/migrate", Phase: "Running", StartedAt: rerun},
+			expected: 2,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := appendHook(tc.hooks, tc.hook)
+			if n := int32(len(actual)); n != tc.expected {
+				t.Fatalf("incorrect length: expected %d, actual %d", tc.expected, n)
+			}
+			last := actual[len(actual)-1]
+			if last.Order != tc.expected || last.Phase != tc.hook.Phase {
+				t.Errorf("incorrect hook: %#v", last)
+			}
+		})
+	}
+}
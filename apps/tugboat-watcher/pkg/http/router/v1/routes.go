@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+	"github.com/object88/tugboat/internal/notifications/outbox"
+	"github.com/object88/tugboat/pkg/http/router/route"
+	"github.com/object88/tugboat/pkg/k8s/informermanager"
+	"github.com/object88/tugboat/pkg/logging"
+)
+
+func Defaults(logger logr.Logger, ob *outbox.Outbox, im *informermanager.Manager) []*route.Route {
+	return []*route.Route{
+		{
+			Path:       "/v1/api",
+			Middleware: []mux.MiddlewareFunc{configureLoggingMiddleware(logger)},
+			Subroutes: []*route.Route{
+				{
+					Path:    "/notifications",
+					Handler: configureHandleNotifications(logger, ob),
+					Methods: []string{http.MethodGet},
+				},
+				{
+					Path:    "/informers",
+					Handler: configureHandleInformers(logger, im),
+					Methods: []string{http.MethodGet},
+				},
+			},
+		},
+	}
+}
+
+func configureLoggingMiddleware(logger logr.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		lch := LogContextHandler{
+			logger: logger,
+			next:   next,
+		}
+		return handlers.LoggingHandler((&logging.Writer{Log: logger}).Out(), &lch)
+	}
+}
+
+// configureHandleNotifications reports the delivery status of each
+// notification listener
+func configureHandleNotifications(logger logr.Logger, ob *outbox.Outbox) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ob.Status()); err != nil {
+			logger.Error(err, "failed to write notification status")
+		}
+	}
+}
+
+// configureHandleInformers reports whether each informer has synced, and
+// its last watch error
+func configureHandleInformers(logger logr.Logger, im *informermanager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(im.Status()); err != nil {
+			logger.Error(err, "failed to write informer status")
+		}
+	}
+}
+
+type LogContextHandler struct {
+	logger logr.Logger
+	next   http.Handler
+}
+
+func (lch *LogContextHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	creq := req.WithContext(logr.NewContext(req.Context(), lch.logger))
+	lch.next.ServeHTTP(w, creq)
+}
@@ -3,24 +3,48 @@ package informerhandlers
 import (
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/go-logr/logr"
-	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
 	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
-	v1 "k8s.io/api/core/v1"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/client-go/tools/cache"
 )
 
 const (
 	helmSecretNameRegex string = `^sh\.helm\.release\.v1\.(?P<releasename>.+)\.v[1-9][0-9]*$`
 )
 
+// Reasons of the events published about revisions
+const (
+	ReasonRevisionStarted = "RevisionStarted"
+	ReasonReleaseDeployed = "ReleaseDeployed"
+	ReasonReleaseFailed   = "ReleaseFailed"
+)
+
+// Verifier reports whether a revision is verified by DeploymentVerification
+// checks, in which case the verifier decides whether it has succeeded
+type Verifier interface {
+	Verifies(rh *v1alpha1.ReleaseHistory, rev v1alpha1.ReleaseHistoryRevision) bool
+}
+
 type ReleaseHistoryInformerHandler struct {
 	log                    logr.Logger
+	sink                   events.Sink
 	releaseSecretName      *regexp.Regexp
 	releaseSecretNameIndex int
+
+	// Verifier, if set, keeps a deployed revision which is verified from being
+	// published as succeeded
+	Verifier Verifier
 }
 
-func NewReleaseHistory(log logr.Logger) (*ReleaseHistoryInformerHandler, error) {
+// NewReleaseHistory returns a handler which publishes a Started event to the
+// sink when a new revision is added to a ReleaseHistory, and a Succeeded or
+// Failed event when the status of its Helm release becomes "deployed" or
+// "failed"
+func NewReleaseHistory(log logr.Logger, sink events.Sink) (*ReleaseHistoryInformerHandler, error) {
 	r, err := regexp.Compile(helmSecretNameRegex)
 	if err != nil {
 		return nil, fmt.Errorf("internal error; helm secret name regex failed to compile: %w", err)
@@ -32,6 +56,7 @@ func NewReleaseHistory(log logr.Logger) (*ReleaseHistoryInformerHandler, error)
 
 	w := &ReleaseHistoryInformerHandler{
 		log:                    log,
+		sink:                   sink,
 		releaseSecretName:      r,
 		releaseSecretNameIndex: index,
 	}
@@ -99,28 +124,117 @@ func (w *ReleaseHistoryInformerHandler) OnAdd(obj interface{}) {
 }
 
 func (w *ReleaseHistoryInformerHandler) OnUpdate(oldObj interface{}, newObj interface{}) {
-	newScrt, ok := newObj.(*v1.Secret)
-	if !ok || newScrt.Type != constants.HelmSecretType {
+	oldRH, ok := oldObj.(*v1alpha1.ReleaseHistory)
+	if !ok {
+		return
+	}
+	newRH, ok := newObj.(*v1alpha1.ReleaseHistory)
+	if !ok {
 		return
 	}
 
-	oldScrt, ok := oldObj.(*v1.Secret)
-	if !ok || oldScrt.Type != constants.HelmSecretType {
+	if oldRH.ResourceVersion == newRH.ResourceVersion {
 		return
 	}
 
-	if newScrt.ObjectMeta.GetGeneration() == oldScrt.ObjectMeta.GetGeneration() {
+	known := map[v1alpha1.Revision]string{}
+	for _, r := range oldRH.Status.Revisions {
+		known[r.Revision] = r.Status
+	}
+	releaseName := newRH.Spec.ReleaseName
+	if releaseName == "" {
+		releaseName = newRH.Name
+	}
+	for _, r := range newRH.Status.Revisions {
+		// A new revision is started before it is completed, so that listeners
+		// open its deployment before they close it
+		status, ok := known[r.Revision]
+		if !ok {
+			w.started(newRH, releaseName, r)
+		}
+		if r.Status != status {
+			w.completed(newRH, releaseName, r)
+		}
+	}
+}
+
+// started publishes the start of a revision which was added to the release's
+// history
+func (w *ReleaseHistoryInformerHandler) started(rh *v1alpha1.ReleaseHistory, releaseName string, r v1alpha1.ReleaseHistoryRevision) {
+	w.log.Info("revision added", "name", rh.Name, "namespace", rh.Namespace, "revision", r.Revision)
+	t := r.DeployedAt.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	var changes []events.Change
+	for _, c := range r.Changes {
+		changes = append(changes, events.Change{Object: c.Object, Container: c.Container, Field: c.Field, From: c.From, To: c.To})
+	}
+	var deployer *events.Deployer
+	if d := r.DeployedBy; d != nil {
+		deployer = &events.Deployer{Username: d.Username, Groups: d.Groups, ServiceAccount: d.ServiceAccount, CI: d.CI}
+	}
+	var source *events.Source
+	if s := r.Source; s != nil {
+		source = &events.Source{Commit: s.Commit, Branch: s.Branch, PullRequest: s.PullRequest, Pipeline: s.Pipeline}
+	}
+	w.sink.Publish(events.Event{
+		Time:       t,
+		Namespace:  rh.Namespace,
+		Release:    releaseName,
+		Revision:   int(r.Revision),
+		Type:       events.TypeStarted,
+		Severity:   events.SeverityInfo,
+		Reason:     ReasonRevisionStarted,
+		Message:    fmt.Sprintf("Revision %d of %s started deploying", r.Revision, releaseName),
+		Object:     "ReleaseHistory/" + rh.Name,
+		Changes:    changes,
+		DeployedBy: deployer,
+		Source:     source,
+	})
+}
+
+// completed publishes the completion of a revision whose Helm release is now
+// deployed or failed.  A deployed revision which is verified is left to the
+// verifier.
+func (w *ReleaseHistoryInformerHandler) completed(rh *v1alpha1.ReleaseHistory, releaseName string, r v1alpha1.ReleaseHistoryRevision) {
+	e := events.Event{
+		Time:      time.Now(),
+		Namespace: rh.Namespace,
+		Release:   releaseName,
+		Revision:  int(r.Revision),
+		Object:    "ReleaseHistory/" + rh.Name,
+	}
+	switch release.Status(r.Status) {
+	case release.StatusDeployed:
+		if w.Verifier != nil && w.Verifier.Verifies(rh, r) {
+			return
+		}
+		e.Type = events.TypeSucceeded
+		e.Severity = events.SeverityInfo
+		e.Reason = ReasonReleaseDeployed
+		e.Message = fmt.Sprintf("Revision %d of %s is deployed", r.Revision, releaseName)
+	case release.StatusFailed:
+		e.Type = events.TypeFailed
+		e.Severity = events.SeverityError
+		e.Reason = ReasonReleaseFailed
+		e.Message = fmt.Sprintf("Revision %d of %s failed to deploy", r.Revision, releaseName)
+	default:
 		return
 	}
 
-	w.log.Info("updated", "name", oldScrt.Name, "namespace", oldScrt.Namespace, "uid", oldScrt.UID)
+	w.log.Info("revision completed", "name", rh.Name, "namespace", rh.Namespace, "revision", r.Revision, "status", r.Status)
+	w.sink.Publish(e)
 }
 
 func (w *ReleaseHistoryInformerHandler) OnDelete(obj interface{}) {
-	oldScrt, ok := obj.(*v1.Secret)
-	if !ok || oldScrt.Type != constants.HelmSecretType {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	oldRH, ok := obj.(*v1alpha1.ReleaseHistory)
+	if !ok {
 		return
 	}
 
-	w.log.Info("deleted", "name", oldScrt.Name, "namespace", oldScrt.Namespace, "uid", oldScrt.UID)
+	w.log.Info("deleted", "name", oldRH.Name, "namespace", oldRH.Namespace, "uid", oldRH.UID)
 }
@@ -0,0 +1,102 @@
+package informerhandlers
+
+import (
+	"testing"
+
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type verifierFunc func(rh *v1alpha1.ReleaseHistory, rev v1alpha1.ReleaseHistoryRevision) bool
+
+func (f verifierFunc) Verifies(rh *v1alpha1.ReleaseHistory, rev v1alpha1.ReleaseHistoryRevision) bool {
+	return f(rh, rev)
+}
+
+func Test_ReleaseHistory_OnUpdate(t *testing.T) {
+	tcs := []struct {
+		name     string
+		old      []v1alpha1.ReleaseHistoryRevision
+		new      []v1alpha1.ReleaseHistoryRevision
+		verified bool
+		expected []events.Type
+	}{
+		{
+			name:     "started",
+			new:      []v1alpha1.ReleaseHistoryRevision{{Revision: 1, Status: "pending-install"}},
+			expected: []events.Type{events.TypeStarted},
+		},
+		{
+			name:     "deployed",
+			old:      []v1alpha1.ReleaseHistoryRevision{{Revision: 1, Status: "pending-install"}},
+			new:      []v1alpha1.ReleaseHistoryRevision{{Revision: 1, Status: "deployed"}},
+			expected: []events.Type{events.TypeSucceeded},
+		},
+		{
+			name:     "deployed-verified",
+			old:      []v1alpha1.ReleaseHistoryRevision{{Revision: 1, Status: "pending-install"}},
+			new:      []v1alpha1.ReleaseHistoryRevision{{Revision: 1, Status: "deployed"}},
+			verified: true,
+		},
+		{
+			name:     "failed",
+			old:      []v1alpha1.ReleaseHistoryRevision{{Revision: 1, Status: "pending-upgrade"}},
+			new:      []v1alpha1.ReleaseHistoryRevision{{Revision: 1, Status: "failed"}},
+			expected: []events.Type{events.TypeFailed},
+		},
+		{
+			name:     "failed-verified",
+			old:      []v1alpha1.ReleaseHistoryRevision{{Revision: 1, Status: "pending-upgrade"}},
+			new:      []v1alpha1.ReleaseHistoryRevision{{Revision: 1, Status: "failed"}},
+			verified: true,
+			expected: []events.Type{events.TypeFailed},
+		},
+		{
+			name:     "added-deployed",
+			old:      []v1alpha1.ReleaseHistoryRevision{{Revision: 1, Status: "deployed"}},
+			new:      []v1alpha1.ReleaseHistoryRevision{{Revision: 1, Status: "superseded"}, {Revision: 2, Status: "deployed"}},
+			expected: []events.Type{events.TypeStarted, events.TypeSucceeded},
+		},
+		{
+			name: "unchanged",
+			old:  []v1alpha1.ReleaseHistoryRevision{{Revision: 1, Status: "deployed"}},
+			new:  []v1alpha1.ReleaseHistoryRevision{{Revision: 1, Status: "deployed"}},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var published []events.Type
+			sink := events.SinkFunc(func(e events.Event) {
+				published = append(published, e.Type)
+			})
+			h, err := NewReleaseHistory(testlogger.TestLogger{T: t}, sink)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			h.Verifier = verifierFunc(func(rh *v1alpha1.ReleaseHistory, rev v1alpha1.ReleaseHistoryRevision) bool {
+				return tc.verified
+			})
+
+			oldRH := &v1alpha1.ReleaseHistory{
+				ObjectMeta: metav1.ObjectMeta{Name: "checkout", Namespace: "payments", ResourceVersion: "1"},
+				Status:     v1alpha1.ReleaseHistoryStatus{Revisions: tc.old},
+			}
+			newRH := oldRH.DeepCopy()
+			newRH.ResourceVersion = "2"
+			newRH.Status.Revisions = tc.new
+			h.OnUpdate(oldRH, newRH)
+
+			if len(published) != len(tc.expected) {
+				t.Fatalf("incorrect events: expected %v, actual %v", tc.expected, published)
+			}
+			for k := range published {
+				if published[k] != tc.expected[k] {
+					t.Errorf("incorrect event %d: expected %s, actual %s", k, tc.expected[k], published[k])
+				}
+			}
+		})
+	}
+}
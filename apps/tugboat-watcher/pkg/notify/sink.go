@@ -0,0 +1,153 @@
+package notify
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/internal/generated/notifier"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// StalledReason is the reason of the update sent when a rollout stalls
+const StalledReason = "Stalled"
+
+// Notifier sends notifications to listeners, e.g. a
+// notificationsclient.Client
+type Notifier interface {
+	DeploymentStarted(req *notifier.StartDeploymentRequest) error
+	DeploymentUpdated(req *notifier.UpdateDeploymentRequest) error
+	DeploymentClosed(req *notifier.CloseDeploymentRequest) error
+}
+
+// Sink converts events to notifications.  Started events open a
+// deployment, Succeeded and Failed events close it, and Progressing and
+// Stalled events update it.  Failed hooks and the results of `helm test`
+// are always sent, with the reason of their event, e.g. "HookFailed" or
+// "TestSucceeded".
+type Sink struct {
+	log      logr.Logger
+	notifier Notifier
+
+	// MinSeverity is the least severe Progressing event which is sent;
+	// milder events are only published to the other sinks
+	MinSeverity events.Severity
+}
+
+var _ events.Sink = &Sink{}
+
+// New returns a new Sink which sends Progressing events of at least warning
+// severity
+func New(log logr.Logger, n Notifier) *Sink {
+	return &Sink{
+		log:         log,
+		notifier:    n,
+		MinSeverity: events.SeverityWarning,
+	}
+}
+
+// Publish satisfies the events.Sink interface
+func (s *Sink) Publish(e events.Event) {
+	id := ID(e.Namespace, e.Release, e.Revision)
+
+	var err error
+	switch e.Type {
+	case events.TypeStarted:
+		changes := make([]*notifier.Change, len(e.Changes))
+		for k, c := range e.Changes {
+			changes[k] = &notifier.Change{Object: c.Object, Container: c.Container, Field: c.Field, From: c.From, To: c.To}
+		}
+		var deployer *notifier.Deployer
+		if d := e.DeployedBy; d != nil {
+			deployer = &notifier.Deployer{Username: d.Username, Groups: d.Groups, ServiceAccount: d.ServiceAccount, Ci: d.CI}
+		}
+		var source *notifier.Source
+		if src := e.Source; src != nil {
+			source = &notifier.Source{Commit: src.Commit, Branch: src.Branch, PullRequest: src.PullRequest, Pipeline: src.Pipeline}
+		}
+		err = s.notifier.DeploymentStarted(&notifier.StartDeploymentRequest{
+			Id:          id,
+			ReleaseName: e.Release,
+			Namespace:   e.Namespace,
+			Revision:    int32(e.Revision),
+			Changes:     changes,
+			DeployedBy:  deployer,
+			Source:      source,
+		})
+	case events.TypeProgressing:
+		if e.Severity < s.MinSeverity && !isHookOutcome(e) {
+			return
+		}
+		err = s.notifier.DeploymentUpdated(&notifier.UpdateDeploymentRequest{
+			Id:          id,
+			ReleaseName: e.Release,
+			Namespace:   e.Namespace,
+			Revision:    int32(e.Revision),
+			Reason:      e.Reason,
+			Message:     message(e),
+			Time:        timestamppb.New(e.Time),
+		})
+	case events.TypeStalled:
+		// Stalls are always sent, whatever their severity, with a reason of
+		// their own so that listeners can tell them apart
+		err = s.notifier.DeploymentUpdated(&notifier.UpdateDeploymentRequest{
+			Id:          id,
+			ReleaseName: e.Release,
+			Namespace:   e.Namespace,
+			Revision:    int32(e.Revision),
+			Reason:      StalledReason,
+			Message:     message(e),
+			Time:        timestamppb.New(e.Time),
+		})
+	case events.TypeSucceeded, events.TypeFailed:
+		outcome := notifier.Outcome_OUTCOME_SUCCEEDED
+		if e.Type == events.TypeFailed {
+			outcome = notifier.Outcome_OUTCOME_FAILED
+		}
+		err = s.notifier.DeploymentClosed(&notifier.CloseDeploymentRequest{
+			Id:          id,
+			ReleaseName: e.Release,
+			Namespace:   e.Namespace,
+			Revision:    int32(e.Revision),
+			Outcome:     outcome,
+			Message:     message(e),
+		})
+	default:
+		return
+	}
+
+	if err != nil {
+		s.log.Error(err, "failed to send notification", "release", e.Release, "namespace", e.Namespace, "revision", e.Revision, "type", string(e.Type), "reason", e.Reason)
+	}
+}
+
+// ID returns the deployment ID shared by every notification about a
+// revision, so that listeners can correlate them
+func ID(namespace string, release string, revision int) *notifier.UUID {
+	name := fmt.Sprintf("%s/%s/%d", namespace, release, revision)
+	return &notifier.UUID{Value: uuid.NewSHA1(uuid.NameSpaceURL, []byte(name)).String()}
+}
+
+// isHookOutcome reports whether the event is a failed hook or the result of
+// a test
+func isHookOutcome(e events.Event) bool {
+	if e.Hook == nil {
+		return false
+	}
+	return e.Hook.Phase == events.HookFailed || (e.Hook.IsTest() && e.Hook.Phase != events.HookRunning)
+}
+
+func message(e events.Event) string {
+	m := e.Message
+	if e.Object != "" {
+		m = fmt.Sprintf("%s: %s", e.Object, m)
+	}
+	if e.Count > 1 {
+		m = fmt.Sprintf("%s (x%d)", m, e.Count)
+	}
+	if e.Diagnostics != nil {
+		m = fmt.Sprintf("%s\n%s", m, e.Diagnostics.String())
+	}
+	return m
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/internal/generated/notifier"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+)
+
+type recorder struct {
+	started []*notifier.StartDeploymentRequest
+	updated []*notifier.UpdateDeploymentRequest
+	closed  []*notifier.CloseDeploymentRequest
+}
+
+func (r *recorder) DeploymentStarted(req *notifier.StartDeploymentRequest) error {
+	r.started = append(r.started, req)
+	return nil
+}
+
+func (r *recorder) DeploymentUpdated(req *notifier.UpdateDeploymentRequest) error {
+	r.updated = append(r.updated, req)
+	return nil
+}
+
+func (r *recorder) DeploymentClosed(req *notifier.CloseDeploymentRequest) error {
+	r.closed = append(r.closed, req)
+	return nil
+}
+
+func Test_Sink_Publish(t *testing.T) {
+	tcs := []struct {
+		name    string
+		event   events.Event
+		started int
+		updated int
+		closed  int
+	}{
+		{
+			name:    "started",
+			event:   events.Event{Type: events.TypeStarted},
+			started: 1,
+		},
+		{
+			name:    "started-with-changes",
+			event:   events.Event{Type: events.TypeStarted, Changes: []events.Change{{Object: "Deployment/web", Container: "app", Field: "tag", From: "1.2.0", To: "1.3.0"}}, DeployedBy: &events.Deployer{Username: "alice@example.com"}, Source: &events.Source{Commit: "abc1234"}},
+			started: 1,
+		},
+		{
+			name:  "mundane",
+			event: events.Event{Type: events.TypeProgressing, Severity: events.SeverityInfo},
+		},
+		{
+			name:    "progressing",
+			event:   events.Event{Type: events.TypeProgressing, Severity: events.SeverityError, Reason: "CrashLoopBackOff"},
+			updated: 1,
+		},
+		{
+			name:  "hook-succeeded",
+			event: events.Event{Type: events.TypeProgressing, Severity: events.SeverityInfo, Hook: &events.Hook{Events: []string{"pre-upgrade"}, Phase: events.HookSucceeded}},
+		},
+		{
+			name:    "test-succeeded",
+			event:   events.Event{Type: events.TypeProgressing, Severity: events.SeverityInfo, Reason: "TestSucceeded", Hook: &events.Hook{Events: []string{"test"}, Phase: events.HookSucceeded}},
+			updated: 1,
+		},
+		{
+			name:  "test-started",
+			event: events.Event{Type: events.TypeProgressing, Severity: events.SeverityInfo, Hook: &events.Hook{Events: []string{"test"}, Phase: events.HookRunning}},
+		},
+		{
+			name:   "succeeded",
+			event:  events.Event{Type: events.TypeSucceeded},
+			closed: 1,
+		},
+		{
+			name:   "failed",
+			event:  events.Event{Type: events.TypeFailed},
+			closed: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &recorder{}
+			tc.event.Namespace = "payments"
+			tc.event.Release = "checkout-api"
+			tc.event.Revision = 3
+			New(testlogger.TestLogger{T: t}, r).Publish(tc.event)
+
+			if len(r.started) != tc.started || len(r.updated) != tc.updated || len(r.closed) != tc.closed {
+				t.Fatalf("incorrect notifications: started %d, updated %d, closed %d", len(r.started), len(r.updated), len(r.closed))
+			}
+			if tc.started == 1 && len(r.started[0].GetChanges()) != len(tc.event.Changes) {
+				t.Errorf("incorrect changes: %v", r.started[0].GetChanges())
+			}
+			if tc.started == 1 && (tc.event.DeployedBy != nil) != (r.started[0].GetDeployedBy() != nil) {
+				t.Errorf("incorrect deployer: %v", r.started[0].GetDeployedBy())
+			}
+			if tc.started == 1 && (tc.event.Source != nil) != (r.started[0].GetSource() != nil) {
+				t.Errorf("incorrect source: %v", r.started[0].GetSource())
+			}
+			if tc.closed == 1 {
+				expected := notifier.Outcome_OUTCOME_SUCCEEDED
+				if tc.event.Type == events.TypeFailed {
+					expected = notifier.Outcome_OUTCOME_FAILED
+				}
+				if r.closed[0].GetOutcome() != expected {
+					t.Errorf("incorrect outcome %s", r.closed[0].GetOutcome())
+				}
+			}
+		})
+	}
+}
+
+func Test_ID(t *testing.T) {
+	a := ID("payments", "checkout-api", 3).GetValue()
+	if a != ID("payments", "checkout-api", 3).GetValue() {
+		t.Errorf("ID is not stable")
+	}
+	if a == ID("payments", "checkout-api", 4).GetValue() {
+		t.Errorf("ID does not distinguish revisions")
+	}
+}
@@ -0,0 +1,363 @@
+package remediation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/http/probes"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned"
+	"helm.sh/helm/v3/pkg/release"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// DefaultInterval is how often failed revisions are remediated
+const DefaultInterval = 5 * time.Second
+
+// Reasons of the events published about rollbacks
+const (
+	ReasonRollingBack     = "RollingBack"
+	ReasonRolledBack      = "RolledBack"
+	ReasonRollbackFailed  = "RollbackFailed"
+	ReasonRollbackSkipped = "RollbackSkipped"
+)
+
+// Helm runs Helm actions, e.g. a helm.Actions
+type Helm interface {
+	History(namespace string, name string) ([]*release.Release, error)
+	Rollback(namespace string, name string, revision int) error
+}
+
+// Options limit automatic rollbacks, so that a release which keeps failing
+// is left for people to fix
+type Options struct {
+	// Limit is the most rollbacks of a release which are attempted within
+	// Window
+	Limit  int
+	Window time.Duration
+}
+
+// DefaultOptions returns the default Options
+func DefaultOptions() Options {
+	return Options{
+		Limit:  3,
+		Window: time.Hour,
+	}
+}
+
+type key struct {
+	namespace string
+	release   string
+}
+
+// Remediator rolls back releases whose latest revision failed or stalled, if
+// the release's chart, or its ReleaseHistory, is annotated with
+// `tugboat.engineering/auto-rollback: "true"`.  The release is rolled back to
+// the latest earlier revision which deployed and did not stall.  A revision
+// is only rolled back once, a revision created by a rollback is never rolled
+// back, and rollbacks of a release are limited by the Options.  Each
+// rollback, or the decision not to roll back, is recorded in the status of
+// the ReleaseHistory, and is published to the sink.
+//
+// Each release is remediated by a worker of its own, so that a slow rollback
+// does not delay the others.
+type Remediator struct {
+	log       logr.Logger
+	clientset versioned.Interface
+	helm      Helm
+	sink      events.Sink
+	opts      Options
+
+	// Interval is how often failed revisions are remediated
+	Interval time.Duration
+
+	now func() time.Time
+
+	mu      sync.Mutex
+	pending map[key]events.Event
+
+	// running are the releases which a worker is remediating
+	running map[key]bool
+	wg      sync.WaitGroup
+}
+
+var _ events.Sink = &Remediator{}
+
+// New returns a new Remediator
+func New(log logr.Logger, clientset versioned.Interface, helm Helm, sink events.Sink, opts Options) *Remediator {
+	return &Remediator{
+		log:       log,
+		clientset: clientset,
+		helm:      helm,
+		sink:      sink,
+		opts:      opts,
+		Interval:  DefaultInterval,
+		now:       time.Now,
+		pending:   map[key]events.Event{},
+		running:   map[key]bool{},
+	}
+}
+
+// Publish satisfies the events.Sink interface.  Failed and Stalled events
+// are remediated by the next flush.
+func (r *Remediator) Publish(e events.Event) {
+	if (e.Type != events.TypeFailed && e.Type != events.TypeStalled) || e.Revision <= 0 || e.Release == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := key{namespace: e.Namespace, release: e.Release}
+	if p, ok := r.pending[k]; !ok || p.Revision < e.Revision {
+		r.pending[k] = e
+	}
+}
+
+// Run remediates failed revisions every interval until the context is done.
+// Rollbacks which are in progress are waited for.
+func (r *Remediator) Run(ctx context.Context, rep probes.Reporter) error {
+	t := time.NewTicker(r.Interval)
+	defer t.Stop()
+
+	rep.Ready()
+	for {
+		select {
+		case <-ctx.Done():
+			rep.NotReady()
+			r.wait()
+			return ctx.Err()
+		case <-t.C:
+			r.Flush(ctx)
+		}
+	}
+}
+
+// Flush starts a worker to remediate each pending failed revision.  A
+// release which is still being remediated stays pending until a later
+// flush.  Revisions whose ReleaseHistory could not be read or updated are
+// retried by the next flush.
+func (r *Remediator) Flush(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, e := range r.pending {
+		if r.running[k] {
+			continue
+		}
+		delete(r.pending, k)
+		r.running[k] = true
+		r.wg.Add(1)
+		go r.work(ctx, k, e)
+	}
+}
+
+// work remediates a release's failed revision
+func (r *Remediator) work(ctx context.Context, k key, e events.Event) {
+	defer r.wg.Done()
+
+	err := r.remediate(ctx, e)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.running, k)
+	if err != nil {
+		r.log.Error(err, "failed to remediate revision", "name", k.release, "namespace", k.namespace, "revision", e.Revision)
+		r.requeue(k, e)
+	}
+}
+
+// wait waits for the workers to finish
+func (r *Remediator) wait() {
+	r.wg.Wait()
+}
+
+func (r *Remediator) remediate(ctx context.Context, e events.Event) error {
+	log := r.log.WithValues("name", e.Release, "namespace", e.Namespace, "revision", e.Revision)
+
+	rh, err := r.clientset.TugboatV1alpha1().ReleaseHistories(e.Namespace).Get(ctx, e.Release, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		log.V(1).Info("ignoring failure of unknown release history")
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get release history: %w", err)
+	}
+	for _, rb := range rh.Status.Rollbacks {
+		if int(rb.From) == e.Revision {
+			log.V(1).Info("revision has already been remediated")
+			return nil
+		}
+	}
+
+	rels, err := r.helm.History(e.Namespace, e.Release)
+	if err != nil {
+		return err
+	}
+	var failed *release.Release
+	latest := 0
+	for _, rel := range rels {
+		if rel.Version == e.Revision {
+			failed = rel
+		}
+		if rel.Version > latest {
+			latest = rel.Version
+		}
+	}
+	switch {
+	case failed == nil:
+		log.V(1).Info("ignoring failure of unknown revision")
+		return nil
+	case latest != e.Revision:
+		// The release has moved on, e.g. someone has already deployed a fix
+		log.V(1).Info("ignoring failure of superseded revision", "latest", latest)
+		return nil
+	case !optedIn(rh, failed):
+		log.V(1).Info("automatic rollback is not enabled")
+		return nil
+	}
+
+	now := r.now()
+	rb := v1alpha1.ReleaseHistoryRollback{
+		Time: metav1.NewTime(now),
+		From: v1alpha1.Revision(e.Revision),
+	}
+	skip := func(message string) error {
+		log.Info("not rolling back", "reason", message)
+		rb.Outcome = v1alpha1.RollbackSkipped
+		rb.Message = message
+		r.publish(e, now, events.SeverityWarning, ReasonRollbackSkipped, fmt.Sprintf("Not rolling back revision %d of %s: %s", e.Revision, e.Release, message))
+		return r.record(ctx, e, rb)
+	}
+
+	if n := r.recentRollbacks(rh, now); n >= r.opts.Limit {
+		return skip(fmt.Sprintf("already rolled back %d times in the last %s", n, r.opts.Window))
+	}
+	if failed.Info != nil && strings.HasPrefix(failed.Info.Description, constants.HelmRollbackDescription) {
+		return skip("the revision is itself a rollback")
+	}
+
+	target := healthy(rels, rh, e.Revision)
+	if target == 0 {
+		return skip("there is no earlier healthy revision")
+	}
+
+	rb.To = v1alpha1.Revision(target)
+	log.Info("rolling back", "to", target)
+	r.publish(e, now, events.SeverityWarning, ReasonRollingBack, fmt.Sprintf("Rolling back %s from revision %d to %d", e.Release, e.Revision, target))
+	if err := r.helm.Rollback(e.Namespace, e.Release, target); err != nil {
+		log.Error(err, "failed to roll back", "to", target)
+		rb.Outcome = v1alpha1.RollbackFailed
+		rb.Message = err.Error()
+		r.publish(e, r.now(), events.SeverityError, ReasonRollbackFailed, fmt.Sprintf("Failed to roll back %s from revision %d to %d: %s", e.Release, e.Revision, target, err.Error()))
+	} else {
+		rb.Outcome = v1alpha1.RollbackSucceeded
+		rb.Message = e.Message
+		r.publish(e, r.now(), events.SeverityWarning, ReasonRolledBack, fmt.Sprintf("Rolled back %s from revision %d to %d", e.Release, e.Revision, target))
+	}
+
+	// The rollback is not retried if it cannot be recorded, so that a
+	// failure to write the status cannot cause a loop
+	if err := r.record(ctx, e, rb); err != nil {
+		log.Error(err, "failed to record rollback")
+	}
+	return nil
+}
+
+// optedIn reports whether the chart of the failed revision, or the
+// ReleaseHistory, is annotated for automatic rollback
+func optedIn(rh *v1alpha1.ReleaseHistory, failed *release.Release) bool {
+	if rh.Annotations[constants.AnnotationAutoRollback] == "true" {
+		return true
+	}
+	if c := failed.Chart; c != nil && c.Metadata != nil {
+		return c.Metadata.Annotations[constants.AnnotationAutoRollback] == "true"
+	}
+	return false
+}
+
+// recentRollbacks counts the rollbacks of the release attempted within the
+// window
+func (r *Remediator) recentRollbacks(rh *v1alpha1.ReleaseHistory, now time.Time) int {
+	n := 0
+	for _, rb := range rh.Status.Rollbacks {
+		if rb.Outcome != v1alpha1.RollbackSkipped && now.Sub(rb.Time.Time) < r.opts.Window {
+			n++
+		}
+	}
+	return n
+}
+
+// healthy returns the latest revision before the failed one which Helm
+// deployed, and which neither stalled nor was rolled back, or 0 if there is
+// none
+func healthy(rels []*release.Release, rh *v1alpha1.ReleaseHistory, failed int) int {
+	unhealthy := map[int]bool{}
+	for _, rev := range rh.Status.Revisions {
+		if rev.StalledAt != nil {
+			unhealthy[int(rev.Revision)] = true
+		}
+	}
+	for _, rb := range rh.Status.Rollbacks {
+		unhealthy[int(rb.From)] = true
+	}
+
+	target := 0
+	for _, rel := range rels {
+		if rel.Version >= failed || rel.Version <= target || unhealthy[rel.Version] || rel.Info == nil {
+			continue
+		}
+		if rel.Info.Status == release.StatusDeployed || rel.Info.Status == release.StatusSuperseded {
+			target = rel.Version
+		}
+	}
+	return target
+}
+
+// record appends the rollback to the status of the ReleaseHistory
+func (r *Remediator) record(ctx context.Context, e events.Event, rb v1alpha1.ReleaseHistoryRollback) error {
+	rhs := r.clientset.TugboatV1alpha1().ReleaseHistories(e.Namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		rh, err := rhs.Get(ctx, e.Release, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		copyrh := rh.DeepCopy()
+		copyrh.Status.Rollbacks = append(copyrh.Status.Rollbacks, rb)
+		if over := len(copyrh.Status.Rollbacks) - v1alpha1.MaxRollbacks; over > 0 {
+			copyrh.Status.Rollbacks = copyrh.Status.Rollbacks[over:]
+		}
+		_, err = rhs.UpdateStatus(ctx, copyrh, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func (r *Remediator) publish(e events.Event, t time.Time, severity events.Severity, reason string, message string) {
+	r.sink.Publish(events.Event{
+		Time:      t,
+		Namespace: e.Namespace,
+		Release:   e.Release,
+		Revision:  e.Revision,
+		Type:      events.TypeProgressing,
+		Severity:  severity,
+		Reason:    reason,
+		Message:   message,
+		Object:    "ReleaseHistory/" + e.Release,
+	})
+}
+
+// requeue returns a revision to the pending revisions, unless a later
+// revision of its release has failed since its worker started.  The caller
+// must hold the lock.
+func (r *Remediator) requeue(k key, e events.Event) {
+	if p, ok := r.pending[k]; !ok || p.Revision < e.Revision {
+		r.pending[k] = e
+	}
+}
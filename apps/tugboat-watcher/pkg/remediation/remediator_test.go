@@ -0,0 +1,257 @@
+package remediation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/fake"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// fakeHelm records rollbacks.  Rollbacks of releases in `blocked` wait until
+// its channel is closed.
+type fakeHelm struct {
+	releases []*release.Release
+	err      error
+	blocked  map[string]chan struct{}
+
+	mu         sync.Mutex
+	rolledBack []int
+	names      []string
+}
+
+func (h *fakeHelm) History(namespace string, name string) ([]*release.Release, error) {
+	return h.releases, nil
+}
+
+func (h *fakeHelm) Rollback(namespace string, name string, revision int) error {
+	if ch, ok := h.blocked[name]; ok {
+		<-ch
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rolledBack = append(h.rolledBack, revision)
+	h.names = append(h.names, name)
+	return h.err
+}
+
+func (h *fakeHelm) rolledBackNames() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string{}, h.names...)
+}
+
+func rel(version int, status release.Status, description string) *release.Release {
+	return &release.Release{Version: version, Info: &release.Info{Status: status, Description: description}}
+}
+
+func Test_Remediator_Flush(t *testing.T) {
+	now := time.Date(2021, time.January, 8, 12, 0, 0, 0, time.UTC)
+	stalledAt := metav1.NewTime(now.Add(-time.Hour))
+	history := []*release.Release{
+		rel(1, release.StatusSuperseded, "Install complete"),
+		rel(2, release.StatusSuperseded, "Upgrade complete"),
+		rel(3, release.StatusDeployed, "Upgrade complete"),
+	}
+	annotatedChart := rel(3, release.StatusDeployed, "Upgrade complete")
+	annotatedChart.Chart = &chart.Chart{Metadata: &chart.Metadata{Annotations: map[string]string{constants.AnnotationAutoRollback: "true"}}}
+
+	tcs := []struct {
+		name       string
+		annotated  bool
+		releases   []*release.Release
+		rollbacks  []v1alpha1.ReleaseHistoryRollback
+		helmErr    error
+		expectedTo int
+		expected   string
+		reasons    []string
+	}{
+		{
+			name:     "not-annotated",
+			releases: history,
+		},
+		{
+			name:       "rolled-back",
+			annotated:  true,
+			releases:   history,
+			expectedTo: 1,
+			expected:   v1alpha1.RollbackSucceeded,
+			reasons:    []string{ReasonRollingBack, ReasonRolledBack},
+		},
+		{
+			name:       "chart-annotated",
+			releases:   []*release.Release{history[0], history[1], annotatedChart},
+			expectedTo: 1,
+			expected:   v1alpha1.RollbackSucceeded,
+			reasons:    []string{ReasonRollingBack, ReasonRolledBack},
+		},
+		{
+			name:       "rollback-failed",
+			annotated:  true,
+			releases:   history,
+			helmErr:    fmt.Errorf("timed out"),
+			expectedTo: 1,
+			expected:   v1alpha1.RollbackFailed,
+			reasons:    []string{ReasonRollingBack, ReasonRollbackFailed},
+		},
+		{
+			name:      "already-remediated",
+			annotated: true,
+			releases:  history,
+			rollbacks: []v1alpha1.ReleaseHistoryRollback{{From: 3, To: 1, Outcome: v1alpha1.RollbackSucceeded}},
+		},
+		{
+			name:      "superseded",
+			annotated: true,
+			releases:  append(history, rel(4, release.StatusDeployed, "Upgrade complete")),
+		},
+		{
+			name:      "is-rollback",
+			annotated: true,
+			releases:  []*release.Release{history[0], history[1], rel(3, release.StatusDeployed, "Rollback to 1")},
+			expected:  v1alpha1.RollbackSkipped,
+			reasons:   []string{ReasonRollbackSkipped},
+		},
+		{
+			name:      "no-healthy-revision",
+			annotated: true,
+			releases:  []*release.Release{rel(1, release.StatusFailed, "Upgrade failed"), history[2]},
+			expected:  v1alpha1.RollbackSkipped,
+			reasons:   []string{ReasonRollbackSkipped},
+		},
+		{
+			name:      "limited",
+			annotated: true,
+			releases:  history,
+			rollbacks: []v1alpha1.ReleaseHistoryRollback{
+				{Time: metav1.NewTime(now.Add(-50 * time.Minute)), From: 5, Outcome: v1alpha1.RollbackSucceeded},
+				{Time: metav1.NewTime(now.Add(-40 * time.Minute)), From: 6, Outcome: v1alpha1.RollbackFailed},
+				{Time: metav1.NewTime(now.Add(-30 * time.Minute)), From: 7, Outcome: v1alpha1.RollbackSucceeded},
+			},
+			expected: v1alpha1.RollbackSkipped,
+			reasons:  []string{ReasonRollbackSkipped},
+		},
+		{
+			name:      "limit-elapsed",
+			annotated: true,
+			releases:  history,
+			rollbacks: []v1alpha1.ReleaseHistoryRollback{
+				{Time: metav1.NewTime(now.Add(-3 * time.Hour)), From: 5, Outcome: v1alpha1.RollbackSucceeded},
+				{Time: metav1.NewTime(now.Add(-2 * time.Hour)), From: 6, Outcome: v1alpha1.RollbackFailed},
+				{Time: metav1.NewTime(now.Add(-30 * time.Minute)), From: 7, Outcome: v1alpha1.RollbackSkipped},
+			},
+			expectedTo: 1,
+			expected:   v1alpha1.RollbackSucceeded,
+			reasons:    []string{ReasonRollingBack, ReasonRolledBack},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			rh := &v1alpha1.ReleaseHistory{
+				ObjectMeta: metav1.ObjectMeta{Name: "checkout-api", Namespace: "payments"},
+				Status: v1alpha1.ReleaseHistoryStatus{
+					Revisions: []v1alpha1.ReleaseHistoryRevision{{Revision: 1}, {Revision: 2, StalledAt: &stalledAt}, {Revision: 3}},
+					Rollbacks: tc.rollbacks,
+				},
+			}
+			if tc.annotated {
+				rh.Annotations = map[string]string{constants.AnnotationAutoRollback: "true"}
+			}
+			clientset := fake.NewSimpleClientset(rh)
+			h := &fakeHelm{releases: tc.releases, err: tc.helmErr}
+			var published []events.Event
+			sink := events.SinkFunc(func(e events.Event) {
+				published = append(published, e)
+			})
+
+			r := New(testlogger.TestLogger{T: t}, clientset, h, sink, DefaultOptions())
+			r.now = func() time.Time { return now }
+
+			r.Publish(events.Event{Namespace: "payments", Release: "checkout-api", Revision: 3, Type: events.TypeProgressing})
+			r.Publish(events.Event{Namespace: "payments", Release: "checkout-api", Revision: 3, Type: events.TypeStalled, Message: "Deployment/api has stalled"})
+			r.Flush(context.Background())
+			r.wait()
+
+			if tc.expectedTo == 0 && len(h.rolledBack) != 0 || tc.expectedTo != 0 && (len(h.rolledBack) != 1 || h.rolledBack[0] != tc.expectedTo) {
+				t.Errorf("incorrect rollbacks: %v", h.rolledBack)
+			}
+
+			actual, err := clientset.TugboatV1alpha1().ReleaseHistories("payments").Get(context.Background(), "checkout-api", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			recorded := actual.Status.Rollbacks[len(tc.rollbacks):]
+			if tc.expected == "" {
+				if len(recorded) != 0 {
+					t.Errorf("unexpected rollback recorded: %v", recorded)
+				}
+			} else if len(recorded) != 1 || recorded[0].Outcome != tc.expected || recorded[0].From != 3 || int(recorded[0].To) != tc.expectedTo {
+				t.Errorf("incorrect rollback recorded: %v", recorded)
+			}
+
+			if len(published) != len(tc.reasons) {
+				t.Fatalf("incorrect events published: %v", published)
+			}
+			for k, e := range published {
+				if e.Reason != tc.reasons[k] || e.Type != events.TypeProgressing || e.Revision != 3 {
+					t.Errorf("incorrect event %d: %#v", k, e)
+				}
+			}
+		})
+	}
+}
+
+func Test_Remediator_Flush_Concurrent(t *testing.T) {
+	history := []*release.Release{
+		rel(1, release.StatusSuperseded, "Install complete"),
+		rel(2, release.StatusDeployed, "Upgrade complete"),
+	}
+	var objs []runtime.Object
+	for _, name := range []string{"slow", "fast"} {
+		objs = append(objs, &v1alpha1.ReleaseHistory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "payments",
+				Annotations: map[string]string{constants.AnnotationAutoRollback: "true"},
+			},
+		})
+	}
+	unblock := make(chan struct{})
+	h := &fakeHelm{releases: history, blocked: map[string]chan struct{}{"slow": unblock}}
+
+	r := New(testlogger.TestLogger{T: t}, fake.NewSimpleClientset(objs...), h, events.SinkFunc(func(e events.Event) {}), DefaultOptions())
+	for _, name := range []string{"slow", "fast"} {
+		r.Publish(events.Event{Namespace: "payments", Release: name, Revision: 2, Type: events.TypeFailed})
+	}
+	r.Flush(context.Background())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(h.rolledBackNames()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("fast release was not rolled back while slow release was rolling back")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A failure of the release which is rolling back waits for its worker.
+	r.Publish(events.Event{Namespace: "payments", Release: "slow", Revision: 2, Type: events.TypeStalled})
+	r.Flush(context.Background())
+
+	close(unblock)
+	r.wait()
+	if names := h.rolledBackNames(); len(names) != 2 || names[0] != "fast" || names[1] != "slow" {
+		t.Errorf("incorrect rollbacks: %v", names)
+	}
+}
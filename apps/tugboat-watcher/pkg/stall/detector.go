@@ -0,0 +1,441 @@
+package stall
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/http/probes"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	listerv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/listers/engineering.tugboat/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	listerappsv1 "k8s.io/client-go/listers/apps/v1"
+	listerbatchv1 "k8s.io/client-go/listers/batch/v1"
+	listercorev1 "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	// DefaultInterval is how often workloads are checked
+	DefaultInterval = 15 * time.Second
+
+	// maxBlockingPods bounds the pods listed in a stall
+	maxBlockingPods = 10
+)
+
+// Deadlines are how long a rollout of each kind of workload may take before
+// it is stalled.  A deadline of 0 disables detection for that kind.
+// Deployments have their own deadline, progressDeadlineSeconds.
+type Deadlines struct {
+	StatefulSet time.Duration
+	DaemonSet   time.Duration
+	Job         time.Duration
+}
+
+// DefaultDeadlines returns the default Deadlines
+func DefaultDeadlines() Deadlines {
+	return Deadlines{
+		StatefulSet: 10 * time.Minute,
+		DaemonSet:   10 * time.Minute,
+		Job:         30 * time.Minute,
+	}
+}
+
+// Listers read the workloads of tracked releases, their pods, and the
+// ReleaseHistories
+type Listers struct {
+	Deployments      listerappsv1.DeploymentLister
+	StatefulSets     listerappsv1.StatefulSetLister
+	DaemonSets       listerappsv1.DaemonSetLister
+	Jobs             listerbatchv1.JobLister
+	Pods             listercorev1.PodLister
+	ReleaseHistories listerv1alpha1.ReleaseHistoryLister
+}
+
+// workload is what the Detector needs to know about a Deployment,
+// StatefulSet, DaemonSet or Job
+type workload struct {
+	kind string
+	meta metav1.ObjectMeta
+
+	selector *metav1.LabelSelector
+
+	// rolledOut reports whether the workload has no update in progress
+	rolledOut func() bool
+
+	// stalled reports whether the rollout has stalled, given when it
+	// started, and why
+	stalled func(start time.Time, now time.Time) (bool, string)
+
+	// blocking reports whether a pod is holding up the rollout
+	blocking func(p *v1.Pod) bool
+}
+
+// Detector finds rollouts of the latest revision of each release which have
+// stopped making progress, and publishes a Stalled event for each, listing
+// the pods which are holding them up.  Each stall is reported once; if the
+// rollout recovers and stalls again, it is reported again.  Once a
+// workload has rolled out a revision which Helm has finished deploying, it
+// is not checked again until the next revision, so that, e.g., a pod which
+// crashes later is not reported as a stalled rollout.
+type Detector struct {
+	log       logr.Logger
+	sink      events.Sink
+	deadlines Deadlines
+	listers   Listers
+
+	// Interval is how often workloads are checked
+	Interval time.Duration
+
+	now      func() time.Time
+	reported map[string]bool
+
+	// done are the workloads which have rolled out their release's latest
+	// revision
+	done map[string]bool
+}
+
+// New returns a new Detector
+func New(log logr.Logger, sink events.Sink, deadlines Deadlines, listers Listers) *Detector {
+	return &Detector{
+		log:       log,
+		sink:      sink,
+		deadlines: deadlines,
+		listers:   listers,
+		Interval:  DefaultInterval,
+		now:       time.Now,
+		reported:  map[string]bool{},
+		done:      map[string]bool{},
+	}
+}
+
+// Run checks for stalls every interval until the context is done
+func (d *Detector) Run(ctx context.Context, r probes.Reporter) error {
+	t := time.NewTicker(d.Interval)
+	defer t.Stop()
+
+	r.Ready()
+	for {
+		select {
+		case <-ctx.Done():
+			r.NotReady()
+			return ctx.Err()
+		case <-t.C:
+			d.Check()
+		}
+	}
+}
+
+// Check looks for stalled rollouts once
+func (d *Detector) Check() {
+	workloads, err := d.workloads()
+	if err != nil {
+		d.log.Error(err, "failed to list workloads")
+		return
+	}
+
+	now := d.now()
+	current := map[string]bool{}
+	done := map[string]bool{}
+	for _, w := range workloads {
+		release := w.meta.Labels[constants.LabelReleaseHistory]
+		rev, ok := d.latestRevision(w.meta.Namespace, release)
+		if !ok {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%d/%s/%s", w.meta.Namespace, release, rev.Revision, w.kind, w.meta.Name)
+		if d.done[key] {
+			done[key] = true
+			continue
+		}
+		if w.rolledOut() {
+			// While Helm is still deploying the revision, the workload may not
+			// have been updated yet.
+			if !strings.HasPrefix(rev.Status, "pending-") {
+				done[key] = true
+			}
+			continue
+		}
+
+		start := rev.DeployedAt.Time
+		if w.meta.CreationTimestamp.Time.After(start) {
+			start = w.meta.CreationTimestamp.Time
+		}
+		stalled, reason := w.stalled(start, now)
+		if !stalled {
+			continue
+		}
+
+		current[key] = true
+		if d.reported[key] {
+			continue
+		}
+
+		message := fmt.Sprintf("%s %s has stalled: %s", w.kind, w.meta.Name, reason)
+		if pods := d.blockingPods(w); len(pods) != 0 {
+			message = fmt.Sprintf("%s; blocking pods: %s", message, strings.Join(pods, ", "))
+		}
+
+		d.log.Info("rollout stalled", "kind", w.kind, "name", w.meta.Name, "namespace", w.meta.Namespace, "release", release, "revision", rev.Revision)
+		d.sink.Publish(events.Event{
+			Time:      now,
+			Namespace: w.meta.Namespace,
+			Release:   release,
+			Revision:  int(rev.Revision),
+			Type:      events.TypeStalled,
+			Severity:  events.SeverityError,
+			Reason:    "RolloutStalled",
+			Message:   message,
+			Object:    w.kind + "/" + w.meta.Name,
+		})
+	}
+
+	// Forget stalls which have recovered, or whose workloads are gone, so
+	// that they are reported if they stall again
+	d.reported = current
+	d.done = done
+}
+
+func (d *Detector) workloads() ([]workload, error) {
+	ws := []workload{}
+
+	deployments, err := d.listers.Deployments.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range deployments {
+		ws = append(ws, deploymentWorkload(o))
+	}
+
+	statefulsets, err := d.listers.StatefulSets.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range statefulsets {
+		if deadline := d.deadline(o.ObjectMeta, d.deadlines.StatefulSet); deadline != 0 {
+			ws = append(ws, statefulSetWorkload(o, deadline))
+		}
+	}
+
+	daemonsets, err := d.listers.DaemonSets.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range daemonsets {
+		if deadline := d.deadline(o.ObjectMeta, d.deadlines.DaemonSet); deadline != 0 {
+			ws = append(ws, daemonSetWorkload(o, deadline))
+		}
+	}
+
+	jobs, err := d.listers.Jobs.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range jobs {
+		if deadline := d.deadline(o.ObjectMeta, d.deadlines.Job); deadline != 0 {
+			ws = append(ws, jobWorkload(o, deadline))
+		}
+	}
+
+	return ws, nil
+}
+
+// deadline returns the deadline for a workload; the stall-deadline
+// annotation of its release's ReleaseHistory overrides the default for its
+// kind
+func (d *Detector) deadline(meta metav1.ObjectMeta, deadline time.Duration) time.Duration {
+	release := meta.Labels[constants.LabelReleaseHistory]
+	if release == "" {
+		return deadline
+	}
+	rh, err := d.listers.ReleaseHistories.ReleaseHistories(meta.Namespace).Get(release)
+	if err != nil {
+		return deadline
+	}
+	raw, ok := rh.Annotations[constants.AnnotationStallDeadline]
+	if !ok {
+		return deadline
+	}
+	override, err := time.ParseDuration(raw)
+	if err != nil || override < 0 {
+		d.log.Info("ignoring invalid stall deadline annotation", "name", rh.Name, "namespace", rh.Namespace, "value", raw)
+		return deadline
+	}
+	return override
+}
+
+// latestRevision returns the most recent revision of a release
+func (d *Detector) latestRevision(namespace string, release string) (v1alpha1.ReleaseHistoryRevision, bool) {
+	if release == "" {
+		return v1alpha1.ReleaseHistoryRevision{}, false
+	}
+	rh, err := d.listers.ReleaseHistories.ReleaseHistories(namespace).Get(release)
+	if err != nil {
+		return v1alpha1.ReleaseHistoryRevision{}, false
+	}
+	return rh.LatestRevision()
+}
+
+// blockingPods returns the pods of the workload which are holding up its
+// rollout, with why
+func (d *Detector) blockingPods(w workload) []string {
+	selector, err := metav1.LabelSelectorAsSelector(w.selector)
+	if err != nil || selector.Empty() {
+		return nil
+	}
+	pods, err := d.listers.Pods.Pods(w.meta.Namespace).List(selector)
+	if err != nil {
+		return nil
+	}
+
+	result := []string{}
+	for _, p := range pods {
+		if w.blocking(p) {
+			result = append(result, fmt.Sprintf("%s (%s)", p.Name, podStatus(p)))
+		}
+	}
+	sort.Strings(result)
+	if len(result) > maxBlockingPods {
+		result = append(result[:maxBlockingPods], fmt.Sprintf("and %d more", len(result)-maxBlockingPods))
+	}
+	return result
+}
+
+func deploymentWorkload(o *appsv1.Deployment) workload {
+	return workload{
+		kind:     "Deployment",
+		meta:     o.ObjectMeta,
+		selector: o.Spec.Selector,
+		rolledOut: func() bool {
+			if o.Status.ObservedGeneration < o.Generation {
+				return false
+			}
+			for _, c := range o.Status.Conditions {
+				if c.Type == appsv1.DeploymentProgressing {
+					return c.Status == v1.ConditionTrue && c.Reason == "NewReplicaSetAvailable"
+				}
+			}
+			return false
+		},
+		stalled: func(start time.Time, now time.Time) (bool, string) {
+			for _, c := range o.Status.Conditions {
+				if c.Type == appsv1.DeploymentProgressing && c.Status == v1.ConditionFalse && c.Reason == "ProgressDeadlineExceeded" {
+					return true, c.Message
+				}
+			}
+			return false, ""
+		},
+		blocking: notReady,
+	}
+}
+
+func statefulSetWorkload(o *appsv1.StatefulSet, deadline time.Duration) workload {
+	replicas := int32(1)
+	if o.Spec.Replicas != nil {
+		replicas = *o.Spec.Replicas
+	}
+	return workload{
+		kind:     "StatefulSet",
+		meta:     o.ObjectMeta,
+		selector: o.Spec.Selector,
+		rolledOut: func() bool {
+			s := o.Status
+			return s.ObservedGeneration >= o.Generation && s.UpdatedReplicas >= replicas && (s.UpdateRevision == "" || s.CurrentRevision == s.UpdateRevision)
+		},
+		stalled: func(start time.Time, now time.Time) (bool, string) {
+			if now.Sub(start) < deadline {
+				return false, ""
+			}
+			s := o.Status
+			return true, fmt.Sprintf("%d of %d replicas updated and %d ready after %s", s.UpdatedReplicas, replicas, s.ReadyReplicas, deadline)
+		},
+		blocking: notReady,
+	}
+}
+
+func daemonSetWorkload(o *appsv1.DaemonSet, deadline time.Duration) workload {
+	return workload{
+		kind:     "DaemonSet",
+		meta:     o.ObjectMeta,
+		selector: o.Spec.Selector,
+		rolledOut: func() bool {
+			s := o.Status
+			return s.ObservedGeneration >= o.Generation && s.UpdatedNumberScheduled >= s.DesiredNumberScheduled
+		},
+		stalled: func(start time.Time, now time.Time) (bool, string) {
+			if now.Sub(start) < deadline {
+				return false, ""
+			}
+			s := o.Status
+			return true, fmt.Sprintf("%d of %d pods updated and %d available after %s", s.UpdatedNumberScheduled, s.DesiredNumberScheduled, s.NumberAvailable, deadline)
+		},
+		blocking: notReady,
+	}
+}
+
+func jobWorkload(o *batchv1.Job, deadline time.Duration) workload {
+	return workload{
+		kind:     "Job",
+		meta:     o.ObjectMeta,
+		selector: o.Spec.Selector,
+		rolledOut: func() bool {
+			for _, c := range o.Status.Conditions {
+				if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == v1.ConditionTrue {
+					return true
+				}
+			}
+			return false
+		},
+		stalled: func(start time.Time, now time.Time) (bool, string) {
+			if now.Sub(start) < deadline {
+				return false, ""
+			}
+			return true, fmt.Sprintf("not complete after %s (%d active, %d succeeded, %d failed)", deadline, o.Status.Active, o.Status.Succeeded, o.Status.Failed)
+		},
+		blocking: func(p *v1.Pod) bool {
+			return p.Status.Phase != v1.PodSucceeded
+		},
+	}
+}
+
+func notReady(p *v1.Pod) bool {
+	if p.DeletionTimestamp != nil || p.Status.Phase == v1.PodSucceeded {
+		return false
+	}
+	for _, c := range p.Status.Conditions {
+		if c.Type == v1.PodReady {
+			return c.Status != v1.ConditionTrue
+		}
+	}
+	return true
+}
+
+// podStatus summarizes why a pod is not ready
+func podStatus(p *v1.Pod) string {
+	for _, cs := range append(append([]v1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...) {
+		if w := cs.State.Waiting; w != nil && w.Reason != "" {
+			return w.Reason
+		}
+		if t := cs.State.Terminated; t != nil && t.ExitCode != 0 {
+			return t.Reason
+		}
+	}
+	for _, c := range p.Status.Conditions {
+		if c.Type == v1.PodScheduled && c.Status == v1.ConditionFalse && c.Reason != "" {
+			return c.Reason
+		}
+	}
+	if p.Status.Phase == v1.PodRunning {
+		return "NotReady"
+	}
+	return string(p.Status.Phase)
+}
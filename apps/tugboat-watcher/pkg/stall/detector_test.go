@@ -0,0 +1,376 @@
+package stall
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	listerv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/listers/engineering.tugboat/v1alpha1"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	listerappsv1 "k8s.io/client-go/listers/apps/v1"
+	listerbatchv1 "k8s.io/client-go/listers/batch/v1"
+	listercorev1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+var deployedAt = time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC)
+
+func Test_Detector_Check(t *testing.T) {
+	tcs := []struct {
+		name        string
+		objects     []runtime.Object
+		annotations map[string]string
+		elapsed     time.Duration
+		deadlines   func(d *Deadlines)
+		expected    string
+	}{
+		{
+			name: "deployment-progressing",
+			objects: []runtime.Object{
+				deployment(appsv1.DeploymentCondition{Type: appsv1.DeploymentProgressing, Status: v1.ConditionTrue, Reason: "ReplicaSetUpdated"}),
+			},
+			elapsed: time.Hour,
+		},
+		{
+			name: "deployment-deadline-exceeded",
+			objects: []runtime.Object{
+				deployment(appsv1.DeploymentCondition{Type: appsv1.DeploymentProgressing, Status: v1.ConditionFalse, Reason: "ProgressDeadlineExceeded", Message: `ReplicaSet "web-5d9c" has timed out progressing.`}),
+				pod("web-5d9c-a", "CrashLoopBackOff"),
+				pod("web-5d9c-b", ""),
+			},
+			expected: "Deployment/web",
+		},
+		{
+			name:    "statefulset-within-deadline",
+			objects: []runtime.Object{statefulSet()},
+			elapsed: 5 * time.Minute,
+		},
+		{
+			name:     "statefulset-stalled",
+			objects:  []runtime.Object{statefulSet()},
+			elapsed:  11 * time.Minute,
+			expected: "StatefulSet/db",
+		},
+		{
+			name:    "statefulset-pod-crashed",
+			objects: []runtime.Object{rolledOut(statefulSet())},
+			elapsed: 14 * 24 * time.Hour,
+		},
+		{
+			name:     "daemonset-stalled",
+			objects:  []runtime.Object{daemonSet(2)},
+			elapsed:  11 * time.Minute,
+			expected: "DaemonSet/agent",
+		},
+		{
+			name:    "daemonset-new-node",
+			objects: []runtime.Object{daemonSet(4)},
+			elapsed: 14 * 24 * time.Hour,
+		},
+		{
+			name:        "statefulset-annotation",
+			objects:     []runtime.Object{statefulSet()},
+			annotations: map[string]string{constants.AnnotationStallDeadline: "1h"},
+			elapsed:     11 * time.Minute,
+		},
+		{
+			name:        "job-annotation",
+			objects:     []runtime.Object{job(nil)},
+			annotations: map[string]string{constants.AnnotationStallDeadline: "1h"},
+			elapsed:     31 * time.Minute,
+		},
+		{
+			name:        "statefulset-annotation-invalid",
+			objects:     []runtime.Object{statefulSet()},
+			annotations: map[string]string{constants.AnnotationStallDeadline: "soon"},
+			elapsed:     11 * time.Minute,
+			expected:    "StatefulSet/db",
+		},
+		{
+			name:     "job-stalled",
+			objects:  []runtime.Object{job(nil)},
+			elapsed:  31 * time.Minute,
+			expected: "Job/migrate",
+		},
+		{
+			name:    "job-complete",
+			objects: []runtime.Object{job(&batchv1.JobCondition{Type: batchv1.JobComplete, Status: v1.ConditionTrue})},
+			elapsed: 31 * time.Minute,
+		},
+		{
+			name:      "job-disabled",
+			objects:   []runtime.Object{job(nil)},
+			elapsed:   31 * time.Minute,
+			deadlines: func(d *Deadlines) { d.Job = 0 },
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var published []events.Event
+			deadlines := DefaultDeadlines()
+			if tc.deadlines != nil {
+				tc.deadlines(&deadlines)
+			}
+			l := listers(t, tc.objects...)
+			histories := newIndexer()
+			rh := releaseHistory()
+			rh.Annotations = tc.annotations
+			histories.Add(rh)
+			l.ReleaseHistories = listerv1alpha1.NewReleaseHistoryLister(histories)
+
+			d := New(testlogger.TestLogger{T: t}, events.SinkFunc(func(e events.Event) {
+				published = append(published, e)
+			}), deadlines, l)
+			d.now = func() time.Time { return deployedAt.Add(tc.elapsed) }
+
+			d.Check()
+			d.Check()
+
+			if tc.expected == "" {
+				if len(published) != 0 {
+					t.Errorf("expected no stall; got %v", published)
+				}
+				return
+			}
+			if len(published) != 1 {
+				t.Fatalf("expected a single stall; got %v", published)
+			}
+			e := published[0]
+			if e.Object != tc.expected || e.Type != events.TypeStalled || e.Release != "shop" || e.Revision != 2 {
+				t.Errorf("incorrect stall: %#v", e)
+			}
+		})
+	}
+}
+
+func Test_Detector_Done(t *testing.T) {
+	tcs := []struct {
+		name     string
+		status   string
+		expected int
+	}{
+		{
+			name:   "deployed",
+			status: "deployed",
+		},
+		{
+			name:     "pending",
+			status:   "pending-upgrade",
+			expected: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var published []events.Event
+			l := listers(t)
+			statefulsets := newIndexer()
+			l.StatefulSets = listerappsv1.NewStatefulSetLister(statefulsets)
+			histories := newIndexer()
+			rh := releaseHistory()
+			rh.Status.Revisions[0].Status = tc.status
+			histories.Add(rh)
+			l.ReleaseHistories = listerv1alpha1.NewReleaseHistoryLister(histories)
+
+			d := New(testlogger.TestLogger{T: t}, events.SinkFunc(func(e events.Event) {
+				published = append(published, e)
+			}), DefaultDeadlines(), l)
+			d.now = func() time.Time { return deployedAt.Add(time.Minute) }
+
+			// The StatefulSet has not been updated yet, or has already rolled
+			// out the revision
+			statefulsets.Add(rolledOut(statefulSet()))
+			d.Check()
+
+			// Later, it is updated again, and does not roll out in time
+			statefulsets.Update(statefulSet())
+			d.now = func() time.Time { return deployedAt.Add(time.Hour) }
+			d.Check()
+
+			if len(published) != tc.expected {
+				t.Errorf("incorrect number of stalls: expected %d, got %v", tc.expected, published)
+			}
+		})
+	}
+}
+
+func Test_Detector_BlockingPods(t *testing.T) {
+	var published []events.Event
+	d := New(testlogger.TestLogger{T: t}, events.SinkFunc(func(e events.Event) {
+		published = append(published, e)
+	}), DefaultDeadlines(), listers(t,
+		deployment(appsv1.DeploymentCondition{Type: appsv1.DeploymentProgressing, Status: v1.ConditionFalse, Reason: "ProgressDeadlineExceeded"}),
+		pod("web-5d9c-a", "CrashLoopBackOff"),
+		pod("web-5d9c-b", ""),
+		pod("web-5d9c-c", "ready"),
+	))
+
+	d.Check()
+	if len(published) != 1 {
+		t.Fatalf("expected a single stall; got %v", published)
+	}
+	m := published[0].Message
+	if !strings.Contains(m, "web-5d9c-a (CrashLoopBackOff)") || !strings.Contains(m, "web-5d9c-b (Unschedulable)") || strings.Contains(m, "web-5d9c-c") {
+		t.Errorf("incorrect blocking pods: %s", m)
+	}
+}
+
+func listers(t *testing.T, objects ...runtime.Object) Listers {
+	deployments := newIndexer()
+	statefulsets := newIndexer()
+	daemonsets := newIndexer()
+	jobs := newIndexer()
+	pods := newIndexer()
+	histories := newIndexer()
+	histories.Add(releaseHistory())
+
+	for _, o := range objects {
+		var err error
+		switch o.(type) {
+		case *appsv1.Deployment:
+			err = deployments.Add(o)
+		case *appsv1.StatefulSet:
+			err = statefulsets.Add(o)
+		case *appsv1.DaemonSet:
+			err = daemonsets.Add(o)
+		case *batchv1.Job:
+			err = jobs.Add(o)
+		case *v1.Pod:
+			err = pods.Add(o)
+		}
+		if err != nil {
+			t.Fatalf("failed to add object: %v", err)
+		}
+	}
+	return Listers{
+		Deployments:      listerappsv1.NewDeploymentLister(deployments),
+		StatefulSets:     listerappsv1.NewStatefulSetLister(statefulsets),
+		DaemonSets:       listerappsv1.NewDaemonSetLister(daemonsets),
+		Jobs:             listerbatchv1.NewJobLister(jobs),
+		Pods:             listercorev1.NewPodLister(pods),
+		ReleaseHistories: listerv1alpha1.NewReleaseHistoryLister(histories),
+	}
+}
+
+func newIndexer() cache.Indexer {
+	return cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+}
+
+func meta(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:              name,
+		Namespace:         "payments",
+		Labels:            map[string]string{constants.LabelReleaseHistory: "shop"},
+		CreationTimestamp: metav1.NewTime(deployedAt.Add(-24 * time.Hour)),
+	}
+}
+
+func releaseHistory() *v1alpha1.ReleaseHistory {
+	return &v1alpha1.ReleaseHistory{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "payments"},
+		Status: v1alpha1.ReleaseHistoryStatus{
+			Revisions: []v1alpha1.ReleaseHistoryRevision{
+				{Revision: 2, DeployedAt: metav1.NewTime(deployedAt)},
+				{Revision: 1, DeployedAt: metav1.NewTime(deployedAt.Add(-24 * time.Hour))},
+			},
+		},
+	}
+}
+
+func deployment(c appsv1.DeploymentCondition) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: meta("web"),
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+		Status: appsv1.DeploymentStatus{
+			Conditions: []appsv1.DeploymentCondition{c},
+		},
+	}
+}
+
+func statefulSet() *appsv1.StatefulSet {
+	replicas := int32(3)
+	return &appsv1.StatefulSet{
+		ObjectMeta: meta("db"),
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+		},
+		Status: appsv1.StatefulSetStatus{
+			Replicas:        3,
+			ReadyReplicas:   2,
+			UpdatedReplicas: 1,
+			CurrentRevision: "db-1",
+			UpdateRevision:  "db-2",
+		},
+	}
+}
+
+// rolledOut marks every replica of the StatefulSet as updated, though not
+// necessarily ready
+func rolledOut(o *appsv1.StatefulSet) *appsv1.StatefulSet {
+	o.Status.UpdatedReplicas = *o.Spec.Replicas
+	o.Status.CurrentRevision = o.Status.UpdateRevision
+	return o
+}
+
+// daemonSet returns a DaemonSet which should run on 4 nodes, and has updated
+// the pods on some of them
+func daemonSet(updated int32) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: meta("agent"),
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "agent"}},
+		},
+		Status: appsv1.DaemonSetStatus{
+			DesiredNumberScheduled: 4,
+			UpdatedNumberScheduled: updated,
+			NumberAvailable:        3,
+		},
+	}
+}
+
+func job(c *batchv1.JobCondition) *batchv1.Job {
+	j := &batchv1.Job{
+		ObjectMeta: meta("migrate"),
+		Status:     batchv1.JobStatus{Active: 1},
+	}
+	if c != nil {
+		j.Status.Conditions = []batchv1.JobCondition{*c}
+	}
+	return j
+}
+
+// pod returns a pod of the "web" deployment.  A pod with a waiting reason
+// is crashing, "ready" is ready, and otherwise, it cannot be scheduled.
+func pod(name string, status string) *v1.Pod {
+	p := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "payments",
+			Labels:    map[string]string{"app": "web", constants.LabelReleaseHistory: "shop"},
+		},
+	}
+	switch status {
+	case "ready":
+		p.Status.Phase = v1.PodRunning
+		p.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	case "":
+		p.Status.Phase = v1.PodPending
+		p.Status.Conditions = []v1.PodCondition{{Type: v1.PodScheduled, Status: v1.ConditionFalse, Reason: "Unschedulable"}}
+	default:
+		p.Status.Phase = v1.PodRunning
+		p.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "app", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: status}}}}
+	}
+	return p
+}
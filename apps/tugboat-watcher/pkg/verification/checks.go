@@ -0,0 +1,236 @@
+package verification
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DefaultWithin is how long a check may take to pass if its spec does not
+// say
+const DefaultWithin = 10 * time.Minute
+
+// Reasons of the conditions of checks
+const (
+	ReasonPending  = "Pending"
+	ReasonPassed   = "Passed"
+	ReasonFailed   = "Failed"
+	ReasonTimedOut = "TimedOut"
+	ReasonRestart  = "Restarted"
+	ReasonInvalid  = "Invalid"
+)
+
+// maxListed bounds the pods named in a message
+const maxListed = 5
+
+// result is the outcome of evaluating a check once.  A check whose status is
+// unknown is still pending, and is evaluated again.
+type result struct {
+	status  metav1.ConditionStatus
+	reason  string
+	message string
+}
+
+func pending(format string, a ...interface{}) result {
+	return result{status: metav1.ConditionUnknown, reason: ReasonPending, message: fmt.Sprintf(format, a...)}
+}
+
+func passed(format string, a ...interface{}) result {
+	return result{status: metav1.ConditionTrue, reason: ReasonPassed, message: fmt.Sprintf(format, a...)}
+}
+
+func failed(reason string, format string, a ...interface{}) result {
+	return result{status: metav1.ConditionFalse, reason: reason, message: fmt.Sprintf(format, a...)}
+}
+
+// target is a revision being verified
+type target struct {
+	namespace string
+	release   string
+	start     time.Time
+}
+
+func (v *Verifier) evaluate(ctx context.Context, t target, c v1alpha1.VerificationCheck, now time.Time) result {
+	switch {
+	case c.PodsReady != nil:
+		return v.podsReady(t, within(c.PodsReady.Within), now)
+	case c.NoRestarts != nil:
+		return v.noRestarts(t, within(c.NoRestarts.For), now)
+	case c.JobComplete != nil:
+		return v.jobComplete(t, c.JobComplete.Job, within(c.JobComplete.Within), now)
+	case c.HTTP != nil:
+		return v.httpOK(ctx, t, c.HTTP, now)
+	default:
+		return failed(ReasonInvalid, "check has none of podsready, norestarts, jobcomplete or http")
+	}
+}
+
+// podsReady passes once every pod of the release is ready.  Pods which are
+// terminating or have completed, and the pods of hooks, are ignored.
+func (v *Verifier) podsReady(t target, within time.Duration, now time.Time) result {
+	pods, err := v.pods(t)
+	if err != nil {
+		return pending("failed to list pods: %s", err.Error())
+	}
+
+	unready := []string{}
+	count := 0
+	for _, p := range pods {
+		if p.DeletionTimestamp != nil || p.Status.Phase == v1.PodSucceeded {
+			continue
+		}
+		count++
+		if !isReady(p) {
+			unready = append(unready, p.Name)
+		}
+	}
+
+	switch {
+	case count != 0 && len(unready) == 0:
+		return passed("%d pods are ready", count)
+	case now.Sub(t.start) > within:
+		if count == 0 {
+			return failed(ReasonTimedOut, "no pods after %s", within)
+		}
+		return failed(ReasonTimedOut, "pods not ready after %s: %s", within, list(unready))
+	case count == 0:
+		return pending("waiting for pods")
+	default:
+		return pending("waiting for pods to be ready: %s", list(unready))
+	}
+}
+
+// noRestarts fails if a container of the release restarts after the revision
+// started, and passes once the period has elapsed without one
+func (v *Verifier) noRestarts(t target, period time.Duration, now time.Time) result {
+	pods, err := v.pods(t)
+	if err != nil {
+		return pending("failed to list pods: %s", err.Error())
+	}
+
+	for _, p := range pods {
+		statuses := append(append([]v1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			terminated := cs.LastTerminationState.Terminated
+			if cs.RestartCount == 0 || terminated == nil || terminated.FinishedAt.Time.Before(t.start) {
+				continue
+			}
+			return failed(ReasonRestart, "container %s of pod %s restarted (%d restarts, last exit code %d)", cs.Name, p.Name, cs.RestartCount, terminated.ExitCode)
+		}
+	}
+
+	if now.Sub(t.start) >= period {
+		return passed("no restarts for %s", period)
+	}
+	return pending("watching for restarts until %s", t.start.Add(period).UTC().Format(time.RFC3339))
+}
+
+// jobComplete passes once the Job completes, and fails if it fails
+func (v *Verifier) jobComplete(t target, name string, within time.Duration, now time.Time) result {
+	job, err := v.listers.Jobs.Jobs(t.namespace).Get(name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return pending("failed to get Job %s: %s", name, err.Error())
+	}
+
+	if job != nil {
+		for _, c := range job.Status.Conditions {
+			if c.Status != v1.ConditionTrue {
+				continue
+			}
+			switch c.Type {
+			case batchv1.JobComplete:
+				return passed("Job %s completed", name)
+			case batchv1.JobFailed:
+				return failed(ReasonFailed, "Job %s failed: %s", name, c.Message)
+			}
+		}
+	}
+
+	if now.Sub(t.start) > within {
+		if job == nil {
+			return failed(ReasonTimedOut, "Job %s not found after %s", name, within)
+		}
+		return failed(ReasonTimedOut, "Job %s not complete after %s", name, within)
+	}
+	if job == nil {
+		return pending("waiting for Job %s", name)
+	}
+	return pending("waiting for Job %s to complete", name)
+}
+
+// httpOK passes once a GET of the Service returns a 2xx status
+func (v *Verifier) httpOK(ctx context.Context, t target, c *v1alpha1.HTTPCheck, now time.Time) result {
+	url := v.serviceURL(t.namespace, c.Service, c.Port) + "/" + strings.TrimPrefix(c.Path, "/")
+
+	var outcome string
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return failed(ReasonInvalid, "invalid request: %s", err.Error())
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		outcome = err.Error()
+	} else {
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return passed("GET %s returned %s", url, resp.Status)
+		}
+		outcome = "returned " + resp.Status
+	}
+
+	if d := within(c.Within); now.Sub(t.start) > d {
+		return failed(ReasonTimedOut, "GET %s did not succeed within %s; last attempt %s", url, d, outcome)
+	}
+	return pending("GET %s %s", url, outcome)
+}
+
+// pods returns the pods of the release, other than those of its hooks
+func (v *Verifier) pods(t target) ([]*v1.Pod, error) {
+	selector := labels.SelectorFromSet(labels.Set{constants.LabelReleaseHistory: t.release})
+	all, err := v.listers.Pods.Pods(t.namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]*v1.Pod, 0, len(all))
+	for _, p := range all {
+		if _, ok := p.Annotations[constants.HelmAnnotationHook]; !ok {
+			pods = append(pods, p)
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	return pods, nil
+}
+
+func isReady(p *v1.Pod) bool {
+	for _, c := range p.Status.Conditions {
+		if c.Type == v1.PodReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func within(d metav1.Duration) time.Duration {
+	if d.Duration <= 0 {
+		return DefaultWithin
+	}
+	return d.Duration
+}
+
+func list(names []string) string {
+	if len(names) > maxListed {
+		return fmt.Sprintf("%s and %d more", strings.Join(names[:maxListed], ", "), len(names)-maxListed)
+	}
+	return strings.Join(names, ", ")
+}
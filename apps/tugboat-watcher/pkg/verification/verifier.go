@@ -0,0 +1,290 @@
+package verification
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/pkg/http/probes"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned"
+	listerv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/listers/engineering.tugboat/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	listerbatchv1 "k8s.io/client-go/listers/batch/v1"
+	listercorev1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// DefaultInterval is how often revisions are verified
+	DefaultInterval = 15 * time.Second
+
+	// DefaultHTTPTimeout is how long each HTTP check request may take
+	DefaultHTTPTimeout = 5 * time.Second
+)
+
+// Reasons of the events published about verification
+const (
+	ReasonCheckPassed        = "CheckPassed"
+	ReasonCheckFailed        = "CheckFailed"
+	ReasonVerified           = "Verified"
+	ReasonVerificationFailed = "VerificationFailed"
+)
+
+// Listers read the pods and Jobs of tracked releases, the ReleaseHistories,
+// and the DeploymentVerifications
+type Listers struct {
+	Pods                    listercorev1.PodLister
+	Jobs                    listerbatchv1.JobLister
+	ReleaseHistories        listerv1alpha1.ReleaseHistoryLister
+	DeploymentVerifications listerv1alpha1.DeploymentVerificationLister
+}
+
+// Verifier runs the DeploymentVerification checks of the latest revision of
+// each release, and records their results as conditions of the revision: one
+// for each check, named after it, and "Verified".  A check which passes or
+// fails is not run again.  Once every check has passed, the revision has
+// succeeded, and once one has failed, it has failed; either is published to
+// the sink, as are the results of each check.
+//
+// A revision is only checked by the DeploymentVerifications which existed
+// when it started deploying, so that a new DeploymentVerification does not
+// fail revisions which were deployed before it.
+type Verifier struct {
+	log       logr.Logger
+	clientset versioned.Interface
+	sink      events.Sink
+	listers   Listers
+
+	// Interval is how often revisions are verified
+	Interval time.Duration
+
+	client     *http.Client
+	serviceURL func(namespace string, service string, port int32) string
+	now        func() time.Time
+}
+
+// New returns a new Verifier
+func New(log logr.Logger, clientset versioned.Interface, sink events.Sink, listers Listers) *Verifier {
+	return &Verifier{
+		log:       log,
+		clientset: clientset,
+		sink:      sink,
+		listers:   listers,
+		Interval:  DefaultInterval,
+		client:    &http.Client{Timeout: DefaultHTTPTimeout},
+		serviceURL: func(namespace string, service string, port int32) string {
+			return fmt.Sprintf("http://%s.%s.svc:%d", service, namespace, port)
+		},
+		now: time.Now,
+	}
+}
+
+// Run verifies revisions every interval until the context is done
+func (v *Verifier) Run(ctx context.Context, r probes.Reporter) error {
+	t := time.NewTicker(v.Interval)
+	defer t.Stop()
+
+	r.Ready()
+	for {
+		select {
+		case <-ctx.Done():
+			r.NotReady()
+			return ctx.Err()
+		case <-t.C:
+			v.Check(ctx)
+		}
+	}
+}
+
+// Check runs the pending checks of the latest revision of each release once
+func (v *Verifier) Check(ctx context.Context) {
+	dvs, err := v.listers.DeploymentVerifications.List(labels.Everything())
+	if err != nil {
+		v.log.Error(err, "failed to list deployment verifications")
+		return
+	} else if len(dvs) == 0 {
+		return
+	}
+	rhs, err := v.listers.ReleaseHistories.List(labels.Everything())
+	if err != nil {
+		v.log.Error(err, "failed to list release histories")
+		return
+	}
+
+	for _, rh := range rhs {
+		rev, ok := rh.LatestRevision()
+		if !ok {
+			continue
+		}
+		if c := meta.FindStatusCondition(rev.Conditions, v1alpha1.ConditionVerified); c != nil && c.Status != metav1.ConditionUnknown {
+			continue
+		}
+		checks := v.checksFor(dvs, rh, rev)
+		if len(checks) == 0 {
+			continue
+		}
+		if err := v.verify(ctx, rh, rev, checks); err != nil {
+			v.log.Error(err, "failed to verify revision", "name", rh.Name, "namespace", rh.Namespace, "revision", rev.Revision)
+		}
+	}
+}
+
+// Verifies reports whether any DeploymentVerification checks apply to the
+// revision
+func (v *Verifier) Verifies(rh *v1alpha1.ReleaseHistory, rev v1alpha1.ReleaseHistoryRevision) bool {
+	dvs, err := v.listers.DeploymentVerifications.List(labels.Everything())
+	if err != nil {
+		v.log.Error(err, "failed to list deployment verifications")
+		return false
+	}
+	return len(v.checksFor(dvs, rh, rev)) != 0
+}
+
+// checksFor returns the checks of the DeploymentVerifications which apply to
+// the revision.  Of checks with the same name, the first is used.
+func (v *Verifier) checksFor(dvs []*v1alpha1.DeploymentVerification, rh *v1alpha1.ReleaseHistory, rev v1alpha1.ReleaseHistoryRevision) []v1alpha1.VerificationCheck {
+	checks := []v1alpha1.VerificationCheck{}
+	names := map[string]bool{}
+	for _, dv := range dvs {
+		if dv.Namespace != rh.Namespace || rev.DeployedAt.Time.Before(dv.CreationTimestamp.Time) {
+			continue
+		}
+		applies, err := dv.AppliesTo(rh.Name)
+		if err != nil {
+			v.log.Error(err, "skipped invalid release pattern")
+		}
+		if !applies {
+			continue
+		}
+		for _, c := range dv.Spec.Checks {
+			if c.Name == "" || c.Name == v1alpha1.ConditionVerified || names[c.Name] {
+				v.log.Info("ignoring check with a missing or duplicate name", "name", dv.Name, "namespace", dv.Namespace, "check", c.Name)
+				continue
+			}
+			names[c.Name] = true
+			checks = append(checks, c)
+		}
+	}
+	return checks
+}
+
+func (v *Verifier) verify(ctx context.Context, rh *v1alpha1.ReleaseHistory, rev v1alpha1.ReleaseHistoryRevision, checks []v1alpha1.VerificationCheck) error {
+	now := v.now()
+	t := target{namespace: rh.Namespace, release: rh.Name, start: rev.DeployedAt.Time}
+
+	conditions := append([]metav1.Condition{}, rev.Conditions...)
+	decided := []events.Event{}
+	waiting := []string{}
+	var failure *metav1.Condition
+	for _, c := range checks {
+		if existing := meta.FindStatusCondition(conditions, c.Name); existing != nil && existing.Status != metav1.ConditionUnknown {
+			if existing.Status == metav1.ConditionFalse && failure == nil {
+				failure = existing
+			}
+			continue
+		}
+
+		res := v.evaluate(ctx, t, c, now)
+		meta.SetStatusCondition(&conditions, metav1.Condition{
+			Type:               c.Name,
+			Status:             res.status,
+			Reason:             res.reason,
+			Message:            res.message,
+			LastTransitionTime: metav1.NewTime(now),
+		})
+		switch res.status {
+		case metav1.ConditionTrue:
+			decided = append(decided, v.event(rh, rev, now, events.TypeProgressing, events.SeverityInfo, ReasonCheckPassed, fmt.Sprintf("Check %s passed: %s", c.Name, res.message)))
+		case metav1.ConditionFalse:
+			decided = append(decided, v.event(rh, rev, now, events.TypeProgressing, events.SeverityError, ReasonCheckFailed, fmt.Sprintf("Check %s failed: %s", c.Name, res.message)))
+			if failure == nil {
+				failure = meta.FindStatusCondition(conditions, c.Name)
+			}
+		default:
+			waiting = append(waiting, c.Name)
+		}
+	}
+
+	verified := metav1.Condition{
+		Type:               v1alpha1.ConditionVerified,
+		LastTransitionTime: metav1.NewTime(now),
+	}
+	switch {
+	case failure != nil:
+		verified.Status = metav1.ConditionFalse
+		verified.Reason = ReasonFailed
+		verified.Message = fmt.Sprintf("check %s failed", failure.Type)
+		decided = append(decided, v.event(rh, rev, now, events.TypeFailed, events.SeverityError, ReasonVerificationFailed, fmt.Sprintf("Revision %d of %s failed verification: check %s failed: %s", rev.Revision, rh.Name, failure.Type, failure.Message)))
+	case len(waiting) == 0:
+		verified.Status = metav1.ConditionTrue
+		verified.Reason = ReasonPassed
+		verified.Message = fmt.Sprintf("%d checks passed", len(checks))
+		decided = append(decided, v.event(rh, rev, now, events.TypeSucceeded, events.SeverityInfo, ReasonVerified, fmt.Sprintf("Revision %d of %s passed %d verification checks", rev.Revision, rh.Name, len(checks))))
+	default:
+		verified.Status = metav1.ConditionUnknown
+		verified.Reason = ReasonPending
+		verified.Message = "waiting for " + strings.Join(waiting, ", ")
+	}
+	meta.SetStatusCondition(&conditions, verified)
+
+	if reflect.DeepEqual(conditions, rev.Conditions) {
+		return nil
+	}
+	if err := v.record(ctx, rh, rev.Revision, conditions); err != nil {
+		return err
+	}
+
+	// Results are only published once they are recorded, so that they are
+	// not published again when the checks are retried
+	for _, e := range decided {
+		v.sink.Publish(e)
+	}
+	return nil
+}
+
+// record sets the conditions of the revision in the status of the
+// ReleaseHistory
+func (v *Verifier) record(ctx context.Context, rh *v1alpha1.ReleaseHistory, revision v1alpha1.Revision, conditions []metav1.Condition) error {
+	rhs := v.clientset.TugboatV1alpha1().ReleaseHistories(rh.Namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := rhs.Get(ctx, rh.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get release history: %w", err)
+		}
+		copyrh := current.DeepCopy()
+		found := false
+		for k := range copyrh.Status.Revisions {
+			if copyrh.Status.Revisions[k].Revision == revision {
+				copyrh.Status.Revisions[k].Conditions = conditions
+				found = true
+			}
+		}
+		if !found {
+			return nil
+		}
+		_, err = rhs.UpdateStatus(ctx, copyrh, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func (v *Verifier) event(rh *v1alpha1.ReleaseHistory, rev v1alpha1.ReleaseHistoryRevision, t time.Time, typ events.Type, severity events.Severity, reason string, message string) events.Event {
+	return events.Event{
+		Time:      t,
+		Namespace: rh.Namespace,
+		Release:   rh.Name,
+		Revision:  int(rev.Revision),
+		Type:      typ,
+		Severity:  severity,
+		Reason:    reason,
+		Message:   message,
+		Object:    "ReleaseHistory/" + rh.Name,
+	}
+}
@@ -0,0 +1,283 @@
+package verification
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/fake"
+	listerv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/listers/engineering.tugboat/v1alpha1"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listerbatchv1 "k8s.io/client-go/listers/batch/v1"
+	listercorev1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func Test_Verifier_Check(t *testing.T) {
+	deployedAt := time.Date(2021, time.January, 8, 12, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	podsReady := v1alpha1.VerificationCheck{Name: "PodsReady", PodsReady: &v1alpha1.PodsReadyCheck{Within: metav1.Duration{Duration: 5 * time.Minute}}}
+	noRestarts := v1alpha1.VerificationCheck{Name: "NoRestarts", NoRestarts: &v1alpha1.NoRestartsCheck{For: metav1.Duration{Duration: 10 * time.Minute}}}
+	migrated := v1alpha1.VerificationCheck{Name: "Migrated", JobComplete: &v1alpha1.JobCompleteCheck{Job: "migrate", Within: metav1.Duration{Duration: 5 * time.Minute}}}
+	healthy := v1alpha1.VerificationCheck{Name: "Healthy", HTTP: &v1alpha1.HTTPCheck{Service: "api", Port: 80, Path: "/healthz", Within: metav1.Duration{Duration: 5 * time.Minute}}}
+	unhealthy := v1alpha1.VerificationCheck{Name: "Healthy", HTTP: &v1alpha1.HTTPCheck{Service: "api", Port: 80, Path: "/broken", Within: metav1.Duration{Duration: 5 * time.Minute}}}
+
+	tcs := []struct {
+		name       string
+		checks     []v1alpha1.VerificationCheck
+		createdAt  time.Time
+		pods       []*v1.Pod
+		jobs       []*batchv1.Job
+		conditions []metav1.Condition
+		elapsed    time.Duration
+		expected   map[string]metav1.ConditionStatus
+		reasons    []string
+	}{
+		{
+			name:     "pods-pending",
+			checks:   []v1alpha1.VerificationCheck{podsReady},
+			pods:     []*v1.Pod{pod("api-1", true, nil), pod("api-2", false, nil)},
+			elapsed:  time.Minute,
+			expected: map[string]metav1.ConditionStatus{"PodsReady": metav1.ConditionUnknown, v1alpha1.ConditionVerified: metav1.ConditionUnknown},
+		},
+		{
+			name:     "pods-timed-out",
+			checks:   []v1alpha1.VerificationCheck{podsReady},
+			pods:     []*v1.Pod{pod("api-1", true, nil), pod("api-2", false, nil)},
+			elapsed:  6 * time.Minute,
+			expected: map[string]metav1.ConditionStatus{"PodsReady": metav1.ConditionFalse, v1alpha1.ConditionVerified: metav1.ConditionFalse},
+			reasons:  []string{ReasonCheckFailed, ReasonVerificationFailed},
+		},
+		{
+			name:   "verified",
+			checks: []v1alpha1.VerificationCheck{podsReady, noRestarts},
+			pods: []*v1.Pod{
+				pod("api-1", true, nil),
+				// Restarted before the revision started
+				pod("api-2", true, &v1.ContainerStateTerminated{ExitCode: 1, FinishedAt: metav1.NewTime(deployedAt.Add(-time.Hour))}),
+			},
+			elapsed:  10 * time.Minute,
+			expected: map[string]metav1.ConditionStatus{"PodsReady": metav1.ConditionTrue, "NoRestarts": metav1.ConditionTrue, v1alpha1.ConditionVerified: metav1.ConditionTrue},
+			reasons:  []string{ReasonCheckPassed, ReasonCheckPassed, ReasonVerified},
+		},
+		{
+			name:     "restarted",
+			checks:   []v1alpha1.VerificationCheck{podsReady, noRestarts},
+			pods:     []*v1.Pod{pod("api-1", true, &v1.ContainerStateTerminated{ExitCode: 137, FinishedAt: metav1.NewTime(deployedAt.Add(2 * time.Minute))})},
+			elapsed:  3 * time.Minute,
+			expected: map[string]metav1.ConditionStatus{"PodsReady": metav1.ConditionTrue, "NoRestarts": metav1.ConditionFalse, v1alpha1.ConditionVerified: metav1.ConditionFalse},
+			reasons:  []string{ReasonCheckPassed, ReasonCheckFailed, ReasonVerificationFailed},
+		},
+		{
+			name:     "job-complete",
+			checks:   []v1alpha1.VerificationCheck{migrated},
+			jobs:     []*batchv1.Job{job("migrate", batchv1.JobComplete)},
+			elapsed:  time.Minute,
+			expected: map[string]metav1.ConditionStatus{"Migrated": metav1.ConditionTrue, v1alpha1.ConditionVerified: metav1.ConditionTrue},
+			reasons:  []string{ReasonCheckPassed, ReasonVerified},
+		},
+		{
+			name:     "job-failed",
+			checks:   []v1alpha1.VerificationCheck{migrated},
+			jobs:     []*batchv1.Job{job("migrate", batchv1.JobFailed)},
+			elapsed:  time.Minute,
+			expected: map[string]metav1.ConditionStatus{"Migrated": metav1.ConditionFalse, v1alpha1.ConditionVerified: metav1.ConditionFalse},
+			reasons:  []string{ReasonCheckFailed, ReasonVerificationFailed},
+		},
+		{
+			name:     "job-missing",
+			checks:   []v1alpha1.VerificationCheck{migrated},
+			elapsed:  6 * time.Minute,
+			expected: map[string]metav1.ConditionStatus{"Migrated": metav1.ConditionFalse, v1alpha1.ConditionVerified: metav1.ConditionFalse},
+			reasons:  []string{ReasonCheckFailed, ReasonVerificationFailed},
+		},
+		{
+			name:     "http-ok",
+			checks:   []v1alpha1.VerificationCheck{healthy},
+			elapsed:  time.Minute,
+			expected: map[string]metav1.ConditionStatus{"Healthy": metav1.ConditionTrue, v1alpha1.ConditionVerified: metav1.ConditionTrue},
+			reasons:  []string{ReasonCheckPassed, ReasonVerified},
+		},
+		{
+			name:     "http-pending",
+			checks:   []v1alpha1.VerificationCheck{unhealthy},
+			elapsed:  time.Minute,
+			expected: map[string]metav1.ConditionStatus{"Healthy": metav1.ConditionUnknown, v1alpha1.ConditionVerified: metav1.ConditionUnknown},
+		},
+		{
+			name:     "http-timed-out",
+			checks:   []v1alpha1.VerificationCheck{unhealthy},
+			elapsed:  6 * time.Minute,
+			expected: map[string]metav1.ConditionStatus{"Healthy": metav1.ConditionFalse, v1alpha1.ConditionVerified: metav1.ConditionFalse},
+			reasons:  []string{ReasonCheckFailed, ReasonVerificationFailed},
+		},
+		{
+			name:   "decided-checks-not-rerun",
+			checks: []v1alpha1.VerificationCheck{migrated, healthy},
+			conditions: []metav1.Condition{
+				{Type: "Migrated", Status: metav1.ConditionTrue, Reason: ReasonPassed},
+				{Type: v1alpha1.ConditionVerified, Status: metav1.ConditionUnknown, Reason: ReasonPending},
+			},
+			elapsed:  time.Minute,
+			expected: map[string]metav1.ConditionStatus{"Migrated": metav1.ConditionTrue, "Healthy": metav1.ConditionTrue, v1alpha1.ConditionVerified: metav1.ConditionTrue},
+			reasons:  []string{ReasonCheckPassed, ReasonVerified},
+		},
+		{
+			name:       "already-verified",
+			checks:     []v1alpha1.VerificationCheck{podsReady},
+			conditions: []metav1.Condition{{Type: v1alpha1.ConditionVerified, Status: metav1.ConditionTrue, Reason: ReasonPassed}},
+			elapsed:    time.Minute,
+			expected:   map[string]metav1.ConditionStatus{v1alpha1.ConditionVerified: metav1.ConditionTrue},
+		},
+		{
+			name:      "verification-created-later",
+			checks:    []v1alpha1.VerificationCheck{migrated},
+			createdAt: deployedAt.Add(time.Minute),
+			elapsed:   6 * time.Minute,
+			expected:  map[string]metav1.ConditionStatus{},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			createdAt := tc.createdAt
+			if createdAt.IsZero() {
+				createdAt = deployedAt.Add(-24 * time.Hour)
+			}
+			dv := &v1alpha1.DeploymentVerification{
+				ObjectMeta: metav1.ObjectMeta{Name: "checks", Namespace: "payments", CreationTimestamp: metav1.NewTime(createdAt)},
+				Spec:       v1alpha1.DeploymentVerificationSpec{Releases: []string{"checkout-*"}, Checks: tc.checks},
+			}
+			rh := &v1alpha1.ReleaseHistory{
+				ObjectMeta: metav1.ObjectMeta{Name: "checkout-api", Namespace: "payments"},
+				Status: v1alpha1.ReleaseHistoryStatus{
+					Revisions: []v1alpha1.ReleaseHistoryRevision{
+						{Revision: 1, DeployedAt: metav1.NewTime(deployedAt.Add(-time.Hour))},
+						{Revision: 2, DeployedAt: metav1.NewTime(deployedAt), Conditions: tc.conditions},
+					},
+				},
+			}
+			other := &v1alpha1.ReleaseHistory{
+				ObjectMeta: metav1.ObjectMeta{Name: "inventory", Namespace: "payments"},
+				Status: v1alpha1.ReleaseHistoryStatus{
+					Revisions: []v1alpha1.ReleaseHistoryRevision{{Revision: 1, DeployedAt: metav1.NewTime(deployedAt)}},
+				},
+			}
+
+			pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, p := range tc.pods {
+				pods.Add(p)
+			}
+			jobs := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, j := range tc.jobs {
+				jobs.Add(j)
+			}
+			histories := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			histories.Add(rh)
+			histories.Add(other)
+			verifications := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			verifications.Add(dv)
+
+			clientset := fake.NewSimpleClientset(rh, other)
+			var published []events.Event
+			sink := events.SinkFunc(func(e events.Event) {
+				published = append(published, e)
+			})
+
+			v := New(testlogger.TestLogger{T: t}, clientset, sink, Listers{
+				Pods:                    listercorev1.NewPodLister(pods),
+				Jobs:                    listerbatchv1.NewJobLister(jobs),
+				ReleaseHistories:        listerv1alpha1.NewReleaseHistoryLister(histories),
+				DeploymentVerifications: listerv1alpha1.NewDeploymentVerificationLister(verifications),
+			})
+			v.serviceURL = func(namespace string, service string, port int32) string {
+				return server.URL
+			}
+			v.now = func() time.Time { return deployedAt.Add(tc.elapsed) }
+
+			v.Check(context.Background())
+
+			actual, err := clientset.TugboatV1alpha1().ReleaseHistories("payments").Get(context.Background(), "checkout-api", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			conditions := actual.Status.Revisions[1].Conditions
+			if len(conditions) != len(tc.expected) {
+				t.Errorf("incorrect conditions: %v", conditions)
+			}
+			for typ, status := range tc.expected {
+				if c := meta.FindStatusCondition(conditions, typ); c == nil || c.Status != status {
+					t.Errorf("incorrect condition %s: %v", typ, c)
+				}
+			}
+			if len(actual.Status.Revisions[0].Conditions) != 0 {
+				t.Errorf("earlier revision should not be verified")
+			}
+
+			if len(published) != len(tc.reasons) {
+				t.Fatalf("incorrect events published: %v", published)
+			}
+			for k, e := range published {
+				if e.Reason != tc.reasons[k] || e.Revision != 2 || e.Release != "checkout-api" {
+					t.Errorf("incorrect event %d: %#v", k, e)
+				}
+			}
+			if n := len(published); n != 0 {
+				last := published[n-1].Type
+				if (last == events.TypeSucceeded) != (tc.expected[v1alpha1.ConditionVerified] == metav1.ConditionTrue) || (last == events.TypeFailed) != (tc.expected[v1alpha1.ConditionVerified] == metav1.ConditionFalse) {
+					t.Errorf("incorrect outcome event: %s", last)
+				}
+			}
+		})
+	}
+}
+
+func pod(name string, ready bool, restarted *v1.ContainerStateTerminated) *v1.Pod {
+	p := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "payments",
+			Labels:    map[string]string{constants.LabelReleaseHistory: "checkout-api"},
+		},
+		Status: v1.PodStatus{
+			Phase:             v1.PodRunning,
+			Conditions:        []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionFalse}},
+			ContainerStatuses: []v1.ContainerStatus{{Name: "app", Ready: ready}},
+		},
+	}
+	if ready {
+		p.Status.Conditions[0].Status = v1.ConditionTrue
+	}
+	if restarted != nil {
+		p.Status.ContainerStatuses[0].RestartCount = 1
+		p.Status.ContainerStatuses[0].LastTerminationState.Terminated = restarted
+	}
+	return p
+}
+
+func job(name string, condition batchv1.JobConditionType) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "payments"},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: condition, Status: v1.ConditionTrue, Message: "BackoffLimitExceeded"}},
+		},
+	}
+}
@@ -0,0 +1,222 @@
+package watcher
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DefaultRepeatInterval is how long a recurring event is suppressed after
+// it is published
+const DefaultRepeatInterval = 5 * time.Minute
+
+// maxOwners bounds the cache of involved objects' releases
+const maxOwners = 1000
+
+// eventSeverities are the Kubernetes event reasons which are correlated
+// with releases; events with other reasons are ignored
+var eventSeverities = map[string]events.Severity{
+	"BackOff":          events.SeverityWarning,
+	"FailedCreate":     events.SeverityError,
+	"FailedMount":      events.SeverityWarning,
+	"FailedScheduling": events.SeverityWarning,
+	"Unhealthy":        events.SeverityWarning,
+}
+
+type owner struct {
+	release  string
+	revision int
+	ok       bool
+}
+
+type occurrence struct {
+	count     int32
+	published time.Time
+}
+
+// EventWatcher correlates Kubernetes events with the release and revision
+// which own the involved object, and publishes them.  Kubernetes reports a
+// recurring event by updating its count; a recurrence is published at most
+// once every RepeatInterval.
+type EventWatcher struct {
+	log      logr.Logger
+	sink     events.Sink
+	resolver Resolver
+
+	// RepeatInterval is the least time between publishing recurrences of an
+	// event
+	RepeatInterval time.Duration
+
+	since time.Time
+	now   func() time.Time
+
+	mu     sync.Mutex
+	seen   map[types.UID]occurrence
+	owners map[types.UID]owner
+}
+
+var _ cache.ResourceEventHandler = &EventWatcher{}
+
+// NewEventWatcher returns a new EventWatcher.  Events last observed before
+// the EventWatcher was created are ignored.
+func NewEventWatcher(log logr.Logger, sink events.Sink, resolver Resolver) *EventWatcher {
+	return &EventWatcher{
+		log:            log,
+		sink:           sink,
+		resolver:       resolver,
+		RepeatInterval: DefaultRepeatInterval,
+		since:          time.Now(),
+		now:            time.Now,
+		seen:           map[types.UID]occurrence{},
+		owners:         map[types.UID]owner{},
+	}
+}
+
+// OnAdd satisfies the cache.ResourceEventHandler interface
+func (w *EventWatcher) OnAdd(obj interface{}) {
+	if e, ok := obj.(*v1.Event); ok {
+		w.handle(e)
+	}
+}
+
+// OnUpdate satisfies the cache.ResourceEventHandler interface
+func (w *EventWatcher) OnUpdate(oldObj interface{}, newObj interface{}) {
+	oldE, ok := oldObj.(*v1.Event)
+	if !ok {
+		return
+	}
+	newE, ok := newObj.(*v1.Event)
+	if !ok {
+		return
+	}
+
+	if oldE.ResourceVersion == newE.ResourceVersion {
+		return
+	}
+
+	w.handle(newE)
+}
+
+// OnDelete satisfies the cache.ResourceEventHandler interface
+func (w *EventWatcher) OnDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	e, ok := obj.(*v1.Event)
+	if !ok {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.seen, e.UID)
+}
+
+func (w *EventWatcher) handle(e *v1.Event) {
+	severity, ok := eventSeverities[e.Reason]
+	if !ok {
+		return
+	}
+
+	last := lastObserved(e)
+	if last.Before(w.since) {
+		return
+	}
+
+	o := w.owner(e.InvolvedObject)
+	if !o.ok {
+		return
+	}
+
+	count := countOf(e)
+	if !w.record(e.UID, count) {
+		return
+	}
+
+	w.log.V(1).Info("event", "name", e.InvolvedObject.Name, "namespace", e.InvolvedObject.Namespace, "release", o.release, "revision", o.revision, "reason", e.Reason, "count", count)
+	w.sink.Publish(events.Event{
+		Time:      last,
+		Namespace: e.InvolvedObject.Namespace,
+		Release:   o.release,
+		Revision:  o.revision,
+		Type:      events.TypeProgressing,
+		Severity:  severity,
+		Reason:    e.Reason,
+		Message:   e.Message,
+		Object:    e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name,
+		Count:     int(count),
+	})
+}
+
+// record reports whether an occurrence of the event should be published,
+// and if so, records that it was
+func (w *EventWatcher) record(uid types.UID, count int32) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	if prev, ok := w.seen[uid]; ok {
+		if count <= prev.count || now.Sub(prev.published) < w.RepeatInterval {
+			return false
+		}
+	}
+	w.seen[uid] = occurrence{count: count, published: now}
+	return true
+}
+
+// owner returns the release and revision of the involved object.  Results
+// are cached, including for objects which do not belong to a release.
+func (w *EventWatcher) owner(ref v1.ObjectReference) owner {
+	w.mu.Lock()
+	o, ok := w.owners[ref.UID]
+	w.mu.Unlock()
+	if ok {
+		return o
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	lbls, err := w.resolver.Labels(ctx, ref)
+	if err != nil {
+		w.log.Error(err, "failed to resolve involved object", "kind", ref.Kind, "name", ref.Name, "namespace", ref.Namespace)
+		return owner{}
+	}
+	o.release, o.revision, o.ok = releaseFromLabels(lbls)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.owners) >= maxOwners {
+		w.owners = map[types.UID]owner{}
+	}
+	w.owners[ref.UID] = o
+	return o
+}
+
+func lastObserved(e *v1.Event) time.Time {
+	switch {
+	case e.Series != nil && !e.Series.LastObservedTime.IsZero():
+		return e.Series.LastObservedTime.Time
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+func countOf(e *v1.Event) int32 {
+	count := e.Count
+	if e.Series != nil && e.Series.Count > count {
+		count = e.Series.Count
+	}
+	if count < 1 {
+		count = 1
+	}
+	return count
+}
@@ -0,0 +1,139 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_EventWatcher(t *testing.T) {
+	start := time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		name     string
+		events   []*v1.Event
+		elapsed  time.Duration
+		expected []int
+	}{
+		{
+			name:     "single",
+			events:   []*v1.Event{event("1", "FailedScheduling", "replicaset-pod", 1, start)},
+			expected: []int{1},
+		},
+		{
+			name:   "unknown-reason",
+			events: []*v1.Event{event("1", "Pulled", "replicaset-pod", 1, start)},
+		},
+		{
+			name:   "unlabelled-object",
+			events: []*v1.Event{event("1", "BackOff", "stray-pod", 1, start)},
+		},
+		{
+			name:   "before-start",
+			events: []*v1.Event{event("1", "BackOff", "replicaset-pod", 1, start.Add(-time.Hour))},
+		},
+		{
+			name: "recurrence-suppressed",
+			events: []*v1.Event{
+				event("1", "BackOff", "replicaset-pod", 1, start),
+				event("2", "BackOff", "replicaset-pod", 2, start.Add(time.Minute)),
+			},
+			elapsed:  time.Minute,
+			expected: []int{1},
+		},
+		{
+			name: "recurrence-published",
+			events: []*v1.Event{
+				event("1", "BackOff", "replicaset-pod", 1, start),
+				event("2", "BackOff", "replicaset-pod", 4, start.Add(10*time.Minute)),
+			},
+			elapsed:  10 * time.Minute,
+			expected: []int{1, 4},
+		},
+		{
+			name: "series",
+			events: []*v1.Event{
+				func() *v1.Event {
+					e := event("1", "Unhealthy", "replicaset-pod", 0, time.Time{})
+					e.EventTime = metav1.NewMicroTime(start)
+					e.Series = &v1.EventSeries{Count: 7, LastObservedTime: metav1.NewMicroTime(start)}
+					return e
+				}(),
+			},
+			expected: []int{7},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var published []events.Event
+			resolved := 0
+			resolver := ResolverFunc(func(ctx context.Context, ref v1.ObjectReference) (map[string]string, error) {
+				resolved++
+				if ref.Name != "replicaset-pod" {
+					return nil, nil
+				}
+				return map[string]string{
+					constants.LabelReleaseHistory: "checkout-api",
+					constants.LabelRevision:       "3",
+				}, nil
+			})
+			w := NewEventWatcher(testlogger.TestLogger{T: t}, events.SinkFunc(func(e events.Event) {
+				published = append(published, e)
+			}), resolver)
+			w.since = start.Add(-time.Minute)
+			now := start
+			w.now = func() time.Time { return now }
+
+			w.OnAdd(tc.events[0])
+			for _, e := range tc.events[1:] {
+				now = now.Add(tc.elapsed)
+				w.OnUpdate(tc.events[0], e)
+			}
+
+			if len(published) != len(tc.expected) {
+				t.Fatalf("incorrect number of events: expected %d, actual %d: %v", len(tc.expected), len(published), published)
+			}
+			for k, e := range published {
+				if e.Count != tc.expected[k] {
+					t.Errorf("incorrect count at %d: expected %d, actual %d", k, tc.expected[k], e.Count)
+				}
+				if e.Release != "checkout-api" || e.Revision != 3 || e.Object != "Pod/replicaset-pod" {
+					t.Errorf("incorrect release mapping: %#v", e)
+				}
+			}
+			if resolved > 1 {
+				t.Errorf("involved object was resolved %d times", resolved)
+			}
+		})
+	}
+}
+
+func event(resourceVersion string, reason string, pod string, count int32, last time.Time) *v1.Event {
+	return &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            pod + ".1660d5c1b2a3",
+			Namespace:       "payments",
+			UID:             "event-uid",
+			ResourceVersion: resourceVersion,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Pod",
+			Namespace:  "payments",
+			Name:       pod,
+			UID:        "pod-uid",
+		},
+		Reason:        reason,
+		Message:       "Back-off restarting failed container",
+		Type:          v1.EventTypeWarning,
+		Count:         count,
+		LastTimestamp: metav1.NewTime(last),
+	}
+}
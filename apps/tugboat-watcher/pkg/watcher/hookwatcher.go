@@ -0,0 +1,197 @@
+package watcher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/internal/constants"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// HookWatcher follows the Helm hooks of tracked releases, i.e. Jobs and
+// pods with a `helm.sh/hook` annotation, and publishes an event when a hook
+// starts and when it completes.  Hooks run by `helm test` are reported as
+// tests, so that their results can be told apart from the hooks which run
+// during an install or upgrade.
+type HookWatcher struct {
+	log  logr.Logger
+	sink events.Sink
+
+	now func() time.Time
+}
+
+var _ cache.ResourceEventHandler = &HookWatcher{}
+
+// NewHookWatcher returns a new HookWatcher which publishes to the sink
+func NewHookWatcher(log logr.Logger, sink events.Sink) *HookWatcher {
+	return &HookWatcher{
+		log:  log,
+		sink: sink,
+		now:  time.Now,
+	}
+}
+
+// OnAdd satisfies the cache.ResourceEventHandler interface.  Hooks which
+// had already completed when the informer started are not reported again.
+func (w *HookWatcher) OnAdd(obj interface{}) {
+	h, ok := w.newHookState(obj)
+	if !ok || h.phase != events.HookRunning {
+		return
+	}
+	w.publish(h)
+}
+
+// OnUpdate satisfies the cache.ResourceEventHandler interface
+func (w *HookWatcher) OnUpdate(oldObj interface{}, newObj interface{}) {
+	oldH, ok := w.newHookState(oldObj)
+	if !ok {
+		return
+	}
+	newH, ok := w.newHookState(newObj)
+	if !ok {
+		return
+	}
+
+	if oldH.meta.ResourceVersion == newH.meta.ResourceVersion || oldH.phase == newH.phase {
+		return
+	}
+	w.publish(newH)
+}
+
+// OnDelete satisfies the cache.ResourceEventHandler interface.  Helm
+// deletes hooks according to their delete policy, which says nothing about
+// the release.
+func (w *HookWatcher) OnDelete(obj interface{}) {}
+
+func (w *HookWatcher) publish(h hookState) {
+	release, revision, ok := releaseFromLabels(h.meta.Labels)
+	if !ok {
+		return
+	}
+
+	hook := &events.Hook{
+		Events:      h.events,
+		Weight:      h.weight,
+		Phase:       h.phase,
+		StartedAt:   h.meta.CreationTimestamp.Time,
+		CompletedAt: h.completedAt,
+	}
+
+	kind := "Hook"
+	if hook.IsTest() {
+		kind = "Test"
+	}
+	reason := kind + string(h.phase)
+	severity := events.SeverityInfo
+	message := fmt.Sprintf("%s %s (weight %d)", kind, strings.Join(h.events, ","), h.weight)
+	switch h.phase {
+	case events.HookRunning:
+		reason = kind + "Started"
+		message += " started"
+	case events.HookSucceeded:
+		message += fmt.Sprintf(" succeeded after %s", hook.Duration().Round(time.Second))
+	case events.HookFailed:
+		severity = events.SeverityError
+		message += fmt.Sprintf(" failed after %s", hook.Duration().Round(time.Second))
+		if h.message != "" {
+			message += ": " + h.message
+		}
+	}
+
+	w.log.V(1).Info("hook transition", "object", h.object, "namespace", h.meta.Namespace, "release", release, "revision", revision, "reason", reason)
+	w.sink.Publish(events.Event{
+		Time:      w.now(),
+		Namespace: h.meta.Namespace,
+		Release:   release,
+		Revision:  revision,
+		Type:      events.TypeProgressing,
+		Severity:  severity,
+		Reason:    reason,
+		Message:   message,
+		Object:    h.object,
+		Hook:      hook,
+	})
+}
+
+// hookState is the part of a hook resource which is tracked for
+// transitions
+type hookState struct {
+	meta   metav1.ObjectMeta
+	object string
+
+	events []string
+	weight int
+
+	phase       events.HookPhase
+	message     string
+	completedAt time.Time
+}
+
+// newHookState returns the state of a hook Job or pod.  Other objects, and
+// Jobs and pods which are not hooks, are ignored.
+func (w *HookWatcher) newHookState(obj interface{}) (hookState, bool) {
+	var h hookState
+	switch o := obj.(type) {
+	case *batchv1.Job:
+		h = hookState{meta: o.ObjectMeta, object: "Job/" + o.Name, phase: events.HookRunning}
+		for _, c := range o.Status.Conditions {
+			if c.Status != v1.ConditionTrue {
+				continue
+			}
+			switch c.Type {
+			case batchv1.JobComplete:
+				h.phase = events.HookSucceeded
+			case batchv1.JobFailed:
+				h.phase = events.HookFailed
+				h.message = c.Message
+			default:
+				continue
+			}
+			h.completedAt = c.LastTransitionTime.Time
+		}
+	case *v1.Pod:
+		h = hookState{meta: o.ObjectMeta, object: "Pod/" + o.Name, phase: events.HookRunning}
+		switch o.Status.Phase {
+		case v1.PodSucceeded:
+			h.phase = events.HookSucceeded
+		case v1.PodFailed:
+			h.phase = events.HookFailed
+			h.message = o.Status.Message
+		}
+		if h.phase != events.HookRunning {
+			for _, cs := range o.Status.ContainerStatuses {
+				if t := cs.State.Terminated; t != nil && t.FinishedAt.After(h.completedAt) {
+					h.completedAt = t.FinishedAt.Time
+				}
+			}
+		}
+	default:
+		return hookState{}, false
+	}
+
+	annotation, ok := h.meta.Annotations[constants.HelmAnnotationHook]
+	if !ok {
+		return hookState{}, false
+	}
+	for _, e := range strings.Split(annotation, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			h.events = append(h.events, e)
+		}
+	}
+	if weight, ok := h.meta.Annotations[constants.HelmAnnotationHookWeight]; ok {
+		// Helm itself treats a malformed weight as 0.
+		h.weight, _ = strconv.Atoi(strings.TrimSpace(weight))
+	}
+	if h.phase != events.HookRunning && h.completedAt.IsZero() {
+		h.completedAt = w.now()
+	}
+
+	return h, true
+}
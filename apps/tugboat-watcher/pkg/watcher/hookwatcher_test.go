@@ -0,0 +1,159 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var hookStarted = time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC)
+
+func Test_HookWatcher_Update(t *testing.T) {
+	tcs := []struct {
+		name     string
+		before   runtime.Object
+		after    runtime.Object
+		expected string
+		severity events.Severity
+		duration time.Duration
+	}{
+		{
+			name:     "job-succeeded",
+			before:   hookJob("pre-upgrade", nil),
+			after:    hookJob("pre-upgrade", &batchv1.JobCondition{Type: batchv1.JobComplete, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(hookStarted.Add(42 * time.Second))}),
+			expected: "HookSucceeded",
+			severity: events.SeverityInfo,
+			duration: 42 * time.Second,
+		},
+		{
+			name:     "job-failed",
+			before:   hookJob("pre-upgrade,pre-install", nil),
+			after:    hookJob("pre-upgrade,pre-install", &batchv1.JobCondition{Type: batchv1.JobFailed, Status: v1.ConditionTrue, Reason: "BackoffLimitExceeded", LastTransitionTime: metav1.NewTime(hookStarted.Add(time.Minute))}),
+			expected: "HookFailed",
+			severity: events.SeverityError,
+			duration: time.Minute,
+		},
+		{
+			name:   "job-unchanged",
+			before: hookJob("pre-upgrade", nil),
+			after:  hookJob("pre-upgrade", nil),
+		},
+		{
+			name:     "test-failed",
+			before:   hookPod("test", v1.PodRunning),
+			after:    hookPod("test", v1.PodFailed),
+			expected: "TestFailed",
+			severity: events.SeverityError,
+			duration: 5 * time.Second,
+		},
+		{
+			name:     "test-succeeded",
+			before:   hookPod("test-success", v1.PodRunning),
+			after:    hookPod("test-success", v1.PodSucceeded),
+			expected: "TestSucceeded",
+			severity: events.SeverityInfo,
+			duration: 5 * time.Second,
+		},
+		{
+			name:   "not-a-hook",
+			before: hookPod("", v1.PodRunning),
+			after:  hookPod("", v1.PodFailed),
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var published []events.Event
+			w := NewHookWatcher(testlogger.TestLogger{T: t}, events.SinkFunc(func(e events.Event) {
+				published = append(published, e)
+			}))
+
+			after := tc.after.DeepCopyObject()
+			after.(metav1.Object).SetResourceVersion("2")
+			w.OnUpdate(tc.before, after)
+
+			if tc.expected == "" {
+				if len(published) != 0 {
+					t.Errorf("expected no events; got %v", published)
+				}
+				return
+			}
+			if len(published) != 1 {
+				t.Fatalf("expected a single event; got %v", published)
+			}
+			e := published[0]
+			if e.Reason != tc.expected || e.Severity != tc.severity {
+				t.Errorf("incorrect event: %s, %s", e.Reason, e.Severity)
+			}
+			if e.Release != "checkout-api" || e.Revision != 3 {
+				t.Errorf("incorrect release mapping: %#v", e)
+			}
+			if e.Hook == nil || e.Hook.Weight != -5 || e.Hook.Duration() != tc.duration {
+				t.Errorf("incorrect hook: %#v", e.Hook)
+			}
+		})
+	}
+}
+
+func Test_HookWatcher_Add(t *testing.T) {
+	var published []events.Event
+	w := NewHookWatcher(testlogger.TestLogger{T: t}, events.SinkFunc(func(e events.Event) {
+		published = append(published, e)
+	}))
+
+	w.OnAdd(hookJob("post-install", &batchv1.JobCondition{Type: batchv1.JobComplete, Status: v1.ConditionTrue}))
+	if len(published) != 0 {
+		t.Errorf("completed hook should not be reported; got %v", published)
+	}
+
+	w.OnAdd(hookPod("test", v1.PodPending))
+	if len(published) != 1 || published[0].Reason != "TestStarted" || !published[0].Hook.IsTest() {
+		t.Errorf("incorrect events: %v", published)
+	}
+}
+
+func hookMeta(name string, hook string) metav1.ObjectMeta {
+	m := metav1.ObjectMeta{
+		Name:              name,
+		Namespace:         "payments",
+		ResourceVersion:   "1",
+		CreationTimestamp: metav1.NewTime(hookStarted),
+		Labels: map[string]string{
+			constants.LabelReleaseHistory: "checkout-api",
+			constants.LabelRevision:       "3",
+		},
+	}
+	if hook != "" {
+		m.Annotations = map[string]string{
+			constants.HelmAnnotationHook:       hook,
+			constants.HelmAnnotationHookWeight: "-5",
+		}
+	}
+	return m
+}
+
+func hookJob(hook string, c *batchv1.JobCondition) *batchv1.Job {
+	j := &batchv1.Job{ObjectMeta: hookMeta("migrate", hook)}
+	if c != nil {
+		j.Status.Conditions = []batchv1.JobCondition{*c}
+	}
+	return j
+}
+
+func hookPod(hook string, phase v1.PodPhase) *v1.Pod {
+	p := &v1.Pod{ObjectMeta: hookMeta("checkout-api-test", hook)}
+	p.Status.Phase = phase
+	if phase == v1.PodSucceeded || phase == v1.PodFailed {
+		p.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "test", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+			FinishedAt: metav1.NewTime(hookStarted.Add(5 * time.Second)),
+		}}}}
+	}
+	return p
+}
@@ -2,102 +2,302 @@ package watcher
 
 import (
 	"fmt"
-	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/internal/constants"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/serializer"
-	"k8s.io/apimachinery/pkg/util/strategicpatch"
-	"k8s.io/client-go/informers"
-	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
 
+// waitingSeverities ranks the reasons a container may be waiting.  Reasons
+// which are not listed are reported as warnings; reasons which are part of
+// every container's normal start-up are not reported at all.
+var waitingSeverities = map[string]events.Severity{
+	"ContainerCreating": 0,
+	"PodInitializing":   0,
+
+	"CrashLoopBackOff":           events.SeverityError,
+	"CreateContainerConfigError": events.SeverityError,
+	"CreateContainerError":       events.SeverityError,
+	"ErrImagePull":               events.SeverityError,
+	"ImagePullBackOff":           events.SeverityError,
+	"InvalidImageName":           events.SeverityError,
+	"RunContainerError":          events.SeverityError,
+}
+
+// Diagnoser collects diagnostics for a failed container
+type Diagnoser interface {
+	Diagnose(p *v1.Pod, container string, release string, revision int)
+}
+
+// PodWatcher follows the pods of tracked releases through their lifecycle,
+// and publishes an event each time a pod or one of its containers changes
+// state.  Pods are mapped to a revision of a release by the labels that the
+// mutating webhook applies.
 type PodWatcher struct {
-	log       logr.Logger
-	clientset *kubernetes.Clientset
+	log  logr.Logger
+	sink events.Sink
 
-	encoder runtime.Encoder
+	// Diagnoser, if set, is asked to diagnose containers which crash loop or
+	// terminate with a non-zero exit code
+	Diagnoser Diagnoser
+
+	now func() time.Time
 }
 
-func NewPodWatcher(log logr.Logger, clientset *kubernetes.Clientset) *PodWatcher {
-	s := runtime.NewScheme()
-	v1.AddToScheme(s)
+var _ cache.ResourceEventHandler = &PodWatcher{}
 
-	codecs := serializer.NewCodecFactory(s)
-	enc := unstructured.NewJSONFallbackEncoder(codecs.LegacyCodec(s.PrioritizedVersionsAllGroups()...))
+// NewPodWatcher returns a new PodWatcher which publishes to the sink
+func NewPodWatcher(log logr.Logger, sink events.Sink) *PodWatcher {
 	return &PodWatcher{
-		log:       log,
-		clientset: clientset,
-		encoder:   enc,
+		log:  log,
+		sink: sink,
+		now:  time.Now,
+	}
+}
+
+// OnAdd satisfies the cache.ResourceEventHandler interface.  Only newly
+// created pods are reported; pods which already existed when the informer
+// started are the baseline for later transitions.
+func (w *PodWatcher) OnAdd(obj interface{}) {
+	p, ok := obj.(*v1.Pod)
+	if !ok {
+		return
+	}
+	if (p.Status.Phase != "" && p.Status.Phase != v1.PodPending) || len(p.Status.ContainerStatuses) != 0 {
+		return
+	}
+
+	w.publish(p, []transition{{severity: events.SeverityInfo, reason: "PodCreated", message: "Pod was created"}})
+}
+
+// OnUpdate satisfies the cache.ResourceEventHandler interface
+func (w *PodWatcher) OnUpdate(oldObj interface{}, newObj interface{}) {
+	oldP, ok := oldObj.(*v1.Pod)
+	if !ok {
+		return
 	}
+	newP, ok := newObj.(*v1.Pod)
+	if !ok {
+		return
+	}
+
+	if oldP.ResourceVersion == newP.ResourceVersion {
+		return
+	}
+
+	w.publish(newP, transitions(newPodState(oldP), newPodState(newP)))
 }
 
-func (w *PodWatcher) GetInformer() cache.SharedIndexInformer {
-	factory := informers.NewSharedInformerFactory(w.clientset, 10*time.Second)
+// OnDelete satisfies the cache.ResourceEventHandler interface
+func (w *PodWatcher) OnDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	p, ok := obj.(*v1.Pod)
+	if !ok {
+		return
+	}
 
-	// factory.Discovery().V1beta1().EndpointSlices().Informer()
-	informer := factory.Core().V1().Pods().Informer()
+	w.publish(p, []transition{{severity: events.SeverityInfo, reason: "PodDeleted", message: "Pod was deleted"}})
+}
+
+func (w *PodWatcher) publish(p *v1.Pod, ts []transition) {
+	if len(ts) == 0 {
+		return
+	}
+
+	release, revision, ok := releaseFromLabels(p.GetLabels())
+	if !ok {
+		return
+	}
 
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    w.added,
-		UpdateFunc: w.updated,
-		DeleteFunc: w.deleted,
-	})
+	now := w.now()
+	for _, t := range ts {
+		w.log.V(1).Info("pod transition", "name", p.Name, "namespace", p.Namespace, "release", release, "revision", revision, "reason", t.reason)
+		w.sink.Publish(events.Event{
+			Time:      now,
+			Namespace: p.Namespace,
+			Release:   release,
+			Revision:  revision,
+			Type:      events.TypeProgressing,
+			Severity:  t.severity,
+			Reason:    t.reason,
+			Message:   t.message,
+			Object:    "Pod/" + p.Name,
+		})
 
-	return informer
+		if t.diagnose != "" && w.Diagnoser != nil {
+			w.Diagnoser.Diagnose(p.DeepCopy(), t.diagnose, release, revision)
+		}
+	}
 }
 
-func (w *PodWatcher) added(obj interface{}) {
-	if p, ok := obj.(*v1.Pod); ok {
-		w.log.Info("added pod", "name", p.Name)
+// releaseFromLabels returns the release and revision that created an
+// object.  An object without a release label is not part of a tracked
+// release.
+func releaseFromLabels(lbls map[string]string) (string, int, bool) {
+	release := lbls[constants.LabelReleaseHistory]
+	if release == "" {
+		return "", 0, false
 	}
+	revision, _ := strconv.Atoi(lbls[constants.LabelRevision])
+	return release, revision, true
 }
 
-func (w *PodWatcher) updated(oldObj interface{}, newObj interface{}) {
-	oldP, ok0 := w.castToPod(oldObj)
-	newP, ok1 := w.castToPod(newObj)
-	if ok0 && ok1 {
-		// Reference material for comparison
-		// https://stackoverflow.com/questions/47100389/what-is-the-difference-between-a-resourceversion-and-a-generation
-		if oldP.ObjectMeta.ResourceVersion == newP.ObjectMeta.ResourceVersion {
-			return
+// podState is the part of a pod's status which is tracked for transitions
+type podState struct {
+	phase   v1.PodPhase
+	reason  string
+	message string
+	ready   bool
+
+	containers []containerState
+}
+
+type containerState struct {
+	name     string
+	restarts int32
+
+	waiting string
+
+	terminated bool
+	reason     string
+	exitCode   int32
+
+	// lastReason and lastExitCode describe the previous termination, if the
+	// container has restarted
+	lastReason   string
+	lastExitCode int32
+}
+
+func newPodState(p *v1.Pod) podState {
+	s := podState{
+		phase:   p.Status.Phase,
+		reason:  p.Status.Reason,
+		message: p.Status.Message,
+	}
+	for _, c := range p.Status.Conditions {
+		if c.Type == v1.PodReady {
+			s.ready = c.Status == v1.ConditionTrue
 		}
+	}
 
-		before, err := runtime.Encode(w.encoder, oldP)
-		if err != nil {
-			w.log.Error(err, "failed to encode old pod")
-			return
+	statuses := make([]v1.ContainerStatus, 0, len(p.Status.InitContainerStatuses)+len(p.Status.ContainerStatuses))
+	statuses = append(statuses, p.Status.InitContainerStatuses...)
+	statuses = append(statuses, p.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		c := containerState{
+			name:     cs.Name,
+			restarts: cs.RestartCount,
+		}
+		if w := cs.State.Waiting; w != nil {
+			c.waiting = w.Reason
 		}
-		after, err := runtime.Encode(w.encoder, newP)
-		if err != nil {
-			w.log.Error(err, "failed to encode new pod")
-			return
+		if t := cs.State.Terminated; t != nil {
+			c.terminated = true
+			c.reason = t.Reason
+			c.exitCode = t.ExitCode
 		}
-		buf, err := strategicpatch.CreateTwoWayMergePatch(before, after, newP)
-		if err != nil {
-			w.log.Error(err, "failed to generate patch")
-			return
+		if t := cs.LastTerminationState.Terminated; t != nil {
+			c.lastReason = t.Reason
+			c.lastExitCode = t.ExitCode
 		}
+		s.containers = append(s.containers, c)
+	}
+
+	return s
+}
 
-		w.log.Info("updated pod", "name", newP.Name, "patch", string(buf))
+func (s podState) container(name string) (containerState, bool) {
+	for _, c := range s.containers {
+		if c.name == name {
+			return c, true
+		}
 	}
+	return containerState{}, false
+}
+
+type transition struct {
+	severity events.Severity
+	reason   string
+	message  string
+
+	// diagnose is the name of a failed container to diagnose, if any
+	diagnose string
 }
 
-func (w *PodWatcher) deleted(obj interface{}) {
-	if p, ok := obj.(*v1.Pod); ok {
-		w.log.Info("deleted pod", "name", p.Name)
+// transitions returns the changes between two states of the same pod
+func transitions(before podState, after podState) []transition {
+	ts := []transition{}
+
+	if before.phase != after.phase {
+		switch after.phase {
+		case v1.PodRunning:
+			ts = append(ts, transition{severity: events.SeverityInfo, reason: "PodRunning", message: "Pod is running"})
+		case v1.PodSucceeded:
+			ts = append(ts, transition{severity: events.SeverityInfo, reason: "PodSucceeded", message: "Pod completed successfully"})
+		case v1.PodFailed:
+			ts = append(ts, transition{severity: events.SeverityError, reason: "PodFailed", message: describe("Pod failed", after.reason, after.message)})
+		}
 	}
+
+	for _, c := range after.containers {
+		prev, _ := before.container(c.name)
+
+		if c.waiting != "" && c.waiting != prev.waiting {
+			severity, ok := waitingSeverities[c.waiting]
+			if !ok {
+				severity = events.SeverityWarning
+			}
+			if severity != 0 {
+				t := transition{severity: severity, reason: c.waiting, message: fmt.Sprintf("Container %s is waiting: %s", c.name, c.waiting)}
+				if c.waiting == "CrashLoopBackOff" {
+					t.diagnose = c.name
+				}
+				ts = append(ts, t)
+			}
+		}
+
+		if c.restarts > prev.restarts {
+			message := fmt.Sprintf("Container %s restarted (%d restarts)", c.name, c.restarts)
+			if c.lastReason != "" {
+				message = fmt.Sprintf("%s after %s with exit code %d", message, c.lastReason, c.lastExitCode)
+			}
+			ts = append(ts, transition{severity: events.SeverityWarning, reason: "ContainerRestarted", message: message})
+		}
+
+		if c.terminated && c.exitCode != 0 && (!prev.terminated || prev.exitCode != c.exitCode || c.restarts != prev.restarts) {
+			reason := "ContainerFailed"
+			if c.reason == "OOMKilled" {
+				reason = c.reason
+			}
+			ts = append(ts, transition{severity: events.SeverityError, reason: reason, message: describe(fmt.Sprintf("Container %s terminated with exit code %d", c.name, c.exitCode), c.reason, ""), diagnose: c.name})
+		}
+	}
+
+	if before.ready != after.ready {
+		if after.ready {
+			ts = append(ts, transition{severity: events.SeverityInfo, reason: "PodReady", message: "Pod is ready"})
+		} else if after.phase == v1.PodRunning {
+			ts = append(ts, transition{severity: events.SeverityWarning, reason: "PodNotReady", message: "Pod is no longer ready"})
+		}
+	}
+
+	return ts
 }
 
-func (w *PodWatcher) castToPod(obj interface{}) (*v1.Pod, bool) {
-	p, ok := obj.(*v1.Pod)
-	if !ok {
-		w.log.Error(fmt.Errorf("PodWatcher received unexpected type"), "blerg", "type", reflect.TypeOf(obj).String())
-		return nil, false
+func describe(summary string, reason string, message string) string {
+	switch {
+	case reason != "" && message != "":
+		return fmt.Sprintf("%s: %s: %s", summary, reason, message)
+	case reason != "":
+		return fmt.Sprintf("%s: %s", summary, reason)
+	case message != "":
+		return fmt.Sprintf("%s: %s", summary, message)
 	}
-	return p, true
+	return summary
 }
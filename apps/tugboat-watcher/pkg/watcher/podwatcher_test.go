@@ -0,0 +1,245 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/object88/tugboat/apps/tugboat-watcher/pkg/events"
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func Test_PodWatcher_Update(t *testing.T) {
+	tcs := []struct {
+		name     string
+		before   func(p *v1.Pod)
+		after    func(p *v1.Pod)
+		expected []string
+	}{
+		{
+			name:   "running-and-ready",
+			before: pending,
+			after: func(p *v1.Pod) {
+				running(p)
+				ready(p, true)
+			},
+			expected: []string{"PodRunning", "PodReady"},
+		},
+		{
+			name:   "image-pull",
+			before: pending,
+			after: func(p *v1.Pod) {
+				waiting(p, "ErrImagePull")
+			},
+			expected: []string{"ErrImagePull"},
+		},
+		{
+			name: "container-creating",
+			before: func(p *v1.Pod) {
+				pending(p)
+				waiting(p, "PodInitializing")
+			},
+			after: func(p *v1.Pod) {
+				waiting(p, "ContainerCreating")
+			},
+		},
+		{
+			name: "crashloop",
+			before: func(p *v1.Pod) {
+				running(p)
+				ready(p, true)
+			},
+			after: func(p *v1.Pod) {
+				running(p)
+				ready(p, false)
+				waiting(p, "CrashLoopBackOff")
+				p.Status.ContainerStatuses[0].RestartCount = 1
+				p.Status.ContainerStatuses[0].LastTerminationState.Terminated = &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}
+			},
+			expected: []string{"CrashLoopBackOff", "ContainerRestarted", "PodNotReady"},
+		},
+		{
+			name:   "oomkilled",
+			before: running,
+			after: func(p *v1.Pod) {
+				running(p)
+				p.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "app", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}}}}
+			},
+			expected: []string{"OOMKilled"},
+		},
+		{
+			name:   "completed",
+			before: running,
+			after: func(p *v1.Pod) {
+				p.Status.Phase = v1.PodSucceeded
+				p.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "app", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Completed"}}}}
+			},
+			expected: []string{"PodSucceeded"},
+		},
+		{
+			name:   "evicted",
+			before: running,
+			after: func(p *v1.Pod) {
+				p.Status.Phase = v1.PodFailed
+				p.Status.Reason = "Evicted"
+			},
+			expected: []string{"PodFailed"},
+		},
+		{
+			name:   "unlabelled",
+			before: pending,
+			after: func(p *v1.Pod) {
+				p.Labels = nil
+				running(p)
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var published []events.Event
+			w := NewPodWatcher(testlogger.TestLogger{T: t}, events.SinkFunc(func(e events.Event) {
+				published = append(published, e)
+			}))
+
+			before := pod("1")
+			tc.before(before)
+			after := before.DeepCopy()
+			after.ResourceVersion = "2"
+			tc.after(after)
+
+			w.OnUpdate(before, after)
+
+			if len(published) != len(tc.expected) {
+				t.Fatalf("incorrect number of events: expected %d, actual %d: %v", len(tc.expected), len(published), published)
+			}
+			for k, e := range published {
+				if e.Reason != tc.expected[k] {
+					t.Errorf("incorrect reason at %d: expected '%s', actual '%s'", k, tc.expected[k], e.Reason)
+				}
+				if e.Release != "checkout-api" || e.Revision != 3 || e.Namespace != "payments" || e.Object != "Pod/checkout-api-abc12" {
+					t.Errorf("incorrect release mapping: %#v", e)
+				}
+				if e.Type != events.TypeProgressing {
+					t.Errorf("incorrect type '%s'", e.Type)
+				}
+			}
+		})
+	}
+}
+
+type diagnoser []string
+
+func (d *diagnoser) Diagnose(p *v1.Pod, container string, release string, revision int) {
+	*d = append(*d, container)
+}
+
+func Test_PodWatcher_Diagnose(t *testing.T) {
+	tcs := []struct {
+		name     string
+		after    func(p *v1.Pod)
+		expected int
+	}{
+		{
+			name: "crashloop",
+			after: func(p *v1.Pod) {
+				waiting(p, "CrashLoopBackOff")
+			},
+			expected: 1,
+		},
+		{
+			name: "image-pull",
+			after: func(p *v1.Pod) {
+				waiting(p, "ImagePullBackOff")
+			},
+		},
+		{
+			name: "failed",
+			after: func(p *v1.Pod) {
+				p.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "app", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}}}}
+			},
+			expected: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			d := diagnoser{}
+			w := NewPodWatcher(testlogger.TestLogger{T: t}, events.SinkFunc(func(events.Event) {}))
+			w.Diagnoser = &d
+
+			before := pod("1")
+			running(before)
+			after := before.DeepCopy()
+			after.ResourceVersion = "2"
+			tc.after(after)
+
+			w.OnUpdate(before, after)
+			if len(d) != tc.expected {
+				t.Errorf("incorrect number of diagnoses: expected %d, actual %d", tc.expected, len(d))
+			}
+		})
+	}
+}
+
+func Test_PodWatcher_AddDelete(t *testing.T) {
+	var published []events.Event
+	w := NewPodWatcher(testlogger.TestLogger{T: t}, events.SinkFunc(func(e events.Event) {
+		published = append(published, e)
+	}))
+
+	existing := pod("1")
+	running(existing)
+	w.OnAdd(existing)
+	if len(published) != 0 {
+		t.Errorf("existing pod should be the baseline; got %v", published)
+	}
+
+	created := pod("1")
+	pending(created)
+	w.OnAdd(created)
+	w.OnDelete(cache.DeletedFinalStateUnknown{Key: "payments/checkout-api-abc12", Obj: created})
+	if len(published) != 2 || published[0].Reason != "PodCreated" || published[1].Reason != "PodDeleted" {
+		t.Errorf("incorrect events: %v", published)
+	}
+}
+
+func pod(resourceVersion string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "checkout-api-abc12",
+			Namespace:       "payments",
+			ResourceVersion: resourceVersion,
+			Labels: map[string]string{
+				constants.LabelReleaseHistory: "checkout-api",
+				constants.LabelRevision:       "3",
+			},
+		},
+	}
+}
+
+func pending(p *v1.Pod) {
+	p.Status.Phase = v1.PodPending
+}
+
+func running(p *v1.Pod) {
+	p.Status.Phase = v1.PodRunning
+	p.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "app", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}}
+}
+
+func ready(p *v1.Pod, ready bool) {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	p.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: status}}
+	for k := range p.Status.ContainerStatuses {
+		p.Status.ContainerStatuses[k].Ready = ready
+	}
+}
+
+func waiting(p *v1.Pod, reason string) {
+	p.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "app", State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: reason}}}}
+}
@@ -0,0 +1,75 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	listercorev1 "k8s.io/client-go/listers/core/v1"
+)
+
+// Resolver finds the labels of the object that an event involves
+type Resolver interface {
+	// Labels returns the labels of the referenced object, or nil if the
+	// object no longer exists
+	Labels(ctx context.Context, ref v1.ObjectReference) (map[string]string, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface
+type ResolverFunc func(ctx context.Context, ref v1.ObjectReference) (map[string]string, error)
+
+// Labels satisfies the Resolver interface
+func (f ResolverFunc) Labels(ctx context.Context, ref v1.ObjectReference) (map[string]string, error) {
+	return f(ctx, ref)
+}
+
+// ObjectResolver reads pods from the pod informer's cache, which holds every
+// pod that belongs to a release, and fetches any other kind of object from
+// the API server
+type ObjectResolver struct {
+	pods   listercorev1.PodLister
+	dyn    dynamic.Interface
+	mapper meta.RESTMapper
+}
+
+var _ Resolver = &ObjectResolver{}
+
+// NewObjectResolver returns a new ObjectResolver
+func NewObjectResolver(pods listercorev1.PodLister, dyn dynamic.Interface, mapper meta.RESTMapper) *ObjectResolver {
+	return &ObjectResolver{
+		pods:   pods,
+		dyn:    dyn,
+		mapper: mapper,
+	}
+}
+
+// Labels satisfies the Resolver interface
+func (r *ObjectResolver) Labels(ctx context.Context, ref v1.ObjectReference) (map[string]string, error) {
+	gvk := schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind)
+	if gvk.Group == "" && gvk.Kind == "Pod" {
+		p, err := r.pods.Pods(ref.Namespace).Get(ref.Name)
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return p.GetLabels(), nil
+	}
+
+	mapping, err := r.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mapping for '%s': %w", gvk.String(), err)
+	}
+	u, err := r.dyn.Resource(mapping.Resource).Namespace(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get %s '%s': %w", gvk.Kind, ref.Name, err)
+	}
+	return u.GetLabels(), nil
+}
@@ -129,6 +129,16 @@ if [[ $DO_VERIFY == "true" ]]; then
   # returns non-zero if this doesn't verify out
   time go mod verify
   echo ""
+
+  if ! command -v controller-gen >/dev/null 2>&1; then
+    echo "Missing prerequisite controller-gen, required to verify CRDs:"
+    echo "  GO111MODULE=on go get sigs.k8s.io/controller-tools/cmd/controller-gen@v0.4.1"
+    exit 1
+  fi
+
+  echo "Verifying CRDs"
+  ./build_tools/crd-gen.sh --verify
+  echo ""
 fi
 
 if [[ $DO_VET == "true" ]]; then
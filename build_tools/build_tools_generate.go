@@ -6,3 +6,5 @@ package tools
 //go:generate go build -o ../bin/protoc-gen-go-grpc ../vendor/google.golang.org/grpc/cmd/protoc-gen-go-grpc
 //go:generate mkdir -p ../internal/generated/notifier
 //go:generate protoc --proto_path=../internal/proto/notifier --go_opt=paths=source_relative --go_out=../internal/generated/notifier --go-grpc_opt=paths=source_relative --go-grpc_out=../internal/generated/notifier ../internal/proto/notifier/notify.proto
+//go:generate mkdir -p ../internal/generated/deployments
+//go:generate protoc --proto_path=../internal/proto/deployments --go_opt=paths=source_relative --go_out=../internal/generated/deployments --go-grpc_opt=paths=source_relative --go-grpc_out=../internal/generated/deployments ../internal/proto/deployments/deployments.proto
@@ -0,0 +1,76 @@
+#!/usr/bin/env bash
+
+# Generates the CustomResourceDefinitions in the chart from the kubebuilder
+# markers of the types in pkg/k8s/apis.  With --verify, fails if the chart's
+# CRDs differ from the generated ones instead of writing them.
+#
+# Requires controller-gen:
+#   GO111MODULE=on go get sigs.k8s.io/controller-tools/cmd/controller-gen@v0.4.1
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+SCRIPT_ROOT=$(dirname ${BASH_SOURCE})/..
+CONTROLLER_GEN=${CONTROLLER_GEN:-controller-gen}
+CRD_DIR=charts/tugboat/templates/controller/crds
+
+cd ${SCRIPT_ROOT}
+
+VERIFY="false"
+if [[ "${1:-}" == "--verify" ]]; then
+  VERIFY="true"
+fi
+
+TEMPDIR=$(mktemp -d)
+trap "rm -rf ${TEMPDIR}" EXIT
+
+${CONTROLLER_GEN} crd:crdVersions=v1 paths=./pkg/k8s/apis/... output:crd:artifacts:config=${TEMPDIR}/generated
+
+echo "generation complete"
+
+mkdir -p ${TEMPDIR}/chart
+for GENERATED in ${TEMPDIR}/generated/*.yaml; do
+  SINGULAR=$(sed -n 's/^    singular: //p' ${GENERATED})
+  PLURAL=$(sed -n 's/^    plural: //p' ${GENERATED})
+
+  # Replace the controller-gen annotation with the chart's labels, and point
+  # the ReleaseHistory CRD at the controller's conversion webhook
+  awk -v plural="${PLURAL}" '
+    /^  annotations:$/ { next }
+    /^    controller-gen.kubebuilder.io\/version:/ { next }
+    /^  name: / {
+      print
+      print "  labels:"
+      print "    {{- include \"tugboat.labels\" . | nindent 4 }}"
+      print "    {{- include \"tugboat-controller.labels\" . | nindent 4 }}"
+      next
+    }
+    /^spec:$/ && plural == "releasehistories" {
+      print
+      print "  conversion:"
+      print "    strategy: Webhook"
+      print "    webhook:"
+      print "      conversionReviewVersions: [\"v1\"]"
+      print "      clientConfig:"
+      print "        service:"
+      print "          name: {{ include \"tugboat.fullname\" . }}-controller"
+      print "          namespace: {{ .Release.Namespace }}"
+      print "          path: /v1/api/convert"
+      print "        caBundle: {{ .Values.network.caBundle }}"
+      next
+    }
+    { print }
+  ' ${GENERATED} > ${TEMPDIR}/chart/${SINGULAR}.yaml
+done
+
+if [[ ${VERIFY} == "true" ]]; then
+  if ! diff -r ${TEMPDIR}/chart ${CRD_DIR}; then
+    echo "CRDs in ${CRD_DIR} are out of date; run build_tools/crd-gen.sh"
+    exit 1
+  fi
+  echo "CRDs are up to date"
+  exit 0
+fi
+
+cp ${TEMPDIR}/chart/*.yaml ${CRD_DIR}/
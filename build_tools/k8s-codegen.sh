@@ -12,7 +12,7 @@ TEMPDIR=$(mktemp -d)
 bash vendor/k8s.io/code-generator/generate-groups.sh all \
   github.com/object88/tugboat/pkg/k8s/client \
   github.com/object88/tugboat/pkg/k8s/apis \
-  engineering.tugboat:v1alpha1 \
+  engineering.tugboat:v1alpha1,v1beta1 \
   --go-header-file ${SCRIPT_ROOT}/build_tools/custom-boilerplate.go.txt \
   --output-base ${TEMPDIR}
 
@@ -76,3 +76,59 @@ app.kubernetes.io/instance: {{ .Release.Name }}
     {{- "" -}}
   {{- end -}}
 {{- end -}}
+
+{{/*
+Environment for gRPC servers (the notifiers), from .Values.grpc
+*/}}
+{{- define "tugboat.grpcServerEnv" -}}
+{{- if eq .Values.grpc.auth "tokenreview" }}
+- name: TUGBOAT_GRPC_AUTH
+  value: tokenreview
+- name: TUGBOAT_GRPC_AUTH_AUDIENCES
+  value: {{ .Values.grpc.audience | quote }}
+- name: TUGBOAT_GRPC_AUTH_ALLOWED_USERS
+  value: "system:serviceaccount:{{ .Release.Namespace }}:{{ include "tugboat-watcher.serviceAccountName" . }}"
+{{- end }}
+{{- include "tugboat.grpcTLSEnv" . }}
+{{- end }}
+
+{{/*
+Environment for gRPC clients (the watcher), from .Values.grpc
+*/}}
+{{- define "tugboat.grpcClientEnv" -}}
+{{- if eq .Values.grpc.auth "tokenreview" }}
+- name: TUGBOAT_GRPC_TOKEN_FILE
+  value: /var/run/secrets/tugboat/grpc-token/token
+{{- end }}
+{{- include "tugboat.grpcTLSEnv" . }}
+{{- end }}
+
+{{- define "tugboat.grpcTLSEnv" -}}
+{{- if .Values.grpc.tls.secretName }}
+- name: TUGBOAT_GRPC_TLS_CERT
+  value: /etc/tugboat/grpc-tls/tls.crt
+- name: TUGBOAT_GRPC_TLS_KEY
+  value: /etc/tugboat/grpc-tls/tls.key
+- name: TUGBOAT_GRPC_TLS_CA
+  value: /etc/tugboat/grpc-tls/ca.crt
+{{- end }}
+{{- end }}
+
+{{/*
+Volume mounts for gRPC TLS certificates and ServiceAccount tokens
+*/}}
+{{- define "tugboat.grpcVolumeMounts" -}}
+{{- if .Values.grpc.tls.secretName }}
+- name: grpc-tls
+  mountPath: /etc/tugboat/grpc-tls
+  readOnly: true
+{{- end }}
+{{- end }}
+
+{{- define "tugboat.grpcVolumes" -}}
+{{- if .Values.grpc.tls.secretName }}
+- name: grpc-tls
+  secret:
+    secretName: {{ .Values.grpc.tls.secretName }}
+{{- end }}
+{{- end }}
@@ -0,0 +1,24 @@
+{{/*
+Create the name of the service account to use
+*/}}
+{{- define "tugboat-notifier-email.serviceAccountName" -}}
+  {{- if .Values.tugboatNotifierEmail.serviceAccount.create }}
+    {{- default (printf "%s-notifier-email" (include "tugboat.fullname" .)) .Values.tugboatNotifierEmail.serviceAccount.name }}
+  {{- else }}
+    {{- default "default" .Values.tugboatNotifierEmail.serviceAccount.name }}
+  {{- end }}
+{{- end }}
+
+{{/*
+Common labels
+*/}}
+{{- define "tugboat-notifier-email.labels" -}}
+{{ include "tugboat-notifier-email.selectorLabels" . }}
+{{- end }}
+
+{{/*
+Selector labels
+*/}}
+{{- define "tugboat-notifier-email.selectorLabels" -}}
+app.kubernetes.io/component: notifier-email
+{{- end }}
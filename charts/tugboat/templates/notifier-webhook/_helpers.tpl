@@ -0,0 +1,24 @@
+{{/*
+Create the name of the service account to use
+*/}}
+{{- define "tugboat-notifier-webhook.serviceAccountName" -}}
+  {{- if .Values.tugboatNotifierWebhook.serviceAccount.create }}
+    {{- default (printf "%s-notifier-webhook" (include "tugboat.fullname" .)) .Values.tugboatNotifierWebhook.serviceAccount.name }}
+  {{- else }}
+    {{- default "default" .Values.tugboatNotifierWebhook.serviceAccount.name }}
+  {{- end }}
+{{- end }}
+
+{{/*
+Common labels
+*/}}
+{{- define "tugboat-notifier-webhook.labels" -}}
+{{ include "tugboat-notifier-webhook.selectorLabels" . }}
+{{- end }}
+
+{{/*
+Selector labels
+*/}}
+{{- define "tugboat-notifier-webhook.selectorLabels" -}}
+app.kubernetes.io/component: notifier-webhook
+{{- end }}
@@ -16,6 +16,7 @@ require (
 	github.com/gorilla/mux v1.8.0
 	github.com/gregjones/httpcache v0.0.0-20181110185634-c63ab54fda8f // indirect
 	github.com/hashicorp/go-multierror v1.1.0
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/slack-go/slack v0.7.4
 	github.com/spf13/cobra v1.1.1
 	github.com/spf13/pflag v1.0.5
@@ -33,4 +34,5 @@ require (
 	k8s.io/client-go v0.20.2
 	k8s.io/code-generator v0.20.2
 	sigs.k8s.io/controller-runtime v0.8.0
+	sigs.k8s.io/yaml v1.2.0
 )
@@ -34,21 +34,20 @@ func Multiblock(log logr.Logger, p *probes.Probe, fs ...Blocker) error {
 	}
 
 	// Trap OS system signals.
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	// exited is closed when the first Blocker func exits.
+	exited := make(chan struct{})
+	var once sync.Once
 
 	var wg sync.WaitGroup
 	wg.Add(len(fs))
 
 	ctx, cancel := context.WithCancel(context.Background())
-	defer func() {
-		// Cancel any running context.
-		cancel()
-
-		// And wait for the below go funcs to complete.
-		wg.Wait()
-	}()
 
+	var mu sync.Mutex
 	var errs *multierror.Error
 	for k, f := range fs {
 		go func(i int, f Blocker) {
@@ -57,15 +56,20 @@ func Multiblock(log logr.Logger, p *probes.Probe, fs ...Blocker) error {
 
 			err := f(ctx, p.Reporter(i+1))
 			if err != nil {
+				mu.Lock()
 				errs = multierror.Append(errs, err)
+				mu.Unlock()
 				log.Error(err, err.Error(), "blocker", i)
 			} else {
 				log.Info("Exited Blocker func without error", "blocker", i)
 			}
 
 			// Closing the channel will allow the wait to finish, and we no longer need
-			// to wait on an `os.Signal`.
-			close(done)
+			// to wait on an `os.Signal`.  The other Blocker funcs exit once the
+			// context is canceled, so only the first may close it.
+			once.Do(func() {
+				close(exited)
+			})
 		}(k, f)
 	}
 
@@ -73,10 +77,17 @@ func Multiblock(log logr.Logger, p *probes.Probe, fs ...Blocker) error {
 
 	// Wait for an signal to exit
 	log.Info("Waiting on any Blocker func to exit")
-	<-done
+	select {
+	case <-sigs:
+	case <-exited:
+	}
 
 	// We have received a signal; set our liveness probe to Down
 	r.Kill()
 
+	// Cancel any running context, and wait for the Blocker funcs to complete.
+	cancel()
+	wg.Wait()
+
 	return errs.ErrorOrNil()
 }
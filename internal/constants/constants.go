@@ -3,19 +3,44 @@ package constants
 import v1 "k8s.io/api/core/v1"
 
 const (
-	HelmSecretLabelName     string = "name"
-	HelmSecretLabelRevision        = "version"
+	HelmSecretLabelName      string = "name"
+	HelmSecretLabelOwner            = "owner"
+	HelmSecretLabelOwnerHelm        = "helm"
+	HelmSecretLabelRevision         = "version"
+	HelmSecretLabelStatus           = "status"
 
 	HelmSecretFinalizer       string = "engineering.tugboat/helm-secret-finalizer"
 	HelmLabelReleaseName             = "meta.helm.sh/release-name"
 	HelmLabelReleaseNamespace        = "meta.helm.sh/release-namespace"
 
+	HelmAnnotationHook       = "helm.sh/hook"
+	HelmAnnotationHookWeight = "helm.sh/hook-weight"
+	HelmHookTest             = "test"
+	HelmHookTestSuccess      = "test-success"
+
+	// HelmRollbackDescription prefixes the description of a revision created
+	// by `helm rollback`
+	HelmRollbackDescription = "Rollback to "
+
+	KubernetesLabelInstance = "app.kubernetes.io/instance"
+
+	AnnotationAutoRollback  = "tugboat.engineering/auto-rollback"
+	AnnotationListenerPort  = "tugboat.engineering/listener-port"
+	AnnotationStallDeadline = "tugboat.engineering/stall-deadline"
+
+	LabelListener         = "tugboat.engineering/listener"
+	LabelReleaseHistory   = "tugboat.engineering/releasehistory"
 	LabelReleaseName      = "tugboat.engineering/release-name"
 	LabelReleaseNamespace = "tugboat.engineering/release-namespace"
 	LabelRevision         = "tugboat.engineering/revision"
 	LabelState            = "tugboat.engineering/state"
 	LabelStateActive      = "active"
 	LabelStateUninstalled = "uninstalled"
+
+	EventReasonOwnerNotResolved = "OwnerNotResolved"
+	EventReasonRevisionAdded    = "RevisionAdded"
+	EventReasonRolloutFailed    = "RolloutFailed"
+	EventReasonUninstalled      = "Uninstalled"
 )
 
 const (
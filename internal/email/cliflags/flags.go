@@ -0,0 +1,91 @@
+package cliflags
+
+import (
+	"time"
+
+	"github.com/object88/tugboat/internal/email/config"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// CLI Flags
+const (
+	fromKey         = "email-from"
+	routesKey       = "email-routes"
+	smtpHostKey     = "smtp-host"
+	smtpPasswordKey = "smtp-password"
+	smtpPortKey     = "smtp-port"
+	smtpUsernameKey = "smtp-username"
+	timeoutKey      = "digest-timeout"
+)
+
+// FlagManager maintains the state of email-related CLI flags
+type FlagManager struct {
+	// Do not access these directly; properties that are set via environment
+	// configs (i.e. `viper.BindEnv`) will not get updated here.
+	from         string
+	routes       string
+	smtpHost     string
+	smtpPassword string
+	smtpPort     int
+	smtpUsername string
+	timeout      time.Duration
+}
+
+// New returns a new instance of FlagManager
+func New() *FlagManager {
+	return &FlagManager{}
+}
+
+func (fm *FlagManager) ConfigureFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&fm.from, fromKey, "tugboat@localhost", "address that digests are sent from")
+	viper.BindEnv(fromKey)
+	viper.BindPFlag(fromKey, flags.Lookup(fromKey))
+
+	flags.StringVar(&fm.routes, routesKey, "", "path to the YAML file mapping namespaces and releases to recipients")
+	viper.BindEnv(routesKey)
+	viper.BindPFlag(routesKey, flags.Lookup(routesKey))
+
+	flags.StringVar(&fm.smtpHost, smtpHostKey, "localhost", "SMTP server host")
+	viper.BindEnv(smtpHostKey)
+	viper.BindPFlag(smtpHostKey, flags.Lookup(smtpHostKey))
+
+	flags.StringVar(&fm.smtpPassword, smtpPasswordKey, "", "SMTP password")
+	viper.BindEnv(smtpPasswordKey)
+	viper.BindPFlag(smtpPasswordKey, flags.Lookup(smtpPasswordKey))
+
+	flags.IntVar(&fm.smtpPort, smtpPortKey, 25, "SMTP server port")
+	viper.BindEnv(smtpPortKey)
+	viper.BindPFlag(smtpPortKey, flags.Lookup(smtpPortKey))
+
+	flags.StringVar(&fm.smtpUsername, smtpUsernameKey, "", "SMTP username; if empty, no authentication is attempted")
+	viper.BindEnv(smtpUsernameKey)
+	viper.BindPFlag(smtpUsernameKey, flags.Lookup(smtpUsernameKey))
+
+	flags.DurationVar(&fm.timeout, timeoutKey, 30*time.Minute, "how long to wait for a deployment to complete before sending its digest")
+	viper.BindEnv(timeoutKey)
+	viper.BindPFlag(timeoutKey, flags.Lookup(timeoutKey))
+}
+
+func (fm *FlagManager) Config() (*config.Config, error) {
+	cfg := &config.Config{
+		SMTP: config.SMTP{
+			Host:     viper.GetString(smtpHostKey),
+			Password: viper.GetString(smtpPasswordKey),
+			Port:     viper.GetInt(smtpPortKey),
+			Username: viper.GetString(smtpUsernameKey),
+		},
+		From:    viper.GetString(fromKey),
+		Timeout: viper.GetDuration(timeoutKey),
+	}
+
+	if p := viper.GetString(routesKey); p != "" {
+		rs, err := config.LoadRoutes(p)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Routes = rs
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Config describes how to connect to the SMTP server and whom to send
+// digests to
+type Config struct {
+	SMTP SMTP
+
+	// From is the sender address
+	From string
+
+	// Timeout is how long to wait for a deployment to close before sending
+	// its digest anyway
+	Timeout time.Duration
+
+	Routes Routes
+}
+
+// SMTP describes the mail server
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+// Address returns the host:port of the SMTP server
+func (s SMTP) Address() string {
+	return fmt.Sprintf("%s:%d", s.Host, s.Port)
+}
+
+// Routes maps releases to recipients
+type Routes struct {
+	// Default recipients receive digests for releases that match no route
+	Default []string `json:"default,omitempty"`
+
+	Routes []Route `json:"routes,omitempty"`
+}
+
+// Route sends digests for matching releases to a set of recipients.  The
+// namespace and release are shell patterns as understood by `path.Match`; an
+// empty pattern matches everything.
+type Route struct {
+	Namespace string   `json:"namespace,omitempty"`
+	Release   string   `json:"release,omitempty"`
+	To        []string `json:"to"`
+}
+
+// LoadRoutes reads the routes from a YAML or JSON file
+func LoadRoutes(p string) (Routes, error) {
+	buf, err := ioutil.ReadFile(p)
+	if err != nil {
+		return Routes{}, fmt.Errorf("failed to read email routes '%s': %w", p, err)
+	}
+	return ParseRoutes(buf)
+}
+
+// ParseRoutes decodes the routes from YAML or JSON and validates them
+func ParseRoutes(buf []byte) (Routes, error) {
+	rs := Routes{}
+	if err := yaml.UnmarshalStrict(buf, &rs); err != nil {
+		return Routes{}, fmt.Errorf("failed to decode email routes: %w", err)
+	}
+
+	for k, r := range rs.Routes {
+		if len(r.To) == 0 {
+			return Routes{}, fmt.Errorf("route %d has no recipients", k)
+		}
+		for _, pattern := range []string{r.Namespace, r.Release} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return Routes{}, fmt.Errorf("route %d has invalid pattern '%s': %w", k, pattern, err)
+			}
+		}
+	}
+
+	return rs, nil
+}
+
+// Recipients returns the de-duplicated addresses of every route that matches
+// the release, or the default recipients if none match
+func (rs Routes) Recipients(namespace string, release string) []string {
+	seen := map[string]bool{}
+	to := []string{}
+	add := func(addrs []string) {
+		for _, a := range addrs {
+			if !seen[a] {
+				seen[a] = true
+				to = append(to, a)
+			}
+		}
+	}
+
+	for _, r := range rs.Routes {
+		if match(r.Namespace, namespace) && match(r.Release, release) {
+			add(r.To)
+		}
+	}
+	if len(to) == 0 {
+		add(rs.Default)
+	}
+	return to
+}
+
+func match(pattern string, s string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, s)
+	return ok
+}
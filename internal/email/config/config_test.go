@@ -0,0 +1,88 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Routes_Recipients(t *testing.T) {
+	rs, err := ParseRoutes([]byte(`
+default:
+  - oncall@example.com
+routes:
+  - namespace: payments
+    to:
+      - payments@example.com
+  - namespace: "payments"
+    release: "checkout*"
+    to:
+      - checkout@example.com
+      - payments@example.com
+  - release: frontend
+    to:
+      - web@example.com
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	tcs := []struct {
+		name      string
+		namespace string
+		release   string
+		expected  []string
+	}{
+		{
+			name:      "namespace",
+			namespace: "payments",
+			release:   "ledger",
+			expected:  []string{"payments@example.com"},
+		},
+		{
+			name:      "namespace-and-release",
+			namespace: "payments",
+			release:   "checkout-api",
+			expected:  []string{"payments@example.com", "checkout@example.com"},
+		},
+		{
+			name:      "release-any-namespace",
+			namespace: "staging",
+			release:   "frontend",
+			expected:  []string{"web@example.com"},
+		},
+		{
+			name:      "default",
+			namespace: "staging",
+			release:   "ledger",
+			expected:  []string{"oncall@example.com"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := rs.Recipients(tc.namespace, tc.release)
+			if strings.Join(actual, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("incorrect recipients: expected %v, actual %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_ParseRoutes_Invalid(t *testing.T) {
+	tcs := []struct {
+		name  string
+		input string
+	}{
+		{name: "no-recipients", input: "routes:\n  - namespace: payments\n"},
+		{name: "bad-pattern", input: "routes:\n  - namespace: \"[\"\n    to: [a@example.com]\n"},
+		{name: "unknown-field", input: "recipients: [a@example.com]\n"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseRoutes([]byte(tc.input)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package deployments
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// DeploymentsClient is the client API for Deployments service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DeploymentsClient interface {
+	// Watch streams deployment events matching the filter, until the caller
+	// cancels.  To resume after a disconnect, pass the resume_token of the last
+	// event received; if the watcher no longer retains the events after it, the
+	// call fails with OUT_OF_RANGE, and the caller should watch again without a
+	// token.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Deployments_WatchClient, error)
+}
+
+type deploymentsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDeploymentsClient(cc grpc.ClientConnInterface) DeploymentsClient {
+	return &deploymentsClient{cc}
+}
+
+func (c *deploymentsClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Deployments_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Deployments_ServiceDesc.Streams[0], "/deployments.Deployments/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &deploymentsWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Deployments_WatchClient interface {
+	Recv() (*DeploymentEvent, error)
+	grpc.ClientStream
+}
+
+type deploymentsWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *deploymentsWatchClient) Recv() (*DeploymentEvent, error) {
+	m := new(DeploymentEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DeploymentsServer is the server API for Deployments service.
+// All implementations must embed UnimplementedDeploymentsServer
+// for forward compatibility
+type DeploymentsServer interface {
+	// Watch streams deployment events matching the filter, until the caller
+	// cancels.  To resume after a disconnect, pass the resume_token of the last
+	// event received; if the watcher no longer retains the events after it, the
+	// call fails with OUT_OF_RANGE, and the caller should watch again without a
+	// token.
+	Watch(*WatchRequest, Deployments_WatchServer) error
+	mustEmbedUnimplementedDeploymentsServer()
+}
+
+// UnimplementedDeploymentsServer must be embedded to have forward compatible implementations.
+type UnimplementedDeploymentsServer struct {
+}
+
+func (UnimplementedDeploymentsServer) Watch(*WatchRequest, Deployments_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedDeploymentsServer) mustEmbedUnimplementedDeploymentsServer() {}
+
+// UnsafeDeploymentsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DeploymentsServer will
+// result in compilation errors.
+type UnsafeDeploymentsServer interface {
+	mustEmbedUnimplementedDeploymentsServer()
+}
+
+func RegisterDeploymentsServer(s grpc.ServiceRegistrar, srv DeploymentsServer) {
+	s.RegisterService(&Deployments_ServiceDesc, srv)
+}
+
+func _Deployments_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DeploymentsServer).Watch(m, &deploymentsWatchServer{stream})
+}
+
+type Deployments_WatchServer interface {
+	Send(*DeploymentEvent) error
+	grpc.ServerStream
+}
+
+type deploymentsWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *deploymentsWatchServer) Send(m *DeploymentEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Deployments_ServiceDesc is the grpc.ServiceDesc for Deployments service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Deployments_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "deployments.Deployments",
+	HandlerType: (*DeploymentsServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Deployments_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "deployments.proto",
+}
@@ -19,6 +19,8 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ListenerClient interface {
 	OpenDeployment(ctx context.Context, in *StartDeploymentRequest, opts ...grpc.CallOption) (*StartDeploymentResponse, error)
+	UpdateDeployment(ctx context.Context, in *UpdateDeploymentRequest, opts ...grpc.CallOption) (*UpdateDeploymentResponse, error)
+	CloseDeployment(ctx context.Context, in *CloseDeploymentRequest, opts ...grpc.CallOption) (*CloseDeploymentResponse, error)
 }
 
 type listenerClient struct {
@@ -38,11 +40,31 @@ func (c *listenerClient) OpenDeployment(ctx context.Context, in *StartDeployment
 	return out, nil
 }
 
+func (c *listenerClient) UpdateDeployment(ctx context.Context, in *UpdateDeploymentRequest, opts ...grpc.CallOption) (*UpdateDeploymentResponse, error) {
+	out := new(UpdateDeploymentResponse)
+	err := c.cc.Invoke(ctx, "/notifier.Listener/UpdateDeployment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *listenerClient) CloseDeployment(ctx context.Context, in *CloseDeploymentRequest, opts ...grpc.CallOption) (*CloseDeploymentResponse, error) {
+	out := new(CloseDeploymentResponse)
+	err := c.cc.Invoke(ctx, "/notifier.Listener/CloseDeployment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ListenerServer is the server API for Listener service.
 // All implementations must embed UnimplementedListenerServer
 // for forward compatibility
 type ListenerServer interface {
 	OpenDeployment(context.Context, *StartDeploymentRequest) (*StartDeploymentResponse, error)
+	UpdateDeployment(context.Context, *UpdateDeploymentRequest) (*UpdateDeploymentResponse, error)
+	CloseDeployment(context.Context, *CloseDeploymentRequest) (*CloseDeploymentResponse, error)
 	mustEmbedUnimplementedListenerServer()
 }
 
@@ -53,6 +75,12 @@ type UnimplementedListenerServer struct {
 func (UnimplementedListenerServer) OpenDeployment(context.Context, *StartDeploymentRequest) (*StartDeploymentResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method OpenDeployment not implemented")
 }
+func (UnimplementedListenerServer) UpdateDeployment(context.Context, *UpdateDeploymentRequest) (*UpdateDeploymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateDeployment not implemented")
+}
+func (UnimplementedListenerServer) CloseDeployment(context.Context, *CloseDeploymentRequest) (*CloseDeploymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseDeployment not implemented")
+}
 func (UnimplementedListenerServer) mustEmbedUnimplementedListenerServer() {}
 
 // UnsafeListenerServer may be embedded to opt out of forward compatibility for this service.
@@ -84,6 +112,42 @@ func _Listener_OpenDeployment_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Listener_UpdateDeployment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateDeploymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ListenerServer).UpdateDeployment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/notifier.Listener/UpdateDeployment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ListenerServer).UpdateDeployment(ctx, req.(*UpdateDeploymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Listener_CloseDeployment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseDeploymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ListenerServer).CloseDeployment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/notifier.Listener/CloseDeployment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ListenerServer).CloseDeployment(ctx, req.(*CloseDeploymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Listener_ServiceDesc is the grpc.ServiceDesc for Listener service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -95,6 +159,14 @@ var Listener_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "OpenDeployment",
 			Handler:    _Listener_OpenDeployment_Handler,
 		},
+		{
+			MethodName: "UpdateDeployment",
+			Handler:    _Listener_UpdateDeployment_Handler,
+		},
+		{
+			MethodName: "CloseDeployment",
+			Handler:    _Listener_CloseDeployment_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "notify.proto",
@@ -0,0 +1,44 @@
+package changes
+
+import (
+	"fmt"
+
+	"github.com/object88/tugboat/internal/generated/notifier"
+)
+
+// Format renders a change for people to read, e.g.
+// "Deployment/web (app): tag 1.2.0 → 1.3.0"
+func Format(c *notifier.Change) string {
+	subject := c.GetObject()
+	if c.GetContainer() != "" {
+		subject = fmt.Sprintf("%s (%s)", subject, c.GetContainer())
+	}
+
+	switch c.GetField() {
+	case "created", "deleted":
+		return fmt.Sprintf("%s: %s", subject, c.GetField())
+	case "env":
+		if c.GetTo() != "" {
+			return fmt.Sprintf("%s: added env %s", subject, c.GetTo())
+		}
+		return fmt.Sprintf("%s: removed env %s", subject, c.GetFrom())
+	default:
+		return fmt.Sprintf("%s: %s %s → %s", subject, c.GetField(), orNone(c.GetFrom()), orNone(c.GetTo()))
+	}
+}
+
+// FormatAll renders each change
+func FormatAll(cs []*notifier.Change) []string {
+	lines := make([]string, len(cs))
+	for k, c := range cs {
+		lines[k] = Format(c)
+	}
+	return lines
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package changes
+
+import (
+	"testing"
+
+	"github.com/object88/tugboat/internal/generated/notifier"
+)
+
+func Test_Format(t *testing.T) {
+	tcs := []struct {
+		name     string
+		change   *notifier.Change
+		expected string
+	}{
+		{
+			name:     "tag",
+			change:   &notifier.Change{Object: "Deployment/web", Container: "app", Field: "tag", From: "1.2.0", To: "1.3.0"},
+			expected: "Deployment/web (app): tag 1.2.0 → 1.3.0",
+		},
+		{
+			name:     "limit-added",
+			change:   &notifier.Change{Object: "Deployment/web", Container: "app", Field: "limits.memory", To: "512Mi"},
+			expected: "Deployment/web (app): limits.memory none → 512Mi",
+		},
+		{
+			name:     "env-removed",
+			change:   &notifier.Change{Object: "Deployment/web", Container: "app", Field: "env", From: "DEBUG"},
+			expected: "Deployment/web (app): removed env DEBUG",
+		},
+		{
+			name:     "created",
+			change:   &notifier.Change{Object: "StatefulSet/db", Field: "created"},
+			expected: "StatefulSet/db: created",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Format(tc.change); actual != tc.expected {
+				t.Errorf("incorrect format:\nexpected: %s\nactual:   %s", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -1,56 +1,84 @@
 package client
 
 import (
-	"context"
 	"net/url"
-	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/object88/tugboat/internal/generated/notifier"
+	"github.com/object88/tugboat/internal/notifications/outbox"
 	grpcclient "github.com/object88/tugboat/pkg/grpc/client"
 	"google.golang.org/grpc"
 )
 
+// Client sends notifications to listeners.  Notifications are queued in an
+// outbox, which delivers them to each listener independently.
 type Client struct {
 	logger logr.Logger
 
-	listeners []notifier.ListenerClient
+	outbox  *outbox.Outbox
+	opts    []grpc.DialOption
+	clients []*grpcclient.Client
 }
 
-func New(logger logr.Logger) *Client {
+// New returns a new Client.  Listeners are dialed with the provided options.
+func New(logger logr.Logger, ob *outbox.Outbox, opts ...grpc.DialOption) *Client {
 	return &Client{
 		logger: logger,
+		outbox: ob,
+		opts:   opts,
 	}
 }
 
+// Connect adds each target as a listener.  The connections are established
+// in the background; the outbox holds messages until they are ready.
 func (c *Client) Connect(targets []*url.URL) error {
-	lcs := make([]notifier.ListenerClient, len(targets))
-	for k, v := range targets {
-		cc := grpcclient.New(c.logger)
+	for _, v := range targets {
+		cc := grpcclient.New(c.logger, c.opts...)
 		c.logger.Info("connecting to gRPC target", "target", v)
-		if err := cc.Connect(v); err != nil {
+		if err := cc.Dial(v); err != nil {
 			return err
 		}
+		c.clients = append(c.clients, cc)
 
-		lcs[k] = notifier.NewListenerClient(cc.ClientConnection())
+		c.outbox.AddListener(v.String(), notifier.NewListenerClient(cc.ClientConnection()))
 	}
 
-	c.listeners = lcs
-
 	return nil
 }
 
-func (c *Client) DeploymentStarted() error {
-	for _, v := range c.listeners {
-		err := func() error {
-			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-			defer cancel()
-			_, err := v.OpenDeployment(ctx, &notifier.StartDeploymentRequest{}, grpc.WaitForReady(true))
-			return err
-		}()
-		if err != nil {
-			return err
+// Close releases the connections to the listeners added by Connect
+func (c *Client) Close() {
+	for _, cc := range c.clients {
+		if err := cc.Close(); err != nil {
+			c.logger.Error(err, "failed to close listener connection")
 		}
 	}
-	return nil
+	c.clients = nil
+}
+
+// DeploymentStarted queues an OpenDeployment notification
+func (c *Client) DeploymentStarted(req *notifier.StartDeploymentRequest) error {
+	m, err := outbox.NewOpenMessage(req)
+	if err != nil {
+		return err
+	}
+	return c.outbox.Enqueue(m)
+}
+
+// DeploymentUpdated queues an UpdateDeployment notification
+func (c *Client) DeploymentUpdated(req *notifier.UpdateDeploymentRequest) error {
+	m, err := outbox.NewUpdateMessage(req)
+	if err != nil {
+		return err
+	}
+	return c.outbox.Enqueue(m)
+}
+
+// DeploymentClosed queues a CloseDeployment notification
+func (c *Client) DeploymentClosed(req *notifier.CloseDeploymentRequest) error {
+	m, err := outbox.NewCloseMessage(req)
+	if err != nil {
+		return err
+	}
+	return c.outbox.Enqueue(m)
 }
@@ -1,18 +1,32 @@
 package cliflags
 
 import (
+	"fmt"
 	"net/url"
+	"strings"
 
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/internal/notifications/outbox"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
-	listenersKey string = "listeners"
+	listenerSelectorKey  string = "listener-selector"
+	listenersKey         string = "listeners"
+	outboxConfigMapKey   string = "outbox-configmap"
+	outboxFileKey        string = "outbox-file"
+	outboxMaxAttemptsKey string = "outbox-max-attempts"
 )
 
 type FlagManager struct {
-	listeners []string
+	listenerSelector  string
+	listeners         []string
+	outboxConfigMap   string
+	outboxFile        string
+	outboxMaxAttempts int
 }
 
 func New() *FlagManager {
@@ -25,6 +39,26 @@ func (fm *FlagManager) ConfigureListenersFlag(flags *pflag.FlagSet) {
 	viper.BindPFlag(listenersKey, flags.Lookup(listenersKey))
 }
 
+func (fm *FlagManager) ConfigureListenerSelectorFlag(flags *pflag.FlagSet) {
+	flags.StringVar(&fm.listenerSelector, listenerSelectorKey, constants.LabelListener+"=true", "label selector for Services implementing a Listener interface; empty disables discovery")
+	viper.BindEnv(listenerSelectorKey)
+	viper.BindPFlag(listenerSelectorKey, flags.Lookup(listenerSelectorKey))
+}
+
+func (fm *FlagManager) ConfigureOutboxFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&fm.outboxConfigMap, outboxConfigMapKey, "", "NAMESPACE/NAME of a ConfigMap to persist undelivered notifications in")
+	viper.BindEnv(outboxConfigMapKey)
+	viper.BindPFlag(outboxConfigMapKey, flags.Lookup(outboxConfigMapKey))
+
+	flags.StringVar(&fm.outboxFile, outboxFileKey, "", "path to a file to persist undelivered notifications in")
+	viper.BindEnv(outboxFileKey)
+	viper.BindPFlag(outboxFileKey, flags.Lookup(outboxFileKey))
+
+	flags.IntVar(&fm.outboxMaxAttempts, outboxMaxAttemptsKey, outbox.DefaultOptions().MaxAttempts, "number of delivery attempts before a notification is dead-lettered")
+	viper.BindEnv(outboxMaxAttemptsKey)
+	viper.BindPFlag(outboxMaxAttemptsKey, flags.Lookup(outboxMaxAttemptsKey))
+}
+
 func (fl *FlagManager) Listeners() ([]*url.URL, error) {
 	raw := viper.GetStringSlice(listenersKey)
 	result := make([]*url.URL, len(raw))
@@ -37,3 +71,46 @@ func (fl *FlagManager) Listeners() ([]*url.URL, error) {
 	}
 	return result, nil
 }
+
+// ListenerSelector returns the label selector for listener Services.  If
+// the selector is empty, listeners should not be discovered.
+func (fl *FlagManager) ListenerSelector() (labels.Selector, bool, error) {
+	raw := viper.GetString(listenerSelectorKey)
+	if raw == "" {
+		return nil, false, nil
+	}
+	selector, err := labels.Parse(raw)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse --%s '%s': %w", listenerSelectorKey, raw, err)
+	}
+	return selector, true, nil
+}
+
+// OutboxJournal returns the journal described by the outbox flags.  If
+// neither a ConfigMap nor a file is provided, undelivered notifications are
+// kept in memory only.
+func (fl *FlagManager) OutboxJournal(clientset kubernetes.Interface) (outbox.Journal, error) {
+	cm := viper.GetString(outboxConfigMapKey)
+	file := viper.GetString(outboxFileKey)
+
+	switch {
+	case cm != "" && file != "":
+		return nil, fmt.Errorf("only one of --%s and --%s may be provided", outboxConfigMapKey, outboxFileKey)
+	case cm != "":
+		parts := strings.Split(cm, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("--%s must be NAMESPACE/NAME; got '%s'", outboxConfigMapKey, cm)
+		}
+		return outbox.NewConfigMapJournal(clientset, parts[0], parts[1]), nil
+	case file != "":
+		return outbox.NewFileJournal(file), nil
+	default:
+		return &outbox.MemoryJournal{}, nil
+	}
+}
+
+func (fl *FlagManager) OutboxOptions() outbox.Options {
+	opts := outbox.DefaultOptions()
+	opts.MaxAttempts = viper.GetInt(outboxMaxAttemptsKey)
+	return opts
+}
@@ -0,0 +1,42 @@
+package deployer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/object88/tugboat/internal/generated/notifier"
+)
+
+// Name returns how to refer to a deployer: by its service account, if it is
+// one, or else by its username
+func Name(d *notifier.Deployer) string {
+	if d.GetServiceAccount() != "" {
+		return d.GetServiceAccount()
+	}
+	return d.GetUsername()
+}
+
+// Format describes a deployer for people to read, referring to them as name
+// and adding any CI identity, e.g.
+// "ci/deployer (ci.example.com/job: 1234)".  An unknown deployer is "".
+func Format(d *notifier.Deployer, name string) string {
+	if d.GetUsername() == "" {
+		return ""
+	}
+
+	ci := d.GetCi()
+	if len(ci) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(ci))
+	for k := range ci {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s: %s", k, ci[k])
+	}
+	return fmt.Sprintf("%s (%s)", name, strings.Join(parts, ", "))
+}
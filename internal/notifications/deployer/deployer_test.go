@@ -0,0 +1,47 @@
+package deployer
+
+import (
+	"testing"
+
+	"github.com/object88/tugboat/internal/generated/notifier"
+)
+
+func Test_Format(t *testing.T) {
+	tcs := []struct {
+		name     string
+		deployer *notifier.Deployer
+		expected string
+	}{
+		{
+			name:     "unknown",
+			expected: "",
+		},
+		{
+			name:     "user",
+			deployer: &notifier.Deployer{Username: "alice@example.com", Groups: []string{"developers"}},
+			expected: "alice@example.com",
+		},
+		{
+			name:     "service-account",
+			deployer: &notifier.Deployer{Username: "system:serviceaccount:ci:deployer", ServiceAccount: "ci/deployer"},
+			expected: "ci/deployer",
+		},
+		{
+			name: "ci",
+			deployer: &notifier.Deployer{
+				Username:       "system:serviceaccount:ci:deployer",
+				ServiceAccount: "ci/deployer",
+				Ci:             map[string]string{"ci.example.com/pipeline": "42", "ci.example.com/job": "1234"},
+			},
+			expected: "ci/deployer (ci.example.com/job: 1234, ci.example.com/pipeline: 42)",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Format(tc.deployer, Name(tc.deployer)); actual != tc.expected {
+				t.Errorf("incorrect format:\nexpected: %s\nactual:   %s", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package discovery
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/internal/generated/notifier"
+	"github.com/object88/tugboat/internal/notifications/outbox"
+	grpcclient "github.com/object88/tugboat/pkg/grpc/client"
+	"google.golang.org/grpc"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// grpcPortName is the conventional name of the Service port that serves the
+// Listener gRPC service
+const grpcPortName = "grpc"
+
+// DialFunc connects to a listener.  The returned Closer releases the
+// connection when the listener goes away.
+type DialFunc func(logger logr.Logger, target *url.URL) (notifier.ListenerClient, io.Closer, error)
+
+// Discoverer adds a listener to the outbox for each Service that it is
+// informed of, and removes it when the Service is deleted.  Pair it with a
+// Service informer filtered by a label selector, such as
+// `tugboat.engineering/listener=true`; a Service that stops matching the
+// selector is reported as deleted.
+type Discoverer struct {
+	logger logr.Logger
+	outbox *outbox.Outbox
+	dial   DialFunc
+
+	// Static are the targets of the listeners which are configured by URL.  A
+	// Service which resolves to one of them is ignored, so that its listener
+	// is not sent every message twice.
+	Static []*url.URL
+
+	mu      sync.Mutex
+	targets map[string]target
+}
+
+type target struct {
+	url    string
+	closer io.Closer
+}
+
+var _ cache.ResourceEventHandler = &Discoverer{}
+
+// New returns a new Discoverer which dials listeners over gRPC with the
+// provided options
+func New(logger logr.Logger, ob *outbox.Outbox, opts ...grpc.DialOption) *Discoverer {
+	return &Discoverer{
+		logger: logger,
+		outbox: ob,
+		dial: func(logger logr.Logger, target *url.URL) (notifier.ListenerClient, io.Closer, error) {
+			return dial(logger, target, opts...)
+		},
+		targets: map[string]target{},
+	}
+}
+
+// OnAdd satisfies the cache.ResourceEventHandler interface
+func (d *Discoverer) OnAdd(obj interface{}) {
+	if svc, ok := obj.(*v1.Service); ok {
+		d.sync(svc)
+	}
+}
+
+// OnUpdate satisfies the cache.ResourceEventHandler interface
+func (d *Discoverer) OnUpdate(oldObj, newObj interface{}) {
+	if svc, ok := newObj.(*v1.Service); ok {
+		d.sync(svc)
+	}
+}
+
+// OnDelete satisfies the cache.ResourceEventHandler interface
+func (d *Discoverer) OnDelete(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		d.logger.Error(err, "failed to get key for deleted service")
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.remove(key)
+}
+
+// Listeners returns the names of the discovered listeners and their targets
+func (d *Discoverer) Listeners() map[string]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	result := make(map[string]string, len(d.targets))
+	for k, v := range d.targets {
+		result[k] = v.url
+	}
+	return result
+}
+
+func (d *Discoverer) sync(svc *v1.Service) {
+	key, err := cache.MetaNamespaceKeyFunc(svc)
+	if err != nil {
+		d.logger.Error(err, "failed to get key for service")
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	u, err := Target(svc)
+	if err != nil {
+		d.logger.Error(err, "failed to determine listener target", "service", key)
+		d.remove(key)
+		return
+	}
+	for _, s := range d.Static {
+		if address(s) == address(u) {
+			d.logger.Info("ignoring listener which is already configured", "service", key, "target", s.String())
+			d.remove(key)
+			return
+		}
+	}
+
+	if t, ok := d.targets[key]; ok {
+		if t.url == u.String() {
+			return
+		}
+		d.logger.Info("listener target changed", "service", key, "from", t.url, "to", u.String())
+		if err := t.closer.Close(); err != nil {
+			d.logger.Error(err, "failed to close listener connection", "service", key)
+		}
+	}
+
+	client, closer, err := d.dial(d.logger, u)
+	if err != nil {
+		d.logger.Error(err, "failed to dial listener", "service", key, "target", u.String())
+		delete(d.targets, key)
+		d.outbox.RemoveListener(key)
+		return
+	}
+
+	d.logger.Info("discovered listener", "service", key, "target", u.String())
+	d.targets[key] = target{url: u.String(), closer: closer}
+	d.outbox.AddListener(key, client)
+}
+
+// remove disconnects the listener, if it is known.  The caller must hold
+// the lock.
+func (d *Discoverer) remove(key string) {
+	t, ok := d.targets[key]
+	if !ok {
+		return
+	}
+
+	d.logger.Info("removing listener", "service", key)
+	delete(d.targets, key)
+	d.outbox.RemoveListener(key)
+	if err := t.closer.Close(); err != nil {
+		d.logger.Error(err, "failed to close listener connection", "service", key)
+	}
+}
+
+// Target returns the gRPC target for the Service.  The port is chosen by the
+// `tugboat.engineering/listener-port` annotation (a port name or number),
+// then a port named `grpc`, then the Service's only port.
+func Target(svc *v1.Service) (*url.URL, error) {
+	port, err := port(svc)
+	if err != nil {
+		return nil, err
+	}
+	return url.Parse(fmt.Sprintf("dns:///%s.%s.svc:%d", svc.Name, svc.Namespace, port))
+}
+
+// address returns the host and port of a gRPC target, with the Service
+// names "NAME.NAMESPACE", "NAME.NAMESPACE.svc" and
+// "NAME.NAMESPACE.svc.cluster.local" all reduced to "NAME.NAMESPACE.svc".  A
+// bare Service name is relative to the watcher's namespace, so is left as is.
+func address(u *url.URL) string {
+	s := u.String()
+	if i := strings.Index(s, ":///"); i != -1 {
+		s = s[i+len(":///"):]
+	} else if u.Host != "" {
+		s = u.Host
+	}
+
+	host, port := s, ""
+	if i := strings.LastIndex(s, ":"); i != -1 {
+		host, port = s[:i], s[i:]
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	host = strings.TrimSuffix(host, ".cluster.local")
+	if strings.Count(host, ".") == 1 {
+		host += ".svc"
+	}
+	return host + port
+}
+
+func port(svc *v1.Service) (int32, error) {
+	if a, ok := svc.Annotations[constants.AnnotationListenerPort]; ok {
+		if n, err := strconv.Atoi(a); err == nil {
+			return int32(n), nil
+		}
+		for _, p := range svc.Spec.Ports {
+			if p.Name == a {
+				return p.Port, nil
+			}
+		}
+		return 0, fmt.Errorf("service does not have port '%s' named by annotation '%s'", a, constants.AnnotationListenerPort)
+	}
+
+	for _, p := range svc.Spec.Ports {
+		if p.Name == grpcPortName {
+			return p.Port, nil
+		}
+	}
+
+	if len(svc.Spec.Ports) == 1 {
+		return svc.Spec.Ports[0].Port, nil
+	}
+
+	return 0, fmt.Errorf("cannot choose a port; name one '%s' or set annotation '%s'", grpcPortName, constants.AnnotationListenerPort)
+}
+
+func dial(logger logr.Logger, target *url.URL, opts ...grpc.DialOption) (notifier.ListenerClient, io.Closer, error) {
+	cc := grpcclient.New(logger, opts...)
+	if err := cc.Dial(target); err != nil {
+		return nil, nil, err
+	}
+	return notifier.NewListenerClient(cc.ClientConnection()), cc, nil
+}
@@ -0,0 +1,180 @@
+package discovery
+
+import (
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/internal/generated/notifier"
+	"github.com/object88/tugboat/internal/notifications/outbox"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+type closer struct {
+	closed bool
+}
+
+func (c *closer) Close() error {
+	c.closed = true
+	return nil
+}
+
+func Test_Target(t *testing.T) {
+	tcs := []struct {
+		name        string
+		annotations map[string]string
+		ports       []v1.ServicePort
+		expected    string
+		expectErr   bool
+	}{
+		{
+			name:     "grpc-port",
+			ports:    []v1.ServicePort{{Name: "http", Port: 80}, {Name: "grpc", Port: 5678}},
+			expected: "dns:///notifier.tugboat.svc:5678",
+		},
+		{
+			name:     "only-port",
+			ports:    []v1.ServicePort{{Name: "listener", Port: 9000}},
+			expected: "dns:///notifier.tugboat.svc:9000",
+		},
+		{
+			name:        "annotation-name",
+			annotations: map[string]string{constants.AnnotationListenerPort: "listener"},
+			ports:       []v1.ServicePort{{Name: "grpc", Port: 5678}, {Name: "listener", Port: 9000}},
+			expected:    "dns:///notifier.tugboat.svc:9000",
+		},
+		{
+			name:        "annotation-number",
+			annotations: map[string]string{constants.AnnotationListenerPort: "7000"},
+			ports:       []v1.ServicePort{{Name: "grpc", Port: 5678}},
+			expected:    "dns:///notifier.tugboat.svc:7000",
+		},
+		{
+			name:        "annotation-missing-port",
+			annotations: map[string]string{constants.AnnotationListenerPort: "listener"},
+			ports:       []v1.ServicePort{{Name: "grpc", Port: 5678}},
+			expectErr:   true,
+		},
+		{
+			name:      "ambiguous",
+			ports:     []v1.ServicePort{{Name: "http", Port: 80}, {Name: "metrics", Port: 9090}},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := createService("notifier", tc.ports...)
+			svc.Annotations = tc.annotations
+
+			actual, err := Target(svc)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected error; got target '%s'", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if actual.String() != tc.expected {
+				t.Errorf("incorrect target: expected '%s', actual '%s'", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_Discoverer(t *testing.T) {
+	ob := outbox.New(testlogger.TestLogger{T: t}, &outbox.MemoryJournal{}, outbox.DefaultOptions())
+	d := New(testlogger.TestLogger{T: t}, ob)
+
+	closers := map[string]*closer{}
+	dials := 0
+	d.dial = func(logger logr.Logger, target *url.URL) (notifier.ListenerClient, io.Closer, error) {
+		dials++
+		c := &closer{}
+		closers[target.String()] = c
+		return notifier.NewListenerClient(nil), c, nil
+	}
+
+	slack := createService("notifier-slack", v1.ServicePort{Name: "grpc", Port: 5678})
+	email := createService("notifier-email", v1.ServicePort{Name: "grpc", Port: 5678})
+	d.OnAdd(slack)
+	d.OnAdd(email)
+	assertConnected(t, ob, map[string]bool{"tugboat/notifier-email": true, "tugboat/notifier-slack": true})
+
+	// An update that does not change the target does not redial.
+	d.OnUpdate(slack, slack.DeepCopy())
+	if dials != 2 {
+		t.Errorf("incorrect number of dials: expected 2, actual %d", dials)
+	}
+
+	// Changing the port redials and closes the old connection.
+	moved := createService("notifier-slack", v1.ServicePort{Name: "grpc", Port: 6789})
+	d.OnUpdate(slack, moved)
+	if !closers["dns:///notifier-slack.tugboat.svc:5678"].closed {
+		t.Errorf("old connection was not closed")
+	}
+	if d.Listeners()["tugboat/notifier-slack"] != "dns:///notifier-slack.tugboat.svc:6789" {
+		t.Errorf("incorrect target after update: %v", d.Listeners())
+	}
+
+	d.OnDelete(cache.DeletedFinalStateUnknown{Key: "tugboat/notifier-email", Obj: email})
+	if !closers["dns:///notifier-email.tugboat.svc:5678"].closed {
+		t.Errorf("connection was not closed on delete")
+	}
+	assertConnected(t, ob, map[string]bool{"tugboat/notifier-email": false, "tugboat/notifier-slack": true})
+}
+
+func Test_Discoverer_Static(t *testing.T) {
+	ob := outbox.New(testlogger.TestLogger{T: t}, &outbox.MemoryJournal{}, outbox.DefaultOptions())
+	d := New(testlogger.TestLogger{T: t}, ob)
+	d.dial = func(logger logr.Logger, target *url.URL) (notifier.ListenerClient, io.Closer, error) {
+		return notifier.NewListenerClient(nil), &closer{}, nil
+	}
+	for _, raw := range []string{"dns:///notifier-slack.tugboat:5678", "notifier-email.tugboat.svc.cluster.local:5678"} {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatalf("failed to parse '%s': %s", raw, err.Error())
+		}
+		d.Static = append(d.Static, u)
+	}
+
+	d.OnAdd(createService("notifier-slack", v1.ServicePort{Name: "grpc", Port: 5678}))
+	d.OnAdd(createService("notifier-email", v1.ServicePort{Name: "grpc", Port: 5678}))
+	d.OnAdd(createService("notifier-webhook", v1.ServicePort{Name: "grpc", Port: 5678}))
+	assertConnected(t, ob, map[string]bool{"tugboat/notifier-webhook": true})
+}
+
+func assertConnected(t *testing.T, ob *outbox.Outbox, expected map[string]bool) {
+	actual := map[string]bool{}
+	for _, ls := range ob.Status().Listeners {
+		actual[ls.Name] = ls.Connected
+	}
+	if len(actual) != len(expected) {
+		t.Fatalf("incorrect listeners: expected %v, actual %v", expected, actual)
+	}
+	for k, v := range expected {
+		if actual[k] != v {
+			t.Errorf("listener '%s' has incorrect connection: expected %t, actual %t", k, v, actual[k])
+		}
+	}
+}
+
+func createService(name string, ports ...v1.ServicePort) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:    map[string]string{constants.LabelListener: "true"},
+			Name:      name,
+			Namespace: "tugboat",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: ports,
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const configMapKey = "outbox.json"
+
+// ConfigMapJournal persists the outbox state in a ConfigMap, creating it if
+// necessary.  A ConfigMap is limited to 1MiB, so keep MaxDeadLetters small.
+type ConfigMapJournal struct {
+	clientset kubernetes.Interface
+	namespace string
+	name      string
+}
+
+var _ Journal = &ConfigMapJournal{}
+
+// NewConfigMapJournal returns a new ConfigMapJournal
+func NewConfigMapJournal(clientset kubernetes.Interface, namespace string, name string) *ConfigMapJournal {
+	return &ConfigMapJournal{
+		clientset: clientset,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+func (j *ConfigMapJournal) Load(ctx context.Context) (*State, error) {
+	cm, err := j.clientset.CoreV1().ConfigMaps(j.namespace).Get(ctx, j.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return newState(), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get outbox configmap '%s/%s': %w", j.namespace, j.name, err)
+	}
+	return decodeState([]byte(cm.Data[configMapKey]))
+}
+
+func (j *ConfigMapJournal) Save(ctx context.Context, s *State) error {
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox state: %w", err)
+	}
+
+	cms := j.clientset.CoreV1().ConfigMaps(j.namespace)
+	cm, err := cms.Get(ctx, j.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      j.name,
+				Namespace: j.namespace,
+			},
+			Data: map[string]string{configMapKey: string(buf)},
+		}
+		if _, err = cms.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create outbox configmap '%s/%s': %w", j.namespace, j.name, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get outbox configmap '%s/%s': %w", j.namespace, j.name, err)
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[configMapKey] = string(buf)
+	if _, err = cms.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update outbox configmap '%s/%s': %w", j.namespace, j.name, err)
+	}
+	return nil
+}
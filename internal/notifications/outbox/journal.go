@@ -0,0 +1,139 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry is a message waiting for, or abandoned after, delivery to a single
+// listener
+type Entry struct {
+	Message
+
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"nextAttempt,omitempty"`
+	LastError   string    `json:"lastError,omitempty"`
+}
+
+// QueueState is the persisted state of a single listener's queue
+type QueueState struct {
+	Pending     []Entry `json:"pending"`
+	DeadLetters []Entry `json:"deadLetters,omitempty"`
+	Delivered   uint64  `json:"delivered"`
+	Skipped     uint64  `json:"skipped"`
+	Dropped     uint64  `json:"dropped,omitempty"`
+}
+
+// State is everything that the outbox persists
+type State struct {
+	Queues map[string]*QueueState `json:"queues"`
+}
+
+// Journal persists the outbox state across restarts
+type Journal interface {
+	Load(ctx context.Context) (*State, error)
+	Save(ctx context.Context, s *State) error
+}
+
+func newState() *State {
+	return &State{Queues: map[string]*QueueState{}}
+}
+
+// copy returns a copy of the state, which shares only the messages
+func (s *State) copy() *State {
+	c := newState()
+	for name, qs := range s.Queues {
+		q := *qs
+		q.Pending = append([]Entry(nil), qs.Pending...)
+		q.DeadLetters = append([]Entry(nil), qs.DeadLetters...)
+		c.Queues[name] = &q
+	}
+	return c
+}
+
+func decodeState(buf []byte) (*State, error) {
+	s := newState()
+	if len(buf) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(buf, s); err != nil {
+		return nil, fmt.Errorf("failed to decode outbox state: %w", err)
+	}
+	if s.Queues == nil {
+		s.Queues = map[string]*QueueState{}
+	}
+	return s, nil
+}
+
+// MemoryJournal keeps no state across restarts
+type MemoryJournal struct{}
+
+var _ Journal = &MemoryJournal{}
+
+func (*MemoryJournal) Load(ctx context.Context) (*State, error) {
+	return newState(), nil
+}
+
+func (*MemoryJournal) Save(ctx context.Context, s *State) error {
+	return nil
+}
+
+// FileJournal persists the outbox state as a JSON file.  Writes go to a
+// temporary file which replaces the journal, so that a crash mid-write
+// does not corrupt it.
+type FileJournal struct {
+	path string
+}
+
+var _ Journal = &FileJournal{}
+
+// NewFileJournal returns a new FileJournal
+func NewFileJournal(path string) *FileJournal {
+	return &FileJournal{
+		path: path,
+	}
+}
+
+func (j *FileJournal) Load(ctx context.Context) (*State, error) {
+	buf, err := ioutil.ReadFile(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return newState(), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read outbox journal '%s': %w", j.path, err)
+	}
+	return decodeState(buf)
+}
+
+func (j *FileJournal) Save(ctx context.Context, s *State) error {
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox state: %w", err)
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(j.path), filepath.Base(j.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary outbox journal: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(buf); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write outbox journal: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), j.path); err != nil {
+		return fmt.Errorf("failed to replace outbox journal '%s': %w", j.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/object88/tugboat/internal/generated/notifier"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Kind identifies the Listener RPC that delivers a message
+type Kind string
+
+const (
+	KindOpen   Kind = "open"
+	KindUpdate Kind = "update"
+	KindClose  Kind = "close"
+)
+
+// Message is a single notification, stored as the JSON encoding of the
+// Listener request so that the journal remains readable
+type Message struct {
+	ID      string          `json:"id"`
+	Kind    Kind            `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
+	Created time.Time       `json:"created"`
+}
+
+// NewOpenMessage wraps an OpenDeployment request
+func NewOpenMessage(req *notifier.StartDeploymentRequest) (Message, error) {
+	return newMessage(KindOpen, req)
+}
+
+// NewUpdateMessage wraps an UpdateDeployment request
+func NewUpdateMessage(req *notifier.UpdateDeploymentRequest) (Message, error) {
+	return newMessage(KindUpdate, req)
+}
+
+// NewCloseMessage wraps a CloseDeployment request
+func NewCloseMessage(req *notifier.CloseDeploymentRequest) (Message, error) {
+	return newMessage(KindClose, req)
+}
+
+func newMessage(kind Kind, req proto.Message) (Message, error) {
+	buf, err := protojson.Marshal(req)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to encode %s message: %w", kind, err)
+	}
+	return Message{
+		ID:      uuid.New().String(),
+		Kind:    kind,
+		Payload: buf,
+		Created: time.Now().UTC(),
+	}, nil
+}
+
+// deliver makes the RPC for the message
+func (m *Message) deliver(ctx context.Context, c notifier.ListenerClient) error {
+	var err error
+	switch m.Kind {
+	case KindOpen:
+		req := &notifier.StartDeploymentRequest{}
+		if err = protojson.Unmarshal(m.Payload, req); err == nil {
+			_, err = c.OpenDeployment(ctx, req)
+		}
+	case KindUpdate:
+		req := &notifier.UpdateDeploymentRequest{}
+		if err = protojson.Unmarshal(m.Payload, req); err == nil {
+			_, err = c.UpdateDeployment(ctx, req)
+		}
+	case KindClose:
+		req := &notifier.CloseDeploymentRequest{}
+		if err = protojson.Unmarshal(m.Payload, req); err == nil {
+			_, err = c.CloseDeployment(ctx, req)
+		}
+	default:
+		err = fmt.Errorf("unknown message kind '%s'", m.Kind)
+	}
+	return err
+}
@@ -0,0 +1,419 @@
+package outbox
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/internal/generated/notifier"
+	"github.com/object88/tugboat/pkg/http/probes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Options control delivery retries
+type Options struct {
+	// MaxAttempts is the number of delivery attempts before a message is
+	// dead-lettered
+	MaxAttempts int
+
+	// InitialBackoff is the delay after the first failed attempt; each
+	// subsequent failure doubles the delay, up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Timeout bounds each delivery attempt
+	Timeout time.Duration
+
+	// MaxDeadLetters is the number of dead-lettered messages kept per
+	// listener; older messages are discarded
+	MaxDeadLetters int
+
+	// MaxPending is the number of pending messages kept per listener; when a
+	// queue is full, its oldest message is dropped.  Zero is unbounded.
+	MaxPending int
+
+	// SaveDelay is how long after a change the state is persisted, so that a
+	// burst of changes is saved once
+	SaveDelay time.Duration
+}
+
+// DefaultOptions returns the default Options
+func DefaultOptions() Options {
+	return Options{
+		MaxAttempts:    10,
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Minute,
+		Timeout:        5 * time.Second,
+		MaxDeadLetters: 50,
+		MaxPending:     100,
+		SaveDelay:      time.Second,
+	}
+}
+
+// Outbox delivers messages to each listener independently, in order, with
+// retries.  A listener that is down does not delay delivery to the others.
+// Pending and dead-lettered messages are persisted with the Journal so that
+// they survive restarts; the state is saved in the background, shortly after
+// it changes, rather than while the lock is held.
+type Outbox struct {
+	logger  logr.Logger
+	journal Journal
+	opts    Options
+
+	mu     sync.Mutex
+	state  *State
+	queues map[string]*queue
+
+	// dirty is signaled when the state changes
+	dirty chan struct{}
+
+	// ctx is set while Run is active
+	ctx context.Context
+	wg  sync.WaitGroup
+
+	now func() time.Time
+}
+
+type queue struct {
+	name   string
+	client notifier.ListenerClient
+	state  *QueueState
+	wake   chan struct{}
+}
+
+// New returns a new Outbox.  Call Load to restore any persisted state before
+// adding listeners.
+func New(logger logr.Logger, journal Journal, opts Options) *Outbox {
+	return &Outbox{
+		logger:  logger,
+		journal: journal,
+		opts:    opts,
+		state:   newState(),
+		queues:  map[string]*queue{},
+		dirty:   make(chan struct{}, 1),
+		now:     time.Now,
+	}
+}
+
+// Load restores the persisted state.  Queues for listeners that are not
+// re-added are retained, and continue to queue messages, but are not
+// delivered.
+func (o *Outbox) Load(ctx context.Context) error {
+	s, err := o.journal.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.state = s
+	for name, qs := range s.Queues {
+		q := o.queue(name)
+		q.state = qs
+		if len(qs.Pending) != 0 {
+			o.logger.Info("restored pending notifications", "listener", name, "pending", len(qs.Pending))
+		}
+	}
+	return nil
+}
+
+// AddListener starts delivering messages to the listener.  If the name is
+// already known, its client is replaced and any pending messages are
+// delivered with the new client, starting immediately.
+func (o *Outbox) AddListener(name string, client notifier.ListenerClient) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	q := o.queue(name)
+	q.client = client
+	if len(q.state.Pending) != 0 {
+		q.state.Pending[0].NextAttempt = time.Time{}
+	}
+	poke(q)
+}
+
+// RemoveListener stops delivering messages to the listener.  Its messages,
+// including those enqueued while it is removed, are kept in case the
+// listener is re-added.
+func (o *Outbox) RemoveListener(name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if q, ok := o.queues[name]; ok {
+		q.client = nil
+	}
+}
+
+// Enqueue adds the message to the queue of every known listener, to be
+// persisted.  Listeners which are not connected, e.g. because they were
+// restored by Load and have not been discovered yet, receive the message
+// once they are added.  If a queue is full, its oldest message is dropped.
+func (o *Outbox) Enqueue(m Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.queues) == 0 {
+		o.logger.Info("no listeners are known; notification dropped", "id", m.ID, "kind", m.Kind)
+		return nil
+	}
+	for _, q := range o.queues {
+		q.state.Pending = append(q.state.Pending, Entry{Message: m})
+		if o.opts.MaxPending > 0 && len(q.state.Pending) > o.opts.MaxPending {
+			// The worker ignores the result of delivering a dropped head.
+			dropped := q.state.Pending[0]
+			q.state.Pending = q.state.Pending[1:]
+			q.state.Dropped++
+			o.logger.Info("listener queue is full; dropped oldest notification", "listener", q.name, "id", dropped.ID, "kind", dropped.Kind, "attempts", dropped.Attempts)
+		}
+		poke(q)
+	}
+	o.changed()
+	return nil
+}
+
+// Run delivers messages until the context is canceled
+func (o *Outbox) Run(ctx context.Context, r probes.Reporter) error {
+	o.mu.Lock()
+	o.ctx = ctx
+	for _, q := range o.queues {
+		o.start(q)
+	}
+	o.wg.Add(1)
+	go o.persist(ctx)
+	o.mu.Unlock()
+
+	r.Ready()
+	<-ctx.Done()
+	r.NotReady()
+
+	o.wg.Wait()
+
+	o.mu.Lock()
+	o.ctx = nil
+	o.mu.Unlock()
+	o.save()
+	return nil
+}
+
+// Status returns the delivery status of every listener, sorted by name
+func (o *Outbox) Status() Status {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	s := Status{Listeners: make([]ListenerStatus, 0, len(o.queues))}
+	for _, q := range o.queues {
+		ls := ListenerStatus{
+			Name:         q.name,
+			Connected:    q.client != nil,
+			Pending:      len(q.state.Pending),
+			Delivered:    q.state.Delivered,
+			Skipped:      q.state.Skipped,
+			Dropped:      q.state.Dropped,
+			DeadLettered: len(q.state.DeadLetters),
+			DeadLetters:  append([]Entry{}, q.state.DeadLetters...),
+		}
+		if len(q.state.Pending) != 0 {
+			head := q.state.Pending[0]
+			ls.Attempts = head.Attempts
+			ls.LastError = head.LastError
+			if !head.NextAttempt.IsZero() {
+				t := head.NextAttempt
+				ls.NextAttempt = &t
+			}
+		}
+		s.Listeners = append(s.Listeners, ls)
+	}
+	sort.Slice(s.Listeners, func(i, j int) bool {
+		return s.Listeners[i].Name < s.Listeners[j].Name
+	})
+	return s
+}
+
+// queue returns the named queue, creating it if necessary.  The caller must
+// hold the lock.
+func (o *Outbox) queue(name string) *queue {
+	q, ok := o.queues[name]
+	if ok {
+		return q
+	}
+
+	qs, ok := o.state.Queues[name]
+	if !ok {
+		qs = &QueueState{}
+		o.state.Queues[name] = qs
+	}
+	q = &queue{
+		name:  name,
+		state: qs,
+		wake:  make(chan struct{}, 1),
+	}
+	o.queues[name] = q
+	if o.ctx != nil {
+		o.start(q)
+	}
+	return q
+}
+
+// start runs a worker for the queue.  The caller must hold the lock.
+func (o *Outbox) start(q *queue) {
+	o.wg.Add(1)
+	go o.work(o.ctx, q)
+}
+
+// work delivers the queue's messages in order until the context is
+// canceled.  There is exactly one worker per queue, so only the worker
+// removes entries from the head of the queue.
+func (o *Outbox) work(ctx context.Context, q *queue) {
+	defer o.wg.Done()
+
+	for {
+		o.mu.Lock()
+		client := q.client
+		var head *Entry
+		if client != nil && len(q.state.Pending) != 0 {
+			e := q.state.Pending[0]
+			head = &e
+		}
+		o.mu.Unlock()
+
+		if head == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+			}
+			continue
+		}
+
+		if wait := head.NextAttempt.Sub(o.now()); wait > 0 {
+			t := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-q.wake:
+				t.Stop()
+			case <-t.C:
+			}
+			continue
+		}
+
+		actx, cancel := context.WithTimeout(ctx, o.opts.Timeout)
+		err := head.deliver(actx, client)
+		cancel()
+		if ctx.Err() != nil {
+			// Shutting down; the attempt will be made again after restart.
+			return
+		}
+
+		o.mu.Lock()
+		o.record(q, head.ID, err)
+		o.mu.Unlock()
+	}
+}
+
+// record updates the queue with the result of a delivery attempt.  The
+// caller must hold the lock.
+func (o *Outbox) record(q *queue, id string, err error) {
+	if len(q.state.Pending) == 0 || q.state.Pending[0].ID != id {
+		return
+	}
+	head := &q.state.Pending[0]
+
+	switch {
+	case err == nil:
+		q.state.Pending = q.state.Pending[1:]
+		q.state.Delivered++
+	case status.Code(err) == codes.Unimplemented:
+		// The listener does not care about this kind of message.
+		q.state.Pending = q.state.Pending[1:]
+		q.state.Skipped++
+	default:
+		head.Attempts++
+		head.LastError = err.Error()
+		if head.Attempts < o.opts.MaxAttempts {
+			head.NextAttempt = o.now().Add(o.backoff(head.Attempts))
+			o.logger.Info("failed to deliver notification; will retry", "listener", q.name, "id", id, "attempts", head.Attempts, "next", head.NextAttempt, "error", err.Error())
+			break
+		}
+
+		o.logger.Error(err, "failed to deliver notification; dead-lettering", "listener", q.name, "id", id, "attempts", head.Attempts)
+		head.NextAttempt = time.Time{}
+		q.state.DeadLetters = append(q.state.DeadLetters, *head)
+		if n := len(q.state.DeadLetters) - o.opts.MaxDeadLetters; n > 0 {
+			q.state.DeadLetters = q.state.DeadLetters[n:]
+		}
+		q.state.Pending = q.state.Pending[1:]
+	}
+
+	o.changed()
+}
+
+func (o *Outbox) backoff(attempts int) time.Duration {
+	d := o.opts.InitialBackoff
+	for i := 1; i < attempts && d < o.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > o.opts.MaxBackoff {
+		d = o.opts.MaxBackoff
+	}
+	return d
+}
+
+// changed marks the state to be persisted.  The caller must hold the lock.
+func (o *Outbox) changed() {
+	select {
+	case o.dirty <- struct{}{}:
+	default:
+	}
+}
+
+// persist saves the state SaveDelay after it changes, until the context is
+// canceled
+func (o *Outbox) persist(ctx context.Context) {
+	defer o.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.dirty:
+		}
+
+		if o.opts.SaveDelay > 0 {
+			t := time.NewTimer(o.opts.SaveDelay)
+			select {
+			case <-ctx.Done():
+				// Run saves the state once every worker has stopped.
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+		o.save()
+	}
+}
+
+// save persists a copy of the state.  The caller must not hold the lock.
+func (o *Outbox) save() {
+	o.mu.Lock()
+	s := o.state.copy()
+	o.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := o.journal.Save(ctx, s); err != nil {
+		o.logger.Error(err, "failed to save outbox")
+	}
+}
+
+func poke(q *queue) {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,329 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/internal/generated/notifier"
+	"github.com/object88/tugboat/pkg/http/probes"
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeListener records the releases it receives.  The first `failures`
+// calls fail, and any call of a kind in `unimplemented` fails with
+// codes.Unimplemented.
+type fakeListener struct {
+	mu            sync.Mutex
+	failures      int
+	unimplemented map[Kind]bool
+	received      []string
+	calls         int
+}
+
+func (f *fakeListener) call(kind Kind, release string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if f.unimplemented[kind] {
+		return status.Error(codes.Unimplemented, "not implemented")
+	}
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("listener is down")
+	}
+	f.received = append(f.received, release)
+	return nil
+}
+
+func (f *fakeListener) snapshot() ([]string, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.received...), f.calls
+}
+
+func (f *fakeListener) OpenDeployment(ctx context.Context, in *notifier.StartDeploymentRequest, opts ...grpc.CallOption) (*notifier.StartDeploymentResponse, error) {
+	return &notifier.StartDeploymentResponse{}, f.call(KindOpen, in.GetReleaseName())
+}
+
+func (f *fakeListener) UpdateDeployment(ctx context.Context, in *notifier.UpdateDeploymentRequest, opts ...grpc.CallOption) (*notifier.UpdateDeploymentResponse, error) {
+	return &notifier.UpdateDeploymentResponse{}, f.call(KindUpdate, in.GetReleaseName())
+}
+
+func (f *fakeListener) CloseDeployment(ctx context.Context, in *notifier.CloseDeploymentRequest, opts ...grpc.CallOption) (*notifier.CloseDeploymentResponse, error) {
+	return &notifier.CloseDeploymentResponse{}, f.call(KindClose, in.GetReleaseName())
+}
+
+func Test_Outbox_Deliver(t *testing.T) {
+	healthy := &fakeListener{}
+	flaky := &fakeListener{failures: 2}
+	down := &fakeListener{failures: 1000}
+	slackish := &fakeListener{unimplemented: map[Kind]bool{KindClose: true}}
+
+	ob := New(testlogger.TestLogger{T: t}, &MemoryJournal{}, testOptions())
+	ob.AddListener("healthy", healthy)
+	ob.AddListener("flaky", flaky)
+	ob.AddListener("down", down)
+	ob.AddListener("slackish", slackish)
+
+	stop := run(t, ob)
+	defer stop()
+
+	enqueue(t, ob, open("a"), open("b"), closed("a"))
+
+	waitFor(t, func() bool {
+		s := listenerStatuses(ob)
+		return s["healthy"].Delivered == 3 && s["flaky"].Delivered == 3 && s["down"].DeadLettered == 3 && s["slackish"].Skipped == 1
+	})
+
+	for _, l := range []*fakeListener{healthy, flaky} {
+		received, _ := l.snapshot()
+		if len(received) != 3 || received[0] != "a" || received[1] != "b" || received[2] != "a" {
+			t.Errorf("messages delivered out of order: %v", received)
+		}
+	}
+
+	s := listenerStatuses(ob)
+	if _, calls := down.snapshot(); calls != 3*testOptions().MaxAttempts {
+		t.Errorf("incorrect number of attempts to down listener: %d", calls)
+	}
+	if s["down"].Pending != 0 || s["down"].DeadLetters[0].LastError != "listener is down" {
+		t.Errorf("incorrect status for down listener: %#v", s["down"])
+	}
+	if s["slackish"].Delivered != 2 || s["slackish"].DeadLettered != 0 {
+		t.Errorf("incorrect status for listener without close: %#v", s["slackish"])
+	}
+}
+
+func Test_Outbox_RemoveListener(t *testing.T) {
+	l := &fakeListener{}
+
+	ob := New(testlogger.TestLogger{T: t}, &MemoryJournal{}, testOptions())
+	ob.AddListener("l", l)
+	ob.RemoveListener("l")
+
+	stop := run(t, ob)
+	defer stop()
+
+	enqueue(t, ob, open("a"))
+	ob.AddListener("l", l)
+	enqueue(t, ob, open("b"))
+
+	waitFor(t, func() bool {
+		return listenerStatuses(ob)["l"].Delivered == 2
+	})
+	if received, _ := l.snapshot(); len(received) != 2 || received[0] != "a" || received[1] != "b" {
+		t.Errorf("incorrect messages delivered: %v", received)
+	}
+}
+
+func Test_Outbox_Persistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	journal := NewFileJournal(filepath.Join(dir, "outbox.json"))
+
+	// The first watcher cannot reach the listener before it shuts down.
+	opts := testOptions()
+	opts.InitialBackoff = time.Hour
+	opts.MaxBackoff = time.Hour
+	ob := New(testlogger.TestLogger{T: t}, journal, opts)
+	if err := ob.Load(context.Background()); err != nil {
+		t.Fatalf("failed to load: %s", err.Error())
+	}
+	ob.AddListener("l", &fakeListener{failures: 1000})
+	stop := run(t, ob)
+	enqueue(t, ob, open("a"), open("b"))
+	waitFor(t, func() bool {
+		return listenerStatuses(ob)["l"].Attempts == 1
+	})
+	stop()
+
+	// After restart, the pending messages, and those sent before the
+	// listener is re-added, are delivered.
+	l := &fakeListener{}
+	ob = New(testlogger.TestLogger{T: t}, journal, testOptions())
+	if err := ob.Load(context.Background()); err != nil {
+		t.Fatalf("failed to load: %s", err.Error())
+	}
+	if s := listenerStatuses(ob)["l"]; s.Pending != 2 || s.Connected {
+		t.Fatalf("incorrect restored status: %#v", s)
+	}
+	stop = run(t, ob)
+	defer stop()
+	enqueue(t, ob, open("c"))
+	ob.AddListener("l", l)
+
+	waitFor(t, func() bool {
+		return listenerStatuses(ob)["l"].Delivered == 3
+	})
+	if received, _ := l.snapshot(); len(received) != 3 || received[0] != "a" || received[1] != "b" || received[2] != "c" {
+		t.Errorf("incorrect messages delivered: %v", received)
+	}
+}
+
+func Test_Outbox_MaxPending(t *testing.T) {
+	opts := testOptions()
+	opts.MaxPending = 2
+	ob := New(testlogger.TestLogger{T: t}, &MemoryJournal{}, opts)
+	ob.AddListener("l", &fakeListener{})
+
+	enqueue(t, ob, open("a"), open("b"), open("c"))
+
+	s := listenerStatuses(ob)["l"]
+	if s.Pending != 2 || s.Dropped != 1 {
+		t.Fatalf("incorrect status: %#v", s)
+	}
+	if ob.state.Queues["l"].Pending[0].Message.ID == open("a").ID {
+		t.Errorf("oldest message was not dropped")
+	}
+}
+
+// countingJournal counts the saves of the state
+type countingJournal struct {
+	MemoryJournal
+	mu    sync.Mutex
+	saves int
+}
+
+func (j *countingJournal) Save(ctx context.Context, s *State) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.saves++
+	return nil
+}
+
+func (j *countingJournal) count() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.saves
+}
+
+func Test_Outbox_SaveDelay(t *testing.T) {
+	journal := &countingJournal{}
+	opts := testOptions()
+	opts.SaveDelay = 50 * time.Millisecond
+	opts.InitialBackoff = time.Hour
+	opts.MaxBackoff = time.Hour
+	ob := New(testlogger.TestLogger{T: t}, journal, opts)
+	ob.AddListener("l", &fakeListener{failures: 1000})
+
+	stop := run(t, ob)
+	enqueue(t, ob, open("a"), open("b"), open("c"), open("d"))
+	if journal.count() != 0 {
+		t.Errorf("state saved on enqueue")
+	}
+	waitFor(t, func() bool {
+		return journal.count() != 0
+	})
+	stop()
+
+	if n := journal.count(); n > 3 {
+		t.Errorf("state saved %d times; expected changes to be batched", n)
+	}
+}
+
+func Test_ConfigMapJournal(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	journal := NewConfigMapJournal(clientset, "tugboat", "outbox")
+
+	s, err := journal.Load(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error loading missing configmap: %s", err.Error())
+	}
+	if len(s.Queues) != 0 {
+		t.Errorf("expected empty state")
+	}
+
+	m, _ := NewOpenMessage(&notifier.StartDeploymentRequest{ReleaseName: "a"})
+	for i := 0; i < 2; i++ {
+		s.Queues["l"] = &QueueState{Pending: []Entry{{Message: m}}, Delivered: uint64(i)}
+		if err := journal.Save(ctx, s); err != nil {
+			t.Fatalf("unexpected error saving: %s", err.Error())
+		}
+	}
+
+	actual, err := journal.Load(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error loading: %s", err.Error())
+	}
+	q := actual.Queues["l"]
+	if q == nil || q.Delivered != 1 || len(q.Pending) != 1 || q.Pending[0].ID != m.ID {
+		t.Errorf("incorrect state loaded: %#v", q)
+	}
+}
+
+func testOptions() Options {
+	return Options{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		Timeout:        time.Second,
+		MaxDeadLetters: 10,
+	}
+}
+
+func run(t *testing.T, ob *Outbox) func() {
+	p := probes.New()
+	p.SetCapacity(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ob.Run(ctx, p.Reporter(0))
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func enqueue(t *testing.T, ob *Outbox, ms ...Message) {
+	for _, m := range ms {
+		if err := ob.Enqueue(m); err != nil {
+			t.Fatalf("failed to enqueue: %s", err.Error())
+		}
+	}
+}
+
+func open(release string) Message {
+	m, _ := NewOpenMessage(&notifier.StartDeploymentRequest{ReleaseName: release})
+	return m
+}
+
+func closed(release string) Message {
+	m, _ := NewCloseMessage(&notifier.CloseDeploymentRequest{ReleaseName: release, Outcome: notifier.Outcome_OUTCOME_SUCCEEDED})
+	return m
+}
+
+func listenerStatuses(ob *Outbox) map[string]ListenerStatus {
+	result := map[string]ListenerStatus{}
+	for _, ls := range ob.Status().Listeners {
+		result[ls.Name] = ls
+	}
+	return result
+}
+
+func waitFor(t *testing.T, f func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
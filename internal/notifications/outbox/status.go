@@ -0,0 +1,24 @@
+package outbox
+
+import "time"
+
+// Status is the delivery status of every known listener
+type Status struct {
+	Listeners []ListenerStatus `json:"listeners"`
+}
+
+// ListenerStatus is the delivery status of a single listener.  Attempts,
+// NextAttempt, and LastError describe the message at the head of the queue.
+type ListenerStatus struct {
+	Name         string     `json:"name"`
+	Connected    bool       `json:"connected"`
+	Pending      int        `json:"pending"`
+	Delivered    uint64     `json:"delivered"`
+	Skipped      uint64     `json:"skipped"`
+	Dropped      uint64     `json:"dropped,omitempty"`
+	DeadLettered int        `json:"deadLettered"`
+	Attempts     int        `json:"attempts,omitempty"`
+	NextAttempt  *time.Time `json:"nextAttempt,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+	DeadLetters  []Entry    `json:"deadLetters,omitempty"`
+}
@@ -0,0 +1,30 @@
+package source
+
+import (
+	"strings"
+
+	"github.com/object88/tugboat/internal/generated/notifier"
+)
+
+// Format renders the source-control metadata of a revision for people to
+// read, e.g. "commit 0123abc on main, https://github.com/shop/web/pull/42,
+// pipeline 981".  Unknown metadata is "".
+func Format(s *notifier.Source) string {
+	parts := []string{}
+	if c := s.GetCommit(); c != "" {
+		commit := "commit " + c
+		if b := s.GetBranch(); b != "" {
+			commit += " on " + b
+		}
+		parts = append(parts, commit)
+	} else if b := s.GetBranch(); b != "" {
+		parts = append(parts, "branch "+b)
+	}
+	if pr := s.GetPullRequest(); pr != "" {
+		parts = append(parts, pr)
+	}
+	if p := s.GetPipeline(); p != "" {
+		parts = append(parts, "pipeline "+p)
+	}
+	return strings.Join(parts, ", ")
+}
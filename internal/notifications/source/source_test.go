@@ -0,0 +1,38 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/object88/tugboat/internal/generated/notifier"
+)
+
+func Test_Format(t *testing.T) {
+	tcs := []struct {
+		name     string
+		source   *notifier.Source
+		expected string
+	}{
+		{
+			name:     "unknown",
+			expected: "",
+		},
+		{
+			name:     "branch",
+			source:   &notifier.Source{Branch: "main"},
+			expected: "branch main",
+		},
+		{
+			name:     "all",
+			source:   &notifier.Source{Commit: "0123abc", Branch: "main", PullRequest: "https://github.com/shop/web/pull/42", Pipeline: "981"},
+			expected: "commit 0123abc on main, https://github.com/shop/web/pull/42, pipeline 981",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Format(tc.source); actual != tc.expected {
+				t.Errorf("incorrect format:\nexpected: %s\nactual:   %s", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -8,12 +8,19 @@ import (
 
 // CLI Flags
 const (
+	// channelKey is the channel that deployment notifications are sent to
+	channelKey = "slack-channel"
+
 	// signingSecretKey verifies that a request has come from Slack
 	signingSecretKey = "slack-signing-secret"
 
 	// tokenKey provides the slack token
 	tokenKey = "slack-token"
 
+	// usersKey is the path to the file mapping Kubernetes usernames to Slack
+	// user IDs
+	usersKey = "slack-users"
+
 	verificationKey = "slack-verification"
 )
 
@@ -21,8 +28,10 @@ const (
 type FlagManager struct {
 	// Do not access these directly; properties that are set via environment
 	// configs (i.e. `viper.BindEnv`) will not get updated here.
+	channel       string
 	signingSecret string
 	token         string
+	users         string
 	verification  string
 }
 
@@ -40,11 +49,28 @@ func (fl *FlagManager) ConfigureFlags(flags *pflag.FlagSet) {
 	viper.BindEnv(tokenKey)
 	viper.BindPFlag(tokenKey, flags.Lookup(tokenKey))
 
+	flags.StringVar(&fl.users, usersKey, "", "path to the YAML file mapping Kubernetes usernames to Slack user IDs, to @-mention deployers")
+	viper.BindEnv(usersKey)
+	viper.BindPFlag(usersKey, flags.Lookup(usersKey))
+
 	flags.StringVar(&fl.verification, verificationKey, "", "slack verification")
 	viper.BindEnv(verificationKey)
 	viper.BindPFlag(verificationKey, flags.Lookup(verificationKey))
 }
 
+// ConfigureChannelFlag configures the channel that deployment notifications
+// are sent to
+func (fl *FlagManager) ConfigureChannelFlag(flags *pflag.FlagSet) {
+	flags.StringVar(&fl.channel, channelKey, "general", "slack channel to send deployment notifications to")
+	viper.BindEnv(channelKey)
+	viper.BindPFlag(channelKey, flags.Lookup(channelKey))
+}
+
+// Channel returns the channel that deployment notifications are sent to
+func (fl *FlagManager) Channel() string {
+	return viper.GetString(channelKey)
+}
+
 func (fl *FlagManager) Config() config.Config {
 	return config.Config{
 		SigningSecret: viper.GetString(signingSecretKey),
@@ -52,3 +78,13 @@ func (fl *FlagManager) Config() config.Config {
 		Verification:  viper.GetString(verificationKey),
 	}
 }
+
+// Users returns the mapping of Kubernetes usernames to Slack user IDs, which
+// is empty if no file is configured
+func (fl *FlagManager) Users() (config.Users, error) {
+	p := viper.GetString(usersKey)
+	if p == "" {
+		return config.Users{}, nil
+	}
+	return config.LoadUsers(p)
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Users maps Kubernetes usernames to Slack user IDs, so that deployers can be
+// @-mentioned
+type Users map[string]string
+
+// LoadUsers reads the users from a YAML or JSON file
+func LoadUsers(p string) (Users, error) {
+	buf, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read slack users '%s': %w", p, err)
+	}
+	return ParseUsers(buf)
+}
+
+// ParseUsers decodes the users from YAML or JSON and validates them
+func ParseUsers(buf []byte) (Users, error) {
+	us := Users{}
+	if err := yaml.UnmarshalStrict(buf, &us); err != nil {
+		return nil, fmt.Errorf("failed to decode slack users: %w", err)
+	}
+	for username, id := range us {
+		if id == "" {
+			return nil, fmt.Errorf("user '%s' has no slack user ID", username)
+		}
+	}
+	return us, nil
+}
+
+// Mention returns how to @-mention the Kubernetes user in Slack, if they are
+// mapped to a Slack user
+func (us Users) Mention(username string) (string, bool) {
+	id, ok := us[username]
+	if !ok {
+		return "", false
+	}
+	return "<@" + id + ">", true
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+)
+
+func Test_ParseUsers(t *testing.T) {
+	tcs := []struct {
+		name        string
+		buf         string
+		expectedErr bool
+	}{
+		{
+			name: "yaml",
+			buf:  "alice@example.com: U01ALICE\nsystem:serviceaccount:ci:deployer: U02DEPLOY\n",
+		},
+		{
+			name:        "missing-id",
+			buf:         "alice@example.com: \"\"\n",
+			expectedErr: true,
+		},
+		{
+			name:        "not-a-map",
+			buf:         "- alice@example.com\n",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			us, err := ParseUsers([]byte(tc.buf))
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m, ok := us.Mention("alice@example.com"); !ok || m != "<@U01ALICE>" {
+				t.Errorf("incorrect mention '%s'", m)
+			}
+			if _, ok := us.Mention("bob@example.com"); ok {
+				t.Errorf("unexpected mention of unmapped user")
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	listerv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/listers/engineering.tugboat/v1alpha1"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+const helpText = "Sorry, I didn't understand that.  Try asking:\n" +
+	"• `what's deploying in NAMESPACE?`\n" +
+	"• `is RELEASE healthy?`\n" +
+	"• `is RELEASE in NAMESPACE healthy?`"
+
+// Answerer replies to a Query using the ReleaseHistory objects in the
+// cluster
+type Answerer struct {
+	lister listerv1alpha1.ReleaseHistoryLister
+
+	now func() time.Time
+}
+
+// NewAnswerer returns a new Answerer
+func NewAnswerer(lister listerv1alpha1.ReleaseHistoryLister) *Answerer {
+	return &Answerer{
+		lister: lister,
+		now:    time.Now,
+	}
+}
+
+// Answer returns a Slack-formatted reply to the query
+func (a *Answerer) Answer(q Query) (string, error) {
+	switch q.Kind {
+	case Deploying:
+		return a.answerDeploying(q)
+	case Health:
+		return a.answerHealth(q)
+	default:
+		return helpText, nil
+	}
+}
+
+func (a *Answerer) answerDeploying(q Query) (string, error) {
+	rhs, err := a.lister.ReleaseHistories(q.Namespace).List(labels.Everything())
+	if err != nil {
+		return "", fmt.Errorf("failed to list release histories in namespace '%s': %w", q.Namespace, err)
+	}
+	sortReleaseHistories(rhs)
+
+	lines := []string{}
+	for _, rh := range rhs {
+		rev, ok := rh.LatestRevision()
+		if !ok {
+			continue
+		}
+		status := revisionStatus(rev)
+		if !status.IsPending() {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("• `%s` revision %d (%s, started %s ago)", rh.Spec.ReleaseName, rev.Revision, status, a.since(rev.DeployedAt.Time)))
+	}
+
+	if len(lines) == 0 {
+		return fmt.Sprintf("Nothing is deploying in `%s` right now.", q.Namespace), nil
+	}
+	return fmt.Sprintf("Deploying in `%s`:\n%s", q.Namespace, strings.Join(lines, "\n")), nil
+}
+
+func (a *Answerer) answerHealth(q Query) (string, error) {
+	r, err := labels.NewRequirement(constants.LabelReleaseName, selection.Equals, []string{q.Release})
+	if err != nil {
+		return "", fmt.Errorf("failed to create requirement for release '%s': %w", q.Release, err)
+	}
+	selector := labels.NewSelector().Add(*r)
+
+	var rhs []*v1alpha1.ReleaseHistory
+	if q.Namespace != "" {
+		rhs, err = a.lister.ReleaseHistories(q.Namespace).List(selector)
+	} else {
+		rhs, err = a.lister.List(selector)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to list release histories for release '%s': %w", q.Release, err)
+	}
+
+	if len(rhs) == 0 {
+		if q.Namespace != "" {
+			return fmt.Sprintf("I don't know of a release `%s` in `%s`.", q.Release, q.Namespace), nil
+		}
+		return fmt.Sprintf("I don't know of a release `%s`.", q.Release), nil
+	}
+	sortReleaseHistories(rhs)
+
+	lines := make([]string, len(rhs))
+	for k, rh := range rhs {
+		lines[k] = a.describeHealth(rh)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (a *Answerer) describeHealth(rh *v1alpha1.ReleaseHistory) string {
+	name := fmt.Sprintf("`%s` in `%s`", rh.Spec.ReleaseName, rh.Namespace)
+
+	if rh.Labels[constants.LabelState] == constants.LabelStateUninstalled {
+		return fmt.Sprintf("%s has been uninstalled.", name)
+	}
+
+	rev, ok := rh.LatestRevision()
+	if !ok {
+		return fmt.Sprintf("%s has no recorded revisions yet.", name)
+	}
+
+	status := revisionStatus(rev)
+	switch {
+	case status == release.StatusDeployed:
+		return fmt.Sprintf("%s is healthy: revision %d was deployed %s ago.", name, rev.Revision, a.since(rev.DeployedAt.Time))
+	case status.IsPending():
+		return fmt.Sprintf("%s is deploying: revision %d is %s, started %s ago.", name, rev.Revision, status, a.since(rev.DeployedAt.Time))
+	case status == release.StatusUnknown:
+		return fmt.Sprintf("%s is at revision %d, but I could not determine its status.", name, rev.Revision)
+	default:
+		return fmt.Sprintf("%s is not healthy: revision %d is %s.", name, rev.Revision, status)
+	}
+}
+
+// revisionStatus returns the status of the helm release of a revision, as
+// recorded by the controller
+func revisionStatus(rev v1alpha1.ReleaseHistoryRevision) release.Status {
+	if rev.Status == "" {
+		return release.StatusUnknown
+	}
+	return release.Status(rev.Status)
+}
+
+func (a *Answerer) since(t time.Time) string {
+	return duration.HumanDuration(a.now().Sub(t))
+}
+
+func sortReleaseHistories(rhs []*v1alpha1.ReleaseHistory) {
+	sort.Slice(rhs, func(i, j int) bool {
+		if rhs[i].Namespace != rhs[j].Namespace {
+			return rhs[i].Namespace < rhs[j].Namespace
+		}
+		return rhs[i].Name < rhs[j].Name
+	})
+}
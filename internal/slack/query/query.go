@@ -0,0 +1,68 @@
+package query
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kind describes the type of question asked of the bot
+type Kind int
+
+const (
+	// Unknown is a question that the bot does not understand
+	Unknown Kind = iota
+
+	// Deploying asks which releases are currently deploying in a namespace
+	Deploying
+
+	// Health asks whether a release is healthy
+	Health
+)
+
+// Query is a parsed app mention
+type Query struct {
+	Kind      Kind
+	Namespace string
+	Release   string
+}
+
+const (
+	// Kubernetes names are DNS labels; be slightly permissive and let the lister
+	// sort out anything that does not exist.
+	nameExpr = `[a-z0-9]([-a-z0-9.]*[a-z0-9])?`
+)
+
+var (
+	mentionRegex = regexp.MustCompile(`<@[^>]+>`)
+
+	deployingRegex = regexp.MustCompile(`^(?:what(?:'s| is| are)|whats) (?:deploying|being deployed|rolling out) in (?P<namespace>` + nameExpr + `)$`)
+	healthRegex    = regexp.MustCompile(`^(?:is|how is|hows|how's) (?P<release>` + nameExpr + `)(?: in (?P<namespace>` + nameExpr + `))? (?:healthy|ok|okay|up|doing)$`)
+)
+
+// Parse converts the text of an app mention, such as
+// "<@U012AB3CD> what's deploying in payments?", into a Query.  Text that does
+// not match a known question returns a Query with the Unknown kind.
+func Parse(text string) Query {
+	s := mentionRegex.ReplaceAllString(text, " ")
+	s = strings.ToLower(s)
+	s = strings.NewReplacer("‘", "'", "’", "'").Replace(s)
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.TrimRight(s, "?!. ")
+
+	if m := deployingRegex.FindStringSubmatch(s); m != nil {
+		return Query{
+			Kind:      Deploying,
+			Namespace: m[deployingRegex.SubexpIndex("namespace")],
+		}
+	}
+
+	if m := healthRegex.FindStringSubmatch(s); m != nil {
+		return Query{
+			Kind:      Health,
+			Namespace: m[healthRegex.SubexpIndex("namespace")],
+			Release:   m[healthRegex.SubexpIndex("release")],
+		}
+	}
+
+	return Query{Kind: Unknown}
+}
@@ -0,0 +1,199 @@
+package query
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/internal/constants"
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	listerv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/listers/engineering.tugboat/v1alpha1"
+	"helm.sh/helm/v3/pkg/release"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func Test_Query_Parse(t *testing.T) {
+	tcs := []struct {
+		name     string
+		input    string
+		expected Query
+	}{
+		{
+			name:     "deploying",
+			input:    "<@U012AB3CD> what's deploying in payments?",
+			expected: Query{Kind: Deploying, Namespace: "payments"},
+		},
+		{
+			name:     "deploying-curly-apostrophe",
+			input:    "<@U012AB3CD> What’s deploying in payments",
+			expected: Query{Kind: Deploying, Namespace: "payments"},
+		},
+		{
+			name:     "deploying-what-is",
+			input:    "<@U012AB3CD>   what is rolling out in   payments ?",
+			expected: Query{Kind: Deploying, Namespace: "payments"},
+		},
+		{
+			name:     "health",
+			input:    "<@U012AB3CD> is checkout healthy?",
+			expected: Query{Kind: Health, Release: "checkout"},
+		},
+		{
+			name:     "health-with-namespace",
+			input:    "<@U012AB3CD> is checkout in payments ok?",
+			expected: Query{Kind: Health, Namespace: "payments", Release: "checkout"},
+		},
+		{
+			name:     "how-is",
+			input:    "how's checkout doing <@U012AB3CD>",
+			expected: Query{Kind: Health, Release: "checkout"},
+		},
+		{
+			name:     "unknown",
+			input:    "<@U012AB3CD> hello there",
+			expected: Query{Kind: Unknown},
+		},
+		{
+			name:     "empty",
+			input:    "<@U012AB3CD>",
+			expected: Query{Kind: Unknown},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := Parse(tc.input)
+			if actual != tc.expected {
+				t.Errorf("incorrect query: expected %#v, actual %#v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_Answerer_Deploying(t *testing.T) {
+	a := createAnswerer(t,
+		[]*v1alpha1.ReleaseHistory{
+			createReleaseHistory("checkout", "payments", constants.LabelStateActive, release.StatusSuperseded, release.StatusPendingUpgrade),
+			createReleaseHistory("ledger", "payments", constants.LabelStateActive, release.StatusDeployed),
+			createReleaseHistory("frontend", "web", constants.LabelStateActive, "", "", release.StatusPendingUpgrade),
+		})
+
+	answer, err := a.Answer(Query{Kind: Deploying, Namespace: "payments"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !strings.Contains(answer, "`checkout` revision 2 (pending-upgrade") {
+		t.Errorf("answer does not report deploying release: '%s'", answer)
+	}
+	if strings.Contains(answer, "ledger") || strings.Contains(answer, "frontend") {
+		t.Errorf("answer reports releases that are not deploying in namespace: '%s'", answer)
+	}
+
+	answer, err = a.Answer(Query{Kind: Deploying, Namespace: "quiet"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !strings.HasPrefix(answer, "Nothing is deploying") {
+		t.Errorf("unexpected answer for quiet namespace: '%s'", answer)
+	}
+}
+
+func Test_Answerer_Health(t *testing.T) {
+	a := createAnswerer(t,
+		[]*v1alpha1.ReleaseHistory{
+			createReleaseHistory("checkout", "payments", constants.LabelStateActive, release.StatusSuperseded, release.StatusDeployed),
+			createReleaseHistory("checkout", "staging", constants.LabelStateActive, release.StatusFailed),
+			createReleaseHistory("ledger", "payments", constants.LabelStateUninstalled, release.StatusUninstalled),
+			createReleaseHistory("search", "web", constants.LabelStateActive, ""),
+		})
+
+	tcs := []struct {
+		name     string
+		query    Query
+		expected []string
+	}{
+		{
+			name:     "all-namespaces",
+			query:    Query{Kind: Health, Release: "checkout"},
+			expected: []string{"`checkout` in `payments` is healthy: revision 2", "`checkout` in `staging` is not healthy: revision 1 is failed"},
+		},
+		{
+			name:     "one-namespace",
+			query:    Query{Kind: Health, Release: "checkout", Namespace: "payments"},
+			expected: []string{"`checkout` in `payments` is healthy: revision 2"},
+		},
+		{
+			name:     "uninstalled",
+			query:    Query{Kind: Health, Release: "ledger"},
+			expected: []string{"`ledger` in `payments` has been uninstalled"},
+		},
+		{
+			name:     "no-status",
+			query:    Query{Kind: Health, Release: "search"},
+			expected: []string{"`search` in `web` is at revision 1, but I could not determine its status"},
+		},
+		{
+			name:     "missing",
+			query:    Query{Kind: Health, Release: "nope"},
+			expected: []string{"I don't know of a release `nope`"},
+		},
+		{
+			name:     "unknown",
+			query:    Query{Kind: Unknown},
+			expected: []string{"Sorry, I didn't understand that"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			answer, err := a.Answer(tc.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			for _, e := range tc.expected {
+				if !strings.Contains(answer, e) {
+					t.Errorf("answer does not contain '%s': '%s'", e, answer)
+				}
+			}
+		})
+	}
+}
+
+func createAnswerer(t *testing.T, rhs []*v1alpha1.ReleaseHistory) *Answerer {
+	rhindexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, rh := range rhs {
+		if err := rhindexer.Add(rh); err != nil {
+			t.Fatalf("failed to add release history: %s", err.Error())
+		}
+	}
+
+	return NewAnswerer(listerv1alpha1.NewReleaseHistoryLister(rhindexer))
+}
+
+// createReleaseHistory returns a ReleaseHistory with a revision for each
+// status, numbered from 1
+func createReleaseHistory(name string, namespace string, state string, statuses ...release.Status) *v1alpha1.ReleaseHistory {
+	rh := &v1alpha1.ReleaseHistory{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				constants.LabelReleaseName:      name,
+				constants.LabelReleaseNamespace: namespace,
+				constants.LabelState:            state,
+			},
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.ReleaseHistorySpec{
+			ReleaseName: name,
+		},
+	}
+	for k, status := range statuses {
+		rh.Status.Revisions = append(rh.Status.Revisions, v1alpha1.ReleaseHistoryRevision{
+			DeployedAt: metav1.Time{Time: time.Now().Add(-5 * time.Minute)},
+			Revision:   v1alpha1.Revision(k + 1),
+			Status:     status.String(),
+		})
+	}
+	return rh
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/object88/tugboat/internal/slack/config"
+	"github.com/object88/tugboat/internal/slack/query"
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 )
@@ -16,6 +17,10 @@ import (
 type Bot struct {
 	Logger logr.Logger
 
+	// Answerer responds to questions asked via app mentions.  If it is nil,
+	// the bot cannot answer questions about releases.
+	Answerer *query.Answerer
+
 	api *slack.Client
 	cfg *config.Config
 }
@@ -80,9 +85,40 @@ func (b *Bot) ProcessEventCommand(w http.ResponseWriter, r *http.Request) {
 		innerEvent := eventsAPIEvent.InnerEvent
 		switch ev := innerEvent.Data.(type) {
 		case *slackevents.AppMentionEvent:
-			b.SendMessage(ev.Channel, "Yes, hello.")
+			b.processAppMention(logger, ev)
+		}
+	}
+}
+
+// processAppMention answers a question asked of the bot, replying in the
+// thread of the mention.
+func (b *Bot) processAppMention(logger logr.Logger, ev *slackevents.AppMentionEvent) {
+	if ev.BotID != "" {
+		// Do not converse with other bots.
+		return
+	}
+
+	ts := ev.ThreadTimeStamp
+	if ts == "" {
+		ts = ev.TimeStamp
+	}
+
+	var answer string
+	if b.Answerer == nil {
+		answer = "Sorry, I'm not able to look up releases right now."
+	} else {
+		q := query.Parse(ev.Text)
+		var err error
+		answer, err = b.Answerer.Answer(q)
+		if err != nil {
+			logger.Error(err, "failed to answer app mention", "text", ev.Text)
+			answer = "Sorry, something went wrong while looking that up."
 		}
 	}
+
+	if err := b.SendThreadedMessage(ev.Channel, ts, answer); err != nil {
+		logger.Error(err, "failed to reply to app mention", "channel", ev.Channel)
+	}
 }
 
 func (b *Bot) ProcessSlashCommand(w http.ResponseWriter, r *http.Request) {
@@ -112,3 +148,22 @@ func (b *Bot) SendMessage(channel string, msg string) error {
 	_, _, err := b.api.PostMessage(channel, slack.MsgOptionText(msg, false))
 	return err
 }
+
+// StartThread sends the message and returns its timestamp, to reply to it
+func (b *Bot) StartThread(channel string, msg string) (string, error) {
+	_, ts, err := b.api.PostMessage(channel, slack.MsgOptionText(msg, false))
+	return ts, err
+}
+
+// SendThreadedMessage replies to the message with timestamp ts
+func (b *Bot) SendThreadedMessage(channel string, ts string, msg string) error {
+	_, _, err := b.api.PostMessage(channel, slack.MsgOptionText(msg, false), slack.MsgOptionTS(ts))
+	return err
+}
+
+// BroadcastThreadedMessage replies to the message with timestamp ts, and
+// also sends the reply to the channel
+func (b *Bot) BroadcastThreadedMessage(channel string, ts string, msg string) error {
+	_, _, err := b.api.PostMessage(channel, slack.MsgOptionText(msg, false), slack.MsgOptionTS(ts), slack.MsgOptionBroadcast())
+	return err
+}
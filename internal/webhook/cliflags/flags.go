@@ -0,0 +1,40 @@
+package cliflags
+
+import (
+	"fmt"
+
+	"github.com/object88/tugboat/internal/webhook/config"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	// configKey is the path to the file describing the webhook endpoints
+	configKey = "webhook-config"
+)
+
+// FlagManager maintains the state of webhook-related CLI flags
+type FlagManager struct {
+	// Do not access these directly; properties that are set via environment
+	// configs (i.e. `viper.BindEnv`) will not get updated here.
+	config string
+}
+
+// New returns a new instance of FlagManager
+func New() *FlagManager {
+	return &FlagManager{}
+}
+
+func (fm *FlagManager) ConfigureFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&fm.config, configKey, "", "path to the YAML file describing webhook endpoints")
+	viper.BindEnv(configKey)
+	viper.BindPFlag(configKey, flags.Lookup(configKey))
+}
+
+func (fm *FlagManager) Config() (*config.Config, error) {
+	path := viper.GetString(configKey)
+	if path == "" {
+		return nil, fmt.Errorf("no webhook config provided; set --%s", configKey)
+	}
+	return config.Load(path)
+}
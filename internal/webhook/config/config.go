@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"text/template"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	DefaultMaxRetries     = 3
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 30 * time.Second
+	DefaultTimeout        = 10 * time.Second
+)
+
+// Config describes the set of HTTP endpoints that receive deployment events
+type Config struct {
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+// Endpoint is a single HTTP receiver of deployment events
+type Endpoint struct {
+	// Name identifies the endpoint in logs
+	Name string `json:"name"`
+
+	// URL is the address that the event is POSTed to
+	URL string `json:"url"`
+
+	// Secret is the HMAC-SHA256 key used to sign the request body.  If empty,
+	// requests are not signed.
+	Secret string `json:"secret,omitempty"`
+
+	// Headers are added to each request
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Template is a Go text/template that renders the JSON request body from
+	// the event.  If empty, the event is marshalled as-is.
+	Template string `json:"template,omitempty"`
+
+	// MaxRetries is the number of times a failed delivery is retried
+	MaxRetries *int `json:"maxRetries,omitempty"`
+
+	// InitialBackoff is the delay before the first retry; each subsequent
+	// retry doubles the delay, up to MaxBackoff
+	InitialBackoff *metav1.Duration `json:"initialBackoff,omitempty"`
+	MaxBackoff     *metav1.Duration `json:"maxBackoff,omitempty"`
+
+	// Timeout bounds each individual request
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// Load reads the configuration from a YAML or JSON file, applies defaults,
+// and validates the result
+func Load(path string) (*Config, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook config '%s': %w", path, err)
+	}
+
+	return Parse(buf)
+}
+
+// Parse decodes the configuration from YAML or JSON, applies defaults, and
+// validates the result
+func Parse(buf []byte) (*Config, error) {
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(buf, cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook config: %w", err)
+	}
+
+	cfg.Default()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Default fills in any unset optional fields
+func (c *Config) Default() {
+	for k := range c.Endpoints {
+		e := &c.Endpoints[k]
+		if e.MaxRetries == nil {
+			r := DefaultMaxRetries
+			e.MaxRetries = &r
+		}
+		if e.InitialBackoff == nil {
+			e.InitialBackoff = &metav1.Duration{Duration: DefaultInitialBackoff}
+		}
+		if e.MaxBackoff == nil {
+			e.MaxBackoff = &metav1.Duration{Duration: DefaultMaxBackoff}
+		}
+		if e.Timeout == nil {
+			e.Timeout = &metav1.Duration{Duration: DefaultTimeout}
+		}
+	}
+}
+
+// Validate ensures that each endpoint is usable
+func (c *Config) Validate() error {
+	names := map[string]bool{}
+	for k, e := range c.Endpoints {
+		if e.Name == "" {
+			return fmt.Errorf("endpoint %d does not have a name", k)
+		}
+		if names[e.Name] {
+			return fmt.Errorf("endpoint name '%s' is used more than once", e.Name)
+		}
+		names[e.Name] = true
+
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			return fmt.Errorf("endpoint '%s' has an invalid URL: %w", e.Name, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("endpoint '%s' URL must use http or https; got '%s'", e.Name, e.URL)
+		}
+
+		if e.Template != "" {
+			if _, err := template.New(e.Name).Funcs(TemplateFuncs()).Parse(e.Template); err != nil {
+				return fmt.Errorf("endpoint '%s' has an invalid template: %w", e.Name, err)
+			}
+		}
+
+		if e.MaxRetries != nil && *e.MaxRetries < 0 {
+			return fmt.Errorf("endpoint '%s' has a negative maxRetries", e.Name)
+		}
+		if e.InitialBackoff != nil && e.InitialBackoff.Duration <= 0 {
+			return fmt.Errorf("endpoint '%s' initialBackoff must be positive", e.Name)
+		}
+		if e.Timeout != nil && e.Timeout.Duration <= 0 {
+			return fmt.Errorf("endpoint '%s' timeout must be positive", e.Name)
+		}
+		if e.InitialBackoff != nil && e.MaxBackoff != nil && e.InitialBackoff.Duration > e.MaxBackoff.Duration {
+			return fmt.Errorf("endpoint '%s' initialBackoff is greater than maxBackoff", e.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+)
+
+func Test_Parse(t *testing.T) {
+	cfg, err := Parse([]byte(`
+endpoints:
+  - name: pagerduty
+    url: https://events.example.com/v2/enqueue
+    maxRetries: 5
+    timeout: 5s
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	e := cfg.Endpoints[0]
+	if *e.MaxRetries != 5 || e.Timeout.Duration.String() != "5s" || e.InitialBackoff.Duration != DefaultInitialBackoff || e.MaxBackoff.Duration != DefaultMaxBackoff {
+		t.Errorf("incorrect endpoint: %#v", e)
+	}
+}
+
+func Test_Parse_Invalid(t *testing.T) {
+	tcs := []struct {
+		name  string
+		input string
+	}{
+		{name: "no-name", input: "endpoints:\n  - url: https://example.com\n"},
+		{name: "duplicate-name", input: "endpoints:\n  - name: a\n    url: https://example.com\n  - name: a\n    url: https://example.com\n"},
+		{name: "bad-scheme", input: "endpoints:\n  - name: a\n    url: ftp://example.com\n"},
+		{name: "negative-retries", input: "endpoints:\n  - name: a\n    url: https://example.com\n    maxRetries: -1\n"},
+		{name: "zero-initial-backoff", input: "endpoints:\n  - name: a\n    url: https://example.com\n    initialBackoff: 0s\n"},
+		{name: "negative-initial-backoff", input: "endpoints:\n  - name: a\n    url: https://example.com\n    initialBackoff: -1s\n"},
+		{name: "initial-backoff-over-max", input: "endpoints:\n  - name: a\n    url: https://example.com\n    initialBackoff: 1m\n    maxBackoff: 1s\n"},
+		{name: "zero-timeout", input: "endpoints:\n  - name: a\n    url: https://example.com\n    timeout: 0s\n"},
+		{name: "negative-timeout", input: "endpoints:\n  - name: a\n    url: https://example.com\n    timeout: -5s\n"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tc.input)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"encoding/json"
+	"text/template"
+)
+
+// TemplateFuncs returns the functions available to endpoint payload templates.
+// `json` renders a value as JSON, so that strings are correctly quoted and
+// escaped.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			buf, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			return string(buf), nil
+		},
+	}
+}
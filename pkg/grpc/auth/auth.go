@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrUnauthenticated is returned by an Authenticator when the token is not
+// valid
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// ErrPermissionDenied is returned by an Authenticator when the token is
+// valid, but its identity is not allowed to call the service
+var ErrPermissionDenied = errors.New("permission denied")
+
+// healthPrefix is the prefix of the methods of the grpc.health.v1 service,
+// which do not require authentication so that probes can reach them
+const healthPrefix = "/grpc.health.v1.Health/"
+
+// Identity is the caller authenticated by a token
+type Identity struct {
+	Username string
+	Groups   []string
+}
+
+// Authenticator validates bearer tokens
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Identity, error)
+}
+
+type identityKey struct{}
+
+// IdentityFromContext returns the caller's identity, if the call was
+// authenticated
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok
+}
+
+// UnaryServerInterceptor rejects unary calls without a valid bearer token.
+// Health checks are always allowed.
+func UnaryServerInterceptor(logger logr.Logger, a Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, logger, a, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rejects streaming calls without a valid bearer
+// token.  Health checks are always allowed.
+func StreamServerInterceptor(logger logr.Logger, a Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), logger, a, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, logger logr.Logger, a Authenticator, method string) (context.Context, error) {
+	if strings.HasPrefix(method, healthPrefix) {
+		return ctx, nil
+	}
+
+	token, err := bearerToken(ctx)
+	if err != nil {
+		logger.Info("rejected gRPC call", "method", method, "reason", err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	id, err := a.Authenticate(ctx, token)
+	switch {
+	case err == nil:
+		return context.WithValue(ctx, identityKey{}, id), nil
+	case errors.Is(err, ErrPermissionDenied):
+		logger.Info("rejected gRPC call", "method", method, "reason", err.Error())
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, ErrUnauthenticated):
+		logger.Info("rejected gRPC call", "method", method, "reason", err.Error())
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	default:
+		logger.Error(err, "failed to authenticate gRPC call", "method", method)
+		return nil, status.Error(codes.Unavailable, "failed to authenticate")
+	}
+}
+
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", errors.New("missing metadata")
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", errors.New("missing authorization header")
+	}
+	const prefix = "bearer "
+	if len(values[0]) <= len(prefix) || !strings.EqualFold(values[0][:len(prefix)], prefix) {
+		return "", errors.New("authorization header is not a bearer token")
+	}
+	return strings.TrimSpace(values[0][len(prefix):]), nil
+}
+
+// serverStream replaces the context of a grpc.ServerStream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_UnaryServerInterceptor(t *testing.T) {
+	a := NewStaticToken(func() (string, error) {
+		return "s3cr3t", nil
+	})
+
+	tcs := []struct {
+		name          string
+		method        string
+		authorization []string
+		expected      codes.Code
+	}{
+		{
+			name:     "health-check",
+			method:   "/grpc.health.v1.Health/Check",
+			expected: codes.OK,
+		},
+		{
+			name:          "valid",
+			authorization: []string{"Bearer s3cr3t"},
+			expected:      codes.OK,
+		},
+		{
+			name:          "lowercase-scheme",
+			authorization: []string{"bearer s3cr3t"},
+			expected:      codes.OK,
+		},
+		{
+			name:     "missing",
+			expected: codes.Unauthenticated,
+		},
+		{
+			name:          "wrong-scheme",
+			authorization: []string{"Basic s3cr3t"},
+			expected:      codes.Unauthenticated,
+		},
+		{
+			name:          "wrong-token",
+			authorization: []string{"Bearer guess"},
+			expected:      codes.Unauthenticated,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.authorization != nil {
+				ctx = metadata.NewIncomingContext(ctx, metadata.MD{"authorization": tc.authorization})
+			}
+
+			method := tc.method
+			if method == "" {
+				method = "/notifier.Listener/OpenDeployment"
+			}
+
+			i := UnaryServerInterceptor(testlogger.TestLogger{T: t}, a)
+			_, err := i(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				if id, ok := IdentityFromContext(ctx); tc.method == "" && (!ok || id.Username != StaticUsername) {
+					t.Errorf("handler called without identity")
+				}
+				return nil, nil
+			})
+			if actual := status.Code(err); actual != tc.expected {
+				t.Errorf("incorrect code: expected %s, actual %s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_TokenReview(t *testing.T) {
+	tcs := []struct {
+		name         string
+		token        string
+		allowedUsers []string
+		expectedErr  error
+	}{
+		{
+			name:  "valid",
+			token: "watcher-token",
+		},
+		{
+			name:         "allowed-user",
+			token:        "watcher-token",
+			allowedUsers: []string{"system:serviceaccount:tugboat:tugboat-watcher"},
+		},
+		{
+			name:         "disallowed-user",
+			token:        "watcher-token",
+			allowedUsers: []string{"system:serviceaccount:tugboat:tugboat-slack"},
+			expectedErr:  ErrPermissionDenied,
+		},
+		{
+			name:        "invalid-token",
+			token:       "forged",
+			expectedErr: ErrUnauthenticated,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			reviews := 0
+			clientset.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				reviews++
+				tr := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+				if len(tr.Spec.Audiences) != 1 || tr.Spec.Audiences[0] != "tugboat" {
+					t.Errorf("incorrect audiences: %v", tr.Spec.Audiences)
+				}
+				if tr.Spec.Token == "watcher-token" {
+					tr.Status = authenticationv1.TokenReviewStatus{
+						Authenticated: true,
+						User: authenticationv1.UserInfo{
+							Username: "system:serviceaccount:tugboat:tugboat-watcher",
+						},
+					}
+				} else {
+					tr.Status = authenticationv1.TokenReviewStatus{Error: "invalid bearer token"}
+				}
+				return true, tr, nil
+			})
+
+			a := NewTokenReview(clientset)
+			a.Audiences = []string{"tugboat"}
+			a.AllowedUsers = tc.allowedUsers
+
+			for i := 0; i < 2; i++ {
+				_, err := a.Authenticate(context.Background(), tc.token)
+				if tc.expectedErr == nil && err != nil {
+					t.Errorf("unexpected error: %s", err.Error())
+				} else if tc.expectedErr != nil && !errors.Is(err, tc.expectedErr) {
+					t.Errorf("incorrect error: expected %v, actual %v", tc.expectedErr, err)
+				}
+			}
+			if reviews != 1 {
+				t.Errorf("result was not cached: %d reviews", reviews)
+			}
+
+			a.now = func() time.Time { return time.Now().Add(2 * a.TTL) }
+			a.Authenticate(context.Background(), tc.token)
+			if reviews != 2 {
+				t.Errorf("result was not expired: %d reviews", reviews)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+)
+
+// StaticUsername is the identity of callers authenticated by a static token
+const StaticUsername = "static-token"
+
+// StaticToken authenticates callers which present a shared token
+type StaticToken struct {
+	token func() (string, error)
+}
+
+var _ Authenticator = &StaticToken{}
+
+// NewStaticToken returns a new StaticToken.  The expected token is fetched
+// for every call, so that it may be rotated.
+func NewStaticToken(token func() (string, error)) *StaticToken {
+	return &StaticToken{
+		token: token,
+	}
+}
+
+// Authenticate satisfies the Authenticator interface
+func (st *StaticToken) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	expected, err := st.token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expected token: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(token)) != 1 {
+		return nil, fmt.Errorf("incorrect token: %w", ErrUnauthenticated)
+	}
+	return &Identity{Username: StaticUsername}, nil
+}
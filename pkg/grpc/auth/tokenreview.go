@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultTokenReviewTTL is how long a TokenReview result is cached
+const DefaultTokenReviewTTL = time.Minute
+
+// TokenReview authenticates Kubernetes ServiceAccount tokens with the
+// TokenReview API.  If Audiences is not empty, the token must be valid for
+// one of them.  If AllowedUsers is not empty, the token's user (e.g.
+// `system:serviceaccount:tugboat:tugboat-watcher`) must be one of them.
+// Results are cached for TTL so that every call does not reach the API
+// server.
+type TokenReview struct {
+	clientset    kubernetes.Interface
+	Audiences    []string
+	AllowedUsers []string
+	TTL          time.Duration
+
+	now func() time.Time
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]review
+}
+
+type review struct {
+	identity *Identity
+	err      error
+	expires  time.Time
+}
+
+var _ Authenticator = &TokenReview{}
+
+// NewTokenReview returns a new TokenReview
+func NewTokenReview(clientset kubernetes.Interface) *TokenReview {
+	return &TokenReview{
+		clientset: clientset,
+		TTL:       DefaultTokenReviewTTL,
+		now:       time.Now,
+		cache:     map[[sha256.Size]byte]review{},
+	}
+}
+
+// Authenticate satisfies the Authenticator interface
+func (tr *TokenReview) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	key := sha256.Sum256([]byte(token))
+	now := tr.now()
+
+	tr.mu.Lock()
+	r, ok := tr.cache[key]
+	tr.mu.Unlock()
+	if ok && now.Before(r.expires) {
+		return r.identity, r.err
+	}
+
+	id, err := tr.review(ctx, token)
+	if err != nil && id == nil {
+		// The review itself failed; do not cache the result.
+		return nil, err
+	}
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	for k, v := range tr.cache {
+		if !now.Before(v.expires) {
+			delete(tr.cache, k)
+		}
+	}
+	if err == nil {
+		err = tr.allow(id)
+	}
+	tr.cache[key] = review{identity: id, err: err, expires: now.Add(tr.TTL)}
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// review calls the TokenReview API.  If the token is rejected, an empty
+// Identity is returned with the error, so that the rejection is cached.
+func (tr *TokenReview) review(ctx context.Context, token string) (*Identity, error) {
+	result, err := tr.clientset.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: tr.Audiences,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to review token: %w", err)
+	}
+
+	if !result.Status.Authenticated {
+		reason := result.Status.Error
+		if reason == "" {
+			reason = "token is not authenticated"
+		}
+		return &Identity{}, fmt.Errorf("%s: %w", reason, ErrUnauthenticated)
+	}
+
+	return &Identity{
+		Username: result.Status.User.Username,
+		Groups:   result.Status.User.Groups,
+	}, nil
+}
+
+func (tr *TokenReview) allow(id *Identity) error {
+	if len(tr.AllowedUsers) == 0 {
+		return nil
+	}
+	for _, u := range tr.AllowedUsers {
+		if u == id.Username {
+			return nil
+		}
+	}
+	return fmt.Errorf("user '%s' is not allowed: %w", id.Username, ErrPermissionDenied)
+}
@@ -1,56 +1,118 @@
 package client
 
 import (
-	"context"
 	"fmt"
 	"net/url"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/backoff"
 )
 
+// BackoffOptions control how quickly a lost connection is re-established
+type BackoffOptions struct {
+	// BaseDelay is the delay after the first failed connection attempt; the
+	// delay grows by Multiplier, with Jitter, up to MaxDelay
+	BaseDelay  time.Duration
+	Multiplier float64
+	Jitter     float64
+	MaxDelay   time.Duration
+
+	// MinConnectTimeout bounds each connection attempt
+	MinConnectTimeout time.Duration
+}
+
+// DefaultBackoffOptions returns the default BackoffOptions
+func DefaultBackoffOptions() BackoffOptions {
+	return BackoffOptions{
+		BaseDelay:         backoff.DefaultConfig.BaseDelay,
+		Multiplier:        backoff.DefaultConfig.Multiplier,
+		Jitter:            backoff.DefaultConfig.Jitter,
+		MaxDelay:          30 * time.Second,
+		MinConnectTimeout: 20 * time.Second,
+	}
+}
+
+// DialOption returns the dial option which applies the BackoffOptions
+func (bo BackoffOptions) DialOption() grpc.DialOption {
+	return grpc.WithConnectParams(grpc.ConnectParams{
+		Backoff: backoff.Config{
+			BaseDelay:  bo.BaseDelay,
+			Multiplier: bo.Multiplier,
+			Jitter:     bo.Jitter,
+			MaxDelay:   bo.MaxDelay,
+		},
+		MinConnectTimeout: bo.MinConnectTimeout,
+	})
+}
+
 type Client struct {
 	logger logr.Logger
-	cc     *grpc.ClientConn
+	opts   []grpc.DialOption
+
+	mu sync.Mutex
+	cc *grpc.ClientConn
 }
 
-func New(logger logr.Logger) *Client {
+// New returns a new Client which dials with the provided options, such as
+// transport and per-RPC credentials.  If no options are provided, the
+// connection is insecure.
+func New(logger logr.Logger, opts ...grpc.DialOption) *Client {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithInsecure()}
+	}
 	return &Client{
 		logger: logger,
+		opts:   opts,
 	}
 }
 
+// Close releases the connection.  It is safe to call Close more than once,
+// or before the client has connected.
 func (c *Client) Close() error {
+	c.mu.Lock()
+	cc := c.cc
+	c.cc = nil
+	c.mu.Unlock()
+
+	if cc == nil {
+		return nil
+	}
+	if err := cc.Close(); err != nil {
+		return fmt.Errorf("failed to close connection to '%s': %w", cc.Target(), err)
+	}
 	return nil
 }
 
-func (c *Client) Connect(address *url.URL) error {
-	cc, err := grpc.Dial(address.String(), grpc.WithInsecure())
+// Dial creates the client connection without waiting for it to become
+// ready; RPCs made before the connection is established fail or wait,
+// depending on their call options.
+func (c *Client) Dial(address *url.URL) error {
+	cc, err := grpc.Dial(address.String(), c.opts...)
 	if err != nil {
 		return fmt.Errorf("failed to dial client: %w", err)
 	}
 
-	state := cc.GetState()
-	for state != connectivity.Ready {
-		// continue checking for state change
-		// until one of break states is found
-		change := cc.WaitForStateChange(context.Background(), state)
-		if !change {
-			// ctx is done, return
-			// something upstream is cancelling
-			return fmt.Errorf("NAH")
-		}
-
-		state = cc.GetState()
-		c.logger.Info("State change", "state", state, "target", cc.Target())
-	}
-
-	c.cc = cc
+	c.set(cc)
 
 	return nil
 }
 
 func (c *Client) ClientConnection() *grpc.ClientConn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.cc
 }
+
+func (c *Client) set(cc *grpc.ClientConn) {
+	c.mu.Lock()
+	prev := c.cc
+	c.cc = cc
+	c.mu.Unlock()
+
+	if prev != nil {
+		prev.Close()
+	}
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	"google.golang.org/grpc"
+)
+
+func Test_Client_Dial(t *testing.T) {
+	s, address := serve(t)
+	defer s.Stop()
+
+	c := New(testlogger.TestLogger{T: t})
+	if err := c.Dial(address); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if c.ClientConnection() == nil {
+		t.Fatalf("no connection after dial")
+	}
+
+	if err := c.Close(); err != nil {
+		t.Errorf("unexpected error closing: %s", err.Error())
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("unexpected error closing twice: %s", err.Error())
+	}
+	if c.ClientConnection() != nil {
+		t.Errorf("connection was kept after close")
+	}
+}
+
+func Test_Client_Close_Undialed(t *testing.T) {
+	c := New(testlogger.TestLogger{T: t})
+	if err := c.Close(); err != nil {
+		t.Errorf("unexpected error closing: %s", err.Error())
+	}
+}
+
+func serve(t *testing.T) (*grpc.Server, *url.URL) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err.Error())
+	}
+	s := grpc.NewServer()
+	go s.Serve(lis)
+	return s, target(lis)
+}
+
+func target(lis net.Listener) *url.URL {
+	return &url.URL{Scheme: "dns", Path: "/" + lis.Addr().String()}
+}
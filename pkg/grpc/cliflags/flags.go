@@ -1,16 +1,65 @@
 package cliflags
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/object88/tugboat/pkg/grpc/auth"
+	grpcclient "github.com/object88/tugboat/pkg/grpc/client"
+	"github.com/object88/tugboat/pkg/grpc/credentials"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
 	portKey string = "grpc-port"
+
+	tlsCAKey         string = "grpc-tls-ca"
+	tlsCertKey       string = "grpc-tls-cert"
+	tlsKeyKey        string = "grpc-tls-key"
+	tlsServerNameKey string = "grpc-tls-server-name"
+
+	authKey             string = "grpc-auth"
+	authAllowedUsersKey string = "grpc-auth-allowed-users"
+	authAudiencesKey    string = "grpc-auth-audiences"
+	authTokenFileKey    string = "grpc-auth-token-file"
+
+	tokenFileKey string = "grpc-token-file"
+
+	backoffBaseDelayKey string = "grpc-backoff-base-delay"
+	backoffMaxDelayKey  string = "grpc-backoff-max-delay"
+	connectTimeoutKey   string = "grpc-connect-timeout"
+)
+
+// Authentication modes for the grpc-auth flag
+const (
+	AuthNone        string = "none"
+	AuthToken       string = "token"
+	AuthTokenReview string = "tokenreview"
 )
 
 type FlagManager struct {
 	port uint
+
+	tlsCA         string
+	tlsCert       string
+	tlsKey        string
+	tlsServerName string
+
+	auth             string
+	authAllowedUsers []string
+	authAudiences    []string
+	authTokenFile    string
+
+	tokenFile string
+
+	backoffBaseDelay time.Duration
+	backoffMaxDelay  time.Duration
+	connectTimeout   time.Duration
 }
 
 func New() *FlagManager {
@@ -23,6 +72,199 @@ func (fm *FlagManager) ConfigureGrpcPortFlag(flags *pflag.FlagSet) {
 	viper.BindPFlag(portKey, flags.Lookup(portKey))
 }
 
+// ConfigureTLSFlags adds the flags for TLS certificates, which are shared by
+// servers and clients
+func (fm *FlagManager) ConfigureTLSFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&fm.tlsCA, tlsCAKey, "", "path to a PEM CA bundle to verify peers with; on a server, requires client certificates (mTLS)")
+	viper.BindEnv(tlsCAKey)
+	viper.BindPFlag(tlsCAKey, flags.Lookup(tlsCAKey))
+
+	flags.StringVar(&fm.tlsCert, tlsCertKey, "", "path to a PEM certificate; enables TLS on a server, or is presented to servers by a client")
+	viper.BindEnv(tlsCertKey)
+	viper.BindPFlag(tlsCertKey, flags.Lookup(tlsCertKey))
+
+	flags.StringVar(&fm.tlsKey, tlsKeyKey, "", "path to the PEM private key for the certificate")
+	viper.BindEnv(tlsKeyKey)
+	viper.BindPFlag(tlsKeyKey, flags.Lookup(tlsKeyKey))
+
+	flags.StringVar(&fm.tlsServerName, tlsServerNameKey, "", "name to verify in server certificates, instead of the host being dialed; enables TLS on a client")
+	viper.BindEnv(tlsServerNameKey)
+	viper.BindPFlag(tlsServerNameKey, flags.Lookup(tlsServerNameKey))
+}
+
+// ConfigureServerAuthFlags adds the flags which control how a server
+// authenticates callers
+func (fm *FlagManager) ConfigureServerAuthFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&fm.auth, authKey, AuthNone, fmt.Sprintf("how callers are authenticated: '%s', '%s' (a shared bearer token), or '%s' (a Kubernetes ServiceAccount token)", AuthNone, AuthToken, AuthTokenReview))
+	viper.BindEnv(authKey)
+	viper.BindPFlag(authKey, flags.Lookup(authKey))
+
+	flags.StringSliceVar(&fm.authAllowedUsers, authAllowedUsersKey, nil, "Kubernetes users allowed to call the service, e.g. 'system:serviceaccount:tugboat:tugboat-watcher'; empty allows any authenticated user")
+	viper.BindEnv(authAllowedUsersKey)
+	viper.BindPFlag(authAllowedUsersKey, flags.Lookup(authAllowedUsersKey))
+
+	flags.StringSliceVar(&fm.authAudiences, authAudiencesKey, nil, "audiences that ServiceAccount tokens must be valid for")
+	viper.BindEnv(authAudiencesKey)
+	viper.BindPFlag(authAudiencesKey, flags.Lookup(authAudiencesKey))
+
+	flags.StringVar(&fm.authTokenFile, authTokenFileKey, "", "path to a file containing the shared bearer token")
+	viper.BindEnv(authTokenFileKey)
+	viper.BindPFlag(authTokenFileKey, flags.Lookup(authTokenFileKey))
+}
+
+// ConfigureClientAuthFlags adds the flags which control how a client
+// authenticates itself to servers
+func (fm *FlagManager) ConfigureClientAuthFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&fm.tokenFile, tokenFileKey, "", "path to a file containing a bearer token to send to servers, e.g. a projected ServiceAccount token")
+	viper.BindEnv(tokenFileKey)
+	viper.BindPFlag(tokenFileKey, flags.Lookup(tokenFileKey))
+}
+
+// ConfigureBackoffFlags adds the flags which control how a client
+// reconnects
+func (fm *FlagManager) ConfigureBackoffFlags(flags *pflag.FlagSet) {
+	defaults := grpcclient.DefaultBackoffOptions()
+
+	flags.DurationVar(&fm.backoffBaseDelay, backoffBaseDelayKey, defaults.BaseDelay, "delay after the first failed connection attempt; later delays grow exponentially")
+	viper.BindEnv(backoffBaseDelayKey)
+	viper.BindPFlag(backoffBaseDelayKey, flags.Lookup(backoffBaseDelayKey))
+
+	flags.DurationVar(&fm.backoffMaxDelay, backoffMaxDelayKey, defaults.MaxDelay, "maximum delay between connection attempts")
+	viper.BindEnv(backoffMaxDelayKey)
+	viper.BindPFlag(backoffMaxDelayKey, flags.Lookup(backoffMaxDelayKey))
+
+	flags.DurationVar(&fm.connectTimeout, connectTimeoutKey, defaults.MinConnectTimeout, "timeout for each connection attempt")
+	viper.BindEnv(connectTimeoutKey)
+	viper.BindPFlag(connectTimeoutKey, flags.Lookup(connectTimeoutKey))
+}
+
 func (fm *FlagManager) GRPCPort() uint {
 	return viper.GetUint(portKey)
 }
+
+// ServerOptions returns the options for a gRPC server described by the TLS
+// and server authentication flags.  The getter is only used to create a
+// clientset for TokenReview authentication.
+func (fm *FlagManager) ServerOptions(logger logr.Logger, getter genericclioptions.RESTClientGetter) ([]grpc.ServerOption, error) {
+	opts := []grpc.ServerOption{}
+
+	files := fm.tlsFiles()
+	if files.CertFile != "" || files.KeyFile != "" || files.CAFile != "" {
+		r, err := credentials.NewReloader(logger, files, credentials.DefaultReloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		creds, err := r.ServerCredentials()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	var a auth.Authenticator
+	switch mode := viper.GetString(authKey); mode {
+	case AuthNone, "":
+	case AuthToken:
+		path := viper.GetString(authTokenFileKey)
+		if path == "" {
+			return nil, fmt.Errorf("--%s is required with --%s=%s", authTokenFileKey, authKey, AuthToken)
+		}
+		tf := credentials.NewTokenFile(path)
+		if _, err := tf.Token(); err != nil {
+			return nil, err
+		}
+		a = auth.NewStaticToken(tf.Token)
+	case AuthTokenReview:
+		if getter == nil {
+			return nil, fmt.Errorf("--%s=%s is not supported by this command", authKey, AuthTokenReview)
+		}
+		cfg, err := getter.ToRESTConfig()
+		if err != nil {
+			return nil, err
+		}
+		clientset, err := kubernetes.NewForConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		tr := auth.NewTokenReview(clientset)
+		tr.Audiences = viper.GetStringSlice(authAudiencesKey)
+		tr.AllowedUsers = viper.GetStringSlice(authAllowedUsersKey)
+		a = tr
+	default:
+		return nil, fmt.Errorf("unknown --%s '%s'; must be one of '%s', '%s', or '%s'", authKey, mode, AuthNone, AuthToken, AuthTokenReview)
+	}
+
+	if a != nil {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(logger, a)),
+			grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(logger, a)),
+		)
+	}
+
+	return opts, nil
+}
+
+// BackoffOptions returns the reconnection options described by the backoff
+// flags
+func (fm *FlagManager) BackoffOptions() (grpcclient.BackoffOptions, error) {
+	bo := grpcclient.DefaultBackoffOptions()
+	if viper.IsSet(backoffBaseDelayKey) {
+		bo.BaseDelay = viper.GetDuration(backoffBaseDelayKey)
+	}
+	if viper.IsSet(backoffMaxDelayKey) {
+		bo.MaxDelay = viper.GetDuration(backoffMaxDelayKey)
+	}
+	if viper.IsSet(connectTimeoutKey) {
+		bo.MinConnectTimeout = viper.GetDuration(connectTimeoutKey)
+	}
+
+	if bo.BaseDelay <= 0 || bo.MaxDelay < bo.BaseDelay {
+		return bo, fmt.Errorf("--%s must be positive and no more than --%s", backoffBaseDelayKey, backoffMaxDelayKey)
+	}
+	if bo.MinConnectTimeout <= 0 {
+		return bo, fmt.Errorf("--%s must be positive", connectTimeoutKey)
+	}
+	return bo, nil
+}
+
+// DialOptions returns the options for a gRPC client described by the TLS,
+// client authentication, and backoff flags.  TLS is used if a certificate,
+// CA, or server name is provided; otherwise the connection is insecure.
+func (fm *FlagManager) DialOptions(logger logr.Logger) ([]grpc.DialOption, error) {
+	bo, err := fm.BackoffOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts := []grpc.DialOption{bo.DialOption()}
+
+	files := fm.tlsFiles()
+	serverName := viper.GetString(tlsServerNameKey)
+	secure := files.CertFile != "" || files.KeyFile != "" || files.CAFile != "" || serverName != ""
+	if secure {
+		r, err := credentials.NewReloader(logger, files, credentials.DefaultReloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(r.ClientCredentials(serverName)))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	if path := viper.GetString(tokenFileKey); path != "" {
+		tf := credentials.NewTokenFile(path)
+		if _, err := tf.Token(); err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(credentials.NewBearer(tf, secure)))
+	}
+
+	return opts, nil
+}
+
+func (fm *FlagManager) tlsFiles() credentials.TLSFiles {
+	return credentials.TLSFiles{
+		CAFile:   viper.GetString(tlsCAKey),
+		CertFile: viper.GetString(tlsCertKey),
+		KeyFile:  viper.GetString(tlsKeyKey),
+	}
+}
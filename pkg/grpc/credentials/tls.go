@@ -0,0 +1,225 @@
+package credentials
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	grpccredentials "google.golang.org/grpc/credentials"
+)
+
+// DefaultReloadInterval is how often the certificate files are checked for
+// changes
+const DefaultReloadInterval = 30 * time.Second
+
+// TLSFiles names the PEM files used to establish TLS.  CertFile and KeyFile
+// are the local identity: the server's certificate, or the client's
+// certificate for mTLS.  CAFile verifies the peer: on a server, providing it
+// requires clients to present a certificate signed by it.
+type TLSFiles struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+// Reloader keeps the certificates named by TLSFiles current.  The files are
+// checked for changes, at most once per interval, when a connection is
+// established, so that rotated certificates (e.g. from cert-manager) are
+// picked up without a restart.
+type Reloader struct {
+	logger   logr.Logger
+	files    TLSFiles
+	interval time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	checked  time.Time
+	modTimes [3]time.Time
+	cert     *tls.Certificate
+	certPool *x509.CertPool
+}
+
+// NewReloader loads the files and returns a new Reloader.  An error is
+// returned if the files cannot be loaded initially; later failures are
+// logged and the previous certificates are kept.
+func NewReloader(logger logr.Logger, files TLSFiles, interval time.Duration) (*Reloader, error) {
+	if (files.CertFile == "") != (files.KeyFile == "") {
+		return nil, errors.New("both a TLS certificate and key must be provided")
+	}
+
+	r := &Reloader{
+		logger:   logger,
+		files:    files,
+		interval: interval,
+		now:      time.Now,
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ServerCredentials returns the transport credentials for a gRPC server.  A
+// certificate and key are required.
+func (r *Reloader) ServerCredentials() (grpccredentials.TransportCredentials, error) {
+	if r.files.CertFile == "" {
+		return nil, errors.New("a TLS certificate and key are required to serve TLS")
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+			c := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				NextProtos:   []string{"h2"},
+			}
+			if pool != nil {
+				c.ClientAuth = tls.RequireAndVerifyClientCert
+				c.ClientCAs = pool
+			}
+			return c, nil
+		},
+	}
+	return grpccredentials.NewTLS(cfg), nil
+}
+
+// ClientCredentials returns the transport credentials for a gRPC client.  If
+// a certificate and key are provided, they are presented to the server for
+// mTLS.  If a CA is provided, the server is verified against it rather than
+// the system roots.  serverName overrides the name verified in the server's
+// certificate.
+func (r *Reloader) ClientCredentials(serverName string) grpccredentials.TransportCredentials {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			if cert == nil {
+				return &tls.Certificate{}, nil
+			}
+			return cert, nil
+		},
+	}
+
+	if r.files.CAFile != "" {
+		// Verification happens in VerifyConnection so that a reloaded CA is
+		// used; the standard verification can only use a fixed RootCAs.
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			_, pool := r.current()
+			return verify(cs, pool)
+		}
+	}
+
+	return grpccredentials.NewTLS(cfg)
+}
+
+func verify(cs tls.ConnectionState, roots *x509.CertPool) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("server did not present a certificate")
+	}
+	opts := x509.VerifyOptions{
+		DNSName:       cs.ServerName,
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, c := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// current returns the certificate and CA pool, reloading them if the files
+// have changed
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := r.now(); now.Sub(r.checked) >= r.interval {
+		r.checked = now
+		if changed, err := r.changed(); err != nil {
+			r.logger.Error(err, "failed to check TLS files for changes")
+		} else if changed {
+			if err := r.loadLocked(); err != nil {
+				r.logger.Error(err, "failed to reload TLS files; keeping previous certificates")
+			} else {
+				r.logger.Info("reloaded TLS files")
+			}
+		}
+	}
+
+	return r.cert, r.certPool
+}
+
+func (r *Reloader) load() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checked = r.now()
+	return r.loadLocked()
+}
+
+// loadLocked reads the files.  The caller must hold the lock.
+func (r *Reloader) loadLocked() error {
+	modTimes, err := r.stat()
+	if err != nil {
+		return err
+	}
+
+	var cert *tls.Certificate
+	if r.files.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(r.files.CertFile, r.files.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS key pair '%s', '%s': %w", r.files.CertFile, r.files.KeyFile, err)
+		}
+		cert = &c
+	}
+
+	var pool *x509.CertPool
+	if r.files.CAFile != "" {
+		buf, err := ioutil.ReadFile(r.files.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read TLS CA '%s': %w", r.files.CAFile, err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(buf) {
+			return fmt.Errorf("TLS CA '%s' does not contain any PEM certificates", r.files.CAFile)
+		}
+	}
+
+	r.cert = cert
+	r.certPool = pool
+	r.modTimes = modTimes
+	return nil
+}
+
+func (r *Reloader) changed() (bool, error) {
+	modTimes, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+	return modTimes != r.modTimes, nil
+}
+
+func (r *Reloader) stat() ([3]time.Time, error) {
+	var result [3]time.Time
+	for k, f := range []string{r.files.CertFile, r.files.KeyFile, r.files.CAFile} {
+		if f == "" {
+			continue
+		}
+		fi, err := os.Stat(f)
+		if err != nil {
+			return result, fmt.Errorf("failed to stat TLS file '%s': %w", f, err)
+		}
+		result[k] = fi.ModTime()
+	}
+	return result, nil
+}
@@ -0,0 +1,256 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	"google.golang.org/grpc"
+	grpccredentials "google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func Test_Reloader_MTLS(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	ca := newCA(t, "tugboat-ca")
+	other := newCA(t, "other-ca")
+
+	serverFiles := writeFiles(t, dir, "server", ca, ca.issue(t, "localhost"))
+	clientFiles := writeFiles(t, dir, "client", ca, ca.issue(t, "tugboat-watcher"))
+	strangerFiles := writeFiles(t, dir, "stranger", ca, other.issue(t, "stranger"))
+	anonymousFiles := TLSFiles{CAFile: clientFiles.CAFile}
+
+	address := serve(t, serverFiles)
+
+	tcs := []struct {
+		name      string
+		files     TLSFiles
+		expectErr bool
+	}{
+		{
+			name:  "trusted-client",
+			files: clientFiles,
+		},
+		{
+			name:      "untrusted-client",
+			files:     strangerFiles,
+			expectErr: true,
+		},
+		{
+			name:      "no-client-certificate",
+			files:     anonymousFiles,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewReloader(testlogger.TestLogger{T: t}, tc.files, time.Hour)
+			if err != nil {
+				t.Fatalf("failed to create reloader: %s", err.Error())
+			}
+
+			err = check(address, r.ClientCredentials("localhost"))
+			if tc.expectErr && err == nil {
+				t.Errorf("expected error")
+			} else if !tc.expectErr && err != nil {
+				t.Errorf("unexpected error: %s", err.Error())
+			}
+		})
+	}
+}
+
+func Test_Reloader_Reload(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	ca := newCA(t, "tugboat-ca")
+	files := writeFiles(t, dir, "server", ca, ca.issue(t, "localhost"))
+
+	r, err := NewReloader(testlogger.TestLogger{T: t}, files, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create reloader: %s", err.Error())
+	}
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	original, _ := r.current()
+
+	// Rotate the certificate; it is not noticed until the interval passes.
+	writeFiles(t, dir, "server", ca, ca.issue(t, "localhost"))
+	later := now.Add(time.Hour)
+	for _, f := range []string{files.CertFile, files.KeyFile} {
+		if err := os.Chtimes(f, later, later); err != nil {
+			t.Fatalf("failed to touch '%s': %s", f, err.Error())
+		}
+	}
+
+	if cert, _ := r.current(); !bytes.Equal(cert.Certificate[0], original.Certificate[0]) {
+		t.Errorf("certificate reloaded before the interval passed")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if cert, _ := r.current(); bytes.Equal(cert.Certificate[0], original.Certificate[0]) {
+		t.Errorf("certificate was not reloaded")
+	}
+
+	// A broken file keeps the previous certificate.
+	if err := ioutil.WriteFile(files.CertFile, []byte("garbage"), 0600); err != nil {
+		t.Fatalf("failed to write certificate: %s", err.Error())
+	}
+	if err := os.Chtimes(files.CertFile, later.Add(time.Hour), later.Add(time.Hour)); err != nil {
+		t.Fatalf("failed to touch certificate: %s", err.Error())
+	}
+	now = now.Add(2 * time.Minute)
+	if cert, _ := r.current(); cert == nil {
+		t.Errorf("certificate was discarded after a failed reload")
+	}
+}
+
+func Test_NewReloader_Invalid(t *testing.T) {
+	if _, err := NewReloader(testlogger.TestLogger{T: t}, TLSFiles{CertFile: "cert.pem"}, time.Minute); err == nil {
+		t.Errorf("expected error for certificate without key")
+	}
+	if _, err := NewReloader(testlogger.TestLogger{T: t}, TLSFiles{CAFile: "missing.pem"}, time.Minute); err == nil {
+		t.Errorf("expected error for missing CA")
+	}
+}
+
+func serve(t *testing.T, files TLSFiles) string {
+	r, err := NewReloader(testlogger.TestLogger{T: t}, files, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create server reloader: %s", err.Error())
+	}
+	creds, err := r.ServerCredentials()
+	if err != nil {
+		t.Fatalf("failed to create server credentials: %s", err.Error())
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err.Error())
+	}
+	s := grpc.NewServer(grpc.Creds(creds))
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	return lis.Addr().String()
+}
+
+func check(address string, creds grpccredentials.TransportCredentials) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cc, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer cc.Close()
+
+	_, err = healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(false))
+	return err
+}
+
+func newCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err.Error())
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create CA: %s", err.Error())
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue returns a PEM certificate and key for the name, which is valid for
+// both server and client authentication
+func (ca *testCA) issue(t *testing.T, name string) [2][]byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err.Error())
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("failed to issue certificate: %s", err.Error())
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %s", err.Error())
+	}
+	return [2][]byte{
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}
+}
+
+// writeFiles writes the certificate and key issued by the issuer, and the
+// CA that verifies peers
+func writeFiles(t *testing.T, dir string, name string, ca *testCA, pair [2][]byte) TLSFiles {
+	files := TLSFiles{
+		CAFile:   filepath.Join(dir, name+"-ca.pem"),
+		CertFile: filepath.Join(dir, name+".pem"),
+		KeyFile:  filepath.Join(dir, name+"-key.pem"),
+	}
+	for f, buf := range map[string][]byte{files.CAFile: ca.pem, files.CertFile: pair[0], files.KeyFile: pair[1]} {
+		if err := ioutil.WriteFile(f, buf, 0600); err != nil {
+			t.Fatalf("failed to write '%s': %s", f, err.Error())
+		}
+	}
+	return files
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err.Error())
+	}
+	return dir
+}
@@ -0,0 +1,91 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	grpccredentials "google.golang.org/grpc/credentials"
+)
+
+// TokenFile reads a bearer token from a file, re-reading it when the file
+// changes.  This suits projected ServiceAccount tokens, which the kubelet
+// rotates in place.
+type TokenFile struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	token   string
+}
+
+// NewTokenFile returns a TokenFile that reads the token at path
+func NewTokenFile(path string) *TokenFile {
+	return &TokenFile{
+		path: path,
+	}
+}
+
+// Token returns the current token
+func (tf *TokenFile) Token() (string, error) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	fi, err := os.Stat(tf.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat token file '%s': %w", tf.path, err)
+	}
+	if tf.token != "" && fi.ModTime().Equal(tf.modTime) {
+		return tf.token, nil
+	}
+
+	buf, err := ioutil.ReadFile(tf.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file '%s': %w", tf.path, err)
+	}
+	token := strings.TrimSpace(string(buf))
+	if token == "" {
+		return "", fmt.Errorf("token file '%s' is empty", tf.path)
+	}
+
+	tf.token = token
+	tf.modTime = fi.ModTime()
+	return tf.token, nil
+}
+
+// bearer attaches a token to each RPC as an `authorization` header
+type bearer struct {
+	tokens     *TokenFile
+	requireTLS bool
+}
+
+var _ grpccredentials.PerRPCCredentials = &bearer{}
+
+// NewBearer returns per-RPC credentials which send the token from the file.
+// If requireTLS is set, the token is never sent over an insecure
+// connection.
+func NewBearer(tokens *TokenFile, requireTLS bool) grpccredentials.PerRPCCredentials {
+	return &bearer{
+		tokens:     tokens,
+		requireTLS: requireTLS,
+	}
+}
+
+// GetRequestMetadata satisfies the credentials.PerRPCCredentials interface
+func (b *bearer) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := b.tokens.Token()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// RequireTransportSecurity satisfies the credentials.PerRPCCredentials
+// interface
+func (b *bearer) RequireTransportSecurity() bool {
+	return b.requireTLS
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/object88/tugboat/pkg/http/probes"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 )
@@ -17,26 +19,47 @@ type Handler interface {
 	Register(s *grpc.Server, logger logr.Logger) error
 }
 
+// HealthInterval is how often the health service is updated from the
+// readiness probe
+const HealthInterval = time.Second
+
 type Server struct {
 	S             *grpc.Server
 	logger        logr.Logger
 	port          uint
 	registerFuncs []Handler
+	health        *health.Server
+
+	// Probe, if set, is the app's probe; the grpc.health.v1 service reports
+	// SERVING only while it is ready.  Otherwise, the health service reports
+	// SERVING while the server is serving.
+	Probe *probes.Probe
 }
 
 func New(logger logr.Logger, port uint, registers ...Handler) (*Server, error) {
+	return NewWithOptions(logger, port, nil, registers...)
+}
+
+// NewWithOptions returns a new Server which is created with the provided
+// options, such as credentials and interceptors, in addition to the defaults
+func NewWithOptions(logger logr.Logger, port uint, opts []grpc.ServerOption, registers ...Handler) (*Server, error) {
 	if len(registers) == 0 {
 		return nil, fmt.Errorf("grpc.New requires at least one RegisterHandler")
 	}
+	opts = append([]grpc.ServerOption{grpc.KeepaliveParams(keepalive.ServerParameters{
+		MaxConnectionIdle: 5 * time.Minute,
+	})}, opts...)
 	s := &Server{
 		logger:        logger,
 		port:          port,
 		registerFuncs: registers,
-		S: grpc.NewServer(grpc.KeepaliveParams(keepalive.ServerParameters{
-			MaxConnectionIdle: 5 * time.Minute,
-		})),
+		S:             grpc.NewServer(opts...),
+		health:        health.NewServer(),
 	}
 
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(s.S, s.health)
+
 	reflection.Register(s.S)
 	return s, nil
 }
@@ -49,7 +72,7 @@ func (s *Server) Serve(ctx context.Context, r probes.Reporter) error {
 		}
 	}
 
-	errCh := make(chan error)
+	errCh := make(chan error, 1)
 
 	s.logger.Info("Starting tcp listener", "port", s.port)
 
@@ -70,11 +93,35 @@ func (s *Server) Serve(ctx context.Context, r probes.Reporter) error {
 
 	r.Ready()
 
-	select {
-	case <-ctx.Done():
-		s.S.GracefulStop()
-		return ctx.Err()
-	case err := <-errCh:
-		return err
+	t := time.NewTicker(HealthInterval)
+	defer t.Stop()
+	for {
+		s.updateHealth()
+
+		select {
+		case <-ctx.Done():
+			s.health.Shutdown()
+			s.S.GracefulStop()
+			<-errCh
+			return ctx.Err()
+		case err := <-errCh:
+			s.health.Shutdown()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+// updateHealth sets the status of the server and every registered service
+// from the probe
+func (s *Server) updateHealth() {
+	status := healthpb.HealthCheckResponse_SERVING
+	if s.Probe != nil && !s.Probe.IsReady() {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	s.health.SetServingStatus("", status)
+	for name := range s.S.GetServiceInfo() {
+		s.health.SetServingStatus(name, status)
 	}
 }
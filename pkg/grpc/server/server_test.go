@@ -13,6 +13,7 @@ import (
 	"github.com/object88/tugboat/pkg/http/probes"
 	"github.com/object88/tugboat/pkg/logging/testlogger"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func Test_GRPC_Server_NoRegisterFuncs(t *testing.T) {
@@ -106,18 +107,14 @@ func Test_GRPC_Server_OneClient(t *testing.T) {
 	if s == nil {
 		t.Fatalf("unexpected nil from New")
 	}
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	go func() {
-		p := probes.New()
-		p.SetCapacity(1)
-		s.Serve(ctx, p.Reporter(0))
-	}()
+	stop := serve(s, nil)
+	defer stop()
 
 	cc, err := grpc.Dial(":4000", grpc.WithInsecure(), grpc.WithBlock())
 	if err != nil {
 		t.Fatalf("failed to dial client: %s", err.Error())
 	}
+	defer cc.Close()
 	client := sample.NewSample0Client(cc)
 	x := uuid.New().String()
 	resp, err := client.Foo(context.Background(), &sample.FooRequest{Id: &sample.UUID{Value: x}})
@@ -134,15 +131,11 @@ func Test_GRPC_Server_OneClient(t *testing.T) {
 
 func Test_GRPC_Server_TwoClients(t *testing.T) {
 	s, _ := New(testlogger.TestLogger{T: t}, 4000, &Fake0{}, &Fake1{})
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	go func() {
-		p := probes.New()
-		p.SetCapacity(1)
-		s.Serve(ctx, p.Reporter(0))
-	}()
+	stop := serve(s, nil)
+	defer stop()
 
 	cc, _ := grpc.Dial(":4000", grpc.WithInsecure(), grpc.WithBlock())
+	defer cc.Close()
 
 	x0 := uuid.New().String()
 	resp0, _ := sample.NewSample0Client(cc).Foo(context.Background(), &sample.FooRequest{Id: &sample.UUID{Value: x0}})
@@ -160,3 +153,64 @@ func Test_GRPC_Server_TwoClients(t *testing.T) {
 		t.Errorf("returned incorrect Id value: expected '%s', actual: '%s'", x1, resp1.Id.Value)
 	}
 }
+
+func Test_GRPC_Server_Health(t *testing.T) {
+	p := probes.New()
+	p.SetCapacity(2)
+	app := p.Reporter(1)
+	app.NotReady()
+
+	s, _ := New(testlogger.TestLogger{T: t}, 4001, &Fake0{})
+	s.Probe = p
+	stop := serve(s, p)
+	defer stop()
+
+	cc, err := grpc.Dial(":4001", grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("failed to dial client: %s", err.Error())
+	}
+	defer cc.Close()
+	client := healthpb.NewHealthClient(cc)
+
+	check := func(service string, expected healthpb.HealthCheckResponse_ServingStatus) {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			if err == nil && resp.Status == expected {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("service '%s' did not become %s: %v, %v", service, expected, resp, err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	check("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	app.Ready()
+	check("", healthpb.HealthCheckResponse_SERVING)
+	check("sample.Sample0", healthpb.HealthCheckResponse_SERVING)
+
+	app.NotReady()
+	check("sample.Sample0", healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// serve runs the server until the returned function is called, which waits
+// for it to stop.  If the probe is nil, a new one is used.
+func serve(s *Server, p *probes.Probe) func() {
+	if p == nil {
+		p = probes.New()
+		p.SetCapacity(1)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Serve(ctx, p.Reporter(0))
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
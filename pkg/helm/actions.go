@@ -0,0 +1,80 @@
+package helm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// DefaultRollbackTimeout is how long a rollback waits for each of its hooks,
+// as with `helm rollback`
+const DefaultRollbackTimeout = 5 * time.Minute
+
+// Actions runs Helm actions against releases stored in secrets, as the Helm
+// CLI does by default
+type Actions struct {
+	log    logr.Logger
+	getter genericclioptions.RESTClientGetter
+
+	// RollbackTimeout is how long a rollback waits for each of its hooks
+	RollbackTimeout time.Duration
+}
+
+// NewActions returns a new Actions
+func NewActions(log logr.Logger, getter genericclioptions.RESTClientGetter) *Actions {
+	return &Actions{
+		log:             log,
+		getter:          getter,
+		RollbackTimeout: DefaultRollbackTimeout,
+	}
+}
+
+// History returns the revisions of a release, as `helm history`
+func (a *Actions) History(namespace string, name string) ([]*release.Release, error) {
+	cfg, err := a.configuration(namespace)
+	if err != nil {
+		return nil, err
+	}
+	rels, err := action.NewHistory(cfg).Run(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history of release '%s/%s': %w", namespace, name, err)
+	}
+	return rels, nil
+}
+
+// Rollback rolls a release back to a revision, as `helm rollback`
+func (a *Actions) Rollback(namespace string, name string, revision int) error {
+	cfg, err := a.configuration(namespace)
+	if err != nil {
+		return err
+	}
+	rb := action.NewRollback(cfg)
+	rb.Version = revision
+	rb.Timeout = a.RollbackTimeout
+	if err := rb.Run(name); err != nil {
+		return fmt.Errorf("failed to roll back release '%s/%s' to revision %d: %w", namespace, name, revision, err)
+	}
+	return nil
+}
+
+func (a *Actions) configuration(namespace string) (*action.Configuration, error) {
+	cfg := &action.Configuration{}
+	debug := func(format string, v ...interface{}) {
+		a.log.V(1).Info(fmt.Sprintf(format, v...), "namespace", namespace)
+	}
+	if err := cfg.Init(a.getter, namespace, "secret", debug); err != nil {
+		return nil, fmt.Errorf("failed to configure helm for namespace '%s': %w", namespace, err)
+	}
+
+	// Objects without a namespace are in the release's namespace, rather than
+	// the kubeconfig's.
+	if kc, ok := cfg.KubeClient.(*kube.Client); ok {
+		kc.Namespace = namespace
+	}
+	return cfg, nil
+}
@@ -0,0 +1,375 @@
+package helm
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Redacted replaces values which may be secret
+const Redacted = "[REDACTED]"
+
+// DefaultRedactKeyPatterns match keys which commonly hold secrets
+var DefaultRedactKeyPatterns = []string{
+	`(?i)passw(or)?d`,
+	`(?i)secret`,
+	`(?i)token`,
+	`(?i)api[-_]?key`,
+	`(?i)private[-_]?key`,
+	`(?i)credential`,
+}
+
+// ObjectStatus describes how an object changed between revisions
+type ObjectStatus string
+
+const (
+	ObjectAdded   ObjectStatus = "added"
+	ObjectChanged ObjectStatus = "changed"
+	ObjectRemoved ObjectStatus = "removed"
+)
+
+// ReleaseDiff is the difference between two revisions of a release
+type ReleaseDiff struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+
+	// Objects are the objects of the rendered manifest which were added,
+	// removed, or changed, in the order that helm would install them
+	Objects []ObjectDiff `json:"objects"`
+
+	// Values are the changes to the user-supplied values
+	Values []FieldDiff `json:"values"`
+}
+
+// ObjectDiff is the difference to a single object of the rendered manifest
+type ObjectDiff struct {
+	// Object is the object, as "Kind/name"
+	Object    string       `json:"object"`
+	Namespace string       `json:"namespace,omitempty"`
+	Status    ObjectStatus `json:"status"`
+
+	// Fields are set for changed objects only
+	Fields []FieldDiff `json:"fields,omitempty"`
+}
+
+// FieldDiff is a change to a single field.  From is unset if the field was
+// added, and To is unset if it was removed.
+type FieldDiff struct {
+	// Path locates the field, e.g. `spec.template.spec.containers[name=app].image`
+	Path string      `json:"path"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// KeyRedactor redacts the values of keys which match any of its patterns.  A
+// nil KeyRedactor redacts only Secret data.
+type KeyRedactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewKeyRedactor compiles the patterns
+func NewKeyRedactor(patterns []string) (*KeyRedactor, error) {
+	r := &KeyRedactor{patterns: make([]*regexp.Regexp, len(patterns))}
+	for k, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile redact pattern '%s': %w", p, err)
+		}
+		r.patterns[k] = re
+	}
+	return r, nil
+}
+
+func (r *KeyRedactor) matches(key string) bool {
+	if r == nil {
+		return false
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the difference between two revisions of a release.  The data
+// of Secret objects is always redacted, as are the values of keys matched by
+// the redactor.
+func Diff(from *release.Release, to *release.Release, r *KeyRedactor) (*ReleaseDiff, error) {
+	prev, err := Objects(from.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of revision %d: %w", from.Version, err)
+	}
+	curr, err := Objects(to.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of revision %d: %w", to.Version, err)
+	}
+
+	d := &ReleaseDiff{
+		Name:      to.Name,
+		Namespace: to.Namespace,
+		From:      from.Version,
+		To:        to.Version,
+		Objects:   DiffObjects(prev, curr, r),
+		Values:    DiffValues(from.Config, to.Config, r),
+	}
+	return d, nil
+}
+
+// DiffObjects returns the objects which were added, removed, or changed.
+// Objects are matched by kind, namespace, and name, so a change of API
+// version is reported as a changed field.
+func DiffObjects(previous []*unstructured.Unstructured, current []*unstructured.Unstructured, r *KeyRedactor) []ObjectDiff {
+	prev := map[string]*unstructured.Unstructured{}
+	for _, u := range previous {
+		prev[objectKey(u)] = u
+	}
+	curr := map[string]*unstructured.Unstructured{}
+	for _, u := range current {
+		curr[objectKey(u)] = u
+	}
+
+	diffs := []ObjectDiff{}
+	for _, u := range current {
+		od := ObjectDiff{
+			Object:    u.GetKind() + "/" + u.GetName(),
+			Namespace: u.GetNamespace(),
+		}
+		p, ok := prev[objectKey(u)]
+		if !ok {
+			od.Status = ObjectAdded
+			diffs = append(diffs, od)
+			continue
+		}
+
+		// All of a Secret's data is redacted, whatever its keys.
+		var always map[string]bool
+		if u.GetKind() == "Secret" && u.GroupVersionKind().Group == "" {
+			always = map[string]bool{"data": true, "stringData": true}
+		}
+		df := differ{r: r, fields: []FieldDiff{}}
+		df.diffMap("", p.Object, u.Object, false, always)
+		if len(df.fields) == 0 {
+			continue
+		}
+		od.Status = ObjectChanged
+		od.Fields = df.fields
+		diffs = append(diffs, od)
+	}
+	for _, u := range previous {
+		if _, ok := curr[objectKey(u)]; !ok {
+			diffs = append(diffs, ObjectDiff{
+				Object:    u.GetKind() + "/" + u.GetName(),
+				Namespace: u.GetNamespace(),
+				Status:    ObjectRemoved,
+			})
+		}
+	}
+	return diffs
+}
+
+// DiffValues returns the changes to the user-supplied values
+func DiffValues(previous map[string]interface{}, current map[string]interface{}, r *KeyRedactor) []FieldDiff {
+	df := differ{r: r, fields: []FieldDiff{}}
+	df.diffMap("", previous, current, false, nil)
+	return df.fields
+}
+
+func objectKey(u *unstructured.Unstructured) string {
+	return u.GetKind() + "/" + u.GetNamespace() + "/" + u.GetName()
+}
+
+type differ struct {
+	r      *KeyRedactor
+	fields []FieldDiff
+}
+
+func (d *differ) diff(path string, a interface{}, b interface{}, redact bool) {
+	am, aok := a.(map[string]interface{})
+	bm, bok := b.(map[string]interface{})
+	if aok && bok {
+		d.diffMap(path, am, bm, redact, nil)
+		return
+	}
+
+	al, aok := a.([]interface{})
+	bl, bok := b.([]interface{})
+	if aok && bok {
+		d.diffList(path, al, bl, redact)
+		return
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return
+	}
+	d.fields = append(d.fields, FieldDiff{
+		Path: path,
+		From: d.redact(a, redact),
+		To:   d.redact(b, redact),
+	})
+}
+
+// diffMap compares two maps by key, in order.  The values of keys in always
+// are redacted regardless of the redactor.
+func (d *differ) diffMap(path string, a map[string]interface{}, b map[string]interface{}, redact bool, always map[string]bool) {
+	keys := []string{}
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		d.diff(joinKey(path, k), a[k], b[k], redact || always[k] || d.r.matches(k))
+	}
+}
+
+// diffList compares lists of named elements, like containers and environment
+// variables, by name.  Other lists are compared by index.
+func (d *differ) diffList(path string, a []interface{}, b []interface{}, redact bool) {
+	an, aok := names(a)
+	bn, bok := names(b)
+	if !aok || !bok {
+		for k := 0; k < len(a) || k < len(b); k++ {
+			var x, y interface{}
+			if k < len(a) {
+				x = a[k]
+			}
+			if k < len(b) {
+				y = b[k]
+			}
+			d.diff(path+"["+strconv.Itoa(k)+"]", x, y, redact)
+		}
+		return
+	}
+
+	prev := map[string]interface{}{}
+	for k, n := range an {
+		prev[n] = a[k]
+	}
+	curr := map[string]interface{}{}
+	for k, n := range bn {
+		curr[n] = b[k]
+	}
+	for k, n := range bn {
+		d.diff(path+"[name="+n+"]", prev[n], b[k], redact || d.r.matches(n))
+	}
+	for k, n := range an {
+		if _, ok := curr[n]; !ok {
+			d.diff(path+"[name="+n+"]", a[k], nil, redact || d.r.matches(n))
+		}
+	}
+}
+
+// redact returns the value to report for a changed field.  Composite values
+// are copied with any matching keys redacted.
+func (d *differ) redact(v interface{}, redact bool) interface{} {
+	if v == nil {
+		return nil
+	}
+	if redact {
+		return Redacted
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, x := range t {
+			m[k] = d.redact(x, d.r.matches(k))
+		}
+		return m
+	case []interface{}:
+		ns, named := names(t)
+		l := make([]interface{}, len(t))
+		for k, x := range t {
+			l[k] = d.redact(x, named && d.r.matches(ns[k]))
+		}
+		return l
+	default:
+		return v
+	}
+}
+
+// names returns the names of a list's elements, if every element is a map
+// with a unique string "name"
+func names(l []interface{}) ([]string, bool) {
+	if len(l) == 0 {
+		return nil, true
+	}
+	ns := make([]string, len(l))
+	seen := map[string]bool{}
+	for k, x := range l {
+		m, ok := x.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		n, ok := m["name"].(string)
+		if !ok || seen[n] {
+			return nil, false
+		}
+		seen[n] = true
+		ns[k] = n
+	}
+	return ns, true
+}
+
+var plainKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// joinKey appends a key to a path, quoting keys like "helm.sh/hook" which
+// would be ambiguous
+func joinKey(path string, key string) string {
+	if !plainKey.MatchString(key) {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// String renders the diff for people
+func (d *ReleaseDiff) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s/%s: revision %d to %d\n", d.Namespace, d.Name, d.From, d.To)
+	if len(d.Objects) == 0 && len(d.Values) == 0 {
+		sb.WriteString("no changes\n")
+		return sb.String()
+	}
+	for _, o := range d.Objects {
+		object := o.Object
+		if o.Namespace != "" {
+			object = o.Namespace + "/" + object
+		}
+		fmt.Fprintf(&sb, "%s %s\n", o.Status, object)
+		writeFields(&sb, o.Fields)
+	}
+	if len(d.Values) != 0 {
+		sb.WriteString("values\n")
+		writeFields(&sb, d.Values)
+	}
+	return sb.String()
+}
+
+func writeFields(sb *strings.Builder, fields []FieldDiff) {
+	for _, f := range fields {
+		switch {
+		case f.From == nil:
+			fmt.Fprintf(sb, "  + %s: %v\n", f.Path, f.To)
+		case f.To == nil:
+			fmt.Fprintf(sb, "  - %s: %v\n", f.Path, f.From)
+		default:
+			fmt.Fprintf(sb, "  ~ %s: %v -> %v\n", f.Path, f.From, f.To)
+		}
+	}
+}
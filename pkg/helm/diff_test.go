@@ -0,0 +1,150 @@
+package helm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_Diff(t *testing.T) {
+	next := strings.Replace(manifest, "replicas: 2", "replicas: 3", 1)
+	next = strings.Replace(next, "kind: Service\nmetadata:\n  name: web", "kind: ConfigMap\nmetadata:\n  name: web", 1)
+
+	from := &release.Release{Name: "shop", Namespace: "store", Version: 3, Manifest: manifest, Config: map[string]interface{}{"replicas": 2.0}}
+	to := &release.Release{Name: "shop", Namespace: "store", Version: 4, Manifest: next, Config: map[string]interface{}{"replicas": 3.0}}
+
+	d, err := Diff(from, to, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Name != "shop" || d.Namespace != "store" || d.From != 3 || d.To != 4 {
+		t.Errorf("incorrect release: %#v", d)
+	}
+
+	expected := []string{
+		"added ConfigMap/web",
+		"changed Deployment/web spec.replicas 2 3",
+		"removed Service/web",
+	}
+	actual := []string{}
+	for _, o := range d.Objects {
+		s := string(o.Status) + " " + o.Object
+		for _, f := range o.Fields {
+			s += fmt.Sprintf(" %s %v %v", f.Path, f.From, f.To)
+		}
+		actual = append(actual, s)
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("incorrect objects:\n%s", strings.Join(actual, "\n"))
+	}
+
+	if len(d.Values) != 1 || d.Values[0].Path != "replicas" || d.Values[0].From != 2.0 || d.Values[0].To != 3.0 {
+		t.Errorf("incorrect values: %#v", d.Values)
+	}
+}
+
+func Test_DiffValues(t *testing.T) {
+	r, err := NewKeyRedactor(DefaultRedactKeyPatterns)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tcs := []struct {
+		name     string
+		previous map[string]interface{}
+		current  map[string]interface{}
+		expected []string
+	}{
+		{
+			name:     "unchanged",
+			previous: map[string]interface{}{"image": map[string]interface{}{"tag": "1.2.0"}},
+			current:  map[string]interface{}{"image": map[string]interface{}{"tag": "1.2.0"}},
+			expected: []string{},
+		},
+		{
+			name:     "nested",
+			previous: map[string]interface{}{"image": map[string]interface{}{"tag": "1.2.0"}},
+			current:  map[string]interface{}{"image": map[string]interface{}{"tag": "1.3.0", "pullPolicy": "Always"}},
+			expected: []string{"image.pullPolicy <nil> Always", "image.tag 1.2.0 1.3.0"},
+		},
+		{
+			name:     "quoted-key",
+			previous: map[string]interface{}{"annotations": map[string]interface{}{"helm.sh/hook": "pre-install"}},
+			current:  map[string]interface{}{"annotations": map[string]interface{}{"helm.sh/hook": "post-install"}},
+			expected: []string{`annotations["helm.sh/hook"] pre-install post-install`},
+		},
+		{
+			name:     "redacted-key",
+			previous: map[string]interface{}{"db": map[string]interface{}{"password": "hunter2"}},
+			current:  map[string]interface{}{"db": map[string]interface{}{"password": "hunter3"}},
+			expected: []string{"db.password [REDACTED] [REDACTED]"},
+		},
+		{
+			name:     "redacted-subtree",
+			previous: map[string]interface{}{},
+			current:  map[string]interface{}{"db": map[string]interface{}{"host": "db", "apiKey": "abc"}},
+			expected: []string{"db <nil> map[apiKey:[REDACTED] host:db]"},
+		},
+		{
+			name:     "named-list",
+			previous: map[string]interface{}{"env": []interface{}{env("PORT", "8080"), env("DB_PASSWORD", "hunter2")}},
+			current:  map[string]interface{}{"env": []interface{}{env("DB_PASSWORD", "hunter3"), env("PORT", "9090")}},
+			expected: []string{"env[name=DB_PASSWORD].value [REDACTED] [REDACTED]", "env[name=PORT].value 8080 9090"},
+		},
+		{
+			name:     "indexed-list",
+			previous: map[string]interface{}{"hosts": []interface{}{"a", "b"}},
+			current:  map[string]interface{}{"hosts": []interface{}{"a", "c", "d"}},
+			expected: []string{"hosts[1] b c", "hosts[2] <nil> d"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := []string{}
+			for _, f := range DiffValues(tc.previous, tc.current, r) {
+				actual = append(actual, fmt.Sprintf("%s %v %v", f.Path, f.From, f.To))
+			}
+			if strings.Join(actual, "\n") != strings.Join(tc.expected, "\n") {
+				t.Errorf("incorrect changes:\nexpected:\n%s\nactual:\n%s", strings.Join(tc.expected, "\n"), strings.Join(actual, "\n"))
+			}
+		})
+	}
+}
+
+func Test_DiffObjects_Secret(t *testing.T) {
+	secret := func(value string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": "web"},
+			"data":       map[string]interface{}{"url": value},
+			"stringData": map[string]interface{}{"host": value},
+		}}
+	}
+
+	// Secret data is redacted even without a redactor, and even though none
+	// of its keys look secret.
+	diffs := DiffObjects([]*unstructured.Unstructured{secret("a")}, []*unstructured.Unstructured{secret("b")}, nil)
+	if len(diffs) != 1 || len(diffs[0].Fields) != 2 {
+		t.Fatalf("incorrect diffs: %#v", diffs)
+	}
+	for _, f := range diffs[0].Fields {
+		if f.From != Redacted || f.To != Redacted {
+			t.Errorf("field '%s' was not redacted: %#v", f.Path, f)
+		}
+	}
+}
+
+func Test_NewKeyRedactor_Invalid(t *testing.T) {
+	if _, err := NewKeyRedactor([]string{"("}); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func env(name string, value string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "value": value}
+}
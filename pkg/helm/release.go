@@ -0,0 +1,75 @@
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+var magicGzip = []byte{0x1f, 0x8b, 0x08}
+
+// DecodeRelease decodes the helm release stored in a helm release secret.
+// Helm stores the release as base64-encoded, gzipped JSON.
+func DecodeRelease(s *v1.Secret) (*release.Release, error) {
+	data, ok := s.Data["release"]
+	if !ok {
+		return nil, fmt.Errorf("secret '%s' does not contain a release", s.Name)
+	}
+
+	b, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode release in secret '%s': %w", s.Name, err)
+	}
+
+	// Releases stored by older versions of helm are not compressed.
+	if bytes.HasPrefix(b, magicGzip) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress release in secret '%s': %w", s.Name, err)
+		}
+		defer r.Close()
+		if b, err = ioutil.ReadAll(r); err != nil {
+			return nil, fmt.Errorf("failed to decompress release in secret '%s': %w", s.Name, err)
+		}
+	}
+
+	var rls release.Release
+	if err := json.Unmarshal(b, &rls); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal release in secret '%s': %w", s.Name, err)
+	}
+	return &rls, nil
+}
+
+// Objects parses the objects of a rendered manifest, in the order that helm
+// would install them.  Empty documents are skipped.
+func Objects(manifest string) ([]*unstructured.Unstructured, error) {
+	docs := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(docs))
+	for k := range docs {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	objs := []*unstructured.Unstructured{}
+	for _, k := range keys {
+		m := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(docs[k]), &m); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest document: %w", err)
+		}
+		if len(m) == 0 {
+			continue
+		}
+		objs = append(objs, &unstructured.Unstructured{Object: m})
+	}
+	return objs, nil
+}
@@ -0,0 +1,105 @@
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/release"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const manifest = `---
+# Source: shop/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: shop/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: app
+        image: registry:5000/shop/web:1.2.0
+        env:
+        - name: PORT
+          value: "8080"
+        resources:
+          requests:
+            cpu: 100m
+---
+# Source: shop/templates/empty.yaml
+`
+
+func Test_DecodeRelease(t *testing.T) {
+	tcs := []struct {
+		name     string
+		compress bool
+	}{
+		{name: "gzipped", compress: true},
+		{name: "uncompressed", compress: false},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			s := releaseSecret(t, &release.Release{Name: "shop", Version: 3, Manifest: manifest}, tc.compress)
+
+			rls, err := DecodeRelease(s)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rls.Name != "shop" || rls.Version != 3 || rls.Manifest != manifest {
+				t.Errorf("incorrect release: %#v", rls)
+			}
+		})
+	}
+}
+
+func Test_DecodeRelease_Invalid(t *testing.T) {
+	s := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "sh.helm.release.v1.shop.v3"}, Data: map[string][]byte{"release": []byte("not base64!")}}
+	if _, err := DecodeRelease(s); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func Test_Objects(t *testing.T) {
+	objs, err := Objects(manifest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("incorrect number of objects: %d", len(objs))
+	}
+	if objs[0].GetKind() != "Service" || objs[1].GetKind() != "Deployment" {
+		t.Errorf("objects are out of order: %s, %s", objs[0].GetKind(), objs[1].GetKind())
+	}
+}
+
+// releaseSecret encodes a release the way that helm stores it
+func releaseSecret(t *testing.T, rls *release.Release, compress bool) *v1.Secret {
+	b, err := json.Marshal(rls)
+	if err != nil {
+		t.Fatalf("failed to marshal release: %v", err)
+	}
+	if compress {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		w.Write(b)
+		w.Close()
+		b = buf.Bytes()
+	}
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sh.helm.release.v1.shop.v3"},
+		Data:       map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(b))},
+	}
+}
@@ -0,0 +1,160 @@
+package helm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"helm.sh/helm/v3/pkg/release"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Source fields, which name both the keys of SourceOptions.Keys and the
+// groups of its description patterns
+const (
+	SourceCommit      = "commit"
+	SourceBranch      = "branch"
+	SourcePullRequest = "pullrequest"
+	SourcePipeline    = "pipeline"
+)
+
+// SourceFields are the source fields, in order
+var SourceFields = []string{SourceCommit, SourceBranch, SourcePullRequest, SourcePipeline}
+
+// SourceOptions describes where a release's source-control metadata is found
+type SourceOptions struct {
+	// Keys are the annotation and label keys read for each source field, in
+	// order of preference
+	Keys map[string][]string
+
+	// DescriptionPatterns are matched against the release description, e.g. as
+	// set with `helm upgrade --description`.  Named groups set the source
+	// field of the same name.
+	DescriptionPatterns []*regexp.Regexp
+}
+
+// DefaultSourceOptions reads the `tugboat.engineering/` annotations and
+// labels, and commits and pull request URLs mentioned in the description
+func DefaultSourceOptions() SourceOptions {
+	return SourceOptions{
+		Keys: map[string][]string{
+			SourceCommit:      {"tugboat.engineering/commit"},
+			SourceBranch:      {"tugboat.engineering/branch"},
+			SourcePullRequest: {"tugboat.engineering/pull-request"},
+			SourcePipeline:    {"tugboat.engineering/pipeline"},
+		},
+		DescriptionPatterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\bcommit[:= ]\s*(?P<commit>[0-9a-f]{7,40})\b`),
+			regexp.MustCompile(`(?P<pullrequest>https?://\S+/(pull|merge_requests)/\d+)`),
+		},
+	}
+}
+
+// ParseSourceKeys parses keys as "field=key", e.g.
+// "commit=example.com/sha"
+func ParseSourceKeys(raw []string) (map[string][]string, error) {
+	keys := map[string][]string{}
+	for _, r := range raw {
+		parts := strings.SplitN(r, "=", 2)
+		if len(parts) != 2 || parts[1] == "" || !isSourceField(parts[0]) {
+			return nil, fmt.Errorf("source key '%s' must be 'field=key', where field is one of %s", r, strings.Join(SourceFields, ", "))
+		}
+		keys[parts[0]] = append(keys[parts[0]], parts[1])
+	}
+	return keys, nil
+}
+
+// ParseDescriptionPatterns compiles the patterns, which must name at least
+// one source field with a group
+func ParseDescriptionPatterns(raw []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, len(raw))
+	for k, r := range raw {
+		re, err := regexp.Compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile description pattern '%s': %w", r, err)
+		}
+		named := false
+		for _, n := range re.SubexpNames() {
+			named = named || isSourceField(n)
+		}
+		if !named {
+			return nil, fmt.Errorf("description pattern '%s' has no group named for a source field", r)
+		}
+		res[k] = re
+	}
+	return res, nil
+}
+
+// Harvest reads the source-control metadata of a release.  Each field's keys
+// are looked up, in order of preference, in the annotations and labels of the
+// release secret, then of its objects and their pod templates in install
+// order.  Fields which are not found are then read from the description.
+// Harvest returns nil if nothing is found.
+func (o SourceOptions) Harvest(s *v1.Secret, rls *release.Release) (*v1alpha1.ReleaseHistorySource, error) {
+	objs, err := Objects(rls.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	metas := []map[string]string{s.Annotations, s.Labels}
+	for _, u := range objs {
+		metas = append(metas, u.GetAnnotations(), u.GetLabels())
+		for _, path := range [][]string{
+			{"spec", "template", "metadata"},
+			{"spec", "jobTemplate", "spec", "template", "metadata"},
+		} {
+			for _, field := range []string{"annotations", "labels"} {
+				m, _, _ := unstructured.NestedStringMap(u.Object, append(path, field)...)
+				metas = append(metas, m)
+			}
+		}
+	}
+
+	found := map[string]string{}
+	for _, field := range SourceFields {
+		for _, key := range o.Keys[field] {
+			for _, m := range metas {
+				if v := m[key]; v != "" && found[field] == "" {
+					found[field] = v
+				}
+			}
+		}
+	}
+
+	description := ""
+	if rls.Info != nil {
+		description = rls.Info.Description
+	}
+	for _, re := range o.DescriptionPatterns {
+		match := re.FindStringSubmatch(description)
+		if match == nil {
+			continue
+		}
+		for k, n := range re.SubexpNames() {
+			if isSourceField(n) && match[k] != "" && found[n] == "" {
+				found[n] = match[k]
+			}
+		}
+	}
+
+	if len(found) == 0 {
+		return nil, nil
+	}
+	return &v1alpha1.ReleaseHistorySource{
+		Commit:      found[SourceCommit],
+		Branch:      found[SourceBranch],
+		PullRequest: found[SourcePullRequest],
+		Pipeline:    found[SourcePipeline],
+	}, nil
+}
+
+func isSourceField(s string) bool {
+	for _, f := range SourceFields {
+		if s == f {
+			return true
+		}
+	}
+	return false
+}
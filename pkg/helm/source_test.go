@@ -0,0 +1,97 @@
+package helm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"helm.sh/helm/v3/pkg/release"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_SourceOptions_Harvest(t *testing.T) {
+	annotated := strings.Replace(manifest, "  template:\n    spec:", `  template:
+    metadata:
+      annotations:
+        tugboat.engineering/commit: 0123456789abcdef
+        tugboat.engineering/branch: main`, 1)
+
+	tcs := []struct {
+		name        string
+		manifest    string
+		labels      map[string]string
+		description string
+		expected    *v1alpha1.ReleaseHistorySource
+	}{
+		{
+			name:     "none",
+			manifest: manifest,
+		},
+		{
+			name:     "pod-template",
+			manifest: annotated,
+			expected: &v1alpha1.ReleaseHistorySource{Commit: "0123456789abcdef", Branch: "main"},
+		},
+		{
+			name:     "secret-first",
+			manifest: annotated,
+			labels:   map[string]string{"tugboat.engineering/branch": "release-1.2", "tugboat.engineering/pipeline": "981"},
+			expected: &v1alpha1.ReleaseHistorySource{Commit: "0123456789abcdef", Branch: "release-1.2", Pipeline: "981"},
+		},
+		{
+			name:        "description",
+			manifest:    manifest,
+			description: "Deploy commit: abc1234 from https://github.com/shop/web/pull/42",
+			expected:    &v1alpha1.ReleaseHistorySource{Commit: "abc1234", PullRequest: "https://github.com/shop/web/pull/42"},
+		},
+		{
+			name:        "annotations-before-description",
+			manifest:    annotated,
+			description: "commit=fedcba9",
+			expected:    &v1alpha1.ReleaseHistorySource{Commit: "0123456789abcdef", Branch: "main"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: tc.labels}}
+			rls := &release.Release{Manifest: tc.manifest, Info: &release.Info{Description: tc.description}}
+
+			actual, err := DefaultSourceOptions().Harvest(s, rls)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (actual == nil) != (tc.expected == nil) || (actual != nil && *actual != *tc.expected) {
+				t.Errorf("incorrect source:\nexpected: %#v\nactual: %#v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_ParseSourceKeys(t *testing.T) {
+	keys, err := ParseSourceKeys([]string{"commit=example.com/sha", "commit=git-sha", "pipeline=example.com/run"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(keys[SourceCommit], ",") != "example.com/sha,git-sha" || strings.Join(keys[SourcePipeline], ",") != "example.com/run" {
+		t.Errorf("incorrect keys: %v", keys)
+	}
+
+	for _, raw := range []string{"sha=example.com/sha", "commit", "commit="} {
+		if _, err := ParseSourceKeys([]string{raw}); err == nil {
+			t.Errorf("expected error parsing '%s'", raw)
+		}
+	}
+}
+
+func Test_ParseDescriptionPatterns(t *testing.T) {
+	if _, err := ParseDescriptionPatterns([]string{`ref (?P<branch>\S+)`}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, raw := range []string{`ref (\S+)`, `(?P<branch>`} {
+		if _, err := ParseDescriptionPatterns([]string{raw}); err == nil {
+			t.Errorf("expected error parsing '%s'", raw)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package helm
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Workload is the part of a workload's spec which is summarized between
+// revisions
+type Workload struct {
+	// Object is the workload, as "Kind/name"
+	Object string
+
+	// Replicas is unset for kinds without replicas, and for workloads which
+	// leave it to the default
+	Replicas *int32
+
+	Containers []Container
+}
+
+// Container is the part of a container's spec which is summarized between
+// revisions
+type Container struct {
+	Name  string
+	Image string
+
+	// Env are the names of the container's environment variables
+	Env []string
+
+	Requests v1.ResourceList
+	Limits   v1.ResourceList
+}
+
+// Workloads returns the workloads among the objects of a rendered manifest.
+// Objects which are not workloads are ignored.
+func Workloads(objs []*unstructured.Unstructured) ([]Workload, error) {
+	ws := []Workload{}
+	for _, u := range objs {
+		var replicas *int32
+		var template *v1.PodTemplateSpec
+		var err error
+
+		switch u.GetKind() {
+		case "Deployment":
+			var o appsv1.Deployment
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &o)
+			replicas, template = o.Spec.Replicas, &o.Spec.Template
+		case "StatefulSet":
+			var o appsv1.StatefulSet
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &o)
+			replicas, template = o.Spec.Replicas, &o.Spec.Template
+		case "DaemonSet":
+			var o appsv1.DaemonSet
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &o)
+			template = &o.Spec.Template
+		case "Job":
+			var o batchv1.Job
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &o)
+			template = &o.Spec.Template
+		case "CronJob":
+			var o batchv1beta1.CronJob
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &o)
+			template = &o.Spec.JobTemplate.Spec.Template
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s/%s: %w", u.GetKind(), u.GetName(), err)
+		}
+
+		w := Workload{
+			Object:   u.GetKind() + "/" + u.GetName(),
+			Replicas: replicas,
+		}
+		cs := make([]v1.Container, 0, len(template.Spec.InitContainers)+len(template.Spec.Containers))
+		cs = append(cs, template.Spec.InitContainers...)
+		cs = append(cs, template.Spec.Containers...)
+		for _, c := range cs {
+			env := make([]string, len(c.Env))
+			for k, e := range c.Env {
+				env[k] = e.Name
+			}
+			w.Containers = append(w.Containers, Container{
+				Name:     c.Name,
+				Image:    c.Image,
+				Env:      env,
+				Requests: c.Resources.Requests,
+				Limits:   c.Resources.Limits,
+			})
+		}
+		ws = append(ws, w)
+	}
+	return ws, nil
+}
+
+// Summarize returns the changes to the workloads between two revisions,
+// ordered by workload and container
+func Summarize(previous []Workload, current []Workload) []v1alpha1.ReleaseHistoryChange {
+	prev := map[string]Workload{}
+	for _, w := range previous {
+		prev[w.Object] = w
+	}
+	curr := map[string]Workload{}
+	for _, w := range current {
+		curr[w.Object] = w
+	}
+
+	changes := []v1alpha1.ReleaseHistoryChange{}
+	for _, w := range current {
+		p, ok := prev[w.Object]
+		if !ok {
+			changes = append(changes, v1alpha1.ReleaseHistoryChange{Object: w.Object, Field: "created"})
+			continue
+		}
+		if from, to := replicas(p.Replicas), replicas(w.Replicas); from != to {
+			changes = append(changes, v1alpha1.ReleaseHistoryChange{Object: w.Object, Field: "replicas", From: from, To: to})
+		}
+
+		prevContainers := map[string]Container{}
+		for _, c := range p.Containers {
+			prevContainers[c.Name] = c
+		}
+		for _, c := range w.Containers {
+			pc, ok := prevContainers[c.Name]
+			if !ok {
+				changes = append(changes, v1alpha1.ReleaseHistoryChange{Object: w.Object, Container: c.Name, Field: "created"})
+				continue
+			}
+			changes = append(changes, summarizeContainer(w.Object, pc, c)...)
+		}
+		for _, pc := range p.Containers {
+			if !hasContainer(w.Containers, pc.Name) {
+				changes = append(changes, v1alpha1.ReleaseHistoryChange{Object: w.Object, Container: pc.Name, Field: "deleted"})
+			}
+		}
+	}
+	for _, w := range previous {
+		if _, ok := curr[w.Object]; !ok {
+			changes = append(changes, v1alpha1.ReleaseHistoryChange{Object: w.Object, Field: "deleted"})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Object != changes[j].Object {
+			return changes[i].Object < changes[j].Object
+		}
+		return changes[i].Container < changes[j].Container
+	})
+	return changes
+}
+
+func summarizeContainer(object string, prev Container, curr Container) []v1alpha1.ReleaseHistoryChange {
+	changes := []v1alpha1.ReleaseHistoryChange{}
+	change := func(field string, from string, to string) {
+		changes = append(changes, v1alpha1.ReleaseHistoryChange{Object: object, Container: curr.Name, Field: field, From: from, To: to})
+	}
+
+	// Only the most significant part of an image reference which changed is
+	// reported.
+	pi, ci := parseImage(prev.Image), parseImage(curr.Image)
+	switch {
+	case pi.repository != ci.repository:
+		change("image", prev.Image, curr.Image)
+	case pi.tag != ci.tag:
+		change("tag", pi.tag, ci.tag)
+	case pi.digest != ci.digest:
+		change("digest", pi.digest, ci.digest)
+	}
+
+	prevEnv := map[string]bool{}
+	for _, e := range prev.Env {
+		prevEnv[e] = true
+	}
+	currEnv := map[string]bool{}
+	for _, e := range curr.Env {
+		currEnv[e] = true
+		if !prevEnv[e] {
+			change("env", "", e)
+		}
+	}
+	for _, e := range prev.Env {
+		if !currEnv[e] {
+			change("env", e, "")
+		}
+	}
+
+	for _, r := range []struct {
+		prefix string
+		prev   v1.ResourceList
+		curr   v1.ResourceList
+	}{{"requests", prev.Requests, curr.Requests}, {"limits", prev.Limits, curr.Limits}} {
+		for _, name := range resourceNames(r.prev, r.curr) {
+			from, to := quantity(r.prev, name), quantity(r.curr, name)
+			if from != to {
+				change(r.prefix+"."+string(name), from, to)
+			}
+		}
+	}
+
+	return changes
+}
+
+type image struct {
+	repository string
+	tag        string
+	digest     string
+}
+
+// parseImage splits an image reference, e.g. "registry:5000/app:1.2@sha256:..."
+func parseImage(ref string) image {
+	var i image
+	if at := strings.Index(ref, "@"); at != -1 {
+		ref, i.digest = ref[:at], ref[at+1:]
+	}
+	// A colon after the last slash separates the tag; one before it is a
+	// registry port.
+	if colon := strings.LastIndex(ref, ":"); colon != -1 && colon > strings.LastIndex(ref, "/") {
+		ref, i.tag = ref[:colon], ref[colon+1:]
+	}
+	i.repository = ref
+	return i
+}
+
+func hasContainer(cs []Container, name string) bool {
+	for _, c := range cs {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func replicas(r *int32) string {
+	if r == nil {
+		return ""
+	}
+	return strconv.Itoa(int(*r))
+}
+
+func resourceNames(lists ...v1.ResourceList) []v1.ResourceName {
+	seen := map[v1.ResourceName]bool{}
+	names := []v1.ResourceName{}
+	for _, l := range lists {
+		for name := range l {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+func quantity(l v1.ResourceList, name v1.ResourceName) string {
+	q, ok := l[name]
+	if !ok {
+		return ""
+	}
+	return q.String()
+}
@@ -0,0 +1,143 @@
+package helm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func Test_Workloads(t *testing.T) {
+	objs, err := Objects(manifest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ws, err := Workloads(objs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ws) != 1 {
+		t.Fatalf("incorrect number of workloads: %d", len(ws))
+	}
+	w := ws[0]
+	if w.Object != "Deployment/web" || w.Replicas == nil || *w.Replicas != 2 {
+		t.Errorf("incorrect workload: %#v", w)
+	}
+	if len(w.Containers) != 1 || w.Containers[0].Image != "registry:5000/shop/web:1.2.0" || strings.Join(w.Containers[0].Env, ",") != "PORT" {
+		t.Errorf("incorrect containers: %#v", w.Containers)
+	}
+}
+
+func Test_Summarize(t *testing.T) {
+	tcs := []struct {
+		name     string
+		previous func(w *Workload)
+		current  func(w *Workload)
+		expected []string
+	}{
+		{
+			name: "unchanged",
+		},
+		{
+			name: "tag",
+			current: func(w *Workload) {
+				w.Containers[0].Image = "registry:5000/shop/web:1.3.0"
+			},
+			expected: []string{"app tag 1.2.0 1.3.0"},
+		},
+		{
+			name: "digest",
+			previous: func(w *Workload) {
+				w.Containers[0].Image += "@sha256:aaa"
+			},
+			current: func(w *Workload) {
+				w.Containers[0].Image += "@sha256:bbb"
+			},
+			expected: []string{"app digest sha256:aaa sha256:bbb"},
+		},
+		{
+			name: "image",
+			current: func(w *Workload) {
+				w.Containers[0].Image = "ghcr.io/shop/web:1.2.0"
+			},
+			expected: []string{"app image registry:5000/shop/web:1.2.0 ghcr.io/shop/web:1.2.0"},
+		},
+		{
+			name: "env-and-resources",
+			current: func(w *Workload) {
+				w.Containers[0].Env = []string{"PORT", "DEBUG"}
+				w.Containers[0].Requests = v1.ResourceList{v1.ResourceCPU: resource.MustParse("0.2")}
+				w.Containers[0].Limits = v1.ResourceList{v1.ResourceMemory: resource.MustParse("512Mi")}
+			},
+			expected: []string{"app env  DEBUG", "app requests.cpu 100m 200m", "app limits.memory  512Mi"},
+		},
+		{
+			name: "replicas-and-sidecar",
+			current: func(w *Workload) {
+				replicas := int32(4)
+				w.Replicas = &replicas
+				w.Containers = append(w.Containers, Container{Name: "proxy", Image: "envoy:1.17"})
+			},
+			expected: []string{" replicas 2 4", "proxy created  "},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			previous, current := workload(), workload()
+			if tc.previous != nil {
+				tc.previous(&previous)
+			}
+			if tc.current != nil {
+				tc.current(&current)
+			}
+
+			actual := Summarize([]Workload{previous}, []Workload{current})
+			if len(actual) != len(tc.expected) {
+				t.Fatalf("incorrect number of changes: expected %d, actual %d: %v", len(tc.expected), len(actual), actual)
+			}
+			for k, c := range actual {
+				if c.Object != "Deployment/web" {
+					t.Errorf("incorrect object '%s'", c.Object)
+				}
+				if s := strings.Join([]string{c.Container, c.Field, c.From, c.To}, " "); s != tc.expected[k] {
+					t.Errorf("incorrect change at %d: expected '%s', actual '%s'", k, tc.expected[k], s)
+				}
+			}
+		})
+	}
+}
+
+func Test_Summarize_Workloads(t *testing.T) {
+	actual := Summarize([]Workload{workload()}, []Workload{{Object: "StatefulSet/db"}})
+	expected := []v1alpha1.ReleaseHistoryChange{
+		{Object: "Deployment/web", Field: "deleted"},
+		{Object: "StatefulSet/db", Field: "created"},
+	}
+	if len(actual) != len(expected) {
+		t.Fatalf("incorrect changes: %v", actual)
+	}
+	for k := range expected {
+		if actual[k] != expected[k] {
+			t.Errorf("incorrect change at %d: %#v", k, actual[k])
+		}
+	}
+}
+
+func workload() Workload {
+	replicas := int32(2)
+	return Workload{
+		Object:   "Deployment/web",
+		Replicas: &replicas,
+		Containers: []Container{
+			{
+				Name:     "app",
+				Image:    "registry:5000/shop/web:1.2.0",
+				Env:      []string{"PORT"},
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")},
+			},
+		},
+	}
+}
@@ -3,6 +3,7 @@ package probes
 import (
 	"fmt"
 	"strings"
+	"sync/atomic"
 )
 
 const (
@@ -32,7 +33,8 @@ type Reporter interface {
 	NotReady()
 }
 
-// Probe manages up to 32 `liveness` and `readiness` states
+// Probe manages up to 32 `liveness` and `readiness` states.  The states may
+// be reported and read from different goroutines.
 type Probe struct {
 	cap    int
 	states uint64
@@ -53,7 +55,7 @@ func (p *Probe) SetCapacity(cap int) error {
 	}
 
 	p.cap = cap
-	p.states <<= cap
+	atomic.StoreUint64(&p.states, atomic.LoadUint64(&p.states)<<cap)
 	return nil
 }
 
@@ -73,7 +75,7 @@ func (p *Probe) IsLive() bool {
 	if p == nil {
 		return false
 	}
-	return p.states&upperBits == upperBits
+	return atomic.LoadUint64(&p.states)&upperBits == upperBits
 }
 
 // IsReady reports whether all ready bits are Up
@@ -81,7 +83,7 @@ func (p *Probe) IsReady() bool {
 	if p == nil {
 		return false
 	}
-	return p.states&lowerBits == lowerBits
+	return atomic.LoadUint64(&p.states)&lowerBits == lowerBits
 }
 
 // String satsifies the Stringer interface
@@ -92,6 +94,7 @@ func (p *Probe) String() string {
 		return "no states"
 	}
 
+	states := atomic.LoadUint64(&p.states)
 	var sb strings.Builder
 
 	f := func(s uint64) {
@@ -111,26 +114,31 @@ func (p *Probe) String() string {
 	}
 
 	sb.WriteString("{ \"live\": [")
-	f(p.states >> Max)
+	f(states >> Max)
 	sb.WriteString("], \"ready\": [")
-	f(p.states)
+	f(states)
 	sb.WriteString("] }")
 	return sb.String()
 }
 
 // GoString satisfies the `fmt.GoStringer` interface
 func (p *Probe) GoString() string {
-	return fmt.Sprintf("{ \"cap\": %d, \"ready\": %b }", p.cap, p.states)
+	return fmt.Sprintf("{ \"cap\": %d, \"ready\": %b }", p.cap, atomic.LoadUint64(&p.states))
 }
 
 func (p *Probe) set(index uint, value state) {
-	switch value {
-	case down:
-		fmt.Printf("before: state: %d\n", p.states)
-		p.states &^= (1 << index)
-		fmt.Printf("after:  state: %d\n", p.states)
-	case up:
-		p.states |= (1 << index)
+	for {
+		old := atomic.LoadUint64(&p.states)
+		new := old
+		switch value {
+		case down:
+			new &^= (1 << index)
+		case up:
+			new |= (1 << index)
+		}
+		if atomic.CompareAndSwapUint64(&p.states, old, new) {
+			return
+		}
 	}
 }
 
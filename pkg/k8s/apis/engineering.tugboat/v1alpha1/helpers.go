@@ -0,0 +1,63 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"path"
+)
+
+// LatestRevision returns the most recent revision of the release, or false if
+// there are none
+func (rh *ReleaseHistory) LatestRevision() (ReleaseHistoryRevision, bool) {
+	if len(rh.Status.Revisions) == 0 {
+		return ReleaseHistoryRevision{}, false
+	}
+	latest := rh.Status.Revisions[0]
+	for _, r := range rh.Status.Revisions[1:] {
+		if r.Revision > latest.Revision {
+			latest = r
+		}
+	}
+	return latest, true
+}
+
+// AppliesTo reports whether the policy applies to the release.  An invalid
+// release pattern is reported in the error; the remaining patterns are still
+// matched.
+func (p *FreezePolicy) AppliesTo(release string) (bool, error) {
+	ok, err := matchReleases(p.Spec.Releases, release)
+	if err != nil {
+		return ok, fmt.Errorf("FreezePolicy '%s/%s' %w", p.Namespace, p.Name, err)
+	}
+	return ok, nil
+}
+
+// AppliesTo reports whether the checks apply to the release.  An invalid
+// release pattern is reported in the error; the remaining patterns are still
+// matched.
+func (dv *DeploymentVerification) AppliesTo(release string) (bool, error) {
+	ok, err := matchReleases(dv.Spec.Releases, release)
+	if err != nil {
+		return ok, fmt.Errorf("DeploymentVerification '%s/%s' %w", dv.Namespace, dv.Name, err)
+	}
+	return ok, nil
+}
+
+// matchReleases reports whether the release matches any of the patterns, or
+// true if there are none
+func matchReleases(patterns []string, release string) (bool, error) {
+	if len(patterns) == 0 {
+		return true, nil
+	}
+	var invalid error
+	for _, pattern := range patterns {
+		ok, err := path.Match(pattern, release)
+		if err != nil {
+			invalid = fmt.Errorf("has invalid release pattern '%s': %w", pattern, err)
+			continue
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, invalid
+}
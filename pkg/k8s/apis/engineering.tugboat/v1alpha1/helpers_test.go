@@ -0,0 +1,68 @@
+package v1alpha1
+
+import (
+	"testing"
+)
+
+func Test_ReleaseHistory_LatestRevision(t *testing.T) {
+	rh := &ReleaseHistory{}
+	if _, ok := rh.LatestRevision(); ok {
+		t.Errorf("found revision without any revisions")
+	}
+
+	rh.Status.Revisions = []ReleaseHistoryRevision{{Revision: 3}, {Revision: 5}, {Revision: 4}}
+	latest, ok := rh.LatestRevision()
+	if !ok || latest.Revision != 5 {
+		t.Errorf("incorrect latest revision: %d", latest.Revision)
+	}
+}
+
+func Test_AppliesTo(t *testing.T) {
+	tcs := []struct {
+		name        string
+		releases    []string
+		expected    bool
+		expectedErr bool
+	}{
+		{
+			name:     "every-release",
+			expected: true,
+		},
+		{
+			name:     "match",
+			releases: []string{"api", "web*"},
+			expected: true,
+		},
+		{
+			name:     "no-match",
+			releases: []string{"api"},
+		},
+		{
+			name:        "invalid",
+			releases:    []string{"["},
+			expectedErr: true,
+		},
+		{
+			name:     "invalid-and-match",
+			releases: []string{"[", "web"},
+			expected: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &FreezePolicy{Spec: FreezePolicySpec{Releases: tc.releases}}
+			dv := &DeploymentVerification{Spec: DeploymentVerificationSpec{Releases: tc.releases}}
+
+			for kind, appliesTo := range map[string]func(string) (bool, error){"FreezePolicy": p.AppliesTo, "DeploymentVerification": dv.AppliesTo} {
+				actual, err := appliesTo("web")
+				if tc.expectedErr != (err != nil) {
+					t.Errorf("%s: unexpected error result: %v", kind, err)
+				}
+				if actual != tc.expected {
+					t.Errorf("%s: incorrect result: expected %t, got %t", kind, tc.expected, actual)
+				}
+			}
+		})
+	}
+}
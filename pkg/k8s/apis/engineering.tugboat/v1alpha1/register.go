@@ -35,6 +35,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&ReleaseHistory{},
 		&ReleaseHistoryList{},
+		&FreezePolicy{},
+		&FreezePolicyList{},
+		&DeploymentVerification{},
+		&DeploymentVerificationList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -10,33 +10,240 @@ import (
 // +k8s:deepcopy-gen=true
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +resource:path=releasehistory
+// +kubebuilder:resource:path=releasehistories,singular=releasehistory,shortName=rh;rhte,categories=tugboat
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Release",type=string,JSONPath=`.spec.releasename`
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.metadata.labels.tugboat\.engineering/state`
+// +kubebuilder:printcolumn:name="Latest Revision",type=integer,JSONPath=`.status.revisions[-1:].revision`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.revisions[-1:].conditions[?(@.type=="Verified")].reason`,description="Whether the latest revision passed its verification checks"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 type ReleaseHistory struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	Spec ReleaseHistorySpec `json:"spec"`
 
-	Status ReleaseHistoryStatus `json:"status"`
+	// +optional
+	Status ReleaseHistoryStatus `json:"status,omitempty"`
 }
 
 // ReleaseHistorySpec is the spec for a ReleaseHistory
 // +k8s:deepcopy-gen=true
 type ReleaseHistorySpec struct {
+	// ReleaseName is the name of the Helm release, and cannot be changed
+	// +kubebuilder:validation:MinLength=1
 	ReleaseName string `json:"releasename"`
 }
 
 // ReleaseHistoryStatus is the status for a ReleaseHistory resource
 type ReleaseHistoryStatus struct {
-	DeployedAt metav1.Time              `json:"deployedat"`
-	Revisions  []ReleaseHistoryRevision `json:"revisions"`
+	DeployedAt metav1.Time `json:"deployedat"`
+
+	// Revisions are the revisions of the release, oldest first.  At most
+	// MaxRevisions are retained.
+	Revisions []ReleaseHistoryRevision `json:"revisions"`
+
+	// Rollbacks are the automatic rollbacks of the release, oldest first.  At
+	// most MaxRollbacks are retained.
+	Rollbacks []ReleaseHistoryRollback `json:"rollbacks,omitempty"`
 }
 
+// Revision is the number of a revision of a release, as numbered by Helm
+// +kubebuilder:validation:Type=integer
+// +kubebuilder:validation:Format=int64
+// +kubebuilder:validation:Minimum=0
 type Revision uint
 
 type ReleaseHistoryRevision struct {
 	Revision   Revision          `json:"revision"`
 	DeployedAt metav1.Time       `json:"deployedat"`
 	GVKs       map[string]string `json:"gvks"`
+
+	// Status is the status of the revision's Helm release, e.g.
+	// "pending-upgrade", "deployed" or "failed", as labelled on its release
+	// secret
+	Status string `json:"status,omitempty"`
+
+	// DeployedBy is who created the revision, as reported to the admission
+	// webhook
+	DeployedBy *ReleaseHistoryDeployer `json:"deployedby,omitempty"`
+
+	// Source links the revision to the change which caused it
+	Source *ReleaseHistorySource `json:"source,omitempty"`
+
+	// Changes summarize how the revision's workloads differ from the previous
+	// revision's, e.g. a new image tag or more replicas
+	Changes []ReleaseHistoryChange `json:"changes,omitempty"`
+
+	// StalledAt is when the revision's rollout was found to have stopped
+	// making progress, if it has
+	StalledAt *metav1.Time `json:"stalledat,omitempty"`
+
+	// Events are the most recent things that happened while the revision
+	// deployed, oldest first.  At most MaxRevisionEvents are retained.
+	Events []ReleaseHistoryEvent `json:"events,omitempty"`
+
+	// Diagnostics describe the most recent container failures, oldest first.
+	// At most MaxRevisionDiagnostics are retained.
+	Diagnostics []ReleaseHistoryDiagnostic `json:"diagnostics,omitempty"`
+
+	// Hooks are the Helm hooks which the revision ran, including `helm test`
+	// pods, in the order that they started.  At most MaxRevisionHooks are
+	// retained.
+	Hooks []ReleaseHistoryHook `json:"hooks,omitempty"`
+
+	// Conditions are the results of the DeploymentVerification checks of the
+	// revision, one for each check, and whether the revision is "Verified"
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// MaxRevisions is the number of revisions retained for each release, so
+	// that the ReleaseHistory stays within the size limit of an object
+	MaxRevisions = 10
+
+	// MaxRevisionEvents is the number of events retained for each revision
+	MaxRevisionEvents = 50
+
+	// MaxRevisionDiagnostics is the number of diagnostics retained for each
+	// revision
+	MaxRevisionDiagnostics = 5
+
+	// MaxRevisionHooks is the number of hook runs retained for each revision
+	MaxRevisionHooks = 20
+
+	// MaxRollbacks is the number of automatic rollbacks retained for each
+	// release
+	MaxRollbacks = 20
+)
+
+// Outcomes of an automatic rollback
+const (
+	RollbackSucceeded = "Succeeded"
+	RollbackFailed    = "Failed"
+	RollbackSkipped   = "Skipped"
+)
+
+// ReleaseHistoryRollback is an automatic rollback of a failed revision, or
+// the decision not to roll it back
+type ReleaseHistoryRollback struct {
+	Time metav1.Time `json:"time"`
+
+	// From is the failed revision
+	From Revision `json:"from"`
+
+	// To is the revision which was rolled back to, if any
+	To Revision `json:"to,omitempty"`
+
+	// Outcome is "Succeeded", "Failed" or "Skipped"
+	// +kubebuilder:validation:Enum=Succeeded;Failed;Skipped
+	Outcome string `json:"outcome"`
+	Message string `json:"message,omitempty"`
+}
+
+// ReleaseHistoryChange is a difference in a workload between a revision and
+// the previous revision
+type ReleaseHistoryChange struct {
+	// Object is the workload, as "Kind/name"
+	Object string `json:"object"`
+
+	// Container is the container which changed, if any
+	Container string `json:"container,omitempty"`
+
+	// Field is what changed: "image", "tag", "digest", "env", "replicas",
+	// a resource such as "requests.cpu", or "created" or "deleted" for a
+	// workload which was added to or removed from the release
+	Field string `json:"field"`
+
+	// From and To are the previous and new values, if any.  A new
+	// environment variable has a To of its name; a removed one, a From.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// ReleaseHistoryDeployer identifies who deployed a revision
+type ReleaseHistoryDeployer struct {
+	Username string   `json:"username"`
+	Groups   []string `json:"groups,omitempty"`
+
+	// ServiceAccount is the deployer's service account, as "namespace/name",
+	// if it is one
+	ServiceAccount string `json:"serviceaccount,omitempty"`
+
+	// CI identifies the CI job which deployed the revision, keyed by the user
+	// extra fields or helm release secret annotations which it was read from
+	CI map[string]string `json:"ci,omitempty"`
+}
+
+// ReleaseHistorySource is the source-control metadata of a revision,
+// harvested from annotations, labels and the Helm release description
+type ReleaseHistorySource struct {
+	Commit string `json:"commit,omitempty"`
+	Branch string `json:"branch,omitempty"`
+
+	// PullRequest is the URL of the pull request
+	PullRequest string `json:"pullrequest,omitempty"`
+
+	// Pipeline identifies the CI pipeline run
+	Pipeline string `json:"pipeline,omitempty"`
+}
+
+// ReleaseHistoryEvent is something that happened to the resources of a
+// revision, e.g. a pod becoming ready or a container crashing
+type ReleaseHistoryEvent struct {
+	Time     metav1.Time `json:"time"`
+	Type     string      `json:"type"`
+	Severity string      `json:"severity"`
+	Reason   string      `json:"reason"`
+	Message  string      `json:"message,omitempty"`
+
+	// Object is the resource the event is about, as "Kind/name"
+	Object string `json:"object,omitempty"`
+
+	// Count is the number of times the event has occurred, if it recurs
+	Count int32 `json:"count,omitempty"`
+}
+
+// ReleaseHistoryDiagnostic describes why a container of a revision failed,
+// with excerpts of its logs
+type ReleaseHistoryDiagnostic struct {
+	Time metav1.Time `json:"time"`
+
+	// Object is the pod, as "Pod/name"
+	Object             string `json:"object"`
+	Container          string `json:"container"`
+	Reason             string `json:"reason,omitempty"`
+	ExitCode           int32  `json:"exitcode,omitempty"`
+	TerminationMessage string `json:"terminationmessage,omitempty"`
+
+	// Logs are the last lines of the container's log, and PreviousLogs the
+	// last lines of the log of the container before it last restarted
+	Logs         []string `json:"logs,omitempty"`
+	PreviousLogs []string `json:"previouslogs,omitempty"`
+}
+
+// ReleaseHistoryHook is a run of a Helm hook, e.g. a pre-upgrade Job or a
+// `helm test` pod
+type ReleaseHistoryHook struct {
+	// Object is the hook resource, as "Kind/name"
+	Object string `json:"object"`
+
+	// Events are the hook events which run the hook, e.g. "pre-upgrade" or
+	// "test"
+	Events []string `json:"events"`
+	Weight int32    `json:"weight,omitempty"`
+
+	// Order is the position in which the hook started among the revision's
+	// hooks, from 1
+	Order int32 `json:"order"`
+
+	// Phase is "Running", "Succeeded" or "Failed"
+	// +kubebuilder:validation:Enum=Running;Succeeded;Failed
+	Phase       string           `json:"phase"`
+	Message     string           `json:"message,omitempty"`
+	StartedAt   metav1.Time      `json:"startedat"`
+	CompletedAt *metav1.Time     `json:"completedat,omitempty"`
+	Duration    *metav1.Duration `json:"duration,omitempty"`
 }
 
 // ReleaseHistoryList is a list of ReleaseHistory resources
@@ -49,3 +256,181 @@ type ReleaseHistoryList struct {
 
 	Items []ReleaseHistory `json:"items"`
 }
+
+// FreezePolicy describes when new revisions of the releases in its namespace
+// may not be deployed.  Policies are only enforced if the controller is run
+// with `--enforce-freezes`.
+// +genclient
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=freezepolicy
+// +kubebuilder:resource:path=freezepolicies,singular=freezepolicy,categories=tugboat
+// +kubebuilder:printcolumn:name="Releases",type=string,JSONPath=`.spec.releases`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type FreezePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec FreezePolicySpec `json:"spec"`
+}
+
+// FreezePolicySpec is the spec for a FreezePolicy
+// +k8s:deepcopy-gen=true
+type FreezePolicySpec struct {
+	// Releases are shell patterns, as understood by `path.Match`, of the
+	// releases that the policy applies to.  If empty, it applies to every
+	// release in the namespace.
+	Releases []string `json:"releases,omitempty"`
+
+	Windows []FreezeWindow `json:"windows"`
+
+	// Overrides allow releases to be deployed while the policy's windows are
+	// open, e.g. for a hotfix
+	Overrides []FreezeOverride `json:"overrides,omitempty"`
+}
+
+// FreezeWindow is a recurring period during which deployments are frozen
+type FreezeWindow struct {
+	Name string `json:"name"`
+
+	// Schedule is a cron expression for when the window opens, e.g.
+	// "0 17 * * FRI"
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open, e.g. "63h"
+	Duration metav1.Duration `json:"duration"`
+
+	// TimeZone is the IANA time zone of the schedule, e.g.
+	// "America/New_York".  The default is UTC.
+	TimeZone string `json:"timezone,omitempty"`
+
+	// Message is shown to whoever attempts to deploy during the window
+	Message string `json:"message,omitempty"`
+}
+
+// FreezeOverride allows a release to be deployed during a freeze until it
+// expires
+type FreezeOverride struct {
+	// Release is the name of the release
+	Release string `json:"release"`
+
+	// Reason is shown to the deployer, and must not be empty
+	Reason string `json:"reason"`
+
+	// Expires is when the override stops applying
+	Expires metav1.Time `json:"expires"`
+}
+
+// FreezePolicyList is a list of FreezePolicy resources
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=freezepolicy
+type FreezePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []FreezePolicy `json:"items"`
+}
+
+// ConditionVerified is the type of the condition of a revision which reports
+// whether it passed all of its verification checks
+const ConditionVerified = "Verified"
+
+// DeploymentVerification declares the checks which new revisions of the
+// releases in its namespace must pass before they have succeeded.  The
+// checks are run by the watcher.
+// +genclient
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=deploymentverification
+// +kubebuilder:resource:path=deploymentverifications,singular=deploymentverification,categories=tugboat
+// +kubebuilder:printcolumn:name="Releases",type=string,JSONPath=`.spec.releases`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type DeploymentVerification struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DeploymentVerificationSpec `json:"spec"`
+}
+
+// DeploymentVerificationSpec is the spec for a DeploymentVerification
+// +k8s:deepcopy-gen=true
+type DeploymentVerificationSpec struct {
+	// Releases are shell patterns, as understood by `path.Match`, of the
+	// releases that the checks apply to.  If empty, they apply to every
+	// release in the namespace.
+	Releases []string `json:"releases,omitempty"`
+
+	Checks []VerificationCheck `json:"checks"`
+}
+
+// VerificationCheck is an expectation of a revision.  Exactly one of
+// PodsReady, NoRestarts, JobComplete and HTTP is set.
+type VerificationCheck struct {
+	// Name is the type of the check's condition on the revision, e.g.
+	// "PodsReady".  It must be unique among the checks of the release.
+	// +kubebuilder:validation:MaxLength=316
+	// +kubebuilder:validation:Pattern=`^([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$`
+	Name string `json:"name"`
+
+	PodsReady   *PodsReadyCheck   `json:"podsready,omitempty"`
+	NoRestarts  *NoRestartsCheck  `json:"norestarts,omitempty"`
+	JobComplete *JobCompleteCheck `json:"jobcomplete,omitempty"`
+	HTTP        *HTTPCheck        `json:"http,omitempty"`
+}
+
+// PodsReadyCheck passes once every pod of the release is ready
+type PodsReadyCheck struct {
+	// Within is how long after the revision started deploying the pods may
+	// take to become ready
+	// +optional
+	Within metav1.Duration `json:"within,omitempty"`
+}
+
+// NoRestartsCheck passes if no container of the release restarts for a
+// period after the revision started deploying
+type NoRestartsCheck struct {
+	// For is how long no container may restart
+	// +optional
+	For metav1.Duration `json:"for,omitempty"`
+}
+
+// JobCompleteCheck passes once a Job completes
+type JobCompleteCheck struct {
+	// Job is the name of the Job, in the release's namespace
+	Job string `json:"job"`
+
+	// Within is how long after the revision started deploying the Job may
+	// take to complete
+	// +optional
+	Within metav1.Duration `json:"within,omitempty"`
+}
+
+// HTTPCheck passes once a GET of a Service in the release's namespace
+// returns a 2xx status
+type HTTPCheck struct {
+	Service string `json:"service"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+
+	// Path is the path requested, e.g. "/healthz"
+	Path string `json:"path,omitempty"`
+
+	// Within is how long after the revision started deploying the check may
+	// take to pass
+	// +optional
+	Within metav1.Duration `json:"within,omitempty"`
+}
+
+// DeploymentVerificationList is a list of DeploymentVerification resources
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=deploymentverification
+type DeploymentVerificationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []DeploymentVerification `json:"items"`
+}
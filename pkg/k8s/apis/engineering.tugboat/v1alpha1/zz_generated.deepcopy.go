@@ -8,9 +8,293 @@ LICENSE
 package v1alpha1
 
 import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentVerification) DeepCopyInto(out *DeploymentVerification) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentVerification.
+func (in *DeploymentVerification) DeepCopy() *DeploymentVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DeploymentVerification) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentVerificationList) DeepCopyInto(out *DeploymentVerificationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DeploymentVerification, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentVerificationList.
+func (in *DeploymentVerificationList) DeepCopy() *DeploymentVerificationList {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentVerificationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DeploymentVerificationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentVerificationSpec) DeepCopyInto(out *DeploymentVerificationSpec) {
+	*out = *in
+	if in.Releases != nil {
+		in, out := &in.Releases, &out.Releases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]VerificationCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentVerificationSpec.
+func (in *DeploymentVerificationSpec) DeepCopy() *DeploymentVerificationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentVerificationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FreezeOverride) DeepCopyInto(out *FreezeOverride) {
+	*out = *in
+	in.Expires.DeepCopyInto(&out.Expires)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FreezeOverride.
+func (in *FreezeOverride) DeepCopy() *FreezeOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(FreezeOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FreezePolicy) DeepCopyInto(out *FreezePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FreezePolicy.
+func (in *FreezePolicy) DeepCopy() *FreezePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(FreezePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FreezePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FreezePolicyList) DeepCopyInto(out *FreezePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FreezePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FreezePolicyList.
+func (in *FreezePolicyList) DeepCopy() *FreezePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(FreezePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FreezePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FreezePolicySpec) DeepCopyInto(out *FreezePolicySpec) {
+	*out = *in
+	if in.Releases != nil {
+		in, out := &in.Releases, &out.Releases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = make([]FreezeWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make([]FreezeOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FreezePolicySpec.
+func (in *FreezePolicySpec) DeepCopy() *FreezePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FreezePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FreezeWindow) DeepCopyInto(out *FreezeWindow) {
+	*out = *in
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FreezeWindow.
+func (in *FreezeWindow) DeepCopy() *FreezeWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(FreezeWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPCheck) DeepCopyInto(out *HTTPCheck) {
+	*out = *in
+	out.Within = in.Within
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPCheck.
+func (in *HTTPCheck) DeepCopy() *HTTPCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobCompleteCheck) DeepCopyInto(out *JobCompleteCheck) {
+	*out = *in
+	out.Within = in.Within
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobCompleteCheck.
+func (in *JobCompleteCheck) DeepCopy() *JobCompleteCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(JobCompleteCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NoRestartsCheck) DeepCopyInto(out *NoRestartsCheck) {
+	*out = *in
+	out.For = in.For
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NoRestartsCheck.
+func (in *NoRestartsCheck) DeepCopy() *NoRestartsCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(NoRestartsCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodsReadyCheck) DeepCopyInto(out *PodsReadyCheck) {
+	*out = *in
+	out.Within = in.Within
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodsReadyCheck.
+func (in *PodsReadyCheck) DeepCopy() *PodsReadyCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(PodsReadyCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReleaseHistory) DeepCopyInto(out *ReleaseHistory) {
 	*out = *in
@@ -39,6 +323,125 @@ func (in *ReleaseHistory) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryChange) DeepCopyInto(out *ReleaseHistoryChange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryChange.
+func (in *ReleaseHistoryChange) DeepCopy() *ReleaseHistoryChange {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryDeployer) DeepCopyInto(out *ReleaseHistoryDeployer) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CI != nil {
+		in, out := &in.CI, &out.CI
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryDeployer.
+func (in *ReleaseHistoryDeployer) DeepCopy() *ReleaseHistoryDeployer {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryDeployer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryDiagnostic) DeepCopyInto(out *ReleaseHistoryDiagnostic) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.Logs != nil {
+		in, out := &in.Logs, &out.Logs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PreviousLogs != nil {
+		in, out := &in.PreviousLogs, &out.PreviousLogs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryDiagnostic.
+func (in *ReleaseHistoryDiagnostic) DeepCopy() *ReleaseHistoryDiagnostic {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryDiagnostic)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryEvent) DeepCopyInto(out *ReleaseHistoryEvent) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryEvent.
+func (in *ReleaseHistoryEvent) DeepCopy() *ReleaseHistoryEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryHook) DeepCopyInto(out *ReleaseHistoryHook) {
+	*out = *in
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryHook.
+func (in *ReleaseHistoryHook) DeepCopy() *ReleaseHistoryHook {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReleaseHistoryList) DeepCopyInto(out *ReleaseHistoryList) {
 	*out = *in
@@ -83,6 +486,53 @@ func (in *ReleaseHistoryRevision) DeepCopyInto(out *ReleaseHistoryRevision) {
 			(*out)[key] = val
 		}
 	}
+	if in.DeployedBy != nil {
+		in, out := &in.DeployedBy, &out.DeployedBy
+		*out = new(ReleaseHistoryDeployer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(ReleaseHistorySource)
+		**out = **in
+	}
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]ReleaseHistoryChange, len(*in))
+		copy(*out, *in)
+	}
+	if in.StalledAt != nil {
+		in, out := &in.StalledAt, &out.StalledAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]ReleaseHistoryEvent, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Diagnostics != nil {
+		in, out := &in.Diagnostics, &out.Diagnostics
+		*out = make([]ReleaseHistoryDiagnostic, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]ReleaseHistoryHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -96,6 +546,39 @@ func (in *ReleaseHistoryRevision) DeepCopy() *ReleaseHistoryRevision {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryRollback) DeepCopyInto(out *ReleaseHistoryRollback) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryRollback.
+func (in *ReleaseHistoryRollback) DeepCopy() *ReleaseHistoryRollback {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryRollback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistorySource) DeepCopyInto(out *ReleaseHistorySource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistorySource.
+func (in *ReleaseHistorySource) DeepCopy() *ReleaseHistorySource {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistorySource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReleaseHistorySpec) DeepCopyInto(out *ReleaseHistorySpec) {
 	*out = *in
@@ -123,6 +606,13 @@ func (in *ReleaseHistoryStatus) DeepCopyInto(out *ReleaseHistoryStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Rollbacks != nil {
+		in, out := &in.Rollbacks, &out.Rollbacks
+		*out = make([]ReleaseHistoryRollback, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -135,3 +625,39 @@ func (in *ReleaseHistoryStatus) DeepCopy() *ReleaseHistoryStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerificationCheck) DeepCopyInto(out *VerificationCheck) {
+	*out = *in
+	if in.PodsReady != nil {
+		in, out := &in.PodsReady, &out.PodsReady
+		*out = new(PodsReadyCheck)
+		**out = **in
+	}
+	if in.NoRestarts != nil {
+		in, out := &in.NoRestarts, &out.NoRestarts
+		*out = new(NoRestartsCheck)
+		**out = **in
+	}
+	if in.JobComplete != nil {
+		in, out := &in.JobComplete, &out.JobComplete
+		*out = new(JobCompleteCheck)
+		**out = **in
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPCheck)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerificationCheck.
+func (in *VerificationCheck) DeepCopy() *VerificationCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(VerificationCheck)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,200 @@
+package v1beta1
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// gvkKindSeparator separates the group and version from the kind in the
+// v1alpha1 GVKs keys, which are written by schema.GroupVersionKind.String
+const gvkKindSeparator = ", Kind="
+
+// ConvertFrom sets the ReleaseHistory to the v1beta1 equivalent of a
+// v1alpha1 ReleaseHistory.  The keys of the v1alpha1 GVKs become Kinds; their
+// values, which are always "true", are dropped.
+func (rh *ReleaseHistory) ConvertFrom(src *v1alpha1.ReleaseHistory) {
+	src = src.DeepCopy()
+
+	rh.TypeMeta = metav1.TypeMeta{APIVersion: SchemeGroupVersion.String(), Kind: "ReleaseHistory"}
+	rh.ObjectMeta = src.ObjectMeta
+	rh.Spec = ReleaseHistorySpec{ReleaseName: src.Spec.ReleaseName}
+	rh.Status = ReleaseHistoryStatus{DeployedAt: src.Status.DeployedAt}
+
+	if src.Status.Revisions != nil {
+		rh.Status.Revisions = make([]ReleaseHistoryRevision, len(src.Status.Revisions))
+	}
+	for k, r := range src.Status.Revisions {
+		rev := ReleaseHistoryRevision{
+			Revision:   int64(r.Revision),
+			DeployedAt: r.DeployedAt,
+			Kinds:      kindsFrom(r.GVKs),
+			Status:     r.Status,
+			StalledAt:  r.StalledAt,
+			Conditions: r.Conditions,
+		}
+		if r.DeployedBy != nil {
+			d := ReleaseHistoryDeployer(*r.DeployedBy)
+			rev.DeployedBy = &d
+		}
+		if r.Source != nil {
+			s := ReleaseHistorySource(*r.Source)
+			rev.Source = &s
+		}
+		if r.Changes != nil {
+			rev.Changes = make([]ReleaseHistoryChange, len(r.Changes))
+			for j, c := range r.Changes {
+				rev.Changes[j] = ReleaseHistoryChange(c)
+			}
+		}
+		if r.Events != nil {
+			rev.Events = make([]ReleaseHistoryEvent, len(r.Events))
+			for j, e := range r.Events {
+				rev.Events[j] = ReleaseHistoryEvent(e)
+			}
+		}
+		if r.Diagnostics != nil {
+			rev.Diagnostics = make([]ReleaseHistoryDiagnostic, len(r.Diagnostics))
+			for j, d := range r.Diagnostics {
+				rev.Diagnostics[j] = ReleaseHistoryDiagnostic(d)
+			}
+		}
+		if r.Hooks != nil {
+			rev.Hooks = make([]ReleaseHistoryHook, len(r.Hooks))
+			for j, h := range r.Hooks {
+				rev.Hooks[j] = ReleaseHistoryHook(h)
+			}
+		}
+		rh.Status.Revisions[k] = rev
+	}
+
+	if src.Status.Rollbacks != nil {
+		rh.Status.Rollbacks = make([]ReleaseHistoryRollback, len(src.Status.Rollbacks))
+	}
+	for k, rb := range src.Status.Rollbacks {
+		rh.Status.Rollbacks[k] = ReleaseHistoryRollback{
+			Time:    rb.Time,
+			From:    int64(rb.From),
+			To:      int64(rb.To),
+			Outcome: rb.Outcome,
+			Message: rb.Message,
+		}
+	}
+}
+
+// ConvertTo sets a v1alpha1 ReleaseHistory to the equivalent of the
+// ReleaseHistory.  Each of the Kinds becomes a GVKs key, with the value
+// "true".
+func (rh *ReleaseHistory) ConvertTo(dst *v1alpha1.ReleaseHistory) {
+	src := rh.DeepCopy()
+
+	dst.TypeMeta = metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "ReleaseHistory"}
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1alpha1.ReleaseHistorySpec{ReleaseName: src.Spec.ReleaseName}
+	dst.Status = v1alpha1.ReleaseHistoryStatus{DeployedAt: src.Status.DeployedAt}
+
+	if src.Status.Revisions != nil {
+		dst.Status.Revisions = make([]v1alpha1.ReleaseHistoryRevision, len(src.Status.Revisions))
+	}
+	for k, r := range src.Status.Revisions {
+		rev := v1alpha1.ReleaseHistoryRevision{
+			Revision:   v1alpha1.Revision(r.Revision),
+			DeployedAt: r.DeployedAt,
+			GVKs:       gvksFrom(r.Kinds),
+			Status:     r.Status,
+			StalledAt:  r.StalledAt,
+			Conditions: r.Conditions,
+		}
+		if r.DeployedBy != nil {
+			d := v1alpha1.ReleaseHistoryDeployer(*r.DeployedBy)
+			rev.DeployedBy = &d
+		}
+		if r.Source != nil {
+			s := v1alpha1.ReleaseHistorySource(*r.Source)
+			rev.Source = &s
+		}
+		if r.Changes != nil {
+			rev.Changes = make([]v1alpha1.ReleaseHistoryChange, len(r.Changes))
+			for j, c := range r.Changes {
+				rev.Changes[j] = v1alpha1.ReleaseHistoryChange(c)
+			}
+		}
+		if r.Events != nil {
+			rev.Events = make([]v1alpha1.ReleaseHistoryEvent, len(r.Events))
+			for j, e := range r.Events {
+				rev.Events[j] = v1alpha1.ReleaseHistoryEvent(e)
+			}
+		}
+		if r.Diagnostics != nil {
+			rev.Diagnostics = make([]v1alpha1.ReleaseHistoryDiagnostic, len(r.Diagnostics))
+			for j, d := range r.Diagnostics {
+				rev.Diagnostics[j] = v1alpha1.ReleaseHistoryDiagnostic(d)
+			}
+		}
+		if r.Hooks != nil {
+			rev.Hooks = make([]v1alpha1.ReleaseHistoryHook, len(r.Hooks))
+			for j, h := range r.Hooks {
+				rev.Hooks[j] = v1alpha1.ReleaseHistoryHook(h)
+			}
+		}
+		dst.Status.Revisions[k] = rev
+	}
+
+	if src.Status.Rollbacks != nil {
+		dst.Status.Rollbacks = make([]v1alpha1.ReleaseHistoryRollback, len(src.Status.Rollbacks))
+	}
+	for k, rb := range src.Status.Rollbacks {
+		dst.Status.Rollbacks[k] = v1alpha1.ReleaseHistoryRollback{
+			Time:    rb.Time,
+			From:    v1alpha1.Revision(rb.From),
+			To:      v1alpha1.Revision(rb.To),
+			Outcome: rb.Outcome,
+			Message: rb.Message,
+		}
+	}
+}
+
+// kindsFrom parses the keys of v1alpha1 GVKs.  A key which was not written
+// by schema.GroupVersionKind.String is taken to be a bare kind.
+func kindsFrom(gvks map[string]string) []metav1.GroupVersionKind {
+	if len(gvks) == 0 {
+		return nil
+	}
+	kinds := make([]metav1.GroupVersionKind, 0, len(gvks))
+	for key := range gvks {
+		gvk := metav1.GroupVersionKind{Kind: key}
+		if i := strings.LastIndex(key, gvkKindSeparator); i != -1 {
+			if gv, err := schema.ParseGroupVersion(key[:i]); err == nil && strings.Contains(key[:i], "/") {
+				gvk = metav1.GroupVersionKind{Group: gv.Group, Version: gv.Version, Kind: key[i+len(gvkKindSeparator):]}
+			}
+		}
+		kinds = append(kinds, gvk)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if kinds[i].Group != kinds[j].Group {
+			return kinds[i].Group < kinds[j].Group
+		}
+		if kinds[i].Version != kinds[j].Version {
+			return kinds[i].Version < kinds[j].Version
+		}
+		return kinds[i].Kind < kinds[j].Kind
+	})
+	return kinds
+}
+
+// gvksFrom returns the v1alpha1 GVKs of the kinds.  The map is never nil, so
+// that v1alpha1 clients can add to it.
+func gvksFrom(kinds []metav1.GroupVersionKind) map[string]string {
+	gvks := make(map[string]string, len(kinds))
+	for _, k := range kinds {
+		key := k.Kind
+		if k.Group != "" || k.Version != "" {
+			key = schema.GroupVersionKind{Group: k.Group, Version: k.Version, Kind: k.Kind}.String()
+		}
+		gvks[key] = "true"
+	}
+	return gvks
+}
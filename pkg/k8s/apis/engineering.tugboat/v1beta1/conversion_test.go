@@ -0,0 +1,135 @@
+package v1beta1
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_ReleaseHistory_RoundTrip_V1alpha1(t *testing.T) {
+	now := metav1.NewTime(time.Date(2021, time.January, 8, 12, 0, 0, 0, time.UTC))
+	completed := metav1.NewTime(now.Add(time.Minute))
+
+	tcs := []struct {
+		name string
+		rh   *v1alpha1.ReleaseHistory
+	}{
+		{
+			name: "empty",
+			rh: &v1alpha1.ReleaseHistory{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "tugboat.engineering/v1alpha1", Kind: "ReleaseHistory"},
+				ObjectMeta: metav1.ObjectMeta{Name: "checkout-api", Namespace: "payments"},
+			},
+		},
+		{
+			name: "full",
+			rh: &v1alpha1.ReleaseHistory{
+				TypeMeta: metav1.TypeMeta{APIVersion: "tugboat.engineering/v1alpha1", Kind: "ReleaseHistory"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "checkout-api",
+					Namespace:   "payments",
+					Labels:      map[string]string{"tugboat.engineering/state": "active"},
+					Annotations: map[string]string{"tugboat.engineering/auto-rollback": "true"},
+				},
+				Spec: v1alpha1.ReleaseHistorySpec{ReleaseName: "checkout-api"},
+				Status: v1alpha1.ReleaseHistoryStatus{
+					DeployedAt: now,
+					Revisions: []v1alpha1.ReleaseHistoryRevision{
+						{
+							Revision:   1,
+							DeployedAt: now,
+							GVKs:       map[string]string{},
+						},
+						{
+							Revision:   2,
+							DeployedAt: now,
+							GVKs: map[string]string{
+								"apps/v1, Kind=Deployment": "true",
+								"/v1, Kind=Service":        "true",
+								"Unparseable":              "true",
+							},
+							DeployedBy: &v1alpha1.ReleaseHistoryDeployer{Username: "jane@example.com", Groups: []string{"developers"}, CI: map[string]string{"build": "42"}},
+							Source:     &v1alpha1.ReleaseHistorySource{Commit: "abc123", Branch: "main"},
+							Status:     "deployed",
+							Changes:    []v1alpha1.ReleaseHistoryChange{{Object: "Deployment/api", Container: "api", Field: "tag", From: "1.0", To: "1.1"}},
+							StalledAt:  &now,
+							Events:     []v1alpha1.ReleaseHistoryEvent{{Time: now, Type: "Progressing", Severity: "Info", Reason: "Ready", Object: "Pod/api-0", Count: 2}},
+							Diagnostics: []v1alpha1.ReleaseHistoryDiagnostic{
+								{Time: now, Object: "Pod/api-0", Container: "api", Reason: "Error", ExitCode: 1, Logs: []string{"panic"}},
+							},
+							Hooks: []v1alpha1.ReleaseHistoryHook{
+								{Object: "Job/migrate", Events: []string{"pre-upgrade"}, Order: 1, Phase: "Succeeded", StartedAt: now, CompletedAt: &completed, Duration: &metav1.Duration{Duration: time.Minute}},
+							},
+							Conditions: []metav1.Condition{{Type: "Verified", Status: metav1.ConditionTrue, Reason: "Passed", LastTransitionTime: now}},
+						},
+					},
+					Rollbacks: []v1alpha1.ReleaseHistoryRollback{{Time: now, From: 2, To: 1, Outcome: v1alpha1.RollbackSucceeded, Message: "stalled"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var beta ReleaseHistory
+			beta.ConvertFrom(tc.rh)
+			if beta.APIVersion != "tugboat.engineering/v1beta1" {
+				t.Errorf("incorrect apiVersion: %s", beta.APIVersion)
+			}
+
+			var actual v1alpha1.ReleaseHistory
+			beta.ConvertTo(&actual)
+			if !reflect.DeepEqual(&actual, tc.rh) {
+				t.Errorf("round trip changed release history:\nexpected: %#v\nactual:   %#v", tc.rh, &actual)
+			}
+		})
+	}
+}
+
+func Test_ReleaseHistory_RoundTrip_V1beta1(t *testing.T) {
+	now := metav1.NewTime(time.Date(2021, time.January, 8, 12, 0, 0, 0, time.UTC))
+
+	rh := &ReleaseHistory{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "tugboat.engineering/v1beta1", Kind: "ReleaseHistory"},
+		ObjectMeta: metav1.ObjectMeta{Name: "checkout-api", Namespace: "payments"},
+		Spec:       ReleaseHistorySpec{ReleaseName: "checkout-api"},
+		Status: ReleaseHistoryStatus{
+			DeployedAt: now,
+			Revisions: []ReleaseHistoryRevision{
+				{
+					Revision:   7,
+					DeployedAt: now,
+					Kinds: []metav1.GroupVersionKind{
+						{Kind: "Unparseable"},
+						{Version: "v1", Kind: "ConfigMap"},
+						{Version: "v1", Kind: "Service"},
+						{Group: "apps", Version: "v1", Kind: "Deployment"},
+					},
+					DeployedBy: &ReleaseHistoryDeployer{Username: "system:serviceaccount:ci:deployer", ServiceAccount: "ci/deployer"},
+				},
+			},
+			Rollbacks: []ReleaseHistoryRollback{{Time: now, From: 7, Outcome: RollbackSkipped}},
+		},
+	}
+
+	var alpha v1alpha1.ReleaseHistory
+	rh.ConvertTo(&alpha)
+	expectedGVKs := map[string]string{
+		"Unparseable":              "true",
+		"/v1, Kind=ConfigMap":      "true",
+		"/v1, Kind=Service":        "true",
+		"apps/v1, Kind=Deployment": "true",
+	}
+	if !reflect.DeepEqual(alpha.Status.Revisions[0].GVKs, expectedGVKs) {
+		t.Errorf("incorrect gvks: %v", alpha.Status.Revisions[0].GVKs)
+	}
+
+	var actual ReleaseHistory
+	actual.ConvertFrom(&alpha)
+	if !reflect.DeepEqual(&actual, rh) {
+		t.Errorf("round trip changed release history:\nexpected: %#v\nactual:   %#v", rh, &actual)
+	}
+}
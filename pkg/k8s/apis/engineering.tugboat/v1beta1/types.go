@@ -0,0 +1,260 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReleaseHistory describes the history of the kubernetes resources described
+// in a particular release of a chart.  It is the storage version; v1alpha1
+// ReleaseHistories are converted to and from it by the controller's
+// conversion webhook.
+// +genclient
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=releasehistory
+// +kubebuilder:resource:path=releasehistories,singular=releasehistory,shortName=rh;rhte,categories=tugboat
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Release",type=string,JSONPath=`.spec.releasename`
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.metadata.labels.tugboat\.engineering/state`
+// +kubebuilder:printcolumn:name="Latest Revision",type=integer,JSONPath=`.status.revisions[-1:].revision`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.revisions[-1:].conditions[?(@.type=="Verified")].reason`,description="Whether the latest revision passed its verification checks"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type ReleaseHistory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ReleaseHistorySpec `json:"spec"`
+
+	// +optional
+	Status ReleaseHistoryStatus `json:"status,omitempty"`
+}
+
+// ReleaseHistorySpec is the spec for a ReleaseHistory
+// +k8s:deepcopy-gen=true
+type ReleaseHistorySpec struct {
+	// ReleaseName is the name of the Helm release, and cannot be changed
+	// +kubebuilder:validation:MinLength=1
+	ReleaseName string `json:"releasename"`
+}
+
+// ReleaseHistoryStatus is the status for a ReleaseHistory resource
+type ReleaseHistoryStatus struct {
+	DeployedAt metav1.Time `json:"deployedat"`
+
+	// Revisions are the revisions of the release, oldest first.  At most
+	// MaxRevisions are retained.
+	Revisions []ReleaseHistoryRevision `json:"revisions"`
+
+	// Rollbacks are the automatic rollbacks of the release, oldest first.  At
+	// most MaxRollbacks are retained.
+	Rollbacks []ReleaseHistoryRollback `json:"rollbacks,omitempty"`
+}
+
+// ReleaseHistoryRevision is a revision of a release, as numbered by Helm
+type ReleaseHistoryRevision struct {
+	// +kubebuilder:validation:Minimum=1
+	Revision   int64       `json:"revision"`
+	DeployedAt metav1.Time `json:"deployedat"`
+
+	// Kinds are the kinds of the resources which the revision deployed,
+	// sorted by group, version and kind
+	Kinds []metav1.GroupVersionKind `json:"kinds,omitempty"`
+
+	// Status is the status of the revision's Helm release, e.g.
+	// "pending-upgrade", "deployed" or "failed", as labelled on its release
+	// secret
+	Status string `json:"status,omitempty"`
+
+	// DeployedBy is who created the revision, as reported to the admission
+	// webhook
+	DeployedBy *ReleaseHistoryDeployer `json:"deployedby,omitempty"`
+
+	// Source links the revision to the change which caused it
+	Source *ReleaseHistorySource `json:"source,omitempty"`
+
+	// Changes summarize how the revision's workloads differ from the previous
+	// revision's, e.g. a new image tag or more replicas
+	Changes []ReleaseHistoryChange `json:"changes,omitempty"`
+
+	// StalledAt is when the revision's rollout was found to have stopped
+	// making progress, if it has
+	StalledAt *metav1.Time `json:"stalledat,omitempty"`
+
+	// Events are the most recent things that happened while the revision
+	// deployed, oldest first.  At most MaxRevisionEvents are retained.
+	Events []ReleaseHistoryEvent `json:"events,omitempty"`
+
+	// Diagnostics describe the most recent container failures, oldest first.
+	// At most MaxRevisionDiagnostics are retained.
+	Diagnostics []ReleaseHistoryDiagnostic `json:"diagnostics,omitempty"`
+
+	// Hooks are the Helm hooks which the revision ran, including `helm test`
+	// pods, in the order that they started.  At most MaxRevisionHooks are
+	// retained.
+	Hooks []ReleaseHistoryHook `json:"hooks,omitempty"`
+
+	// Conditions are the results of the DeploymentVerification checks of the
+	// revision, one for each check, and whether the revision is "Verified"
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// MaxRevisions is the number of revisions retained for each release, so
+	// that the ReleaseHistory stays within the size limit of an object
+	MaxRevisions = 10
+
+	// MaxRevisionEvents is the number of events retained for each revision
+	MaxRevisionEvents = 50
+
+	// MaxRevisionDiagnostics is the number of diagnostics retained for each
+	// revision
+	MaxRevisionDiagnostics = 5
+
+	// MaxRevisionHooks is the number of hook runs retained for each revision
+	MaxRevisionHooks = 20
+
+	// MaxRollbacks is the number of automatic rollbacks retained for each
+	// release
+	MaxRollbacks = 20
+)
+
+// Outcomes of an automatic rollback
+const (
+	RollbackSucceeded = "Succeeded"
+	RollbackFailed    = "Failed"
+	RollbackSkipped   = "Skipped"
+)
+
+// ReleaseHistoryRollback is an automatic rollback of a failed revision, or
+// the decision not to roll it back
+type ReleaseHistoryRollback struct {
+	Time metav1.Time `json:"time"`
+
+	// From is the failed revision
+	From int64 `json:"from"`
+
+	// To is the revision which was rolled back to, if any
+	To int64 `json:"to,omitempty"`
+
+	// Outcome is "Succeeded", "Failed" or "Skipped"
+	// +kubebuilder:validation:Enum=Succeeded;Failed;Skipped
+	Outcome string `json:"outcome"`
+	Message string `json:"message,omitempty"`
+}
+
+// ReleaseHistoryChange is a difference in a workload between a revision and
+// the previous revision
+type ReleaseHistoryChange struct {
+	// Object is the workload, as "Kind/name"
+	Object string `json:"object"`
+
+	// Container is the container which changed, if any
+	Container string `json:"container,omitempty"`
+
+	// Field is what changed: "image", "tag", "digest", "env", "replicas",
+	// a resource such as "requests.cpu", or "created" or "deleted" for a
+	// workload which was added to or removed from the release
+	Field string `json:"field"`
+
+	// From and To are the previous and new values, if any.  A new
+	// environment variable has a To of its name; a removed one, a From.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// ReleaseHistoryDeployer identifies who deployed a revision
+type ReleaseHistoryDeployer struct {
+	Username string   `json:"username"`
+	Groups   []string `json:"groups,omitempty"`
+
+	// ServiceAccount is the deployer's service account, as "namespace/name",
+	// if it is one
+	ServiceAccount string `json:"serviceaccount,omitempty"`
+
+	// CI identifies the CI job which deployed the revision, keyed by the user
+	// extra fields or helm release secret annotations which it was read from
+	CI map[string]string `json:"ci,omitempty"`
+}
+
+// ReleaseHistorySource is the source-control metadata of a revision,
+// harvested from annotations, labels and the Helm release description
+type ReleaseHistorySource struct {
+	Commit string `json:"commit,omitempty"`
+	Branch string `json:"branch,omitempty"`
+
+	// PullRequest is the URL of the pull request
+	PullRequest string `json:"pullrequest,omitempty"`
+
+	// Pipeline identifies the CI pipeline run
+	Pipeline string `json:"pipeline,omitempty"`
+}
+
+// ReleaseHistoryEvent is something that happened to the resources of a
+// revision, e.g. a pod becoming ready or a container crashing
+type ReleaseHistoryEvent struct {
+	Time     metav1.Time `json:"time"`
+	Type     string      `json:"type"`
+	Severity string      `json:"severity"`
+	Reason   string      `json:"reason"`
+	Message  string      `json:"message,omitempty"`
+
+	// Object is the resource the event is about, as "Kind/name"
+	Object string `json:"object,omitempty"`
+
+	// Count is the number of times the event has occurred, if it recurs
+	Count int32 `json:"count,omitempty"`
+}
+
+// ReleaseHistoryDiagnostic describes why a container of a revision failed,
+// with excerpts of its logs
+type ReleaseHistoryDiagnostic struct {
+	Time metav1.Time `json:"time"`
+
+	// Object is the pod, as "Pod/name"
+	Object             string `json:"object"`
+	Container          string `json:"container"`
+	Reason             string `json:"reason,omitempty"`
+	ExitCode           int32  `json:"exitcode,omitempty"`
+	TerminationMessage string `json:"terminationmessage,omitempty"`
+
+	// Logs are the last lines of the container's log, and PreviousLogs the
+	// last lines of the log of the container before it last restarted
+	Logs         []string `json:"logs,omitempty"`
+	PreviousLogs []string `json:"previouslogs,omitempty"`
+}
+
+// ReleaseHistoryHook is a run of a Helm hook, e.g. a pre-upgrade Job or a
+// `helm test` pod
+type ReleaseHistoryHook struct {
+	// Object is the hook resource, as "Kind/name"
+	Object string `json:"object"`
+
+	// Events are the hook events which run the hook, e.g. "pre-upgrade" or
+	// "test"
+	Events []string `json:"events"`
+	Weight int32    `json:"weight,omitempty"`
+
+	// Order is the position in which the hook started among the revision's
+	// hooks, from 1
+	Order int32 `json:"order"`
+
+	// Phase is "Running", "Succeeded" or "Failed"
+	// +kubebuilder:validation:Enum=Running;Succeeded;Failed
+	Phase       string           `json:"phase"`
+	Message     string           `json:"message,omitempty"`
+	StartedAt   metav1.Time      `json:"startedat"`
+	CompletedAt *metav1.Time     `json:"completedat,omitempty"`
+	Duration    *metav1.Duration `json:"duration,omitempty"`
+}
+
+// ReleaseHistoryList is a list of ReleaseHistory resources
+// +k8s:deepcopy-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=releasehistory
+type ReleaseHistoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ReleaseHistory `json:"items"`
+}
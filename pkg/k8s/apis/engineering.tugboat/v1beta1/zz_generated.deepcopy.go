@@ -0,0 +1,342 @@
+// +build !ignore_autogenerated
+
+/*
+LICENSE
+*/
+// Code generated by deepcopy-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistory) DeepCopyInto(out *ReleaseHistory) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistory.
+func (in *ReleaseHistory) DeepCopy() *ReleaseHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReleaseHistory) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryChange) DeepCopyInto(out *ReleaseHistoryChange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryChange.
+func (in *ReleaseHistoryChange) DeepCopy() *ReleaseHistoryChange {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryDeployer) DeepCopyInto(out *ReleaseHistoryDeployer) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CI != nil {
+		in, out := &in.CI, &out.CI
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryDeployer.
+func (in *ReleaseHistoryDeployer) DeepCopy() *ReleaseHistoryDeployer {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryDeployer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryDiagnostic) DeepCopyInto(out *ReleaseHistoryDiagnostic) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.Logs != nil {
+		in, out := &in.Logs, &out.Logs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PreviousLogs != nil {
+		in, out := &in.PreviousLogs, &out.PreviousLogs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryDiagnostic.
+func (in *ReleaseHistoryDiagnostic) DeepCopy() *ReleaseHistoryDiagnostic {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryDiagnostic)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryEvent) DeepCopyInto(out *ReleaseHistoryEvent) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryEvent.
+func (in *ReleaseHistoryEvent) DeepCopy() *ReleaseHistoryEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryHook) DeepCopyInto(out *ReleaseHistoryHook) {
+	*out = *in
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryHook.
+func (in *ReleaseHistoryHook) DeepCopy() *ReleaseHistoryHook {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryList) DeepCopyInto(out *ReleaseHistoryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ReleaseHistory, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryList.
+func (in *ReleaseHistoryList) DeepCopy() *ReleaseHistoryList {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReleaseHistoryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryRevision) DeepCopyInto(out *ReleaseHistoryRevision) {
+	*out = *in
+	in.DeployedAt.DeepCopyInto(&out.DeployedAt)
+	if in.Kinds != nil {
+		in, out := &in.Kinds, &out.Kinds
+		*out = make([]v1.GroupVersionKind, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeployedBy != nil {
+		in, out := &in.DeployedBy, &out.DeployedBy
+		*out = new(ReleaseHistoryDeployer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(ReleaseHistorySource)
+		**out = **in
+	}
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]ReleaseHistoryChange, len(*in))
+		copy(*out, *in)
+	}
+	if in.StalledAt != nil {
+		in, out := &in.StalledAt, &out.StalledAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]ReleaseHistoryEvent, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Diagnostics != nil {
+		in, out := &in.Diagnostics, &out.Diagnostics
+		*out = make([]ReleaseHistoryDiagnostic, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]ReleaseHistoryHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryRevision.
+func (in *ReleaseHistoryRevision) DeepCopy() *ReleaseHistoryRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryRollback) DeepCopyInto(out *ReleaseHistoryRollback) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryRollback.
+func (in *ReleaseHistoryRollback) DeepCopy() *ReleaseHistoryRollback {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryRollback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistorySource) DeepCopyInto(out *ReleaseHistorySource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistorySource.
+func (in *ReleaseHistorySource) DeepCopy() *ReleaseHistorySource {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistorySource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistorySpec) DeepCopyInto(out *ReleaseHistorySpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistorySpec.
+func (in *ReleaseHistorySpec) DeepCopy() *ReleaseHistorySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistorySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseHistoryStatus) DeepCopyInto(out *ReleaseHistoryStatus) {
+	*out = *in
+	in.DeployedAt.DeepCopyInto(&out.DeployedAt)
+	if in.Revisions != nil {
+		in, out := &in.Revisions, &out.Revisions
+		*out = make([]ReleaseHistoryRevision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rollbacks != nil {
+		in, out := &in.Rollbacks, &out.Rollbacks
+		*out = make([]ReleaseHistoryRollback, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseHistoryStatus.
+func (in *ReleaseHistoryStatus) DeepCopy() *ReleaseHistoryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseHistoryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
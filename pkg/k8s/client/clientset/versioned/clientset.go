@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	tugboatv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/typed/engineering.tugboat/v1alpha1"
+	tugboatv1beta1 "github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/typed/engineering.tugboat/v1beta1"
 	discovery "k8s.io/client-go/discovery"
 	rest "k8s.io/client-go/rest"
 	flowcontrol "k8s.io/client-go/util/flowcontrol"
@@ -17,6 +18,7 @@ import (
 type Interface interface {
 	Discovery() discovery.DiscoveryInterface
 	TugboatV1alpha1() tugboatv1alpha1.TugboatV1alpha1Interface
+	TugboatV1beta1() tugboatv1beta1.TugboatV1beta1Interface
 }
 
 // Clientset contains the clients for groups. Each group has exactly one
@@ -24,6 +26,7 @@ type Interface interface {
 type Clientset struct {
 	*discovery.DiscoveryClient
 	tugboatV1alpha1 *tugboatv1alpha1.TugboatV1alpha1Client
+	tugboatV1beta1  *tugboatv1beta1.TugboatV1beta1Client
 }
 
 // TugboatV1alpha1 retrieves the TugboatV1alpha1Client
@@ -31,6 +34,11 @@ func (c *Clientset) TugboatV1alpha1() tugboatv1alpha1.TugboatV1alpha1Interface {
 	return c.tugboatV1alpha1
 }
 
+// TugboatV1beta1 retrieves the TugboatV1beta1Client
+func (c *Clientset) TugboatV1beta1() tugboatv1beta1.TugboatV1beta1Interface {
+	return c.tugboatV1beta1
+}
+
 // Discovery retrieves the DiscoveryClient
 func (c *Clientset) Discovery() discovery.DiscoveryInterface {
 	if c == nil {
@@ -56,6 +64,10 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 	if err != nil {
 		return nil, err
 	}
+	cs.tugboatV1beta1, err = tugboatv1beta1.NewForConfig(&configShallowCopy)
+	if err != nil {
+		return nil, err
+	}
 
 	cs.DiscoveryClient, err = discovery.NewDiscoveryClientForConfig(&configShallowCopy)
 	if err != nil {
@@ -69,6 +81,7 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 func NewForConfigOrDie(c *rest.Config) *Clientset {
 	var cs Clientset
 	cs.tugboatV1alpha1 = tugboatv1alpha1.NewForConfigOrDie(c)
+	cs.tugboatV1beta1 = tugboatv1beta1.NewForConfigOrDie(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClientForConfigOrDie(c)
 	return &cs
@@ -78,6 +91,7 @@ func NewForConfigOrDie(c *rest.Config) *Clientset {
 func New(c rest.Interface) *Clientset {
 	var cs Clientset
 	cs.tugboatV1alpha1 = tugboatv1alpha1.New(c)
+	cs.tugboatV1beta1 = tugboatv1beta1.New(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClient(c)
 	return &cs
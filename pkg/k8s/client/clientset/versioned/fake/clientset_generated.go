@@ -9,6 +9,8 @@ import (
 	clientset "github.com/object88/tugboat/pkg/k8s/client/clientset/versioned"
 	tugboatv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/typed/engineering.tugboat/v1alpha1"
 	faketugboatv1alpha1 "github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/typed/engineering.tugboat/v1alpha1/fake"
+	tugboatv1beta1 "github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/typed/engineering.tugboat/v1beta1"
+	faketugboatv1beta1 "github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/typed/engineering.tugboat/v1beta1/fake"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
@@ -67,3 +69,8 @@ var _ clientset.Interface = &Clientset{}
 func (c *Clientset) TugboatV1alpha1() tugboatv1alpha1.TugboatV1alpha1Interface {
 	return &faketugboatv1alpha1.FakeTugboatV1alpha1{Fake: &c.Fake}
 }
+
+// TugboatV1beta1 retrieves the TugboatV1beta1Client
+func (c *Clientset) TugboatV1beta1() tugboatv1beta1.TugboatV1beta1Interface {
+	return &faketugboatv1beta1.FakeTugboatV1beta1{Fake: &c.Fake}
+}
@@ -7,6 +7,7 @@ package fake
 
 import (
 	tugboatv1alpha1 "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	tugboatv1beta1 "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
@@ -19,6 +20,7 @@ var codecs = serializer.NewCodecFactory(scheme)
 
 var localSchemeBuilder = runtime.SchemeBuilder{
 	tugboatv1alpha1.AddToScheme,
+	tugboatv1beta1.AddToScheme,
 }
 
 // AddToScheme adds all types of this clientset into the given scheme. This allows composition
@@ -0,0 +1,165 @@
+/*
+LICENSE
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	scheme "github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// DeploymentVerificationsGetter has a method to return a DeploymentVerificationInterface.
+// A group's client should implement this interface.
+type DeploymentVerificationsGetter interface {
+	DeploymentVerifications(namespace string) DeploymentVerificationInterface
+}
+
+// DeploymentVerificationInterface has methods to work with DeploymentVerification resources.
+type DeploymentVerificationInterface interface {
+	Create(ctx context.Context, deploymentVerification *v1alpha1.DeploymentVerification, opts v1.CreateOptions) (*v1alpha1.DeploymentVerification, error)
+	Update(ctx context.Context, deploymentVerification *v1alpha1.DeploymentVerification, opts v1.UpdateOptions) (*v1alpha1.DeploymentVerification, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.DeploymentVerification, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.DeploymentVerificationList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.DeploymentVerification, err error)
+	DeploymentVerificationExpansion
+}
+
+// deploymentVerifications implements DeploymentVerificationInterface
+type deploymentVerifications struct {
+	client rest.Interface
+	ns     string
+}
+
+// newDeploymentVerifications returns a DeploymentVerifications
+func newDeploymentVerifications(c *TugboatV1alpha1Client, namespace string) *deploymentVerifications {
+	return &deploymentVerifications{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the deploymentVerification, and returns the corresponding deploymentVerification object, and an error if there is any.
+func (c *deploymentVerifications) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.DeploymentVerification, err error) {
+	result = &v1alpha1.DeploymentVerification{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("deploymentverifications").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of DeploymentVerifications that match those selectors.
+func (c *deploymentVerifications) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.DeploymentVerificationList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.DeploymentVerificationList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("deploymentverifications").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested deploymentVerifications.
+func (c *deploymentVerifications) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("deploymentverifications").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a deploymentVerification and creates it.  Returns the server's representation of the deploymentVerification, and an error, if there is any.
+func (c *deploymentVerifications) Create(ctx context.Context, deploymentVerification *v1alpha1.DeploymentVerification, opts v1.CreateOptions) (result *v1alpha1.DeploymentVerification, err error) {
+	result = &v1alpha1.DeploymentVerification{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("deploymentverifications").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(deploymentVerification).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a deploymentVerification and updates it. Returns the server's representation of the deploymentVerification, and an error, if there is any.
+func (c *deploymentVerifications) Update(ctx context.Context, deploymentVerification *v1alpha1.DeploymentVerification, opts v1.UpdateOptions) (result *v1alpha1.DeploymentVerification, err error) {
+	result = &v1alpha1.DeploymentVerification{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("deploymentverifications").
+		Name(deploymentVerification.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(deploymentVerification).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the deploymentVerification and deletes it. Returns an error if one occurs.
+func (c *deploymentVerifications) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("deploymentverifications").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *deploymentVerifications) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("deploymentverifications").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched deploymentVerification.
+func (c *deploymentVerifications) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.DeploymentVerification, err error) {
+	result = &v1alpha1.DeploymentVerification{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("deploymentverifications").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
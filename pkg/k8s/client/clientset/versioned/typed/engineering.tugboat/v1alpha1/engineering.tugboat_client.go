@@ -13,6 +13,8 @@ import (
 
 type TugboatV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	DeploymentVerificationsGetter
+	FreezePoliciesGetter
 	ReleaseHistoriesGetter
 }
 
@@ -21,6 +23,14 @@ type TugboatV1alpha1Client struct {
 	restClient rest.Interface
 }
 
+func (c *TugboatV1alpha1Client) DeploymentVerifications(namespace string) DeploymentVerificationInterface {
+	return newDeploymentVerifications(c, namespace)
+}
+
+func (c *TugboatV1alpha1Client) FreezePolicies(namespace string) FreezePolicyInterface {
+	return newFreezePolicies(c, namespace)
+}
+
 func (c *TugboatV1alpha1Client) ReleaseHistories(namespace string) ReleaseHistoryInterface {
 	return newReleaseHistories(c, namespace)
 }
@@ -0,0 +1,117 @@
+/*
+LICENSE
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeDeploymentVerifications implements DeploymentVerificationInterface
+type FakeDeploymentVerifications struct {
+	Fake *FakeTugboatV1alpha1
+	ns   string
+}
+
+var deploymentverificationsResource = schema.GroupVersionResource{Group: "tugboat.engineering", Version: "v1alpha1", Resource: "deploymentverifications"}
+
+var deploymentverificationsKind = schema.GroupVersionKind{Group: "tugboat.engineering", Version: "v1alpha1", Kind: "DeploymentVerification"}
+
+// Get takes name of the deploymentVerification, and returns the corresponding deploymentVerification object, and an error if there is any.
+func (c *FakeDeploymentVerifications) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.DeploymentVerification, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(deploymentverificationsResource, c.ns, name), &v1alpha1.DeploymentVerification{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.DeploymentVerification), err
+}
+
+// List takes label and field selectors, and returns the list of DeploymentVerifications that match those selectors.
+func (c *FakeDeploymentVerifications) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.DeploymentVerificationList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(deploymentverificationsResource, deploymentverificationsKind, c.ns, opts), &v1alpha1.DeploymentVerificationList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.DeploymentVerificationList{ListMeta: obj.(*v1alpha1.DeploymentVerificationList).ListMeta}
+	for _, item := range obj.(*v1alpha1.DeploymentVerificationList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested deploymentVerifications.
+func (c *FakeDeploymentVerifications) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(deploymentverificationsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a deploymentVerification and creates it.  Returns the server's representation of the deploymentVerification, and an error, if there is any.
+func (c *FakeDeploymentVerifications) Create(ctx context.Context, deploymentVerification *v1alpha1.DeploymentVerification, opts v1.CreateOptions) (result *v1alpha1.DeploymentVerification, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(deploymentverificationsResource, c.ns, deploymentVerification), &v1alpha1.DeploymentVerification{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.DeploymentVerification), err
+}
+
+// Update takes the representation of a deploymentVerification and updates it. Returns the server's representation of the deploymentVerification, and an error, if there is any.
+func (c *FakeDeploymentVerifications) Update(ctx context.Context, deploymentVerification *v1alpha1.DeploymentVerification, opts v1.UpdateOptions) (result *v1alpha1.DeploymentVerification, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(deploymentverificationsResource, c.ns, deploymentVerification), &v1alpha1.DeploymentVerification{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.DeploymentVerification), err
+}
+
+// Delete takes name of the deploymentVerification and deletes it. Returns an error if one occurs.
+func (c *FakeDeploymentVerifications) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(deploymentverificationsResource, c.ns, name), &v1alpha1.DeploymentVerification{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeDeploymentVerifications) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(deploymentverificationsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.DeploymentVerificationList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched deploymentVerification.
+func (c *FakeDeploymentVerifications) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.DeploymentVerification, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(deploymentverificationsResource, c.ns, name, pt, data, subresources...), &v1alpha1.DeploymentVerification{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.DeploymentVerification), err
+}
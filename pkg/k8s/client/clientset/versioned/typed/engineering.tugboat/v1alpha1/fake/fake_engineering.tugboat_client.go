@@ -15,6 +15,14 @@ type FakeTugboatV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeTugboatV1alpha1) DeploymentVerifications(namespace string) v1alpha1.DeploymentVerificationInterface {
+	return &FakeDeploymentVerifications{c, namespace}
+}
+
+func (c *FakeTugboatV1alpha1) FreezePolicies(namespace string) v1alpha1.FreezePolicyInterface {
+	return &FakeFreezePolicies{c, namespace}
+}
+
 func (c *FakeTugboatV1alpha1) ReleaseHistories(namespace string) v1alpha1.ReleaseHistoryInterface {
 	return &FakeReleaseHistories{c, namespace}
 }
@@ -0,0 +1,117 @@
+/*
+LICENSE
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeFreezePolicies implements FreezePolicyInterface
+type FakeFreezePolicies struct {
+	Fake *FakeTugboatV1alpha1
+	ns   string
+}
+
+var freezepoliciesResource = schema.GroupVersionResource{Group: "tugboat.engineering", Version: "v1alpha1", Resource: "freezepolicies"}
+
+var freezepoliciesKind = schema.GroupVersionKind{Group: "tugboat.engineering", Version: "v1alpha1", Kind: "FreezePolicy"}
+
+// Get takes name of the freezePolicy, and returns the corresponding freezePolicy object, and an error if there is any.
+func (c *FakeFreezePolicies) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.FreezePolicy, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(freezepoliciesResource, c.ns, name), &v1alpha1.FreezePolicy{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.FreezePolicy), err
+}
+
+// List takes label and field selectors, and returns the list of FreezePolicies that match those selectors.
+func (c *FakeFreezePolicies) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.FreezePolicyList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(freezepoliciesResource, freezepoliciesKind, c.ns, opts), &v1alpha1.FreezePolicyList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.FreezePolicyList{ListMeta: obj.(*v1alpha1.FreezePolicyList).ListMeta}
+	for _, item := range obj.(*v1alpha1.FreezePolicyList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested freezePolicies.
+func (c *FakeFreezePolicies) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(freezepoliciesResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a freezePolicy and creates it.  Returns the server's representation of the freezePolicy, and an error, if there is any.
+func (c *FakeFreezePolicies) Create(ctx context.Context, freezePolicy *v1alpha1.FreezePolicy, opts v1.CreateOptions) (result *v1alpha1.FreezePolicy, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(freezepoliciesResource, c.ns, freezePolicy), &v1alpha1.FreezePolicy{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.FreezePolicy), err
+}
+
+// Update takes the representation of a freezePolicy and updates it. Returns the server's representation of the freezePolicy, and an error, if there is any.
+func (c *FakeFreezePolicies) Update(ctx context.Context, freezePolicy *v1alpha1.FreezePolicy, opts v1.UpdateOptions) (result *v1alpha1.FreezePolicy, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(freezepoliciesResource, c.ns, freezePolicy), &v1alpha1.FreezePolicy{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.FreezePolicy), err
+}
+
+// Delete takes name of the freezePolicy and deletes it. Returns an error if one occurs.
+func (c *FakeFreezePolicies) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(freezepoliciesResource, c.ns, name), &v1alpha1.FreezePolicy{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeFreezePolicies) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(freezepoliciesResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.FreezePolicyList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched freezePolicy.
+func (c *FakeFreezePolicies) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.FreezePolicy, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(freezepoliciesResource, c.ns, name, pt, data, subresources...), &v1alpha1.FreezePolicy{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.FreezePolicy), err
+}
@@ -0,0 +1,165 @@
+/*
+LICENSE
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	scheme "github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// FreezePoliciesGetter has a method to return a FreezePolicyInterface.
+// A group's client should implement this interface.
+type FreezePoliciesGetter interface {
+	FreezePolicies(namespace string) FreezePolicyInterface
+}
+
+// FreezePolicyInterface has methods to work with FreezePolicy resources.
+type FreezePolicyInterface interface {
+	Create(ctx context.Context, freezePolicy *v1alpha1.FreezePolicy, opts v1.CreateOptions) (*v1alpha1.FreezePolicy, error)
+	Update(ctx context.Context, freezePolicy *v1alpha1.FreezePolicy, opts v1.UpdateOptions) (*v1alpha1.FreezePolicy, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.FreezePolicy, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.FreezePolicyList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.FreezePolicy, err error)
+	FreezePolicyExpansion
+}
+
+// freezePolicies implements FreezePolicyInterface
+type freezePolicies struct {
+	client rest.Interface
+	ns     string
+}
+
+// newFreezePolicies returns a FreezePolicies
+func newFreezePolicies(c *TugboatV1alpha1Client, namespace string) *freezePolicies {
+	return &freezePolicies{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the freezePolicy, and returns the corresponding freezePolicy object, and an error if there is any.
+func (c *freezePolicies) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.FreezePolicy, err error) {
+	result = &v1alpha1.FreezePolicy{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("freezepolicies").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of FreezePolicies that match those selectors.
+func (c *freezePolicies) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.FreezePolicyList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.FreezePolicyList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("freezepolicies").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested freezePolicies.
+func (c *freezePolicies) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("freezepolicies").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a freezePolicy and creates it.  Returns the server's representation of the freezePolicy, and an error, if there is any.
+func (c *freezePolicies) Create(ctx context.Context, freezePolicy *v1alpha1.FreezePolicy, opts v1.CreateOptions) (result *v1alpha1.FreezePolicy, err error) {
+	result = &v1alpha1.FreezePolicy{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("freezepolicies").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(freezePolicy).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a freezePolicy and updates it. Returns the server's representation of the freezePolicy, and an error, if there is any.
+func (c *freezePolicies) Update(ctx context.Context, freezePolicy *v1alpha1.FreezePolicy, opts v1.UpdateOptions) (result *v1alpha1.FreezePolicy, err error) {
+	result = &v1alpha1.FreezePolicy{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("freezepolicies").
+		Name(freezePolicy.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(freezePolicy).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the freezePolicy and deletes it. Returns an error if one occurs.
+func (c *freezePolicies) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("freezepolicies").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *freezePolicies) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("freezepolicies").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched freezePolicy.
+func (c *freezePolicies) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.FreezePolicy, err error) {
+	result = &v1alpha1.FreezePolicy{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("freezepolicies").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -5,4 +5,8 @@ LICENSE
 
 package v1alpha1
 
+type DeploymentVerificationExpansion interface{}
+
+type FreezePolicyExpansion interface{}
+
 type ReleaseHistoryExpansion interface{}
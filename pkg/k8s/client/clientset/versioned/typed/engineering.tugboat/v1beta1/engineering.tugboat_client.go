@@ -0,0 +1,76 @@
+/*
+LICENSE
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1beta1"
+	"github.com/object88/tugboat/pkg/k8s/client/clientset/versioned/scheme"
+	rest "k8s.io/client-go/rest"
+)
+
+type TugboatV1beta1Interface interface {
+	RESTClient() rest.Interface
+	ReleaseHistoriesGetter
+}
+
+// TugboatV1beta1Client is used to interact with features provided by the tugboat.engineering group.
+type TugboatV1beta1Client struct {
+	restClient rest.Interface
+}
+
+func (c *TugboatV1beta1Client) ReleaseHistories(namespace string) ReleaseHistoryInterface {
+	return newReleaseHistories(c, namespace)
+}
+
+// NewForConfig creates a new TugboatV1beta1Client for the given config.
+func NewForConfig(c *rest.Config) (*TugboatV1beta1Client, error) {
+	config := *c
+	if err := setConfigDefaults(&config); err != nil {
+		return nil, err
+	}
+	client, err := rest.RESTClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+	return &TugboatV1beta1Client{client}, nil
+}
+
+// NewForConfigOrDie creates a new TugboatV1beta1Client for the given config and
+// panics if there is an error in the config.
+func NewForConfigOrDie(c *rest.Config) *TugboatV1beta1Client {
+	client, err := NewForConfig(c)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// New creates a new TugboatV1beta1Client for the given RESTClient.
+func New(c rest.Interface) *TugboatV1beta1Client {
+	return &TugboatV1beta1Client{c}
+}
+
+func setConfigDefaults(config *rest.Config) error {
+	gv := v1beta1.SchemeGroupVersion
+	config.GroupVersion = &gv
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+
+	return nil
+}
+
+// RESTClient returns a RESTClient that is used to communicate
+// with API server by this client implementation.
+func (c *TugboatV1beta1Client) RESTClient() rest.Interface {
+	if c == nil {
+		return nil
+	}
+	return c.restClient
+}
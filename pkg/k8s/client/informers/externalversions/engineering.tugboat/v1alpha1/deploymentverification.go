@@ -0,0 +1,77 @@
+/*
+LICENSE
+*/
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	time "time"
+
+	engineeringtugboatv1alpha1 "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	versioned "github.com/object88/tugboat/pkg/k8s/client/clientset/versioned"
+	internalinterfaces "github.com/object88/tugboat/pkg/k8s/client/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/object88/tugboat/pkg/k8s/client/listers/engineering.tugboat/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// DeploymentVerificationInformer provides access to a shared informer and lister for
+// DeploymentVerifications.
+type DeploymentVerificationInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.DeploymentVerificationLister
+}
+
+type deploymentVerificationInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewDeploymentVerificationInformer constructs a new informer for DeploymentVerification type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewDeploymentVerificationInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredDeploymentVerificationInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredDeploymentVerificationInformer constructs a new informer for DeploymentVerification type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredDeploymentVerificationInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.TugboatV1alpha1().DeploymentVerifications(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.TugboatV1alpha1().DeploymentVerifications(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&engineeringtugboatv1alpha1.DeploymentVerification{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *deploymentVerificationInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredDeploymentVerificationInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *deploymentVerificationInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&engineeringtugboatv1alpha1.DeploymentVerification{}, f.defaultInformer)
+}
+
+func (f *deploymentVerificationInformer) Lister() v1alpha1.DeploymentVerificationLister {
+	return v1alpha1.NewDeploymentVerificationLister(f.Informer().GetIndexer())
+}
@@ -0,0 +1,77 @@
+/*
+LICENSE
+*/
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	time "time"
+
+	engineeringtugboatv1alpha1 "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	versioned "github.com/object88/tugboat/pkg/k8s/client/clientset/versioned"
+	internalinterfaces "github.com/object88/tugboat/pkg/k8s/client/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/object88/tugboat/pkg/k8s/client/listers/engineering.tugboat/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// FreezePolicyInformer provides access to a shared informer and lister for
+// FreezePolicies.
+type FreezePolicyInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.FreezePolicyLister
+}
+
+type freezePolicyInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewFreezePolicyInformer constructs a new informer for FreezePolicy type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFreezePolicyInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredFreezePolicyInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredFreezePolicyInformer constructs a new informer for FreezePolicy type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredFreezePolicyInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.TugboatV1alpha1().FreezePolicies(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.TugboatV1alpha1().FreezePolicies(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&engineeringtugboatv1alpha1.FreezePolicy{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *freezePolicyInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredFreezePolicyInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *freezePolicyInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&engineeringtugboatv1alpha1.FreezePolicy{}, f.defaultInformer)
+}
+
+func (f *freezePolicyInformer) Lister() v1alpha1.FreezePolicyLister {
+	return v1alpha1.NewFreezePolicyLister(f.Informer().GetIndexer())
+}
@@ -11,6 +11,10 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// DeploymentVerifications returns a DeploymentVerificationInformer.
+	DeploymentVerifications() DeploymentVerificationInformer
+	// FreezePolicies returns a FreezePolicyInformer.
+	FreezePolicies() FreezePolicyInformer
 	// ReleaseHistories returns a ReleaseHistoryInformer.
 	ReleaseHistories() ReleaseHistoryInformer
 }
@@ -26,6 +30,16 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// DeploymentVerifications returns a DeploymentVerificationInformer.
+func (v *version) DeploymentVerifications() DeploymentVerificationInformer {
+	return &deploymentVerificationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// FreezePolicies returns a FreezePolicyInformer.
+func (v *version) FreezePolicies() FreezePolicyInformer {
+	return &freezePolicyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // ReleaseHistories returns a ReleaseHistoryInformer.
 func (v *version) ReleaseHistories() ReleaseHistoryInformer {
 	return &releaseHistoryInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
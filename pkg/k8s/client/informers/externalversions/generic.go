@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	v1alpha1 "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	v1beta1 "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1beta1"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 	cache "k8s.io/client-go/tools/cache"
 )
@@ -40,9 +41,17 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=tugboat.engineering, Version=v1alpha1
+	case v1alpha1.SchemeGroupVersion.WithResource("deploymentverifications"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tugboat().V1alpha1().DeploymentVerifications().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("freezepolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tugboat().V1alpha1().FreezePolicies().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("releasehistories"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Tugboat().V1alpha1().ReleaseHistories().Informer()}, nil
 
+		// Group=tugboat.engineering, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithResource("releasehistories"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tugboat().V1beta1().ReleaseHistories().Informer()}, nil
+
 	}
 
 	return nil, fmt.Errorf("no informer found for %v", resource)
@@ -0,0 +1,86 @@
+/*
+LICENSE
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DeploymentVerificationLister helps list DeploymentVerifications.
+// All objects returned here must be treated as read-only.
+type DeploymentVerificationLister interface {
+	// List lists all DeploymentVerifications in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.DeploymentVerification, err error)
+	// DeploymentVerifications returns an object that can list and get DeploymentVerifications.
+	DeploymentVerifications(namespace string) DeploymentVerificationNamespaceLister
+	DeploymentVerificationListerExpansion
+}
+
+// deploymentVerificationLister implements the DeploymentVerificationLister interface.
+type deploymentVerificationLister struct {
+	indexer cache.Indexer
+}
+
+// NewDeploymentVerificationLister returns a new DeploymentVerificationLister.
+func NewDeploymentVerificationLister(indexer cache.Indexer) DeploymentVerificationLister {
+	return &deploymentVerificationLister{indexer: indexer}
+}
+
+// List lists all DeploymentVerifications in the indexer.
+func (s *deploymentVerificationLister) List(selector labels.Selector) (ret []*v1alpha1.DeploymentVerification, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.DeploymentVerification))
+	})
+	return ret, err
+}
+
+// DeploymentVerifications returns an object that can list and get DeploymentVerifications.
+func (s *deploymentVerificationLister) DeploymentVerifications(namespace string) DeploymentVerificationNamespaceLister {
+	return deploymentVerificationNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// DeploymentVerificationNamespaceLister helps list and get DeploymentVerifications.
+// All objects returned here must be treated as read-only.
+type DeploymentVerificationNamespaceLister interface {
+	// List lists all DeploymentVerifications in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.DeploymentVerification, err error)
+	// Get retrieves the DeploymentVerification from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.DeploymentVerification, error)
+	DeploymentVerificationNamespaceListerExpansion
+}
+
+// deploymentVerificationNamespaceLister implements the DeploymentVerificationNamespaceLister
+// interface.
+type deploymentVerificationNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all DeploymentVerifications in the indexer for a given namespace.
+func (s deploymentVerificationNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.DeploymentVerification, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.DeploymentVerification))
+	})
+	return ret, err
+}
+
+// Get retrieves the DeploymentVerification from the indexer for a given namespace and name.
+func (s deploymentVerificationNamespaceLister) Get(name string) (*v1alpha1.DeploymentVerification, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("deploymentverification"), name)
+	}
+	return obj.(*v1alpha1.DeploymentVerification), nil
+}
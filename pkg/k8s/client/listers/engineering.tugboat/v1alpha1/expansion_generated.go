@@ -5,6 +5,22 @@ LICENSE
 
 package v1alpha1
 
+// DeploymentVerificationListerExpansion allows custom methods to be added to
+// DeploymentVerificationLister.
+type DeploymentVerificationListerExpansion interface{}
+
+// DeploymentVerificationNamespaceListerExpansion allows custom methods to be added to
+// DeploymentVerificationNamespaceLister.
+type DeploymentVerificationNamespaceListerExpansion interface{}
+
+// FreezePolicyListerExpansion allows custom methods to be added to
+// FreezePolicyLister.
+type FreezePolicyListerExpansion interface{}
+
+// FreezePolicyNamespaceListerExpansion allows custom methods to be added to
+// FreezePolicyNamespaceLister.
+type FreezePolicyNamespaceListerExpansion interface{}
+
 // ReleaseHistoryListerExpansion allows custom methods to be added to
 // ReleaseHistoryLister.
 type ReleaseHistoryListerExpansion interface{}
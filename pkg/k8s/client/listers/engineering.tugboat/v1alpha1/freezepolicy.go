@@ -0,0 +1,86 @@
+/*
+LICENSE
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/object88/tugboat/pkg/k8s/apis/engineering.tugboat/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// FreezePolicyLister helps list FreezePolicies.
+// All objects returned here must be treated as read-only.
+type FreezePolicyLister interface {
+	// List lists all FreezePolicies in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.FreezePolicy, err error)
+	// FreezePolicies returns an object that can list and get FreezePolicies.
+	FreezePolicies(namespace string) FreezePolicyNamespaceLister
+	FreezePolicyListerExpansion
+}
+
+// freezePolicyLister implements the FreezePolicyLister interface.
+type freezePolicyLister struct {
+	indexer cache.Indexer
+}
+
+// NewFreezePolicyLister returns a new FreezePolicyLister.
+func NewFreezePolicyLister(indexer cache.Indexer) FreezePolicyLister {
+	return &freezePolicyLister{indexer: indexer}
+}
+
+// List lists all FreezePolicies in the indexer.
+func (s *freezePolicyLister) List(selector labels.Selector) (ret []*v1alpha1.FreezePolicy, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.FreezePolicy))
+	})
+	return ret, err
+}
+
+// FreezePolicies returns an object that can list and get FreezePolicies.
+func (s *freezePolicyLister) FreezePolicies(namespace string) FreezePolicyNamespaceLister {
+	return freezePolicyNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// FreezePolicyNamespaceLister helps list and get FreezePolicies.
+// All objects returned here must be treated as read-only.
+type FreezePolicyNamespaceLister interface {
+	// List lists all FreezePolicies in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.FreezePolicy, err error)
+	// Get retrieves the FreezePolicy from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.FreezePolicy, error)
+	FreezePolicyNamespaceListerExpansion
+}
+
+// freezePolicyNamespaceLister implements the FreezePolicyNamespaceLister
+// interface.
+type freezePolicyNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all FreezePolicies in the indexer for a given namespace.
+func (s freezePolicyNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.FreezePolicy, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.FreezePolicy))
+	})
+	return ret, err
+}
+
+// Get retrieves the FreezePolicy from the indexer for a given namespace and name.
+func (s freezePolicyNamespaceLister) Get(name string) (*v1alpha1.FreezePolicy, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("freezepolicy"), name)
+	}
+	return obj.(*v1alpha1.FreezePolicy), nil
+}
@@ -2,60 +2,329 @@ package informermanager
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/object88/tugboat/pkg/http/probes"
 	"k8s.io/client-go/tools/cache"
 )
 
+// DefaultSyncTimeout is how long an informer may take to sync its cache
+const DefaultSyncTimeout = 2 * time.Minute
+
+// Status is the health of an informer
+type Status struct {
+	// Synced is true once the informer's cache has synced
+	Synced bool
+
+	// LastError is the most recent error from the informer's watch, if any,
+	// and LastErrorTime is when it occurred.  The informer retries failed
+	// watches itself.
+	LastError     error
+	LastErrorTime time.Time
+}
+
+// MarshalJSON reports the last error as its message
+func (s Status) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Synced        bool       `json:"synced"`
+		LastError     string     `json:"lastError,omitempty"`
+		LastErrorTime *time.Time `json:"lastErrorTime,omitempty"`
+	}{
+		Synced: s.Synced,
+	}
+	if s.LastError != nil {
+		out.LastError = s.LastError.Error()
+		out.LastErrorTime = &s.LastErrorTime
+	}
+	return json.Marshal(out)
+}
+
+type entry struct {
+	informer cache.SharedIndexInformer
+	status   Status
+
+	// cancel stops the informer, and done is closed once it has stopped.
+	// Both are nil until the informer starts.
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// synced is closed once the informer's cache has synced
+	synced chan struct{}
+}
+
+// Manager runs informers, and reports ready once all of their caches have
+// synced.  Informers may be added and removed while the manager runs, e.g.
+// to watch kinds which are only known at runtime.  If an informer does not
+// sync within the SyncTimeout, the manager stops with an error which
+// includes the informer's last watch error.
 type Manager struct {
 	log logr.Logger
+
+	// SyncTimeout is how long an informer may take to sync its cache
+	SyncTimeout time.Duration
+
+	// Ordered starts the informers which are registered when Run is called
+	// one at a time, in the order that they were added, each once the
+	// previous one has synced; e.g. so that an event handler can rely on the
+	// cache of an informer added before its own.  Otherwise, they are
+	// started together.
+	Ordered bool
+
+	mu        sync.Mutex
+	informers map[string]*entry
+	order     []string
+	running   bool
+	ctx       context.Context
+	reporter  probes.Reporter
+	errs      chan error
+
+	now func() time.Time
 }
 
+// New returns a new Manager
 func New(log logr.Logger) *Manager {
 	return &Manager{
-		log: log,
+		log:         log,
+		SyncTimeout: DefaultSyncTimeout,
+		informers:   map[string]*entry{},
+		errs:        make(chan error, 1),
+		now:         time.Now,
 	}
 }
 
-func (m *Manager) Run(ctx context.Context, r probes.Reporter, informers ...cache.SharedIndexInformer) error {
-	stopper := make(chan struct{})
-	defer func() {
-		r.NotReady()
-		close(stopper)
-		m.log.Info("closed watchmanager stopper")
-	}()
+// Add registers an informer under a unique name.  If the manager is
+// running, the informer is started immediately.  The informer must not have
+// been started already, so that the manager can observe its watch errors.
+func (m *Manager) Add(name string, informer cache.SharedIndexInformer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	go func() {
-		for _, i := range informers {
-			m.startInformer(i, stopper)
+	if _, ok := m.informers[name]; ok {
+		return fmt.Errorf("informer '%s' is already registered", name)
+	}
+
+	e := &entry{informer: informer, synced: make(chan struct{})}
+	err := informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+		m.watchError(name, e, err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set watch error handler for informer '%s': %w", name, err)
+	}
+
+	m.informers[name] = e
+	m.order = append(m.order, name)
+	if m.ctx != nil {
+		m.start(name, e)
+		m.report()
+	}
+	return nil
+}
+
+// Remove stops an informer and waits for it to exit.  A stopped informer
+// cannot be started again; to watch the same kind again, add a new
+// informer.
+func (m *Manager) Remove(name string) error {
+	m.mu.Lock()
+	e, ok := m.informers[name]
+	if !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("informer '%s' is not registered", name)
+	}
+	delete(m.informers, name)
+	for k, n := range m.order {
+		if n == name {
+			m.order = append(m.order[:k:k], m.order[k+1:]...)
+			break
 		}
-		r.Ready()
-	}()
+	}
+	m.report()
+	m.mu.Unlock()
+
+	if e.cancel != nil {
+		e.cancel()
+		<-e.done
+	}
+	m.log.Info("removed informer", "name", name)
+	return nil
+}
+
+// Status returns the health of each informer, by name
+func (m *Manager) Status() map[string]Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make(map[string]Status, len(m.informers))
+	for name, e := range m.informers {
+		statuses[name] = e.status
+	}
+	return statuses
+}
+
+// Run starts the registered informers, and blocks until the context is
+// done or an informer fails to sync.  Every informer has stopped by the time
+// Run returns.
+func (m *Manager) Run(ctx context.Context, r probes.Reporter) error {
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return errors.New("informer manager is already running")
+	}
+	m.running = true
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	m.mu.Lock()
+	m.ctx = ctx
+	m.reporter = r
+	if m.Ordered {
+		go m.startInOrder(ctx, append([]string{}, m.order...))
+	} else {
+		for name, e := range m.informers {
+			m.start(name, e)
+		}
+	}
+	m.report()
+	m.mu.Unlock()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		m.log.Info("informer manager context complete")
+		err = ctx.Err()
+	case err = <-m.errs:
+	}
+
+	// Cancelling first ensures that an informer added while stopping does not
+	// outlive Run.
+	cancel()
+	m.stop()
+	r.NotReady()
+	return err
+}
+
+// startInOrder starts the named informers one at a time, each once the
+// previous one has synced.  An informer which has been removed, or which has
+// already started, is skipped.
+func (m *Manager) startInOrder(ctx context.Context, names []string) {
+	for _, name := range names {
+		m.mu.Lock()
+		if ctx.Err() != nil {
+			m.mu.Unlock()
+			return
+		}
+		e, ok := m.informers[name]
+		if !ok || e.cancel != nil {
+			m.mu.Unlock()
+			continue
+		}
+		m.start(name, e)
+		m.mu.Unlock()
 
-	for {
 		select {
 		case <-ctx.Done():
-			m.log.Info("watchmanager context complete")
-			return ctx.Err()
+			return
+		case <-e.synced:
+		case <-e.done:
+			// The informer was removed.
 		}
 	}
 }
 
-func (m *Manager) startInformer(informer cache.SharedIndexInformer, stopper <-chan struct{}) {
-	// TODO: if there _is_ an error, it goes unnoticed and we never get to
-	// ready.  This may functionally work out correctly, but it would be
-	// better to get out of `Run` entirely.
+// start runs an informer and waits for its cache to sync in the background.
+// It is called with the lock held.
+func (m *Manager) start(name string, e *entry) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	e.cancel = cancel
+	e.done = make(chan struct{})
 
 	go func() {
-		informer.Run(stopper)
-		m.log.Info("informer complete")
+		defer close(e.done)
+		e.informer.Run(ctx.Done())
+		m.log.Info("informer complete", "name", name)
 	}()
 
-	if !cache.WaitForCacheSync(stopper, informer.HasSynced) {
-		m.log.Info("watchmanager failed to sync cache")
-		return
+	go func() {
+		syncCtx, syncCancel := context.WithTimeout(ctx, m.SyncTimeout)
+		defer syncCancel()
+
+		if cache.WaitForCacheSync(syncCtx.Done(), e.informer.HasSynced) {
+			m.synced(name, e)
+			return
+		}
+		if ctx.Err() != nil {
+			// The informer was stopped before it synced.
+			return
+		}
+
+		m.mu.Lock()
+		lastErr := e.status.LastError
+		m.mu.Unlock()
+		err := fmt.Errorf("informer '%s' failed to sync within %s", name, m.SyncTimeout)
+		if lastErr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), lastErr)
+		}
+		select {
+		case m.errs <- err:
+		default:
+			// Run is already stopping.
+		}
+	}()
+}
+
+// stop waits for every informer to exit, once the context is done
+func (m *Manager) stop() {
+	m.mu.Lock()
+	entries := make([]*entry, 0, len(m.informers))
+	for _, e := range m.informers {
+		entries = append(entries, e)
 	}
+	m.mu.Unlock()
+
+	for _, e := range entries {
+		if e.done != nil {
+			<-e.done
+		}
+	}
+	m.log.Info("informers stopped")
+}
+
+func (m *Manager) synced(name string, e *entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	m.log.Info("watchmanager informer running")
+	e.status.Synced = true
+	close(e.synced)
+	m.log.Info("informer synced", "name", name)
+	m.report()
+}
+
+func (m *Manager) watchError(name string, e *entry, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.log.Error(err, "informer watch failed", "name", name)
+	e.status.LastError = err
+	e.status.LastErrorTime = m.now()
+}
+
+// report raises the readiness probe if every informer has synced, and
+// lowers it otherwise.  It is called with the lock held.
+func (m *Manager) report() {
+	if m.reporter == nil {
+		return
+	}
+	for _, e := range m.informers {
+		if !e.status.Synced {
+			m.reporter.NotReady()
+			return
+		}
+	}
+	m.reporter.Ready()
 }
@@ -0,0 +1,303 @@
+package informermanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/object88/tugboat/pkg/logging/testlogger"
+	"k8s.io/client-go/tools/cache"
+)
+
+// fakeInformer is a SharedIndexInformer which syncs when told to.  Methods
+// which the manager does not use are left unimplemented.
+type fakeInformer struct {
+	cache.SharedIndexInformer
+
+	mu      sync.Mutex
+	synced  bool
+	running bool
+	stopped bool
+	handler cache.WatchErrorHandler
+}
+
+func (f *fakeInformer) Run(stopCh <-chan struct{}) {
+	f.mu.Lock()
+	f.running = true
+	f.mu.Unlock()
+
+	<-stopCh
+
+	f.mu.Lock()
+	f.running = false
+	f.stopped = true
+	f.mu.Unlock()
+}
+
+func (f *fakeInformer) HasSynced() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.synced
+}
+
+func (f *fakeInformer) SetWatchErrorHandler(handler cache.WatchErrorHandler) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.running || f.stopped {
+		return errors.New("informer has already started")
+	}
+	f.handler = handler
+	return nil
+}
+
+func (f *fakeInformer) sync() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.synced = true
+}
+
+func (f *fakeInformer) watchError(err error) {
+	f.mu.Lock()
+	handler := f.handler
+	f.mu.Unlock()
+	handler(nil, err)
+}
+
+func (f *fakeInformer) state() (bool, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.running, f.stopped
+}
+
+type fakeReporter struct {
+	mu    sync.Mutex
+	ready bool
+}
+
+func (r *fakeReporter) Kill() {}
+
+func (r *fakeReporter) Ready() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = true
+}
+
+func (r *fakeReporter) NotReady() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = false
+}
+
+func (r *fakeReporter) isReady() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ready
+}
+
+func eventually(t *testing.T, description string, f func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", description)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_Manager_Run(t *testing.T) {
+	a := &fakeInformer{synced: true}
+	b := &fakeInformer{}
+	r := &fakeReporter{}
+
+	m := New(testlogger.TestLogger{T: t})
+	add(t, m, "a", a)
+	add(t, m, "b", b)
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error)
+	go func() {
+		result <- m.Run(ctx, r)
+	}()
+
+	eventually(t, "a to sync", func() bool { return m.Status()["a"].Synced })
+	if r.isReady() {
+		t.Errorf("ready before every informer synced")
+	}
+
+	b.sync()
+	eventually(t, "ready", r.isReady)
+
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for k, f := range []*fakeInformer{a, b} {
+		if _, stopped := f.state(); !stopped {
+			t.Errorf("informer %d was not stopped", k)
+		}
+	}
+	if r.isReady() {
+		t.Errorf("ready after stopping")
+	}
+}
+
+func Test_Manager_Run_Ordered(t *testing.T) {
+	a := &fakeInformer{}
+	b := &fakeInformer{synced: true}
+	r := &fakeReporter{}
+
+	m := New(testlogger.TestLogger{T: t})
+	m.Ordered = true
+	add(t, m, "a", a)
+	add(t, m, "b", b)
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error)
+	go func() {
+		result <- m.Run(ctx, r)
+	}()
+
+	eventually(t, "a to run", func() bool {
+		running, _ := a.state()
+		return running
+	})
+	time.Sleep(50 * time.Millisecond)
+	if running, _ := b.state(); running {
+		t.Errorf("b started before a synced")
+	}
+
+	a.sync()
+	eventually(t, "ready", r.isReady)
+
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func Test_Manager_Run_AlreadyRunning(t *testing.T) {
+	m := New(testlogger.TestLogger{T: t})
+	add(t, m, "a", &fakeInformer{synced: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error)
+	go func() {
+		result <- m.Run(ctx, &fakeReporter{})
+	}()
+	eventually(t, "a to sync", func() bool { return m.Status()["a"].Synced })
+
+	if err := m.Run(ctx, &fakeReporter{}); err == nil {
+		t.Errorf("expected error running twice")
+	}
+
+	cancel()
+	<-result
+}
+
+func Test_Manager_SyncTimeout(t *testing.T) {
+	a := &fakeInformer{}
+	watchErr := errors.New("releasehistories is forbidden")
+
+	m := New(testlogger.TestLogger{T: t})
+	m.SyncTimeout = 300 * time.Millisecond
+	if err := m.Add("releasehistories", a); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := make(chan error)
+	go func() {
+		result <- m.Run(context.Background(), &fakeReporter{})
+	}()
+
+	eventually(t, "informer to run", func() bool {
+		running, _ := a.state()
+		return running
+	})
+	a.watchError(watchErr)
+	if status := m.Status()["releasehistories"]; status.LastError != watchErr || status.LastErrorTime.IsZero() {
+		t.Errorf("watch error was not recorded: %#v", status)
+	}
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, watchErr) {
+			t.Errorf("expected the watch error; got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("manager did not stop")
+	}
+	if _, stopped := a.state(); !stopped {
+		t.Errorf("informer was not stopped")
+	}
+}
+
+func Test_Manager_AddRemove(t *testing.T) {
+	a := &fakeInformer{synced: true}
+	r := &fakeReporter{}
+
+	m := New(testlogger.TestLogger{T: t})
+	add(t, m, "a", a)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	result := make(chan error)
+	go func() {
+		result <- m.Run(ctx, r)
+	}()
+	eventually(t, "ready", r.isReady)
+
+	b := &fakeInformer{}
+	if err := m.Add("widgets", b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.isReady() {
+		t.Errorf("ready before the added informer synced")
+	}
+	if err := m.Add("widgets", &fakeInformer{}); err == nil {
+		t.Errorf("expected error adding a duplicate name")
+	}
+	if err := m.Add("started", a); err == nil {
+		t.Errorf("expected error adding a started informer")
+	}
+
+	b.sync()
+	eventually(t, "ready", r.isReady)
+
+	if err := m.Remove("widgets"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, stopped := b.state(); !stopped {
+		t.Errorf("removed informer was not stopped")
+	}
+	if _, ok := m.Status()["widgets"]; ok {
+		t.Errorf("removed informer is still reported")
+	}
+	if err := m.Remove("widgets"); err == nil {
+		t.Errorf("expected error removing an unknown informer")
+	}
+
+	cancel()
+	<-result
+}
+
+func add(t *testing.T, m *Manager, name string, informer cache.SharedIndexInformer) {
+	t.Helper()
+	if err := m.Add(name, informer); err != nil {
+		t.Fatalf("failed to add informer '%s': %v", name, err)
+	}
+}
+
+func Test_Status_MarshalJSON(t *testing.T) {
+	statuses := map[string]Status{
+		"pods":             {Synced: true},
+		"releasehistories": {LastError: errors.New("forbidden"), LastErrorTime: time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	buf, err := json.Marshal(statuses)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"pods":{"synced":true},"releasehistories":{"synced":false,"lastError":"forbidden","lastErrorTime":"2021-02-01T12:00:00Z"}}`
+	if string(buf) != expected {
+		t.Errorf("incorrect JSON:\nexpected %s\nactual   %s", expected, buf)
+	}
+}